@@ -8,18 +8,34 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // embed the zone database so user time zones resolve on minimal images
 
 	"expensify/internal/api"
 	"expensify/internal/config"
 	"expensify/internal/db"
+	"expensify/internal/middleware"
 	"expensify/internal/services"
+)
 
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
+const (
+	minSessionSecretLength = 32
+	devSessionSecret       = "insecure-development-session-secret"
 )
 
 func main() {
 	cfg := config.Load()
+	if cfg.DevLogin && cfg.SecureCookies {
+		log.Fatal("DEV_LOGIN lets anyone sign in as any user and cannot be enabled with SECURE_COOKIES=true")
+	}
+	// The secret signs invitation and pending-login tokens, so a guessable one lets anyone
+	// forge them.
+	if len(cfg.SessionSecret) < minSessionSecretLength || cfg.SessionSecret == devSessionSecret {
+		if !cfg.DevLogin {
+			log.Fatalf("SESSION_SECRET must be at least %d random characters", minSessionSecretLength)
+		}
+		log.Print("SESSION_SECRET is unset or too short; using a fixed development secret")
+		cfg.SessionSecret = devSessionSecret
+	}
 
 	// Database
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
@@ -37,19 +53,72 @@ func main() {
 		}
 	}()
 
-	// Indexes
+	// Migrations and indexes
+	if n, err := db.MigrateGoogleIdentities(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not migrate google users: %v", err)
+	} else if n > 0 {
+		log.Printf("migrated %d users to provider identities", n)
+	}
+	if err := db.EnsureUserIndexes(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not ensure user indexes: %v", err)
+	}
+	if n, err := db.DeletePlaintextSessions(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not remove plaintext sessions: %v", err)
+	} else if n > 0 {
+		log.Printf("removed %d sessions stored before token hashing", n)
+	}
 	if err := db.EnsureSessionIndexes(context.Background(), mongoClient.DB); err != nil {
 		log.Printf("warning: could not ensure session indexes: %v", err)
 	}
 	if err := db.EnsureTransactionIndexes(context.Background(), mongoClient.DB); err != nil {
 		log.Printf("warning: could not ensure transaction indexes: %v", err)
 	}
+	if err := db.EnsureAPITokenIndexes(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not ensure api token indexes: %v", err)
+	}
+	if err := db.EnsureLedgerIndexes(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not ensure ledger indexes: %v", err)
+	}
+	if err := db.EnsureInvitationIndexes(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not ensure invitation indexes: %v", err)
+	}
+	if err := db.EnsureSplitIndexes(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not ensure split indexes: %v", err)
+	}
+	if err := db.EnsureExpenseReportIndexes(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not ensure expense report indexes: %v", err)
+	}
+	if err := db.EnsureAuditIndexes(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not ensure audit indexes: %v", err)
+	}
+	if err := db.EnsureTrashIndexes(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not ensure trash indexes: %v", err)
+	}
+	if err := db.EnsureIdempotencyIndexes(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not ensure idempotency key indexes: %v", err)
+	}
+
+	// Move data created before shared ledgers into each user's personal ledger.
+	if n, err := db.MigratePersonalLedgers(context.Background(), mongoClient.DB); err != nil {
+		log.Printf("warning: could not migrate personal ledgers: %v", err)
+	} else if n > 0 {
+		log.Printf("migrated data of %d users into personal ledgers", n)
+	}
 
 	// Repositories
 	userRepo := db.NewUserRepository(mongoClient.DB)
 	sessionRepo := db.NewSessionRepository(mongoClient.DB)
 	catRepo := db.NewCategoryRepository(mongoClient.DB)
 	txRepo := db.NewTransactionRepository(mongoClient.DB)
+	tokenRepo := db.NewAPITokenRepository(mongoClient.DB)
+	prefsRepo := db.NewPreferencesRepository(mongoClient.DB)
+	ledgerRepo := db.NewLedgerRepository(mongoClient.DB)
+	invRepo := db.NewInvitationRepository(mongoClient.DB)
+	expenseRepo := db.NewGroupExpenseRepository(mongoClient.DB)
+	settlementRepo := db.NewSettlementRepository(mongoClient.DB)
+	reportRepo := db.NewExpenseReportRepository(mongoClient.DB)
+	auditRepo := db.NewAuditRepository(mongoClient.DB)
+	idempotencyRepo := db.NewIdempotencyRepository(mongoClient.DB)
 
 	// Seed default categories
 	if err := db.SeedDefaultCategories(context.Background(), catRepo); err != nil {
@@ -57,24 +126,103 @@ func main() {
 	}
 
 	// Services
-	authSvc := services.NewAuthService(userRepo, sessionRepo)
-	catSvc := services.NewCategoryService(catRepo, txRepo)
-	txSvc := services.NewTransactionService(txRepo, catRepo)
-
-	// OAuth config
-	oauthCfg := &oauth2.Config{
-		ClientID:     cfg.GoogleClientID,
-		ClientSecret: cfg.GoogleClientSecret,
-		RedirectURL:  cfg.GoogleRedirectURL,
-		Scopes: []string{
-			"https://www.googleapis.com/auth/userinfo.email",
-			"https://www.googleapis.com/auth/userinfo.profile",
-		},
-		Endpoint: google.Endpoint,
+	sessionPolicy := services.SessionPolicy{
+		TTL:          cfg.SessionTTL,
+		RefreshAfter: cfg.SessionRefreshAfter,
+		MaxLifetime:  cfg.SessionMaxLifetime,
+	}
+	authSvc := services.NewAuthService(userRepo, sessionRepo, sessionPolicy, []byte(cfg.SessionSecret))
+	catSvc := services.NewCategoryService(catRepo, txRepo, ledgerRepo, auditRepo)
+	txSvc := services.NewTransactionService(txRepo, catRepo, ledgerRepo, auditRepo)
+	reportSvc := services.NewReportService(txRepo, catRepo, ledgerRepo)
+	tokenSvc := services.NewTokenService(tokenRepo, userRepo)
+	prefsSvc := services.NewPreferencesService(prefsRepo, userRepo)
+	ledgerSvc := services.NewLedgerService(ledgerRepo, userRepo)
+	splitSvc := services.NewSplitService(expenseRepo, settlementRepo, ledgerRepo)
+	expenseReportSvc := services.NewExpenseReportService(reportRepo, txRepo, catRepo, ledgerRepo, auditRepo)
+	auditSvc := services.NewAuditService(auditRepo, ledgerRepo)
+	trashSvc := services.NewTrashService(txRepo, catRepo, ledgerRepo, auditRepo)
+	idempotencySvc := services.NewIdempotencyService(idempotencyRepo)
+
+	// Logged mail includes invitation links, which grant access to ledgers, so it is only
+	// used for local development; elsewhere invitations are off until SMTP is configured.
+	var mailer services.Mailer
+	switch {
+	case cfg.SMTPAddr != "":
+		mailer = services.NewSMTPMailer(services.SMTPConfig{
+			Addr:     cfg.SMTPAddr,
+			From:     cfg.SMTPFrom,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		})
+	case cfg.DevLogin:
+		log.Println("SMTP_ADDR not set; outgoing mail is written to the log (dev login only)")
+		mailer = services.LogMailer{}
+	default:
+		log.Println("WARNING: SMTP_ADDR not set; ledger invitations are disabled")
+	}
+	invSvc := services.NewInvitationService(invRepo, ledgerRepo, userRepo, mailer, services.InvitationConfig{
+		SigningKey: []byte(cfg.SessionSecret),
+		AcceptURL:  cfg.FrontendURL + "/invitations/accept",
+		TTL:        cfg.InvitationTTL,
+	})
+
+	// Login providers
+	var providers []services.IdentityProvider
+	if cfg.GoogleClientID != "" {
+		providers = append(providers, services.NewGoogleProvider(services.GoogleConfig{
+			ClientID:     cfg.GoogleClientID,
+			ClientSecret: cfg.GoogleClientSecret,
+			RedirectURL:  cfg.GoogleRedirectURL,
+		}))
+	}
+	for _, p := range cfg.OIDCProviders {
+		discoverCtx, discoverCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		provider, err := services.NewOIDCProvider(discoverCtx, services.OIDCConfig{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		})
+		discoverCancel()
+		if err != nil {
+			log.Printf("warning: login provider %q disabled: %v", p.Name, err)
+			continue
+		}
+		providers = append(providers, provider)
 	}
 
 	// Router
-	router := api.NewRouter(authSvc, catSvc, txSvc, oauthCfg, cfg.FrontendURL, cfg.SecureCookies)
+	router := api.NewRouter(api.Services{
+		Auth:           authSvc,
+		Categories:     catSvc,
+		Transactions:   txSvc,
+		Reports:        reportSvc,
+		Tokens:         tokenSvc,
+		Preferences:    prefsSvc,
+		Ledgers:        ledgerSvc,
+		Invitations:    invSvc,
+		Splits:         splitSvc,
+		ExpenseReports: expenseReportSvc,
+		Audit:          auditSvc,
+		Trash:          trashSvc,
+		Idempotency:    idempotencySvc,
+	}, api.RouterConfig{
+		Providers:     providers,
+		FrontendURL:   cfg.FrontendURL,
+		SecureCookies: cfg.SecureCookies,
+		DevLogin:      cfg.DevLogin,
+		TrustProxy:    cfg.TrustProxyHeaders,
+		Limits: api.RateLimits{
+			Store:   middleware.NewMemoryRateLimitStore(),
+			Auth:    cfg.RateLimitAuth,
+			IP:      cfg.RateLimitIP,
+			API:     cfg.RateLimitAPI,
+			Reports: cfg.RateLimitReports,
+		},
+	})
 
 	// Server
 	srv := &http.Server{
@@ -85,6 +233,10 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Background jobs, stopped on shutdown
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	go purgeTrash(jobsCtx, trashSvc, cfg.TrashRetention)
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -98,6 +250,7 @@ func main() {
 
 	<-quit
 	log.Println("shutting down server...")
+	stopJobs()
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer shutdownCancel()
@@ -106,3 +259,25 @@ func main() {
 	}
 	log.Println("server stopped")
 }
+
+// trashPurgeInterval is how often purgeTrash looks for expired trash.
+const trashPurgeInterval = time.Hour
+
+// purgeTrash permanently removes trashed transactions and categories once they are older
+// than retention, checking at startup and then every trashPurgeInterval until ctx is done.
+func purgeTrash(ctx context.Context, svc services.TrashService, retention time.Duration) {
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+	for {
+		if n, err := svc.Purge(ctx, time.Now().Add(-retention)); err != nil {
+			log.Printf("warning: could not purge trash: %v", err)
+		} else if n > 0 {
+			log.Printf("purged %d items from the trash", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"expensify/internal/middleware"
+	"expensify/internal/services"
+)
+
+// AuditHandler serves the audit log of the selected ledger.
+type AuditHandler struct {
+	svc services.AuditService
+}
+
+// NewAuditHandler constructs an AuditHandler.
+func NewAuditHandler(svc services.AuditService) *AuditHandler {
+	return &AuditHandler{svc: svc}
+}
+
+// List returns audit entries, newest first. Accepts ?entity=transaction|category,
+// ?entity_id=, ?from= and ?to= (YYYY-MM-DD in the user's time zone, to exclusive) and
+// ?limit=N (default 100, max 500).
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	loc := userLocation(user)
+	q := r.URL.Query()
+
+	query := services.AuditQuery{
+		EntityType: q.Get("entity"),
+		EntityID:   q.Get("entity_id"),
+		Limit:      queryInt(r, "limit", 0),
+	}
+	for key, dst := range map[string]*time.Time{"from": &query.Since, "to": &query.Until} {
+		if v := q.Get(key); v != "" {
+			d, err := time.ParseInLocation("2006-01-02", v, loc)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid "+key+", expected YYYY-MM-DD")
+				return
+			}
+			*dst = d
+		}
+	}
+
+	entries, err := h.svc.List(r.Context(), user.ID.Hex(), ledgerID(r), query)
+	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidID):
+			writeError(w, http.StatusBadRequest, "invalid id")
+		case errors.Is(err, services.ErrInvalidFilter):
+			writeError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrInvalidPeriod):
+			writeError(w, http.StatusBadRequest, "from must be before to")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to fetch audit log")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
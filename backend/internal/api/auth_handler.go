@@ -3,103 +3,276 @@ package api
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
-	"time"
+	"net/url"
+	"sort"
 
 	"expensify/internal/middleware"
 	"expensify/internal/services"
 
-	"golang.org/x/oauth2"
+	"github.com/go-chi/chi/v5"
 )
 
-// AuthHandler handles Google OAuth and session endpoints.
+// AuthHandler handles external login and session endpoints.
 type AuthHandler struct {
 	authSvc       services.AuthService
-	oauthCfg      *oauth2.Config
+	providers     map[string]services.IdentityProvider
 	frontendURL   string
 	secureCookies bool
+	devLogin      bool
 }
 
-// NewAuthHandler constructs an AuthHandler.
-func NewAuthHandler(authSvc services.AuthService, oauthCfg *oauth2.Config, frontendURL string, secureCookies bool) *AuthHandler {
+// NewAuthHandler constructs an AuthHandler accepting logins from the given providers,
+// plus the dev login when devLogin is set.
+func NewAuthHandler(authSvc services.AuthService, providers []services.IdentityProvider, frontendURL string, secureCookies bool, devLogin bool) *AuthHandler {
+	byName := make(map[string]services.IdentityProvider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
 	return &AuthHandler{
 		authSvc:       authSvc,
-		oauthCfg:      oauthCfg,
+		providers:     byName,
 		frontendURL:   frontendURL,
 		secureCookies: secureCookies,
+		devLogin:      devLogin,
+	}
+}
+
+// Providers lists the names of the configured login providers, for rendering sign-in buttons.
+func (h *AuthHandler) Providers(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(h.providers))
+	for name := range h.providers {
+		names = append(names, name)
 	}
+	if h.devLogin {
+		names = append(names, services.DevProvider)
+	}
+	sort.Strings(names)
+	writeJSON(w, http.StatusOK, names)
+}
+
+// Login redirects the user to the provider's consent screen.
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	h.redirectToProvider(w, r, false)
+}
+
+// Link starts a login at another provider whose identity is attached to the current user
+// when the provider redirects back to Callback.
+func (h *AuthHandler) Link(w http.ResponseWriter, r *http.Request) {
+	h.redirectToProvider(w, r, true)
 }
 
-// GoogleLogin redirects the user to the Google OAuth consent screen.
-func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
+func (h *AuthHandler) redirectToProvider(w http.ResponseWriter, r *http.Request, link bool) {
+	provider, ok := h.providers[chi.URLParam(r, "provider")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown login provider")
+		return
+	}
 	state, err := generateState()
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to generate state")
 		return
 	}
+	nonce, err := generateState()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate state")
+		return
+	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "oauth_state",
-		Value:    state,
-		MaxAge:   300,
-		HttpOnly: true,
-		Secure:   h.secureCookies,
-		SameSite: http.SameSiteLaxMode,
-		Path:     "/",
-	})
+	h.setOAuthCookie(w, "oauth_state", state)
+	h.setOAuthCookie(w, "oauth_nonce", nonce)
+	if link {
+		h.setOAuthCookie(w, "oauth_link", "1")
+	} else {
+		http.SetCookie(w, &http.Cookie{Name: "oauth_link", MaxAge: -1, Secure: h.secureCookies, Path: "/"})
+	}
 
-	url := h.oauthCfg.AuthCodeURL(state, oauth2.AccessTypeOnline)
-	http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+	http.Redirect(w, r, provider.AuthCodeURL(state, nonce), http.StatusTemporaryRedirect)
 }
 
-// GoogleCallback handles the redirect from Google after the user authenticates.
-func (h *AuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
+// Callback handles the redirect from the provider after the user authenticates.
+func (h *AuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	provider, ok := h.providers[chi.URLParam(r, "provider")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown login provider")
+		return
+	}
+
 	// Validate state to prevent CSRF.
 	stateCookie, err := r.Cookie("oauth_state")
 	if err != nil || stateCookie.Value != r.URL.Query().Get("state") {
 		writeError(w, http.StatusBadRequest, "invalid oauth state")
 		return
 	}
-	http.SetCookie(w, &http.Cookie{Name: "oauth_state", MaxAge: -1, Secure: h.secureCookies, Path: "/"})
+	// Login always sets a nonce, so a callback without one did not start here.
+	nonceCookie, err := r.Cookie("oauth_nonce")
+	if err != nil || nonceCookie.Value == "" {
+		writeError(w, http.StatusBadRequest, "invalid oauth nonce")
+		return
+	}
+	nonce := nonceCookie.Value
+	linkCookie, linkErr := r.Cookie("oauth_link")
+	linking := linkErr == nil && linkCookie.Value == "1"
+	for _, name := range []string{"oauth_state", "oauth_nonce", "oauth_link"} {
+		http.SetCookie(w, &http.Cookie{Name: name, MaxAge: -1, Secure: h.secureCookies, Path: "/"})
+	}
 
-	code := r.URL.Query().Get("code")
-	token, err := h.oauthCfg.Exchange(r.Context(), code)
+	identity, err := provider.Exchange(r.Context(), r.URL.Query().Get("code"), nonce)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to exchange oauth code")
+		if errors.Is(err, services.ErrInvalidIDToken) {
+			writeError(w, http.StatusUnauthorized, "invalid identity token")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to complete login")
+		return
+	}
+
+	if linking {
+		h.linkIdentity(w, r, identity)
 		return
 	}
 
-	userInfo, err := h.authSvc.GetGoogleUserInfo(r.Context(), token.AccessToken)
+	meta := services.SessionMeta{UserAgent: r.UserAgent(), IP: middleware.ClientIP(r)}
+	result, err := h.authSvc.HandleCallback(r.Context(), identity, meta)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to fetch google profile")
+		writeError(w, http.StatusInternalServerError, "failed to create session")
+		return
+	}
+	if result.PendingToken != "" {
+		// Let the user choose between signing in to the existing account to link this
+		// login from there, and creating a separate account.
+		h.setPendingLoginCookie(w, result.PendingToken, 600)
+		http.Redirect(w, r, h.frontendURL+"/login?existing="+url.QueryEscape(result.ExistingEmail), http.StatusTemporaryRedirect)
 		return
 	}
 
-	session, err := h.authSvc.HandleCallback(r.Context(), userInfo)
+	middleware.SetSessionCookie(w, result.Session.Token, result.Session.ExpiresAt, h.secureCookies)
+	http.Redirect(w, r, h.frontendURL+"/dashboard", http.StatusTemporaryRedirect)
+}
+
+// DevLogin signs in as the test user named by ?name= (default "Dev User"), creating it on
+// first use. It is only routed when dev login is enabled and cookies are not secure.
+func (h *AuthHandler) DevLogin(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		name = "Dev User"
+	}
+
+	meta := services.SessionMeta{UserAgent: r.UserAgent(), IP: middleware.ClientIP(r)}
+	session, err := h.authSvc.DevLogin(r.Context(), name, meta)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidName) {
+			writeError(w, http.StatusBadRequest, "invalid name")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "failed to create session")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     "session",
-		Value:    session.Token,
-		MaxAge:   int(30 * 24 * time.Hour / time.Second),
-		HttpOnly: true,
-		Secure:   h.secureCookies,
-		SameSite: http.SameSiteLaxMode,
-		Path:     "/",
-	})
+	middleware.SetSessionCookie(w, session.Token, session.ExpiresAt, h.secureCookies)
+	http.Redirect(w, r, h.frontendURL+"/dashboard", http.StatusTemporaryRedirect)
+}
 
+// linkIdentity attaches identity to the user of the current session.
+func (h *AuthHandler) linkIdentity(w http.ResponseWriter, r *http.Request, identity *services.ExternalIdentity) {
+	user, err := h.authSvc.GetCurrentUser(r.Context(), sessionToken(r))
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "log in before linking another account")
+		return
+	}
+	if _, err := h.authSvc.LinkIdentity(r.Context(), user.ID.Hex(), identity); err != nil {
+		switch {
+		case errors.Is(err, services.ErrIdentityInUse):
+			writeError(w, http.StatusConflict, "this login is already linked to another account")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to link account")
+		}
+		return
+	}
 	http.Redirect(w, r, h.frontendURL+"/dashboard", http.StatusTemporaryRedirect)
 }
 
-// Me returns the currently authenticated user's profile.
+// ResolvePendingLogin completes a login held back because its verified email belongs to an
+// existing account, by creating a separate account. Joining the existing account instead
+// requires signing in to it and linking through /auth/{provider}/link, so {"merge": true}
+// is rejected.
+func (h *AuthHandler) ResolvePendingLogin(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie("pending_login")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "no pending login")
+		return
+	}
+	var req struct {
+		Merge bool `json:"merge"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Merge {
+		writeError(w, http.StatusBadRequest, "sign in to the existing account and link this login from there")
+		return
+	}
+
+	meta := services.SessionMeta{UserAgent: r.UserAgent(), IP: middleware.ClientIP(r)}
+	session, err := h.authSvc.ResolvePendingLogin(r.Context(), cookie.Value, meta)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSignedToken):
+			writeError(w, http.StatusBadRequest, "login expired, please sign in again")
+		case errors.Is(err, services.ErrIdentityInUse):
+			writeError(w, http.StatusConflict, "this login already belongs to an account, sign in again")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to complete login")
+		}
+		return
+	}
+
+	h.setPendingLoginCookie(w, "", -1)
+	middleware.SetSessionCookie(w, session.Token, session.ExpiresAt, h.secureCookies)
+	writeJSON(w, http.StatusOK, map[string]string{"message": "logged in"})
+}
+
+// Me returns the currently authenticated user's profile. Cookie sessions also receive
+// their CSRF token in the X-CSRF-Token response header.
 func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
+	if token := sessionToken(r); token != "" && middleware.TokenFromContext(r.Context()) == nil {
+		w.Header().Set(middleware.CSRFHeader, h.authSvc.CSRFToken(token))
+	}
 	writeJSON(w, http.StatusOK, user)
 }
 
+// UpdateTimeZone stores the authenticated user's IANA time zone, used for cashflow aggregation.
+func (h *AuthHandler) UpdateTimeZone(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req struct {
+		TimeZone string `json:"time_zone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	updated, err := h.authSvc.UpdateTimeZone(r.Context(), user.ID.Hex(), req.TimeZone)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTimeZone):
+			writeError(w, http.StatusBadRequest, "invalid time zone")
+		case errors.Is(err, services.ErrNotFound):
+			writeError(w, http.StatusNotFound, "user not found")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to update time zone")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
 // Logout deletes the session and clears the cookie.
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie("session")
@@ -118,6 +291,99 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"message": "logged out"})
 }
 
+// UnlinkIdentity detaches one of the user's login identities.
+func (h *AuthHandler) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	updated, err := h.authSvc.UnlinkIdentity(r.Context(), user.ID.Hex(), chi.URLParam(r, "provider"), chi.URLParam(r, "subject"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			writeError(w, http.StatusNotFound, "identity not found")
+		case errors.Is(err, services.ErrLastIdentity):
+			writeError(w, http.StatusConflict, "cannot remove your only login")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to unlink identity")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// ListSessions returns the user's active sessions, marking the one making this request.
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	sessions, err := h.authSvc.ListSessions(r.Context(), user.ID.Hex(), sessionToken(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch sessions")
+		return
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession logs out one of the user's sessions, e.g. one left open on a shared computer.
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	sessionID := chi.URLParam(r, "id")
+
+	if err := h.authSvc.RevokeSession(r.Context(), user.ID.Hex(), sessionID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			writeError(w, http.StatusNotFound, "session not found")
+		case errors.Is(err, services.ErrInvalidID):
+			writeError(w, http.StatusBadRequest, "invalid id")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to revoke session")
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeOtherSessions logs the user out everywhere except this session.
+func (h *AuthHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	n, err := h.authSvc.RevokeOtherSessions(r.Context(), user.ID.Hex(), sessionToken(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"revoked": n})
+}
+
+// setOAuthCookie stores a short-lived value that must round-trip through the provider's redirect.
+func (h *AuthHandler) setOAuthCookie(w http.ResponseWriter, name, value string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		MaxAge:   300,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/",
+	})
+}
+
+func (h *AuthHandler) setPendingLoginCookie(w http.ResponseWriter, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "pending_login",
+		Value:    value,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   h.secureCookies,
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/auth",
+	})
+}
+
+// sessionToken returns the caller's session cookie value, or "" if there is none.
+func sessionToken(r *http.Request) string {
+	cookie, err := r.Cookie("session")
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
 func generateState() (string, error) {
 	b := make([]byte, 16)
 	if _, err := rand.Read(b); err != nil {
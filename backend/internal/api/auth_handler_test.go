@@ -0,0 +1,365 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"expensify/internal/api"
+	"expensify/internal/middleware"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const frontendURL = "http://frontend.test"
+
+// memoryAuthRepos backs the user and session mocks with maps so a login persists
+// across requests.
+type memoryAuthRepos struct {
+	mu       sync.Mutex
+	users    map[primitive.ObjectID]*models.User
+	sessions map[string]*models.Session
+}
+
+func newMemoryAuthRepos() (*memoryAuthRepos, *testutil.MockUserRepo, *testutil.MockSessionRepo) {
+	m := &memoryAuthRepos{users: map[primitive.ObjectID]*models.User{}, sessions: map[string]*models.Session{}}
+	userRepo := &testutil.MockUserRepo{
+		UpsertFn: func(_ context.Context, identity models.Identity, profile *models.User) (*models.User, error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			for _, u := range m.users {
+				for _, id := range u.Identities {
+					if id.Provider == identity.Provider && id.Subject == identity.Subject {
+						u.Email, u.Name, u.Picture = profile.Email, profile.Name, profile.Picture
+						return u, nil
+					}
+				}
+			}
+			profile.ID = primitive.NewObjectID()
+			profile.Identities = []models.Identity{identity}
+			m.users[profile.ID] = profile
+			return profile, nil
+		},
+		FindByIDFn: func(_ context.Context, id primitive.ObjectID) (*models.User, error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return m.users[id], nil
+		},
+	}
+	sessionRepo := &testutil.MockSessionRepo{
+		CreateFn: func(_ context.Context, s *models.Session) (*models.Session, error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			s.ID = primitive.NewObjectID()
+			s.CreatedAt = time.Now()
+			m.sessions[s.Token] = s
+			return s, nil
+		},
+		FindByTokenFn: func(_ context.Context, token string) (*models.Session, error) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return m.sessions[token], nil
+		},
+		DeleteFn: func(_ context.Context, token string) error {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			delete(m.sessions, token)
+			return nil
+		},
+	}
+	return m, userRepo, sessionRepo
+}
+
+func newGoogleTestRouter(t *testing.T, oauth *testutil.FakeOAuthServer) (http.Handler, *memoryAuthRepos) {
+	t.Helper()
+	repos, userRepo, sessionRepo := newMemoryAuthRepos()
+	authSvc := services.NewAuthService(userRepo, sessionRepo, services.DefaultSessionPolicy(), []byte("test-secret"))
+	tokenSvc := services.NewTokenService(&testutil.MockAPITokenRepo{}, userRepo)
+	google := services.NewGoogleProvider(services.GoogleConfig{
+		ClientID:     oauth.ClientID,
+		ClientSecret: oauth.ClientSecret,
+		RedirectURL:  "http://backend.test/auth/google/callback",
+		Endpoint:     oauth.Endpoint(),
+		UserInfoURL:  oauth.UserInfoURL(),
+		HTTPClient:   oauth.Client(),
+	})
+	router := api.NewRouter(api.Services{Auth: authSvc, Tokens: tokenSvc}, api.RouterConfig{
+		Providers:   []services.IdentityProvider{google},
+		FrontendURL: frontendURL,
+	})
+	return router, repos
+}
+
+func newDevTestRouter(secureCookies bool) (http.Handler, *memoryAuthRepos) {
+	repos, userRepo, sessionRepo := newMemoryAuthRepos()
+	authSvc := services.NewAuthService(userRepo, sessionRepo, services.DefaultSessionPolicy(), []byte("test-secret"))
+	tokenSvc := services.NewTokenService(&testutil.MockAPITokenRepo{}, userRepo)
+	router := api.NewRouter(api.Services{Auth: authSvc, Tokens: tokenSvc}, api.RouterConfig{
+		FrontendURL:   frontendURL,
+		SecureCookies: secureCookies,
+		DevLogin:      true,
+	})
+	return router, repos
+}
+
+// serve runs req against the router, sending cookies and returning the response.
+// Cookies being cleared (MaxAge < 0) are skipped, as a browser would.
+func serve(router http.Handler, req *http.Request, cookies []*http.Cookie) *http.Response {
+	for _, c := range cookies {
+		if c.MaxAge >= 0 {
+			req.AddCookie(c)
+		}
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+func TestGoogleLogin_EndToEnd(t *testing.T) {
+	oauth := testutil.NewFakeOAuthServer(t, testutil.FakeOAuthUser{
+		ID:            "g-1001",
+		Email:         "grace@example.com",
+		VerifiedEmail: true,
+		Name:          "Grace",
+	})
+	router, repos := newGoogleTestRouter(t, oauth)
+
+	// 1. Start the login: we are sent to the provider with a state cookie.
+	login := serve(router, httptest.NewRequest(http.MethodGet, "/auth/google", nil), nil)
+	if login.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatalf("login: got status %d", login.StatusCode)
+	}
+	authorizeURL := login.Header.Get("Location")
+	if !strings.HasPrefix(authorizeURL, oauth.URL+"/authorize") {
+		t.Fatalf("login should redirect to the provider, got %q", authorizeURL)
+	}
+	oauthCookies := login.Cookies()
+
+	// 2. The provider approves and redirects back with a code.
+	noFollow := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	approval, err := noFollow.Get(authorizeURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	approval.Body.Close()
+	callback, err := url.Parse(approval.Header.Get("Location"))
+	if err != nil || callback.Path != "/auth/google/callback" {
+		t.Fatalf("provider should redirect to the callback, got %q", approval.Header.Get("Location"))
+	}
+
+	// 3. The callback exchanges the code, reads the profile and starts a session.
+	done := serve(router, httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil), oauthCookies)
+	if done.StatusCode != http.StatusTemporaryRedirect || done.Header.Get("Location") != frontendURL+"/dashboard" {
+		t.Fatalf("callback: got %d to %q", done.StatusCode, done.Header.Get("Location"))
+	}
+	var session *http.Cookie
+	for _, c := range done.Cookies() {
+		if c.Name == "session" && c.Value != "" {
+			session = c
+		}
+	}
+	if session == nil {
+		t.Fatal("callback did not set a session cookie")
+	}
+	if oauth.UserInfoCalls != 1 {
+		t.Errorf("expected one bearer-authenticated userinfo call, got %d", oauth.UserInfoCalls)
+	}
+
+	// 4. The session cookie authenticates API calls as the new user.
+	me := serve(router, httptest.NewRequest(http.MethodGet, "/auth/me", nil), []*http.Cookie{session})
+	if me.StatusCode != http.StatusOK {
+		t.Fatalf("/auth/me: got status %d", me.StatusCode)
+	}
+	var body struct {
+		Data models.User `json:"data"`
+	}
+	if err := json.NewDecoder(me.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding /auth/me: %v", err)
+	}
+	if body.Data.Email != "grace@example.com" {
+		t.Errorf("email: got %q", body.Data.Email)
+	}
+	if len(body.Data.Identities) != 1 || body.Data.Identities[0].Provider != "google" || body.Data.Identities[0].Subject != "g-1001" {
+		t.Errorf("identities: got %+v", body.Data.Identities)
+	}
+	if len(repos.users) != 1 {
+		t.Errorf("expected one user, got %d", len(repos.users))
+	}
+}
+
+func TestGoogleCallback_RejectsStateMismatch(t *testing.T) {
+	oauth := testutil.NewFakeOAuthServer(t, testutil.FakeOAuthUser{ID: "g-1"})
+	router, repos := newGoogleTestRouter(t, oauth)
+
+	login := serve(router, httptest.NewRequest(http.MethodGet, "/auth/google", nil), nil)
+	resp := serve(router, httptest.NewRequest(http.MethodGet, "/auth/google/callback?code=x&state=forged", nil), login.Cookies())
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for a forged state, got %d", resp.StatusCode)
+	}
+	if len(repos.sessions) != 0 {
+		t.Error("no session should be created")
+	}
+}
+
+func TestGoogleCallback_RequiresNonceCookie(t *testing.T) {
+	oauth := testutil.NewFakeOAuthServer(t, testutil.FakeOAuthUser{ID: "g-1", Email: "g@example.com"})
+	router, repos := newGoogleTestRouter(t, oauth)
+
+	login := serve(router, httptest.NewRequest(http.MethodGet, "/auth/google", nil), nil)
+	state, _ := url.Parse(login.Header.Get("Location"))
+	var cookies []*http.Cookie
+	for _, c := range login.Cookies() {
+		if c.Name != "oauth_nonce" {
+			cookies = append(cookies, c)
+		}
+	}
+	target := "/auth/google/callback?code=x&state=" + url.QueryEscape(state.Query().Get("state"))
+	resp := serve(router, httptest.NewRequest(http.MethodGet, target, nil), cookies)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 without a nonce cookie, got %d", resp.StatusCode)
+	}
+	if len(repos.sessions) != 0 {
+		t.Error("no session should be created")
+	}
+}
+
+func TestGoogleCallback_InvalidCode(t *testing.T) {
+	oauth := testutil.NewFakeOAuthServer(t, testutil.FakeOAuthUser{ID: "g-1"})
+	router, _ := newGoogleTestRouter(t, oauth)
+
+	login := serve(router, httptest.NewRequest(http.MethodGet, "/auth/google", nil), nil)
+	state, _ := url.Parse(login.Header.Get("Location"))
+	target := "/auth/google/callback?code=never-issued&state=" + url.QueryEscape(state.Query().Get("state"))
+	resp := serve(router, httptest.NewRequest(http.MethodGet, target, nil), login.Cookies())
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("expected 500 when the code exchange fails, got %d", resp.StatusCode)
+	}
+}
+
+func TestAuthProviders_ListsConfigured(t *testing.T) {
+	oauth := testutil.NewFakeOAuthServer(t, testutil.FakeOAuthUser{ID: "g-1"})
+	router, _ := newGoogleTestRouter(t, oauth)
+
+	resp := serve(router, httptest.NewRequest(http.MethodGet, "/auth/providers", nil), nil)
+	var body struct {
+		Data []string `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if len(body.Data) != 1 || body.Data[0] != "google" {
+		t.Errorf("providers: got %v", body.Data)
+	}
+}
+
+func TestDevLogin_SignsInAsNamedUser(t *testing.T) {
+	router, repos := newDevTestRouter(false)
+
+	resp := serve(router, httptest.NewRequest(http.MethodGet, "/auth/dev?name=Alice+Smith", nil), nil)
+	if resp.StatusCode != http.StatusTemporaryRedirect || resp.Header.Get("Location") != frontendURL+"/dashboard" {
+		t.Fatalf("dev login: got %d to %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+	me := serve(router, httptest.NewRequest(http.MethodGet, "/auth/me", nil), resp.Cookies())
+	var body struct {
+		Data models.User `json:"data"`
+	}
+	json.NewDecoder(me.Body).Decode(&body)
+	if body.Data.Name != "Alice Smith" || len(body.Data.Identities) != 1 || body.Data.Identities[0].Subject != "alice-smith" {
+		t.Errorf("/auth/me: got %+v", body.Data)
+	}
+
+	// Signing in again under the same name reuses the user.
+	serve(router, httptest.NewRequest(http.MethodGet, "/auth/dev?name=alice+smith", nil), nil)
+	if len(repos.users) != 1 {
+		t.Errorf("expected one user, got %d", len(repos.users))
+	}
+}
+
+func TestDevLogin_RefusedWithSecureCookies(t *testing.T) {
+	router, repos := newDevTestRouter(true)
+
+	resp := serve(router, httptest.NewRequest(http.MethodGet, "/auth/dev?name=mallory", nil), nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected 404 with secure cookies, got %d", resp.StatusCode)
+	}
+	if len(repos.sessions) != 0 {
+		t.Error("no session should be created")
+	}
+
+	providers := serve(router, httptest.NewRequest(http.MethodGet, "/auth/providers", nil), nil)
+	var body struct {
+		Data []string `json:"data"`
+	}
+	json.NewDecoder(providers.Body).Decode(&body)
+	if len(body.Data) != 0 {
+		t.Errorf("dev should not be listed, got %v", body.Data)
+	}
+}
+
+func TestCSRF_CookieMutationsNeedToken(t *testing.T) {
+	router, repos := newDevTestRouter(false)
+	cookies := serve(router, httptest.NewRequest(http.MethodGet, "/auth/dev?name=carol", nil), nil).Cookies()
+
+	// A cross-site POST carries the cookie but not the token.
+	forged := serve(router, httptest.NewRequest(http.MethodPost, "/auth/logout", nil), cookies)
+	if forged.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 without a CSRF token, got %d", forged.StatusCode)
+	}
+	if len(repos.sessions) != 1 {
+		t.Fatal("the session should survive a forged logout")
+	}
+
+	me := serve(router, httptest.NewRequest(http.MethodGet, "/auth/me", nil), cookies)
+	token := me.Header.Get("X-CSRF-Token")
+	if token == "" {
+		t.Fatal("/auth/me should issue a CSRF token")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+	req.Header.Set("X-CSRF-Token", token)
+	if resp := serve(router, req, cookies); resp.StatusCode != http.StatusOK {
+		t.Fatalf("logout with token: got %d", resp.StatusCode)
+	}
+	if len(repos.sessions) != 0 {
+		t.Error("logout should delete the session")
+	}
+}
+
+func TestRouter_LimitsPerIPBeforeAuthentication(t *testing.T) {
+	for _, trustProxy := range []bool{false, true} {
+		_, userRepo, sessionRepo := newMemoryAuthRepos()
+		authSvc := services.NewAuthService(userRepo, sessionRepo, services.DefaultSessionPolicy(), []byte("test-secret"))
+		tokenSvc := services.NewTokenService(&testutil.MockAPITokenRepo{}, userRepo)
+		limits := api.RateLimits{Store: middleware.NewMemoryRateLimitStore(), IP: middleware.RateLimit{Requests: 1, Per: time.Minute}}
+		router := api.NewRouter(api.Services{Auth: authSvc, Tokens: tokenSvc}, api.RouterConfig{
+			FrontendURL: frontendURL,
+			TrustProxy:  trustProxy,
+			Limits:      limits,
+		})
+
+		// Two clients behind the same proxy.
+		request := func(client string) int {
+			req := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+			req.RemoteAddr = "10.0.0.1:4000"
+			req.Header.Set("X-Forwarded-For", client)
+			return serve(router, req, nil).StatusCode
+		}
+		if got := request("198.51.100.1"); got != http.StatusUnauthorized {
+			t.Fatalf("trustProxy=%v: first unauthenticated request: got %d, want 401", trustProxy, got)
+		}
+		want := http.StatusTooManyRequests
+		if trustProxy {
+			want = http.StatusUnauthorized
+		}
+		if got := request("198.51.100.2"); got != want {
+			t.Errorf("trustProxy=%v: second client: got %d, want %d", trustProxy, got, want)
+		}
+	}
+}
@@ -21,18 +21,25 @@ func NewCategoryHandler(svc services.CategoryService) *CategoryHandler {
 	return &CategoryHandler{svc: svc}
 }
 
-// List returns all categories available to the user (defaults + custom).
+// List returns all categories available in the selected ledger (defaults + custom).
 func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
-	cats, err := h.svc.GetCategories(r.Context(), user.ID.Hex())
+	cats, err := h.svc.GetCategories(r.Context(), user.ID.Hex(), ledgerID(r))
 	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidID) {
+			writeError(w, http.StatusBadRequest, "invalid ledger id")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "failed to fetch categories")
 		return
 	}
 	writeJSON(w, http.StatusOK, cats)
 }
 
-// Create adds a new custom category for the authenticated user.
+// Create adds a new custom category to the selected ledger.
 func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 
@@ -46,22 +53,38 @@ func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cat, err := h.svc.CreateCategory(r.Context(), user.ID.Hex(), req)
+	cat, err := h.svc.CreateCategory(r.Context(), user.ID.Hex(), ledgerID(r), req)
 	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidID) {
+			writeError(w, http.StatusBadRequest, "invalid ledger id")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "failed to create category")
 		return
 	}
+	setETag(w, cat.Version)
 	writeJSON(w, http.StatusCreated, cat)
 }
 
-// Delete removes a custom category owned by the authenticated user.
+// Delete moves a custom category of the selected ledger to the trash. If-Match must
+// carry the category's current ETag.
 func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	catID := chi.URLParam(r, "id")
+	versions, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	if err := h.svc.DeleteCategory(r.Context(), user.ID.Hex(), catID); err != nil {
+	if err := h.svc.DeleteCategory(r.Context(), user.ID.Hex(), ledgerID(r), catID, versions); err != nil {
+		if writeLedgerError(w, err) || writeVersionError(w, err) {
+			return
+		}
 		if errors.Is(err, services.ErrNotFound) {
-			writeError(w, http.StatusNotFound, "category not found or not owned by you")
+			writeError(w, http.StatusNotFound, "category not found in this ledger")
 			return
 		}
 		if errors.Is(err, services.ErrInvalidID) {
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"expensify/internal/services"
+)
+
+// Transactions and categories are versioned. Their version is sent as a strong ETag, and
+// writes to an existing one must send it back in If-Match, so that a client editing a
+// stale copy gets a 412 instead of overwriting someone else's change.
+
+// setETag sets the ETag header to a resource's version.
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// ifMatch returns the versions named by the If-Match header, a comma-separated list of
+// ETags, or services.AnyVersion for "*". If-Match uses strong comparison, so weak tags
+// (W/"3") never match. It writes a 428 and returns false if the header is missing, and a
+// 412 if it names no strong ETag the API hands out.
+func ifMatch(w http.ResponseWriter, r *http.Request) ([]int64, bool) {
+	header := strings.TrimSpace(strings.Join(r.Header.Values("If-Match"), ","))
+	if header == "" {
+		writeError(w, http.StatusPreconditionRequired, "If-Match header with the ETag last read is required")
+		return nil, false
+	}
+	if header == "*" {
+		return []int64{services.AnyVersion}, true
+	}
+	var versions []int64
+	for _, tag := range strings.Split(header, ",") {
+		if version, ok := parseETag(strings.TrimSpace(tag)); ok {
+			versions = append(versions, version)
+		}
+	}
+	if len(versions) == 0 {
+		writeError(w, http.StatusPreconditionFailed, "If-Match does not match the current version")
+		return nil, false
+	}
+	return versions, true
+}
+
+// parseETag returns the version named by a strong ETag such as "3".
+func parseETag(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version < 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// writeVersionError writes the response for a failed versioned write: 412 when If-Match
+// named a stale version, 409 when a concurrent write got in first. It returns false for
+// any other error.
+func writeVersionError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrVersionMismatch):
+		writeError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, services.ErrEditConflict):
+		writeError(w, http.StatusConflict, "modified concurrently, reload and try again")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"expensify/internal/api"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIfMatch_StrongComparison(t *testing.T) {
+	_, userRepo, sessionRepo := newMemoryAuthRepos()
+	authSvc := services.NewAuthService(userRepo, sessionRepo, services.DefaultSessionPolicy(), []byte("test-secret"))
+	tokenSvc := services.NewTokenService(&testutil.MockAPITokenRepo{}, userRepo)
+	ledgerRepo := &testutil.MockLedgerRepo{
+		UpsertPersonalFn: func(_ context.Context, uid primitive.ObjectID) (*models.Ledger, error) {
+			return &models.Ledger{ID: primitive.NewObjectID(), Members: []models.LedgerMember{{UserID: uid, Role: models.RoleOwner}}}, nil
+		},
+	}
+	txRepo := &testutil.MockTransactionRepo{
+		FindByIDFn: func(_ context.Context, lid, id primitive.ObjectID) (*models.Transaction, error) {
+			return &models.Transaction{ID: id, LedgerID: lid, Amount: 5, Version: 3}, nil
+		},
+	}
+	txSvc := services.NewTransactionService(txRepo, &testutil.MockCategoryRepo{}, ledgerRepo, &testutil.MockAuditRepo{})
+	router := api.NewRouter(api.Services{Auth: authSvc, Tokens: tokenSvc, Transactions: txSvc}, api.RouterConfig{
+		FrontendURL: frontendURL,
+		DevLogin:    true,
+	})
+
+	cookies := serve(router, httptest.NewRequest(http.MethodGet, "/auth/dev?name=erin", nil), nil).Cookies()
+	csrf := serve(router, httptest.NewRequest(http.MethodGet, "/auth/me", nil), cookies).Header.Get("X-CSRF-Token")
+
+	tests := []struct {
+		ifMatch []string
+		want    int
+	}{
+		{nil, http.StatusPreconditionRequired},
+		{[]string{`"3"`}, http.StatusNoContent},
+		{[]string{`*`}, http.StatusNoContent},
+		{[]string{`"1", "3"`}, http.StatusNoContent},
+		{[]string{`"1"`, `"3"`}, http.StatusNoContent},
+		{[]string{`"2"`}, http.StatusPreconditionFailed},
+		{[]string{`W/"3"`}, http.StatusPreconditionFailed},
+		{[]string{`"2", W/"3"`}, http.StatusPreconditionFailed},
+		{[]string{`3`}, http.StatusPreconditionFailed},
+	}
+	for _, tc := range tests {
+		req := httptest.NewRequest(http.MethodDelete, "/api/transactions/"+primitive.NewObjectID().Hex(), nil)
+		req.Header.Set("X-CSRF-Token", csrf)
+		for _, v := range tc.ifMatch {
+			req.Header.Add("If-Match", v)
+		}
+		if resp := serve(router, req, cookies); resp.StatusCode != tc.want {
+			t.Errorf("If-Match %q: got %d, want %d", tc.ifMatch, resp.StatusCode, tc.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expensify/internal/middleware"
+	"expensify/internal/models"
+	"expensify/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ExpenseReportHandler handles expense reports and their approval workflow in the
+// selected ledger.
+type ExpenseReportHandler struct {
+	svc services.ExpenseReportService
+}
+
+// NewExpenseReportHandler constructs an ExpenseReportHandler.
+func NewExpenseReportHandler(svc services.ExpenseReportService) *ExpenseReportHandler {
+	return &ExpenseReportHandler{svc: svc}
+}
+
+// List returns the ledger's reports, optionally filtered by ?status=.
+func (h *ExpenseReportHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	reports, err := h.svc.List(r.Context(), user.ID.Hex(), ledgerID(r), r.URL.Query().Get("status"))
+	if err != nil {
+		h.writeErr(w, err, "failed to fetch expense reports")
+		return
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+// Create drafts a report of the caller's outflow transactions.
+func (h *ExpenseReportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.ExpenseReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	report, err := h.svc.Create(r.Context(), user.ID.Hex(), ledgerID(r), req)
+	if err != nil {
+		h.writeErr(w, err, "failed to create expense report")
+		return
+	}
+	writeJSON(w, http.StatusCreated, report)
+}
+
+// Get returns a single report with its history.
+func (h *ExpenseReportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	report, err := h.svc.Get(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeErr(w, err, "failed to fetch expense report")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// Update changes the title and transactions of the caller's draft.
+func (h *ExpenseReportHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.ExpenseReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	report, err := h.svc.Update(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.writeErr(w, err, "failed to update expense report")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// Delete removes the caller's draft.
+func (h *ExpenseReportHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	if err := h.svc.Delete(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id")); err != nil {
+		h.writeErr(w, err, "failed to delete expense report")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reportAction is a workflow step of ExpenseReportService taking an optional note.
+type reportAction func(ctx context.Context, userID, ledgerID, reportID string, req services.ReportActionRequest) (*models.ExpenseReport, error)
+
+// Submit sends the caller's draft for approval.
+func (h *ExpenseReportHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.svc.Submit, "failed to submit expense report")
+}
+
+// Approve approves a submitted report. Approvers and owners only.
+func (h *ExpenseReportHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.svc.Approve, "failed to approve expense report")
+}
+
+// Reject rejects a submitted report. Approvers and owners only.
+func (h *ExpenseReportHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.act(w, r, h.svc.Reject, "failed to reject expense report")
+}
+
+// Reimburse marks an approved report paid and records the reimbursement inflow.
+// Approvers and owners only.
+func (h *ExpenseReportHandler) Reimburse(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.ReimburseReportRequest
+	if !decodeOptional(w, r, &req) {
+		return
+	}
+
+	report, err := h.svc.Reimburse(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.writeErr(w, err, "failed to reimburse expense report")
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *ExpenseReportHandler) act(w http.ResponseWriter, r *http.Request, action reportAction, fallback string) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.ReportActionRequest
+	if !decodeOptional(w, r, &req) {
+		return
+	}
+
+	report, err := action(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.writeErr(w, err, fallback)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *ExpenseReportHandler) writeErr(w http.ResponseWriter, err error, fallback string) {
+	if errors.Is(err, services.ErrSelfApproval) {
+		writeError(w, http.StatusForbidden, "you cannot approve or reimburse your own expense report")
+		return
+	}
+	if writeLedgerError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrInvalidID):
+		writeError(w, http.StatusBadRequest, "invalid id")
+	case errors.Is(err, services.ErrInvalidReport):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrUnknownCategory):
+		writeError(w, http.StatusBadRequest, "unknown category")
+	case errors.Is(err, services.ErrNotFound):
+		writeError(w, http.StatusNotFound, "expense report not found")
+	case errors.Is(err, services.ErrReportStatus):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, fallback)
+	}
+}
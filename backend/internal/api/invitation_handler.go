@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expensify/internal/middleware"
+	"expensify/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// InvitationHandler handles email invitations to ledgers.
+type InvitationHandler struct {
+	svc services.InvitationService
+}
+
+// NewInvitationHandler constructs an InvitationHandler.
+func NewInvitationHandler(svc services.InvitationService) *InvitationHandler {
+	return &InvitationHandler{svc: svc}
+}
+
+type acceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// List returns a ledger's pending invitations. Owners only.
+func (h *InvitationHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	invs, err := h.svc.List(r.Context(), user.ID.Hex(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeErr(w, err, "failed to fetch invitations")
+		return
+	}
+	writeJSON(w, http.StatusOK, invs)
+}
+
+// Create invites an email address to a ledger and mails the invitee a link. Owners only.
+func (h *InvitationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	inv, err := h.svc.Create(r.Context(), user.ID.Hex(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.writeErr(w, err, "failed to send invitation")
+		return
+	}
+	writeJSON(w, http.StatusCreated, inv)
+}
+
+// Revoke withdraws a pending invitation. Owners only.
+func (h *InvitationHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	if err := h.svc.Revoke(r.Context(), user.ID.Hex(), chi.URLParam(r, "id"), chi.URLParam(r, "invitationID")); err != nil {
+		h.writeErr(w, err, "failed to revoke invitation")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Accept joins the signed-in user to the ledger of the invitation token in the body.
+func (h *InvitationHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req acceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		writeError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	ledger, err := h.svc.Accept(r.Context(), user.ID.Hex(), req.Token)
+	if err != nil {
+		h.writeErr(w, err, "failed to accept invitation")
+		return
+	}
+	writeJSON(w, http.StatusOK, ledger)
+}
+
+func (h *InvitationHandler) writeErr(w http.ResponseWriter, err error, fallback string) {
+	if writeLedgerError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrInvalidID):
+		writeError(w, http.StatusBadRequest, "invalid id")
+	case errors.Is(err, services.ErrInvalidEmail):
+		writeError(w, http.StatusBadRequest, "invalid email address")
+	case errors.Is(err, services.ErrInvalidRole):
+		writeError(w, http.StatusBadRequest, "role must be owner, approver, editor or viewer")
+	case errors.Is(err, services.ErrInvalidSignedToken):
+		writeError(w, http.StatusBadRequest, "invalid or expired invitation link")
+	case errors.Is(err, services.ErrNotFound):
+		writeError(w, http.StatusNotFound, "invitation not found")
+	case errors.Is(err, services.ErrAlreadyMember):
+		writeError(w, http.StatusConflict, "already a member of this ledger")
+	case errors.Is(err, services.ErrInvitationEmailMismatch):
+		writeError(w, http.StatusForbidden, "this invitation was sent to another email address")
+	case errors.Is(err, services.ErrInvitationsDisabled):
+		writeError(w, http.StatusServiceUnavailable, "invitations are disabled because no mail server is configured")
+	case errors.Is(err, services.ErrInvitationUnavailable):
+		writeError(w, http.StatusGone, "this invitation has been used, revoked or has expired")
+	default:
+		writeError(w, http.StatusInternalServerError, fallback)
+	}
+}
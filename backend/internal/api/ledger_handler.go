@@ -0,0 +1,186 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expensify/internal/middleware"
+	"expensify/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// LedgerHeader selects the ledger a request acts on. Without it, requests use the
+// caller's default (personal) ledger.
+const LedgerHeader = "X-Ledger-ID"
+
+// LedgerHandler handles ledgers and their members.
+type LedgerHandler struct {
+	svc services.LedgerService
+}
+
+// NewLedgerHandler constructs a LedgerHandler.
+func NewLedgerHandler(svc services.LedgerService) *LedgerHandler {
+	return &LedgerHandler{svc: svc}
+}
+
+type renameLedgerRequest struct {
+	Name string `json:"name"`
+}
+
+type memberRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// List returns the ledgers the authenticated user belongs to with their role in each.
+func (h *LedgerHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	ledgers, err := h.svc.List(r.Context(), user.ID.Hex())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch ledgers")
+		return
+	}
+	writeJSON(w, http.StatusOK, ledgers)
+}
+
+// Create makes a new ledger owned by the authenticated user.
+func (h *LedgerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.CreateLedgerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ledger, err := h.svc.Create(r.Context(), user.ID.Hex(), req)
+	if err != nil {
+		h.writeErr(w, err, "failed to create ledger")
+		return
+	}
+	writeJSON(w, http.StatusCreated, ledger)
+}
+
+// Get returns a ledger the authenticated user belongs to.
+func (h *LedgerHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	ledger, err := h.svc.Get(r.Context(), user.ID.Hex(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeErr(w, err, "failed to fetch ledger")
+		return
+	}
+	writeJSON(w, http.StatusOK, ledger)
+}
+
+// Rename changes a ledger's name. Owners only.
+func (h *LedgerHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req renameLedgerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ledger, err := h.svc.Rename(r.Context(), user.ID.Hex(), chi.URLParam(r, "id"), req.Name)
+	if err != nil {
+		h.writeErr(w, err, "failed to rename ledger")
+		return
+	}
+	writeJSON(w, http.StatusOK, ledger)
+}
+
+// AddMember adds an existing user, identified by an email their login provider verified,
+// with the given role. Owners only.
+func (h *LedgerHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.AddMemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ledger, err := h.svc.AddMember(r.Context(), user.ID.Hex(), chi.URLParam(r, "id"), req)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "no account has verified that email; send an invitation instead")
+			return
+		}
+		h.writeErr(w, err, "failed to add member")
+		return
+	}
+	writeJSON(w, http.StatusOK, ledger)
+}
+
+// SetMemberRole changes a member's role. Owners only.
+func (h *LedgerHandler) SetMemberRole(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req memberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ledger, err := h.svc.SetMemberRole(r.Context(), user.ID.Hex(), chi.URLParam(r, "id"), chi.URLParam(r, "userID"), req.Role)
+	if err != nil {
+		h.writeErr(w, err, "failed to change member role")
+		return
+	}
+	writeJSON(w, http.StatusOK, ledger)
+}
+
+// RemoveMember removes a member. Owners may remove anyone; any member may remove themselves.
+func (h *LedgerHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	if err := h.svc.RemoveMember(r.Context(), user.ID.Hex(), chi.URLParam(r, "id"), chi.URLParam(r, "userID")); err != nil {
+		h.writeErr(w, err, "failed to remove member")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *LedgerHandler) writeErr(w http.ResponseWriter, err error, fallback string) {
+	if writeLedgerError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrInvalidID):
+		writeError(w, http.StatusBadRequest, "invalid id")
+	case errors.Is(err, services.ErrInvalidName):
+		writeError(w, http.StatusBadRequest, "name must be 1 to 100 characters")
+	case errors.Is(err, services.ErrInvalidRole):
+		writeError(w, http.StatusBadRequest, "role must be owner, approver, editor or viewer")
+	case errors.Is(err, services.ErrNotFound):
+		writeError(w, http.StatusNotFound, "member not found")
+	case errors.Is(err, services.ErrAlreadyMember):
+		writeError(w, http.StatusConflict, "user is already a member")
+	case errors.Is(err, services.ErrLastOwner):
+		writeError(w, http.StatusConflict, "a ledger must keep at least one owner")
+	default:
+		writeError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+// ledgerID returns the ledger selected by the request's X-Ledger-ID header, or "" for
+// the caller's default ledger.
+func ledgerID(r *http.Request) string {
+	return r.Header.Get(LedgerHeader)
+}
+
+// writeLedgerError writes the response for ledger access errors and reports whether err
+// was one: 404 when the caller is not a member, 403 when their role is too weak.
+func writeLedgerError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrLedgerNotFound):
+		writeError(w, http.StatusNotFound, "ledger not found")
+	case errors.Is(err, services.ErrUnauthorized):
+		writeError(w, http.StatusForbidden, "insufficient role in ledger")
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expensify/internal/middleware"
+	"expensify/internal/services"
+)
+
+// PreferencesHandler serves the authenticated user's preferences.
+type PreferencesHandler struct {
+	svc services.PreferencesService
+}
+
+// NewPreferencesHandler constructs a PreferencesHandler.
+func NewPreferencesHandler(svc services.PreferencesService) *PreferencesHandler {
+	return &PreferencesHandler{svc: svc}
+}
+
+// Get returns the user's preferences, falling back to the defaults.
+func (h *PreferencesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	prefs, err := h.svc.Get(r.Context(), user.ID.Hex())
+	if err != nil {
+		h.writeErr(w, err, "failed to fetch preferences")
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+// Update replaces the user's preferences.
+func (h *PreferencesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.UpdatePreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	prefs, err := h.svc.Update(r.Context(), user.ID.Hex(), req)
+	if err != nil {
+		h.writeErr(w, err, "failed to update preferences")
+		return
+	}
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+func (h *PreferencesHandler) writeErr(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidID):
+		writeError(w, http.StatusBadRequest, "invalid id")
+	case errors.Is(err, services.ErrNotFound):
+		writeError(w, http.StatusNotFound, "user not found")
+	case errors.Is(err, services.ErrInvalidPreferences):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrInvalidTimeZone):
+		writeError(w, http.StatusBadRequest, "invalid time zone")
+	case errors.Is(err, services.ErrInvalidTransactionType):
+		writeError(w, http.StatusBadRequest, "default_transaction_type must be inflow or outflow")
+	default:
+		writeError(w, http.StatusInternalServerError, fallback)
+	}
+}
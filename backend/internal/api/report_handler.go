@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"expensify/internal/middleware"
+	"expensify/internal/services"
+)
+
+// ReportHandler serves analytical reports built on cashflow aggregates.
+type ReportHandler struct {
+	svc services.ReportService
+}
+
+// NewReportHandler constructs a ReportHandler.
+func NewReportHandler(svc services.ReportService) *ReportHandler {
+	return &ReportHandler{svc: svc}
+}
+
+// Compare returns per-category totals for two periods with deltas and the biggest movers.
+// Periods are chosen with one of:
+//   - ?year=YYYY — the calendar year against the one before it
+//   - ?month=YYYY-MM[&against=previous|last_year] — the month against the previous month (default)
+//     or the same month a year earlier
+//   - ?from=&to=&prev_from=&prev_to= — explicit YYYY-MM-DD dates, each range end-exclusive
+//
+// All dates are interpreted in the user's time zone. ?movers=N limits biggest_movers (default 5).
+func (h *ReportHandler) Compare(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	loc := userLocation(user)
+
+	current, previous, err := comparisonPeriods(r, loc)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.svc.Compare(r.Context(), user.ID.Hex(), ledgerID(r), current, previous, loc, queryInt(r, "movers", 0))
+	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidPeriod):
+			writeError(w, http.StatusBadRequest, "each period must start before it ends")
+		case errors.Is(err, services.ErrInvalidID):
+			writeError(w, http.StatusBadRequest, "invalid id")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to build comparison")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+// Forecast projects inflow and outflow for the coming months from historical per-category averages.
+// Accepts ?months=N (default 6, max 24), ?lookback=N complete past months (default 6, max 36),
+// ?seasonal=true to use the same month last year where the ledger has history for it,
+// ?include_scheduled=true to use future-dated transactions already recorded, and
+// ?opening_balance=X to anchor the running balance.
+func (h *ReportHandler) Forecast(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	q := r.URL.Query()
+
+	opts := services.ForecastOptions{
+		Months:           queryInt(r, "months", 0),
+		Lookback:         queryInt(r, "lookback", 0),
+		Seasonal:         q.Get("seasonal") == "true",
+		IncludeScheduled: q.Get("include_scheduled") == "true",
+	}
+	if v := q.Get("opening_balance"); v != "" {
+		balance, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid opening_balance")
+			return
+		}
+		opts.OpeningBalance = balance
+	}
+
+	forecast, err := h.svc.Forecast(r.Context(), user.ID.Hex(), ledgerID(r), time.Now().In(userLocation(user)), opts)
+	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidID) {
+			writeError(w, http.StatusBadRequest, "invalid id")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to build forecast")
+		return
+	}
+	writeJSON(w, http.StatusOK, forecast)
+}
+
+// Statistics returns per-category mean, median, standard deviation and percentile of monthly totals,
+// plus months and transactions that are outliers. Accepts ?months=N complete past months (default 12,
+// max 36), ?type=outflow|inflow (default outflow), ?sigma=X (default 3) and ?percentile=P (default 90).
+func (h *ReportHandler) Statistics(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	q := r.URL.Query()
+
+	opts := services.StatisticsOptions{
+		Months: queryInt(r, "months", 0),
+		Type:   q.Get("type"),
+	}
+	for key, dst := range map[string]*float64{"sigma": &opts.Sigma, "percentile": &opts.Percentile} {
+		if v := q.Get(key); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil || f <= 0 {
+				writeError(w, http.StatusBadRequest, "invalid "+key)
+				return
+			}
+			*dst = f
+		}
+	}
+
+	stats, err := h.svc.Statistics(r.Context(), user.ID.Hex(), ledgerID(r), time.Now().In(userLocation(user)), opts)
+	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidTransactionType):
+			writeError(w, http.StatusBadRequest, "type must be inflow or outflow")
+		case errors.Is(err, services.ErrInvalidID):
+			writeError(w, http.StatusBadRequest, "invalid id")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to compute statistics")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func comparisonPeriods(r *http.Request, loc *time.Location) (current, previous services.Period, err error) {
+	q := r.URL.Query()
+
+	if yearStr := q.Get("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil || year < 2000 || year > 2100 {
+			return current, previous, errors.New("invalid year")
+		}
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+		current = services.Period{Since: start, Until: start.AddDate(1, 0, 0)}
+		previous = services.Period{Since: start.AddDate(-1, 0, 0), Until: start}
+		return current, previous, nil
+	}
+
+	if monthStr := q.Get("month"); monthStr != "" {
+		m, err := time.ParseInLocation("2006-01", monthStr, loc)
+		if err != nil {
+			return current, previous, errors.New("invalid month, expected YYYY-MM")
+		}
+		current = services.Period{Since: m, Until: m.AddDate(0, 1, 0)}
+		switch q.Get("against") {
+		case "", "previous":
+			previous = services.Period{Since: m.AddDate(0, -1, 0), Until: m}
+		case "last_year":
+			previous = services.Period{Since: m.AddDate(-1, 0, 0), Until: m.AddDate(-1, 1, 0)}
+		default:
+			return current, previous, errors.New("against must be previous or last_year")
+		}
+		return current, previous, nil
+	}
+
+	dates := make(map[string]time.Time, 4)
+	for _, key := range []string{"from", "to", "prev_from", "prev_to"} {
+		v := q.Get(key)
+		if v == "" {
+			return current, previous, errors.New("year, month, or from/to/prev_from/prev_to is required")
+		}
+		d, err := time.ParseInLocation("2006-01-02", v, loc)
+		if err != nil {
+			return current, previous, errors.New("invalid " + key + ", expected YYYY-MM-DD")
+		}
+		dates[key] = d
+	}
+	current = services.Period{Since: dates["from"], Until: dates["to"]}
+	previous = services.Period{Since: dates["prev_from"], Until: dates["prev_to"]}
+	return current, previous, nil
+}
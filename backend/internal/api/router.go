@@ -2,68 +2,215 @@ package api
 
 import (
 	"expensify/internal/middleware"
+	"expensify/internal/models"
 	"expensify/internal/services"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
-	"golang.org/x/oauth2"
 )
 
+// RateLimits configures request limits per route group; a zero limit disables it.
+type RateLimits struct {
+	Store   middleware.RateLimitStore
+	Auth    middleware.RateLimit // public /auth routes, per IP
+	IP      middleware.RateLimit // protected routes, per IP, before authentication
+	API     middleware.RateLimit // authenticated routes, per user
+	Reports middleware.RateLimit // cashflow aggregations, per user, on top of API
+}
+
+// Services are the application services the router's handlers call.
+type Services struct {
+	Auth           services.AuthService
+	Categories     services.CategoryService
+	Transactions   services.TransactionService
+	Reports        services.ReportService
+	Tokens         services.TokenService
+	Preferences    services.PreferencesService
+	Ledgers        services.LedgerService
+	Invitations    services.InvitationService
+	Splits         services.SplitService
+	ExpenseReports services.ExpenseReportService
+	Audit          services.AuditService
+	Trash          services.TrashService
+	Idempotency    services.IdempotencyService
+}
+
+// RouterConfig holds the router's settings.
+type RouterConfig struct {
+	Providers     []services.IdentityProvider
+	FrontendURL   string
+	SecureCookies bool
+	DevLogin      bool
+	// TrustProxy takes the client IP from the proxy headers chi's RealIP understands
+	// instead of the peer address.
+	TrustProxy bool
+	Limits     RateLimits
+}
+
 // NewRouter builds and returns the fully configured chi router.
-func NewRouter(
-	authSvc services.AuthService,
-	catSvc services.CategoryService,
-	txSvc services.TransactionService,
-	oauthCfg *oauth2.Config,
-	frontendURL string,
-	secureCookies bool,
-) *chi.Mux {
+func NewRouter(svc Services, cfg RouterConfig) *chi.Mux {
 	r := chi.NewRouter()
 
 	// Global middleware
+	if cfg.TrustProxy {
+		// First, so that logs and per-IP limits see the client rather than the proxy.
+		r.Use(chimiddleware.RealIP)
+	}
 	r.Use(chimiddleware.Logger)
 	r.Use(chimiddleware.Recoverer)
 	r.Use(chimiddleware.RequestID)
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{frontendURL},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Content-Type"},
+		AllowedOrigins:   []string{cfg.FrontendURL},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "If-Match", middleware.IdempotencyKeyHeader, middleware.CSRFHeader, LedgerHeader},
+		ExposedHeaders:   []string{"ETag", middleware.IdempotentReplayedHeader, middleware.CSRFHeader},
 		AllowCredentials: true,
 		MaxAge:           86400, // cache preflight for 24 h
 	}))
 
-	authHandler := NewAuthHandler(authSvc, oauthCfg, frontendURL, secureCookies)
-	catHandler := NewCategoryHandler(catSvc)
-	txHandler := NewTransactionHandler(txSvc)
+	// Dev login is never served over secure cookies, i.e. in production.
+	devLogin := cfg.DevLogin && !cfg.SecureCookies
+	authHandler := NewAuthHandler(svc.Auth, cfg.Providers, cfg.FrontendURL, cfg.SecureCookies, devLogin)
+	catHandler := NewCategoryHandler(svc.Categories)
+	txHandler := NewTransactionHandler(svc.Transactions, svc.Preferences)
+	prefsHandler := NewPreferencesHandler(svc.Preferences)
+	reportHandler := NewReportHandler(svc.Reports)
+	tokenHandler := NewTokenHandler(svc.Tokens)
+	ledgerHandler := NewLedgerHandler(svc.Ledgers)
+	invHandler := NewInvitationHandler(svc.Invitations)
+	splitHandler := NewSplitHandler(svc.Splits)
+	expenseReportHandler := NewExpenseReportHandler(svc.ExpenseReports)
+	auditHandler := NewAuditHandler(svc.Audit)
+	trashHandler := NewTrashHandler(svc.Trash)
+
+	// Mutations made with the session cookie must echo the session's CSRF token.
+	csrf := middleware.RequireCSRF(svc.Auth)
 
 	// Public auth routes
 	r.Route("/auth", func(r chi.Router) {
-		r.Get("/google", authHandler.GoogleLogin)
-		r.Get("/google/callback", authHandler.GoogleCallback)
-		r.Post("/logout", authHandler.Logout)
+		r.Use(middleware.RateLimiter(cfg.Limits.Store, "auth", cfg.Limits.Auth))
+		r.Get("/providers", authHandler.Providers)
+		if devLogin {
+			r.Get("/dev", authHandler.DevLogin)
+		}
+		r.Get("/{provider}", authHandler.Login)
+		r.Get("/{provider}/callback", authHandler.Callback)
+		r.Post("/pending", authHandler.ResolvePendingLogin)
+		r.With(csrf).Post("/logout", authHandler.Logout)
 	})
 
-	// Protected routes
+	// Protected routes. API tokens are limited per route by scope: reads need
+	// "read", mutations need "write". Cookie sessions are unrestricted.
+	read := middleware.RequireScope(models.ScopeRead)
+	write := middleware.RequireScope(models.ScopeWrite)
+	// Creates honour an Idempotency-Key header so that clients can retry them safely.
+	idem := middleware.Idempotent(svc.Idempotency)
+
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.Authenticate(authSvc))
+		// Unauthenticated floods are limited per IP before they cost a session or token lookup.
+		r.Use(middleware.RateLimiter(cfg.Limits.Store, "ip", cfg.Limits.IP))
+		r.Use(middleware.Authenticate(svc.Auth, svc.Tokens, cfg.SecureCookies))
+		r.Use(middleware.RateLimiter(cfg.Limits.Store, "api", cfg.Limits.API))
+		r.Use(csrf)
+
+		r.With(read).Get("/auth/me", authHandler.Me)
+		r.With(write).Put("/auth/me/timezone", authHandler.UpdateTimeZone)
+		r.With(middleware.RequireSession).Get("/auth/{provider}/link", authHandler.Link)
+		r.With(middleware.RequireSession).Delete("/auth/me/identities/{provider}/{subject}", authHandler.UnlinkIdentity)
+
+		r.With(read).Get("/api/me/preferences", prefsHandler.Get)
+		r.With(write).Put("/api/me/preferences", prefsHandler.Update)
 
-		r.Get("/auth/me", authHandler.Me)
+		// Ledger data below is scoped to the ledger named by the X-Ledger-ID header.
+		r.Route("/api/ledgers", func(r chi.Router) {
+			r.With(read).Get("/", ledgerHandler.List)
+			r.With(write, idem).Post("/", ledgerHandler.Create)
+			r.With(read).Get("/{id}", ledgerHandler.Get)
+			r.With(write).Put("/{id}", ledgerHandler.Rename)
+			r.With(write).Post("/{id}/members", ledgerHandler.AddMember)
+			r.With(write).Put("/{id}/members/{userID}", ledgerHandler.SetMemberRole)
+			r.With(write).Delete("/{id}/members/{userID}", ledgerHandler.RemoveMember)
+			r.With(read).Get("/{id}/invitations", invHandler.List)
+			r.With(write, idem).Post("/{id}/invitations", invHandler.Create)
+			r.With(write).Delete("/{id}/invitations/{invitationID}", invHandler.Revoke)
+		})
+
+		// Invitations are accepted by the person who signed in, not by an API token.
+		r.With(middleware.RequireSession).Post("/api/invitations/accept", invHandler.Accept)
 
 		r.Route("/api/categories", func(r chi.Router) {
-			r.Get("/", catHandler.List)
-			r.Post("/", catHandler.Create)
-			r.Delete("/{id}", catHandler.Delete)
+			r.With(read).Get("/", catHandler.List)
+			r.With(write, idem).Post("/", catHandler.Create)
+			r.With(write).Delete("/{id}", catHandler.Delete)
 		})
 
 		r.Route("/api/transactions", func(r chi.Router) {
-			r.Get("/", txHandler.List)
-			r.Post("/", txHandler.Create)
-			r.Put("/{id}", txHandler.Update)
-			r.Delete("/{id}", txHandler.Delete)
+			r.With(read).Get("/", txHandler.List)
+			r.With(write, idem).Post("/", txHandler.Create)
+			r.With(write).Post("/bulk", txHandler.Bulk)
+			r.With(read).Get("/{id}", txHandler.Get)
+			r.With(write).Put("/{id}", txHandler.Update)
+			r.With(write).Patch("/{id}", txHandler.Patch)
+			r.With(write).Delete("/{id}", txHandler.Delete)
+		})
+
+		r.Route("/api/group-expenses", func(r chi.Router) {
+			r.With(read).Get("/", splitHandler.ListExpenses)
+			r.With(write, idem).Post("/", splitHandler.CreateExpense)
+			r.With(write).Delete("/{id}", splitHandler.DeleteExpense)
+		})
+
+		r.Route("/api/settlements", func(r chi.Router) {
+			r.With(read).Get("/", splitHandler.ListSettlements)
+			r.With(write, idem).Post("/", splitHandler.CreateSettlement)
+			r.With(write).Delete("/{id}", splitHandler.DeleteSettlement)
 		})
+		r.With(read).Get("/api/balances", splitHandler.Balances)
 
-		r.Get("/api/cashflow/summary", txHandler.Summary)
+		r.Route("/api/expense-reports", func(r chi.Router) {
+			r.With(read).Get("/", expenseReportHandler.List)
+			r.With(write, idem).Post("/", expenseReportHandler.Create)
+			r.With(read).Get("/{id}", expenseReportHandler.Get)
+			r.With(write).Put("/{id}", expenseReportHandler.Update)
+			r.With(write).Delete("/{id}", expenseReportHandler.Delete)
+			r.With(write).Post("/{id}/submit", expenseReportHandler.Submit)
+			r.With(write).Post("/{id}/approve", expenseReportHandler.Approve)
+			r.With(write).Post("/{id}/reject", expenseReportHandler.Reject)
+			r.With(write).Post("/{id}/reimburse", expenseReportHandler.Reimburse)
+		})
+
+		r.With(read).Get("/api/audit", auditHandler.List)
+
+		r.Route("/api/trash", func(r chi.Router) {
+			r.With(read).Get("/", trashHandler.List)
+			r.With(write).Post("/transactions/{id}/restore", trashHandler.RestoreTransaction)
+			r.With(write).Post("/categories/{id}/restore", trashHandler.RestoreCategory)
+		})
+
+		// Aggregations are the most expensive queries and get a tighter limit.
+		r.Route("/api/cashflow", func(r chi.Router) {
+			r.Use(middleware.RateLimiter(cfg.Limits.Store, "reports", cfg.Limits.Reports))
+			r.With(read).Get("/summary", txHandler.Summary)
+			r.With(read).Get("/compare", reportHandler.Compare)
+			r.With(read).Get("/forecast", reportHandler.Forecast)
+			r.With(read).Get("/statistics", reportHandler.Statistics)
+		})
+
+		// Session and token management is only available to interactive logins.
+		r.Route("/api/sessions", func(r chi.Router) {
+			r.Use(middleware.RequireSession)
+			r.Get("/", authHandler.ListSessions)
+			r.Delete("/", authHandler.RevokeOtherSessions)
+			r.Delete("/{id}", authHandler.RevokeSession)
+		})
+
+		r.Route("/api/tokens", func(r chi.Router) {
+			r.Use(middleware.RequireSession)
+			r.Get("/", tokenHandler.List)
+			r.Post("/", tokenHandler.Create)
+			r.Delete("/{id}", tokenHandler.Revoke)
+		})
 	})
 
 	return r
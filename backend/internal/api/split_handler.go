@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expensify/internal/middleware"
+	"expensify/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SplitHandler handles group expenses, settlements and the balances between members of
+// the selected ledger.
+type SplitHandler struct {
+	svc services.SplitService
+}
+
+// NewSplitHandler constructs a SplitHandler.
+func NewSplitHandler(svc services.SplitService) *SplitHandler {
+	return &SplitHandler{svc: svc}
+}
+
+// ListExpenses returns the ledger's group expenses, newest first.
+func (h *SplitHandler) ListExpenses(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	expenses, err := h.svc.ListExpenses(r.Context(), user.ID.Hex(), ledgerID(r))
+	if err != nil {
+		h.writeErr(w, err, "failed to fetch group expenses")
+		return
+	}
+	writeJSON(w, http.StatusOK, expenses)
+}
+
+// CreateExpense records an amount one member paid on behalf of several.
+func (h *SplitHandler) CreateExpense(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.CreateGroupExpenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	expense, err := h.svc.CreateExpense(r.Context(), user.ID.Hex(), ledgerID(r), req)
+	if err != nil {
+		h.writeErr(w, err, "failed to create group expense")
+		return
+	}
+	writeJSON(w, http.StatusCreated, expense)
+}
+
+// DeleteExpense removes a group expense.
+func (h *SplitHandler) DeleteExpense(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	if err := h.svc.DeleteExpense(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id")); err != nil {
+		h.writeErr(w, err, "failed to delete group expense")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSettlements returns the ledger's settlements, newest first.
+func (h *SplitHandler) ListSettlements(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	settlements, err := h.svc.ListSettlements(r.Context(), user.ID.Hex(), ledgerID(r))
+	if err != nil {
+		h.writeErr(w, err, "failed to fetch settlements")
+		return
+	}
+	writeJSON(w, http.StatusOK, settlements)
+}
+
+// CreateSettlement records a payment from one member to another.
+func (h *SplitHandler) CreateSettlement(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.CreateSettlementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	settlement, err := h.svc.CreateSettlement(r.Context(), user.ID.Hex(), ledgerID(r), req)
+	if err != nil {
+		h.writeErr(w, err, "failed to record settlement")
+		return
+	}
+	writeJSON(w, http.StatusCreated, settlement)
+}
+
+// DeleteSettlement removes a settlement.
+func (h *SplitHandler) DeleteSettlement(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	if err := h.svc.DeleteSettlement(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id")); err != nil {
+		h.writeErr(w, err, "failed to delete settlement")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Balances returns each member's net balance and the payments that settle them.
+func (h *SplitHandler) Balances(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	balances, err := h.svc.Balances(r.Context(), user.ID.Hex(), ledgerID(r))
+	if err != nil {
+		h.writeErr(w, err, "failed to compute balances")
+		return
+	}
+	writeJSON(w, http.StatusOK, balances)
+}
+
+func (h *SplitHandler) writeErr(w http.ResponseWriter, err error, fallback string) {
+	if writeLedgerError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrInvalidID):
+		writeError(w, http.StatusBadRequest, "invalid id")
+	case errors.Is(err, services.ErrInvalidSplit):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+	default:
+		writeError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"expensify/internal/middleware"
+	"expensify/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TokenHandler manages personal access tokens for the authenticated user.
+type TokenHandler struct {
+	svc services.TokenService
+}
+
+// NewTokenHandler constructs a TokenHandler.
+func NewTokenHandler(svc services.TokenService) *TokenHandler {
+	return &TokenHandler{svc: svc}
+}
+
+// List returns the user's tokens. Secrets are never included.
+func (h *TokenHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	tokens, err := h.svc.ListTokens(r.Context(), user.ID.Hex())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to fetch tokens")
+		return
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+// Create issues a new token. The secret is returned in this response only.
+func (h *TokenHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	created, err := h.svc.CreateToken(r.Context(), user.ID.Hex(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidTokenName):
+			writeError(w, http.StatusBadRequest, "name is required and must be at most 100 characters")
+		case errors.Is(err, services.ErrInvalidScope):
+			writeError(w, http.StatusBadRequest, "scopes must be a non-empty list of read or write")
+		case errors.Is(err, services.ErrInvalidExpiry):
+			writeError(w, http.StatusBadRequest, "expires_at must be in the future")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to create token")
+		}
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// Revoke deletes a token owned by the authenticated user.
+func (h *TokenHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	tokenID := chi.URLParam(r, "id")
+
+	if err := h.svc.RevokeToken(r.Context(), user.ID.Hex(), tokenID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			writeError(w, http.StatusNotFound, "token not found")
+		case errors.Is(err, services.ErrInvalidID):
+			writeError(w, http.StatusBadRequest, "invalid id")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to revoke token")
+		}
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
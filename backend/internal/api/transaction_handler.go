@@ -1,13 +1,18 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"expensify/internal/middleware"
+	"expensify/internal/models"
 	"expensify/internal/services"
 
 	"github.com/go-chi/chi/v5"
@@ -15,36 +20,48 @@ import (
 
 // TransactionHandler handles CRUD for spending transactions.
 type TransactionHandler struct {
-	svc services.TransactionService
+	svc      services.TransactionService
+	prefsSvc services.PreferencesService
 }
 
-// NewTransactionHandler constructs a TransactionHandler.
-func NewTransactionHandler(svc services.TransactionService) *TransactionHandler {
-	return &TransactionHandler{svc: svc}
+// NewTransactionHandler constructs a TransactionHandler. The user's preferences supply
+// defaults for parameters a request leaves out.
+func NewTransactionHandler(svc services.TransactionService, prefsSvc services.PreferencesService) *TransactionHandler {
+	return &TransactionHandler{svc: svc, prefsSvc: prefsSvc}
 }
 
-// List returns a paginated list of transactions for the authenticated user.
+// List returns a paginated list of transactions in the selected ledger.
+// page_size defaults to the user's preferred page size.
 func (h *TransactionHandler) List(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
+	prefs := h.preferences(r, user)
 
 	page := queryInt(r, "page", 1)
-	pageSize := queryInt(r, "page_size", 20)
+	pageSize := queryInt(r, "page_size", prefs.DefaultPageSize)
 	if page < 1 {
 		page = 1
 	}
 	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+		pageSize = prefs.DefaultPageSize
 	}
 
-	result, err := h.svc.List(r.Context(), user.ID.Hex(), page, pageSize)
+	result, err := h.svc.List(r.Context(), user.ID.Hex(), ledgerID(r), page, pageSize)
 	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidID) {
+			writeError(w, http.StatusBadRequest, "invalid ledger id")
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "failed to fetch transactions")
 		return
 	}
 	writeJSON(w, http.StatusOK, result)
 }
 
-// Create adds a new transaction for the authenticated user.
+// Create adds a new transaction to the selected ledger. A missing type defaults to
+// the user's preferred transaction type.
 func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 
@@ -53,55 +70,223 @@ func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	if req.Type == "" {
+		req.Type = h.preferences(r, user).DefaultTransactionType
+	}
 	if req.Amount == 0 || req.CategoryID == "" {
 		writeError(w, http.StatusBadRequest, "amount and category_id are required")
 		return
 	}
 
-	tx, err := h.svc.Create(r.Context(), user.ID.Hex(), req)
+	tx, err := h.svc.Create(r.Context(), user.ID.Hex(), ledgerID(r), req)
 	if err != nil {
-		if errors.Is(err, services.ErrInvalidID) {
-			writeError(w, http.StatusBadRequest, "invalid id")
+		if writeLedgerError(w, err) {
 			return
 		}
-		writeError(w, http.StatusInternalServerError, "failed to create transaction")
+		switch {
+		case errors.Is(err, services.ErrInvalidID):
+			writeError(w, http.StatusBadRequest, "invalid id")
+		case errors.Is(err, services.ErrUnknownCategory):
+			writeError(w, http.StatusBadRequest, "category is not available in this ledger")
+		case errors.Is(err, services.ErrInvalidTags):
+			writeError(w, http.StatusBadRequest, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to create transaction")
+		}
 		return
 	}
+	setETag(w, tx.Version)
 	writeJSON(w, http.StatusCreated, tx)
 }
 
-// Update modifies an existing transaction owned by the authenticated user.
+// Get returns one transaction of the selected ledger, with its version as the ETag.
+func (h *TransactionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	tx, err := h.svc.Get(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrNotFound):
+			writeError(w, http.StatusNotFound, "transaction not found")
+		case errors.Is(err, services.ErrInvalidID):
+			writeError(w, http.StatusBadRequest, "invalid id")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to fetch transaction")
+		}
+		return
+	}
+	setETag(w, tx.Version)
+	writeJSON(w, http.StatusOK, tx)
+}
+
+// transactionFields are the members of a transaction body. PUT must send all of them;
+// PATCH may send any.
+var transactionFields = []string{"category_id", "type", "amount", "description", "date", "tags"}
+
+// Update replaces an existing transaction in the selected ledger. The body must carry
+// every field, so that an omitted one is not silently zeroed; PATCH changes only some.
+// If-Match must carry the transaction's current ETag.
 func (h *TransactionHandler) Update(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	txID := chi.URLParam(r, "id")
+	versions, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
+	body, members, ok := decodeMembers(w, r)
+	if !ok {
+		return
+	}
+	var missing []string
+	for _, name := range transactionFields {
+		if v, ok := members[name]; !ok || isNull(v) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		writeError(w, http.StatusBadRequest, "missing fields: "+strings.Join(missing, ", ")+"; use PATCH to change only some fields")
+		return
+	}
 	var req services.UpdateTransactionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := json.Unmarshal(body, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
-	tx, err := h.svc.Update(r.Context(), user.ID.Hex(), txID, req)
+	tx, err := h.svc.Update(r.Context(), user.ID.Hex(), ledgerID(r), txID, versions, req)
 	if err != nil {
+		h.writeUpdateError(w, err, "failed to update transaction")
+		return
+	}
+	setETag(w, tx.Version)
+	writeJSON(w, http.StatusOK, tx)
+}
+
+// Patch changes some fields of a transaction in the selected ledger, following JSON
+// Merge Patch (RFC 7396): members present in the body are set and absent ones are kept.
+// A null description or tags clears it; the other fields are required and cannot be removed.
+// Values are checked as on create. If-Match must carry the transaction's current ETag.
+func (h *TransactionHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	txID := chi.URLParam(r, "id")
+	versions, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	body, members, ok := decodeMembers(w, r)
+	if !ok {
+		return
+	}
+	clearDescription, clearTags := false, false
+	for name, v := range members {
 		switch {
-		case errors.Is(err, services.ErrNotFound):
-			writeError(w, http.StatusNotFound, "transaction not found")
-		case errors.Is(err, services.ErrInvalidID):
-			writeError(w, http.StatusBadRequest, "invalid id")
-		default:
-			writeError(w, http.StatusInternalServerError, "failed to update transaction")
+		case !isTransactionField(name):
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown field %q", name))
+			return
+		case isNull(v) && name == "description":
+			clearDescription = true
+		case isNull(v) && name == "tags":
+			clearTags = true
+		case isNull(v):
+			writeError(w, http.StatusBadRequest, name+" is required and cannot be removed")
+			return
 		}
+	}
+	var req services.PatchTransactionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if clearDescription {
+		empty := ""
+		req.Description = &empty
+	}
+	if clearTags {
+		req.Tags = &[]string{}
+	}
+	if req.Type != nil && *req.Type == "" {
+		req.Type = &h.preferences(r, user).DefaultTransactionType
+	}
+	if (req.Amount != nil && *req.Amount == 0) || (req.CategoryID != nil && *req.CategoryID == "") {
+		writeError(w, http.StatusBadRequest, "amount and category_id are required")
+		return
+	}
+
+	tx, err := h.svc.Patch(r.Context(), user.ID.Hex(), ledgerID(r), txID, versions, req)
+	if err != nil {
+		h.writeUpdateError(w, err, "failed to update transaction")
 		return
 	}
+	setETag(w, tx.Version)
 	writeJSON(w, http.StatusOK, tx)
 }
 
-// Delete removes a transaction owned by the authenticated user.
+func (h *TransactionHandler) writeUpdateError(w http.ResponseWriter, err error, fallback string) {
+	if writeLedgerError(w, err) || writeVersionError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		writeError(w, http.StatusNotFound, "transaction not found")
+	case errors.Is(err, services.ErrInvalidID):
+		writeError(w, http.StatusBadRequest, "invalid id")
+	case errors.Is(err, services.ErrUnknownCategory):
+		writeError(w, http.StatusBadRequest, "category is not available in this ledger")
+	case errors.Is(err, services.ErrInvalidTags):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, fallback)
+	}
+}
+
+// decodeMembers reads a JSON object body, returning it along with its raw members so that
+// callers can tell absent members from null ones.
+func decodeMembers(w http.ResponseWriter, r *http.Request) ([]byte, map[string]json.RawMessage, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return nil, nil, false
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return nil, nil, false
+	}
+	return body, members, true
+}
+
+func isNull(v json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(v), []byte("null"))
+}
+
+func isTransactionField(name string) bool {
+	for _, f := range transactionFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete moves a transaction of the selected ledger to the trash. If-Match must carry
+// the transaction's current ETag.
 func (h *TransactionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
 	txID := chi.URLParam(r, "id")
+	versions, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
 
-	if err := h.svc.Delete(r.Context(), user.ID.Hex(), txID); err != nil {
+	if err := h.svc.Delete(r.Context(), user.ID.Hex(), ledgerID(r), txID, versions); err != nil {
+		if writeLedgerError(w, err) || writeVersionError(w, err) {
+			return
+		}
 		switch {
 		case errors.Is(err, services.ErrNotFound):
 			writeError(w, http.StatusNotFound, "transaction not found")
@@ -115,10 +300,49 @@ func (h *TransactionHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Summary returns aggregated cashflow data for the authenticated user.
+// Bulk applies one operation (recategorize, retag, delete or change-date) to many
+// transactions of the selected ledger, named by ids or selected by a filter. It takes no
+// If-Match: each target is written at the version read, and one changed in the meantime
+// is reported as a conflict. The response has matched and modified counts and a result
+// per transaction.
+func (h *TransactionHandler) Bulk(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+
+	var req services.BulkTransactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	result, err := h.svc.Bulk(r.Context(), user.ID.Hex(), ledgerID(r), req)
+	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, services.ErrInvalidBulkOperation), errors.Is(err, services.ErrInvalidFilter), errors.Is(err, services.ErrInvalidTags):
+			writeError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrInvalidID):
+			writeError(w, http.StatusBadRequest, "invalid id")
+		case errors.Is(err, services.ErrUnknownCategory):
+			writeError(w, http.StatusBadRequest, "category is not available in this ledger")
+		default:
+			writeError(w, http.StatusInternalServerError, "failed to apply bulk operation")
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Summary returns aggregated cashflow data for the selected ledger.
 // Accepts ?year=YYYY for a calendar year view, or ?months=N for a trailing window (default 12).
+// Period boundaries and monthly buckets use the user's time zone, and totals are labelled
+// with the user's home currency.
 func (h *TransactionHandler) Summary(w http.ResponseWriter, r *http.Request) {
 	user := middleware.UserFromContext(r.Context())
+	prefs := h.preferences(r, user)
+
+	loc := userLocation(user)
 
 	var since, until time.Time
 
@@ -128,8 +352,8 @@ func (h *TransactionHandler) Summary(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusBadRequest, "invalid year")
 			return
 		}
-		since = time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
-		until = time.Date(year+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+		since = time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+		until = time.Date(year+1, time.January, 1, 0, 0, 0, 0, loc)
 	} else {
 		months := queryInt(r, "months", 12)
 		if months < 1 {
@@ -138,12 +362,16 @@ func (h *TransactionHandler) Summary(w http.ResponseWriter, r *http.Request) {
 		if months > 24 {
 			months = 24
 		}
-		since = time.Now().AddDate(0, -months, 0)
+		start := time.Now().In(loc).AddDate(0, -months, 0)
+		since = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
 		// until is zero — no upper bound, shows up to now
 	}
 
-	summary, err := h.svc.Summary(r.Context(), user.ID.Hex(), since, until)
+	summary, err := h.svc.Summary(r.Context(), user.ID.Hex(), ledgerID(r), since, until, loc)
 	if err != nil {
+		if writeLedgerError(w, err) {
+			return
+		}
 		if errors.Is(err, services.ErrInvalidID) {
 			writeError(w, http.StatusBadRequest, "invalid id")
 			return
@@ -151,9 +379,31 @@ func (h *TransactionHandler) Summary(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "failed to fetch summary")
 		return
 	}
+	summary.Currency = prefs.Currency
 	writeJSON(w, http.StatusOK, summary)
 }
 
+// preferences returns the user's preferences, or the defaults if they cannot be loaded:
+// a preferences outage should not take listing and reporting down with it.
+func (h *TransactionHandler) preferences(r *http.Request, user *models.User) *models.Preferences {
+	if h.prefsSvc != nil {
+		if prefs, err := h.prefsSvc.Get(r.Context(), user.ID.Hex()); err == nil {
+			return prefs
+		}
+	}
+	return services.DefaultPreferences()
+}
+
+// userLocation returns the user's configured time zone, falling back to UTC so a
+// stale or corrupt stored zone does not break reporting endpoints.
+func userLocation(user *models.User) *time.Location {
+	loc, err := services.LoadUserLocation(user.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
 func queryInt(r *http.Request, key string, defaultVal int) int {
 	v := r.URL.Query().Get(key)
 	if v == "" {
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"expensify/internal/middleware"
+	"expensify/internal/services"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TrashHandler lists and restores the deleted transactions and categories of the
+// selected ledger.
+type TrashHandler struct {
+	svc services.TrashService
+}
+
+// NewTrashHandler constructs a TrashHandler.
+func NewTrashHandler(svc services.TrashService) *TrashHandler {
+	return &TrashHandler{svc: svc}
+}
+
+// List returns the trashed transactions and categories, most recently deleted first.
+func (h *TrashHandler) List(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	trash, err := h.svc.List(r.Context(), user.ID.Hex(), ledgerID(r))
+	if err != nil {
+		h.writeErr(w, err, "failed to fetch trash")
+		return
+	}
+	writeJSON(w, http.StatusOK, trash)
+}
+
+// RestoreTransaction takes a transaction out of the trash. It fails with 409 while the
+// transaction's category is itself in the trash.
+func (h *TrashHandler) RestoreTransaction(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	tx, err := h.svc.RestoreTransaction(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeErr(w, err, "failed to restore transaction")
+		return
+	}
+	setETag(w, tx.Version)
+	writeJSON(w, http.StatusOK, tx)
+}
+
+// RestoreCategory takes a custom category out of the trash.
+func (h *TrashHandler) RestoreCategory(w http.ResponseWriter, r *http.Request) {
+	user := middleware.UserFromContext(r.Context())
+	cat, err := h.svc.RestoreCategory(r.Context(), user.ID.Hex(), ledgerID(r), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeErr(w, err, "failed to restore category")
+		return
+	}
+	setETag(w, cat.Version)
+	writeJSON(w, http.StatusOK, cat)
+}
+
+func (h *TrashHandler) writeErr(w http.ResponseWriter, err error, fallback string) {
+	if writeLedgerError(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrInvalidID):
+		writeError(w, http.StatusBadRequest, "invalid id")
+	case errors.Is(err, services.ErrNotFound):
+		writeError(w, http.StatusNotFound, "not found in trash")
+	case errors.Is(err, services.ErrUnknownCategory):
+		writeError(w, http.StatusConflict, "the transaction's category is in the trash, restore it first")
+	default:
+		writeError(w, http.StatusInternalServerError, fallback)
+	}
+}
@@ -3,6 +3,11 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"expensify/internal/middleware"
 
 	"github.com/joho/godotenv"
 )
@@ -17,6 +22,45 @@ type Config struct {
 	FrontendURL        string
 	Port               string
 	SecureCookies      bool // set true in production (HTTPS)
+	DevLogin           bool // allow /auth/dev sign-in as any named test user; local development only
+	// TrustProxyHeaders takes the client IP from True-Client-IP, X-Real-IP or
+	// X-Forwarded-For. Only enable it behind a reverse proxy that sets these headers.
+	TrustProxyHeaders bool
+
+	SessionTTL          time.Duration // lifetime of a session since it was last issued or renewed
+	SessionRefreshAfter float64       // fraction of SessionTTL after which use renews the session
+	SessionMaxLifetime  time.Duration // absolute cap from login, regardless of activity
+
+	OIDCProviders []OIDCProvider
+
+	// Outbound mail. Without SMTPAddr, mail is written to the log instead.
+	SMTPAddr      string // host:port
+	SMTPFrom      string
+	SMTPUsername  string
+	SMTPPassword  string
+	InvitationTTL time.Duration // how long a ledger invitation can be accepted
+
+	// Request limits per route group, written as "<requests>/<duration>" (e.g. "60/1m");
+	// "0" disables a limit.
+	RateLimitAuth    middleware.RateLimit // public /auth routes, per IP
+	RateLimitIP      middleware.RateLimit // protected routes, per IP, before authentication
+	RateLimitAPI     middleware.RateLimit // authenticated routes, per user
+	RateLimitReports middleware.RateLimit // cashflow aggregations, per user, on top of RateLimitAPI
+
+	// How long deleted transactions and categories stay restorable before they are purged.
+	TrashRetention time.Duration
+}
+
+// OIDCProvider configures an OpenID Connect issuer. Providers are listed by name in
+// OIDC_PROVIDERS and configured with OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET,
+// _REDIRECT_URL and optionally _SCOPES (space separated).
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
 }
 
 func Load() *Config {
@@ -30,16 +74,106 @@ func Load() *Config {
 		GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
 		GoogleClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
 		GoogleRedirectURL:  getEnv("GOOGLE_REDIRECT_URL", "http://localhost:8080/auth/google/callback"),
-		SessionSecret:      getEnv("SESSION_SECRET", "change-me-in-production"),
+		SessionSecret:      getEnv("SESSION_SECRET", ""),
 		FrontendURL:        getEnv("FRONTEND_URL", "http://localhost:5173"),
 		Port:               getEnv("PORT", "8080"),
 		SecureCookies:      getEnv("SECURE_COOKIES", "") == "true",
+		DevLogin:           getEnv("DEV_LOGIN", "") == "true",
+		TrustProxyHeaders:  getEnv("TRUST_PROXY_HEADERS", "") == "true",
+
+		SessionTTL:          getDuration("SESSION_TTL", 30*24*time.Hour),
+		SessionRefreshAfter: getFloat("SESSION_REFRESH_AFTER", 0.5),
+		SessionMaxLifetime:  getDuration("SESSION_MAX_LIFETIME", 90*24*time.Hour),
+
+		OIDCProviders: loadOIDCProviders(),
+
+		SMTPAddr:      getEnv("SMTP_ADDR", ""),
+		SMTPFrom:      getEnv("SMTP_FROM", "Expensify <no-reply@localhost>"),
+		SMTPUsername:  getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
+		InvitationTTL: getDuration("INVITATION_TTL", 7*24*time.Hour),
+
+		RateLimitAuth:    getRateLimit("RATE_LIMIT_AUTH", middleware.RateLimit{Requests: 20, Per: time.Minute}),
+		RateLimitIP:      getRateLimit("RATE_LIMIT_IP", middleware.RateLimit{Requests: 1200, Per: time.Minute}),
+		RateLimitAPI:     getRateLimit("RATE_LIMIT_API", middleware.RateLimit{Requests: 600, Per: time.Minute}),
+		RateLimitReports: getRateLimit("RATE_LIMIT_REPORTS", middleware.RateLimit{Requests: 60, Per: time.Minute}),
+
+		TrashRetention: getDuration("TRASH_RETENTION", 30*24*time.Hour),
 	}
 }
 
+func loadOIDCProviders() []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		p := OIDCProvider{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "")),
+		}
+		if p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+			log.Printf("OIDC provider %q needs %sISSUER, %sCLIENT_ID and %sREDIRECT_URL; skipping", name, prefix, prefix, prefix)
+			continue
+		}
+		providers = append(providers, p)
+	}
+	return providers
+}
+
 func getEnv(key, defaultVal string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
 	}
 	return defaultVal
 }
+
+func getDuration(key string, defaultVal time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultVal
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("invalid %s %q, using %s", key, v, defaultVal)
+		return defaultVal
+	}
+	return d
+}
+
+func getFloat(key string, defaultVal float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultVal
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f > 1 {
+		log.Printf("invalid %s %q, using %g", key, v, defaultVal)
+		return defaultVal
+	}
+	return f
+}
+
+func getRateLimit(key string, defaultVal middleware.RateLimit) middleware.RateLimit {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultVal
+	}
+	if v == "0" {
+		return middleware.RateLimit{}
+	}
+	n, per, ok := strings.Cut(v, "/")
+	requests, err := strconv.Atoi(n)
+	d, perErr := time.ParseDuration(per)
+	if !ok || err != nil || perErr != nil || requests <= 0 || d <= 0 {
+		log.Printf("invalid %s %q, using %d/%s", key, v, defaultVal.Requests, defaultVal.Per)
+		return defaultVal
+	}
+	return middleware.RateLimit{Requests: requests, Per: d}
+}
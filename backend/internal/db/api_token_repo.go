@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const apiTokensCollection = "api_tokens"
+
+type mongoAPITokenRepo struct {
+	col *mongo.Collection
+}
+
+// NewAPITokenRepository returns a MongoDB-backed APITokenRepository.
+func NewAPITokenRepository(db *mongo.Database) APITokenRepository {
+	return &mongoAPITokenRepo{col: db.Collection(apiTokensCollection)}
+}
+
+func (r *mongoAPITokenRepo) Create(ctx context.Context, token *models.APIToken) (*models.APIToken, error) {
+	token.ID = primitive.NewObjectID()
+	token.CreatedAt = time.Now()
+
+	if _, err := r.col.InsertOne(ctx, token); err != nil {
+		return nil, fmt.Errorf("api token create: %w", err)
+	}
+	return token, nil
+}
+
+func (r *mongoAPITokenRepo) FindByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	err := r.col.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("api token findByHash: %w", err)
+	}
+	return &token, nil
+}
+
+// FindByUserID returns the user's tokens, newest first.
+func (r *mongoAPITokenRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.APIToken, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("api token findByUserID: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var tokens []*models.APIToken
+	if err := cursor.All(ctx, &tokens); err != nil {
+		return nil, fmt.Errorf("api token decode list: %w", err)
+	}
+	return tokens, nil
+}
+
+func (r *mongoAPITokenRepo) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	if _, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"last_used_at": at}}); err != nil {
+		return fmt.Errorf("api token touchLastUsed: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a token only if it belongs to the given user.
+func (r *mongoAPITokenRepo) Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	result, err := r.col.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("api token delete: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EnsureAPITokenIndexes creates the unique hash lookup index and a TTL index so MongoDB
+// removes expired tokens. Tokens without an expiry are kept.
+func EnsureAPITokenIndexes(ctx context.Context, db *mongo.Database) error {
+	col := db.Collection(apiTokensCollection)
+	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
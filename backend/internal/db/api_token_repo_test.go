@@ -0,0 +1,81 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAPITokenRepo_CreateAndFindByHash(t *testing.T) {
+	database := testDB(t)
+	repo := db.NewAPITokenRepository(database)
+	ctx := context.Background()
+	if err := db.EnsureAPITokenIndexes(ctx, database); err != nil {
+		t.Fatalf("EnsureAPITokenIndexes: %v", err)
+	}
+
+	uid := primitive.NewObjectID()
+	created, err := repo.Create(ctx, &models.APIToken{UserID: uid, Name: "script", TokenHash: "hash-1", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	found, err := repo.FindByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("FindByHash: %v", err)
+	}
+	if found == nil || found.ID != created.ID {
+		t.Fatal("FindByHash returned wrong or nil token")
+	}
+
+	missing, err := repo.FindByHash(ctx, "nope")
+	if err != nil || missing != nil {
+		t.Errorf("expected nil for missing hash, got %v, %v", missing, err)
+	}
+
+	// Hashes are unique.
+	if _, err := repo.Create(ctx, &models.APIToken{UserID: uid, Name: "dup", TokenHash: "hash-1"}); err == nil {
+		t.Error("expected duplicate hash to be rejected")
+	}
+}
+
+func TestAPITokenRepo_ListTouchDelete(t *testing.T) {
+	repo := db.NewAPITokenRepository(testDB(t))
+	ctx := context.Background()
+
+	uid := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+	tok, _ := repo.Create(ctx, &models.APIToken{UserID: uid, Name: "a", TokenHash: "h-a"})
+	repo.Create(ctx, &models.APIToken{UserID: other, Name: "b", TokenHash: "h-b"})
+
+	list, err := repo.FindByUserID(ctx, uid)
+	if err != nil {
+		t.Fatalf("FindByUserID: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("expected 1 token for user, got %d", len(list))
+	}
+
+	now := time.Now()
+	if err := repo.TouchLastUsed(ctx, tok.ID, now); err != nil {
+		t.Fatalf("TouchLastUsed: %v", err)
+	}
+	found, _ := repo.FindByHash(ctx, "h-a")
+	if found.LastUsedAt == nil {
+		t.Error("expected last_used_at to be set")
+	}
+
+	if err := repo.Delete(ctx, tok.ID, other); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound deleting another user's token, got %v", err)
+	}
+	if err := repo.Delete(ctx, tok.ID, uid); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+}
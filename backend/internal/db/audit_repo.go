@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const auditCollection = "audit_log"
+
+type mongoAuditRepo struct {
+	col *mongo.Collection
+}
+
+// NewAuditRepository returns a MongoDB-backed AuditRepository.
+func NewAuditRepository(db *mongo.Database) AuditRepository {
+	return &mongoAuditRepo{col: db.Collection(auditCollection)}
+}
+
+func (r *mongoAuditRepo) Create(ctx context.Context, entry *models.AuditEntry) (*models.AuditEntry, error) {
+	entry.ID = primitive.NewObjectID()
+	if _, err := r.col.InsertOne(ctx, entry); err != nil {
+		return nil, fmt.Errorf("audit create: %w", err)
+	}
+	return entry, nil
+}
+
+func (r *mongoAuditRepo) Find(ctx context.Context, f AuditFilter) ([]*models.AuditEntry, error) {
+	filter := bson.M{}
+	if !f.LedgerID.IsZero() {
+		filter["ledger_id"] = f.LedgerID
+	}
+	if f.EntityType != "" {
+		filter["entity_type"] = f.EntityType
+	}
+	if !f.EntityID.IsZero() {
+		filter["entity_id"] = f.EntityID
+	}
+	at := bson.M{}
+	if !f.Since.IsZero() {
+		at["$gte"] = f.Since
+	}
+	if !f.Until.IsZero() {
+		at["$lt"] = f.Until
+	}
+	if len(at) > 0 {
+		filter["at"] = at
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}})
+	if f.Limit > 0 {
+		opts.SetLimit(int64(f.Limit))
+	}
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("audit find: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var entries []*models.AuditEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("audit decode list: %w", err)
+	}
+	return entries, nil
+}
+
+// EnsureAuditIndexes indexes the audit log by ledger and time, and by entity for the
+// history of a single record.
+func EnsureAuditIndexes(ctx context.Context, db *mongo.Database) error {
+	col := db.Collection(auditCollection)
+	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "at", Value: -1}}},
+		{Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "entity_type", Value: 1}, {Key: "entity_id", Value: 1}, {Key: "at", Value: -1}}},
+	})
+	return err
+}
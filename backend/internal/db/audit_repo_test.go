@@ -0,0 +1,60 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAuditRepo_FindFilters(t *testing.T) {
+	repo := db.NewAuditRepository(testDB(t))
+	ctx := context.Background()
+	ledgerID := primitive.NewObjectID()
+	txID, catID := primitive.NewObjectID(), primitive.NewObjectID()
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, e := range []*models.AuditEntry{
+		{EntityType: models.AuditEntityTransaction, EntityID: txID, Action: models.AuditCreate},
+		{EntityType: models.AuditEntityCategory, EntityID: catID, Action: models.AuditCreate},
+		{EntityType: models.AuditEntityTransaction, EntityID: txID, Action: models.AuditUpdate,
+			Changes: []models.FieldChange{{Field: "amount", Before: 10.0, After: 12.5}}},
+	} {
+		e.LedgerID = ledgerID
+		e.At = base.Add(time.Duration(i) * 24 * time.Hour)
+		if _, err := repo.Create(ctx, e); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	repo.Create(ctx, &models.AuditEntry{LedgerID: primitive.NewObjectID(), EntityType: models.AuditEntityTransaction, At: base})
+
+	all, err := repo.Find(ctx, db.AuditFilter{LedgerID: ledgerID})
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(all) != 3 || all[0].Action != models.AuditUpdate {
+		t.Fatalf("expected the ledger's 3 entries newest first, got %+v", all)
+	}
+	if c := all[0].Changes; len(c) != 1 || c[0].Before != 10.0 || c[0].After != 12.5 {
+		t.Errorf("changes not round-tripped: %+v", c)
+	}
+
+	txs, _ := repo.Find(ctx, db.AuditFilter{LedgerID: ledgerID, EntityType: models.AuditEntityTransaction, EntityID: txID})
+	if len(txs) != 2 {
+		t.Errorf("expected 2 transaction entries, got %d", len(txs))
+	}
+	ranged, _ := repo.Find(ctx, db.AuditFilter{LedgerID: ledgerID, Since: base.Add(time.Hour), Until: base.Add(48 * time.Hour)})
+	if len(ranged) != 1 || ranged[0].EntityID != catID {
+		t.Errorf("expected only the category entry in range, got %+v", ranged)
+	}
+	limited, _ := repo.Find(ctx, db.AuditFilter{LedgerID: ledgerID, Limit: 1})
+	if len(limited) != 1 {
+		t.Errorf("expected 1 entry with limit 1, got %d", len(limited))
+	}
+}
@@ -25,24 +25,29 @@ func NewCategoryRepository(db *mongo.Database) CategoryRepository {
 }
 
 func (r *mongoCategoryRepo) FindDefaultCategories(ctx context.Context) ([]*models.Category, error) {
-	cursor, err := r.col.Find(ctx, bson.M{"is_default": true})
+	cursor, err := r.col.Find(ctx, notDeleted(bson.M{"is_default": true}))
 	if err != nil {
 		return nil, fmt.Errorf("category findDefaults: %w", err)
 	}
 	return decodeCategoryList(ctx, cursor)
 }
 
-func (r *mongoCategoryRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Category, error) {
-	cursor, err := r.col.Find(ctx, bson.M{"user_id": userID})
+func (r *mongoCategoryRepo) FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Category, error) {
+	cursor, err := r.col.Find(ctx, notDeleted(bson.M{"ledger_id": ledgerID}))
 	if err != nil {
-		return nil, fmt.Errorf("category findByUserID: %w", err)
+		return nil, fmt.Errorf("category findByLedgerID: %w", err)
 	}
 	return decodeCategoryList(ctx, cursor)
 }
 
-func (r *mongoCategoryRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error) {
+// visibleIn matches default categories and the custom categories of ledgerID.
+func visibleIn(ledgerID primitive.ObjectID) bson.A {
+	return bson.A{bson.M{"is_default": true}, bson.M{"ledger_id": ledgerID}}
+}
+
+func (r *mongoCategoryRepo) FindByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Category, error) {
 	var cat models.Category
-	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&cat)
+	err := r.col.FindOne(ctx, notDeleted(bson.M{"_id": id, "$or": visibleIn(ledgerID)})).Decode(&cat)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, nil
 	}
@@ -52,8 +57,8 @@ func (r *mongoCategoryRepo) FindByID(ctx context.Context, id primitive.ObjectID)
 	return &cat, nil
 }
 
-func (r *mongoCategoryRepo) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Category, error) {
-	cursor, err := r.col.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+func (r *mongoCategoryRepo) FindByIDs(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Category, error) {
+	cursor, err := r.col.Find(ctx, notDeleted(bson.M{"_id": bson.M{"$in": ids}, "$or": visibleIn(ledgerID)}))
 	if err != nil {
 		return nil, fmt.Errorf("category findByIDs: %w", err)
 	}
@@ -63,6 +68,7 @@ func (r *mongoCategoryRepo) FindByIDs(ctx context.Context, ids []primitive.Objec
 func (r *mongoCategoryRepo) Create(ctx context.Context, category *models.Category) (*models.Category, error) {
 	category.ID = primitive.NewObjectID()
 	category.CreatedAt = time.Now()
+	category.Version = 1
 
 	if _, err := r.col.InsertOne(ctx, category); err != nil {
 		return nil, fmt.Errorf("category create: %w", err)
@@ -70,17 +76,35 @@ func (r *mongoCategoryRepo) Create(ctx context.Context, category *models.Categor
 	return category, nil
 }
 
-// Delete removes a custom category only if it belongs to the given user.
-func (r *mongoCategoryRepo) Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
-	filter := bson.M{"_id": id, "user_id": userID, "is_default": false}
-	result, err := r.col.DeleteOne(ctx, filter)
-	if err != nil {
-		return fmt.Errorf("category delete: %w", err)
+// Delete moves a custom category to the trash only if it belongs to the given ledger and
+// is still at the given version.
+func (r *mongoCategoryRepo) Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error {
+	filter := bson.M{"_id": id, "ledger_id": ledgerID, "is_default": false}
+	return trash(ctx, r.col, filter, version, time.Now(), "category delete")
+}
+
+// FindDeleted returns the ledger's trashed categories, most recently deleted first.
+func (r *mongoCategoryRepo) FindDeleted(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Category, error) {
+	var categories []*models.Category
+	if err := findTrashed(ctx, r.col, bson.M{"ledger_id": ledgerID}, &categories, "category findDeleted"); err != nil {
+		return nil, err
 	}
-	if result.DeletedCount == 0 {
-		return ErrNotFound
+	return categories, nil
+}
+
+// Restore takes a custom category of the ledger out of the trash.
+func (r *mongoCategoryRepo) Restore(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) (*models.Category, error) {
+	var cat models.Category
+	if err := untrash(ctx, r.col, bson.M{"_id": id, "ledger_id": ledgerID}, &cat, "category restore"); err != nil {
+		return nil, err
 	}
-	return nil
+	return &cat, nil
+}
+
+// PurgeDeleted permanently removes categories trashed before the given time and returns
+// how many were removed.
+func (r *mongoCategoryRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	return purgeTrashed(ctx, r.col, before, "category purge")
 }
 
 func decodeCategoryList(ctx context.Context, cursor *mongo.Cursor) ([]*models.Category, error) {
@@ -37,16 +37,16 @@ func TestCategoryRepo_FindDefaultCategories(t *testing.T) {
 	}
 }
 
-func TestCategoryRepo_CreateAndFindByUserID(t *testing.T) {
+func TestCategoryRepo_CreateAndFindByLedgerID(t *testing.T) {
 	repo := db.NewCategoryRepository(testDB(t))
 	ctx := context.Background()
-	userID := primitive.NewObjectID()
+	ledgerID := primitive.NewObjectID()
 
 	cat := &models.Category{
-		UserID: &userID,
-		Name:   "Coffee",
-		Icon:   "☕",
-		Color:  "#6F4E37",
+		LedgerID: &ledgerID,
+		Name:     "Coffee",
+		Icon:     "☕",
+		Color:    "#6F4E37",
 	}
 	created, err := repo.Create(ctx, cat)
 	if err != nil {
@@ -56,40 +56,48 @@ func TestCategoryRepo_CreateAndFindByUserID(t *testing.T) {
 		t.Error("expected non-zero ID")
 	}
 
-	byUser, err := repo.FindByUserID(ctx, userID)
+	byLedger, err := repo.FindByLedgerID(ctx, ledgerID)
 	if err != nil {
-		t.Fatalf("FindByUserID: %v", err)
+		t.Fatalf("FindByLedgerID: %v", err)
 	}
-	if len(byUser) != 1 || byUser[0].Name != "Coffee" {
-		t.Errorf("unexpected categories: %v", byUser)
+	if len(byLedger) != 1 || byLedger[0].Name != "Coffee" {
+		t.Errorf("unexpected categories: %v", byLedger)
 	}
 }
 
 func TestCategoryRepo_FindByID(t *testing.T) {
 	repo := db.NewCategoryRepository(testDB(t))
 	ctx := context.Background()
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 
-	created, _ := repo.Create(ctx, &models.Category{UserID: &uid, Name: "Gym", Icon: "🏋", Color: "#fff"})
+	created, _ := repo.Create(ctx, &models.Category{LedgerID: &lid, Name: "Gym", Icon: "🏋", Color: "#fff"})
 
-	found, err := repo.FindByID(ctx, created.ID)
+	found, err := repo.FindByID(ctx, lid, created.ID)
 	if err != nil {
 		t.Fatalf("FindByID: %v", err)
 	}
 	if found == nil || found.ID != created.ID {
 		t.Error("FindByID returned wrong result")
 	}
+
+	other, err := repo.FindByID(ctx, primitive.NewObjectID(), created.ID)
+	if err != nil {
+		t.Fatalf("FindByID other ledger: %v", err)
+	}
+	if other != nil {
+		t.Error("a ledger's category should not be visible in another ledger")
+	}
 }
 
 func TestCategoryRepo_FindByIDs(t *testing.T) {
 	repo := db.NewCategoryRepository(testDB(t))
 	ctx := context.Background()
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 
-	c1, _ := repo.Create(ctx, &models.Category{UserID: &uid, Name: "A", Icon: "a", Color: "#aaa"})
-	c2, _ := repo.Create(ctx, &models.Category{UserID: &uid, Name: "B", Icon: "b", Color: "#bbb"})
+	c1, _ := repo.Create(ctx, &models.Category{LedgerID: &lid, Name: "A", Icon: "a", Color: "#aaa"})
+	c2, _ := repo.Create(ctx, &models.Category{LedgerID: &lid, Name: "B", Icon: "b", Color: "#bbb"})
 
-	found, err := repo.FindByIDs(ctx, []primitive.ObjectID{c1.ID, c2.ID})
+	found, err := repo.FindByIDs(ctx, lid, []primitive.ObjectID{c1.ID, c2.ID})
 	if err != nil {
 		t.Fatalf("FindByIDs: %v", err)
 	}
@@ -101,30 +109,30 @@ func TestCategoryRepo_FindByIDs(t *testing.T) {
 func TestCategoryRepo_Delete_OwnedCategory(t *testing.T) {
 	repo := db.NewCategoryRepository(testDB(t))
 	ctx := context.Background()
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 
-	cat, _ := repo.Create(ctx, &models.Category{UserID: &uid, Name: "ToDelete", Icon: "x", Color: "#000"})
+	cat, _ := repo.Create(ctx, &models.Category{LedgerID: &lid, Name: "ToDelete", Icon: "x", Color: "#000"})
 
-	if err := repo.Delete(ctx, cat.ID, uid); err != nil {
+	if err := repo.Delete(ctx, cat.ID, lid, cat.Version); err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
-	found, _ := repo.FindByID(ctx, cat.ID)
+	found, _ := repo.FindByID(ctx, lid, cat.ID)
 	if found != nil {
 		t.Error("expected category to be deleted")
 	}
 }
 
-func TestCategoryRepo_Delete_WrongUser(t *testing.T) {
+func TestCategoryRepo_Delete_WrongLedger(t *testing.T) {
 	repo := db.NewCategoryRepository(testDB(t))
 	ctx := context.Background()
 	ownerID := primitive.NewObjectID()
 	otherID := primitive.NewObjectID()
 
-	cat, _ := repo.Create(ctx, &models.Category{UserID: &ownerID, Name: "Protected", Icon: "x", Color: "#000"})
+	cat, _ := repo.Create(ctx, &models.Category{LedgerID: &ownerID, Name: "Protected", Icon: "x", Color: "#000"})
 
-	err := repo.Delete(ctx, cat.ID, otherID)
+	err := repo.Delete(ctx, cat.ID, otherID, cat.Version)
 	if err != db.ErrNotFound {
-		t.Errorf("expected ErrNotFound when deleting another user's category, got %v", err)
+		t.Errorf("expected ErrNotFound when deleting another ledger's category, got %v", err)
 	}
 }
 
@@ -134,9 +142,9 @@ func TestCategoryRepo_Delete_DefaultCategory(t *testing.T) {
 	seedDefaults(t, repo)
 
 	defaults, _ := repo.FindDefaultCategories(ctx)
-	anyUID := primitive.NewObjectID()
+	anyLedger := primitive.NewObjectID()
 
-	err := repo.Delete(ctx, defaults[0].ID, anyUID)
+	err := repo.Delete(ctx, defaults[0].ID, anyLedger, defaults[0].Version)
 	if err != db.ErrNotFound {
 		t.Errorf("expected ErrNotFound when deleting default category, got %v", err)
 	}
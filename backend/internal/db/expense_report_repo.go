@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const expenseReportsCollection = "expense_reports"
+
+type mongoExpenseReportRepo struct {
+	col *mongo.Collection
+}
+
+// NewExpenseReportRepository returns a MongoDB-backed ExpenseReportRepository.
+func NewExpenseReportRepository(db *mongo.Database) ExpenseReportRepository {
+	return &mongoExpenseReportRepo{col: db.Collection(expenseReportsCollection)}
+}
+
+func (r *mongoExpenseReportRepo) Create(ctx context.Context, report *models.ExpenseReport) (*models.ExpenseReport, error) {
+	report.ID = primitive.NewObjectID()
+	now := time.Now()
+	report.CreatedAt = now
+	report.UpdatedAt = now
+
+	if _, err := r.col.InsertOne(ctx, report); err != nil {
+		return nil, fmt.Errorf("expense report create: %w", err)
+	}
+	return report, nil
+}
+
+func (r *mongoExpenseReportRepo) FindByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.ExpenseReport, error) {
+	var report models.ExpenseReport
+	err := r.col.FindOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID}).Decode(&report)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expense report findByID: %w", err)
+	}
+	return &report, nil
+}
+
+func (r *mongoExpenseReportRepo) FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID, status string) ([]*models.ExpenseReport, error) {
+	filter := bson.M{"ledger_id": ledgerID}
+	if status != "" {
+		filter["status"] = status
+	}
+	return r.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}))
+}
+
+func (r *mongoExpenseReportRepo) FindClaiming(ctx context.Context, ledgerID primitive.ObjectID, txIDs []primitive.ObjectID, exclude primitive.ObjectID) ([]*models.ExpenseReport, error) {
+	return r.find(ctx, bson.M{
+		"_id":             bson.M{"$ne": exclude},
+		"ledger_id":       ledgerID,
+		"status":          bson.M{"$ne": models.ReportRejected},
+		"transaction_ids": bson.M{"$in": txIDs},
+	})
+}
+
+func (r *mongoExpenseReportRepo) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]*models.ExpenseReport, error) {
+	cursor, err := r.col.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("expense report find: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*models.ExpenseReport
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, fmt.Errorf("expense report decode list: %w", err)
+	}
+	return reports, nil
+}
+
+func (r *mongoExpenseReportRepo) UpdateDraft(ctx context.Context, report *models.ExpenseReport) (*models.ExpenseReport, error) {
+	filter := bson.M{"_id": report.ID, "ledger_id": report.LedgerID, "status": models.ReportDraft}
+	update := bson.M{"$set": bson.M{
+		"title":           report.Title,
+		"transaction_ids": report.TransactionIDs,
+		"total":           report.Total,
+		"updated_at":      time.Now(),
+	}}
+	return r.findOneAndUpdate(ctx, filter, update)
+}
+
+func (r *mongoExpenseReportRepo) Transition(ctx context.Context, report *models.ExpenseReport, from string, entry models.ReportTransition) (*models.ExpenseReport, error) {
+	filter := bson.M{"_id": report.ID, "ledger_id": report.LedgerID, "status": from}
+	set := bson.M{"status": report.Status, "total": report.Total, "updated_at": entry.At}
+	if report.ReimbursementID != nil {
+		set["reimbursement_id"] = report.ReimbursementID
+	}
+	return r.findOneAndUpdate(ctx, filter, bson.M{"$set": set, "$push": bson.M{"history": entry}})
+}
+
+func (r *mongoExpenseReportRepo) findOneAndUpdate(ctx context.Context, filter, update bson.M) (*models.ExpenseReport, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated models.ExpenseReport
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("expense report update: %w", err)
+	}
+	return &updated, nil
+}
+
+func (r *mongoExpenseReportRepo) DeleteDraft(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error {
+	result, err := r.col.DeleteOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID, "status": models.ReportDraft})
+	if err != nil {
+		return fmt.Errorf("expense report delete: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EnsureExpenseReportIndexes indexes reports by ledger for listing them and by transaction
+// for finding the reports that claim a transaction.
+func EnsureExpenseReportIndexes(ctx context.Context, db *mongo.Database) error {
+	col := db.Collection(expenseReportsCollection)
+	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "transaction_ids", Value: 1}}},
+	})
+	return err
+}
@@ -0,0 +1,76 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestExpenseReportRepo_TransitionOnlyFromStatus(t *testing.T) {
+	repo := db.NewExpenseReportRepository(testDB(t))
+	ctx := context.Background()
+	ledgerID, userID := primitive.NewObjectID(), primitive.NewObjectID()
+	txID := primitive.NewObjectID()
+
+	report, err := repo.Create(ctx, &models.ExpenseReport{
+		LedgerID: ledgerID, UserID: userID, Title: "Trip", TransactionIDs: []primitive.ObjectID{txID},
+		Total: 12.5, Status: models.ReportDraft,
+		History: []models.ReportTransition{{To: models.ReportDraft, By: userID, At: time.Now()}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	report.Status = models.ReportSubmitted
+	submitted, err := repo.Transition(ctx, report, models.ReportDraft, models.ReportTransition{From: models.ReportDraft, To: models.ReportSubmitted, By: userID, At: time.Now()})
+	if err != nil {
+		t.Fatalf("Transition: %v", err)
+	}
+	if submitted.Status != models.ReportSubmitted || len(submitted.History) != 2 {
+		t.Errorf("unexpected submitted report: %+v", submitted)
+	}
+	if _, err := repo.Transition(ctx, report, models.ReportDraft, models.ReportTransition{}); err != db.ErrNotFound {
+		t.Errorf("transitioning from a stale status: expected ErrNotFound, got %v", err)
+	}
+	if _, err := repo.UpdateDraft(ctx, report); err != db.ErrNotFound {
+		t.Errorf("updating a submitted report: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.DeleteDraft(ctx, report.ID, ledgerID); err != db.ErrNotFound {
+		t.Errorf("deleting a submitted report: expected ErrNotFound, got %v", err)
+	}
+
+	if list, _ := repo.FindByLedgerID(ctx, ledgerID, models.ReportSubmitted); len(list) != 1 {
+		t.Errorf("expected 1 submitted report, got %d", len(list))
+	}
+	if list, _ := repo.FindByLedgerID(ctx, ledgerID, models.ReportDraft); len(list) != 0 {
+		t.Errorf("expected no drafts, got %d", len(list))
+	}
+}
+
+func TestExpenseReportRepo_FindClaiming(t *testing.T) {
+	repo := db.NewExpenseReportRepository(testDB(t))
+	ctx := context.Background()
+	ledgerID := primitive.NewObjectID()
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+
+	active, _ := repo.Create(ctx, &models.ExpenseReport{LedgerID: ledgerID, TransactionIDs: []primitive.ObjectID{a}, Status: models.ReportSubmitted})
+	repo.Create(ctx, &models.ExpenseReport{LedgerID: ledgerID, TransactionIDs: []primitive.ObjectID{b}, Status: models.ReportRejected})
+
+	claiming, err := repo.FindClaiming(ctx, ledgerID, []primitive.ObjectID{a, b}, primitive.NilObjectID)
+	if err != nil {
+		t.Fatalf("FindClaiming: %v", err)
+	}
+	if len(claiming) != 1 || claiming[0].ID != active.ID {
+		t.Errorf("expected only the active report to claim, got %+v", claiming)
+	}
+	if claiming, _ := repo.FindClaiming(ctx, ledgerID, []primitive.ObjectID{a}, active.ID); len(claiming) != 0 {
+		t.Errorf("a report does not claim against itself, got %+v", claiming)
+	}
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const groupExpensesCollection = "group_expenses"
+
+type mongoGroupExpenseRepo struct {
+	col *mongo.Collection
+}
+
+// NewGroupExpenseRepository returns a MongoDB-backed GroupExpenseRepository.
+func NewGroupExpenseRepository(db *mongo.Database) GroupExpenseRepository {
+	return &mongoGroupExpenseRepo{col: db.Collection(groupExpensesCollection)}
+}
+
+func (r *mongoGroupExpenseRepo) Create(ctx context.Context, expense *models.GroupExpense) (*models.GroupExpense, error) {
+	expense.ID = primitive.NewObjectID()
+	if _, err := r.col.InsertOne(ctx, expense); err != nil {
+		return nil, fmt.Errorf("group expense create: %w", err)
+	}
+	return expense, nil
+}
+
+// FindByLedgerID returns all of the ledger's group expenses, newest first.
+func (r *mongoGroupExpenseRepo) FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.GroupExpense, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := r.col.Find(ctx, bson.M{"ledger_id": ledgerID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("group expense findByLedgerID: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var list []*models.GroupExpense
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("group expense decode list: %w", err)
+	}
+	return list, nil
+}
+
+// Delete removes a group expense only if it belongs to the given ledger.
+func (r *mongoGroupExpenseRepo) Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error {
+	result, err := r.col.DeleteOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID})
+	if err != nil {
+		return fmt.Errorf("group expense delete: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const idempotencyCollection = "idempotency_keys"
+
+type mongoIdempotencyRepo struct {
+	col *mongo.Collection
+}
+
+// NewIdempotencyRepository returns a MongoDB-backed IdempotencyRepository.
+func NewIdempotencyRepository(db *mongo.Database) IdempotencyRepository {
+	return &mongoIdempotencyRepo{col: db.Collection(idempotencyCollection)}
+}
+
+func (r *mongoIdempotencyRepo) Create(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	rec.ID = primitive.NewObjectID()
+	if _, err := r.col.InsertOne(ctx, rec); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicate
+		}
+		return nil, fmt.Errorf("idempotency create: %w", err)
+	}
+	return rec, nil
+}
+
+func (r *mongoIdempotencyRepo) FindByKey(ctx context.Context, userID primitive.ObjectID, key string) (*models.IdempotencyRecord, error) {
+	var rec models.IdempotencyRecord
+	err := r.col.FindOne(ctx, bson.M{"user_id": userID, "key": key}).Decode(&rec)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("idempotency findByKey: %w", err)
+	}
+	return &rec, nil
+}
+
+func (r *mongoIdempotencyRepo) SetResponse(ctx context.Context, id primitive.ObjectID, resp models.StoredResponse) error {
+	result, err := r.col.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"response": resp}})
+	if err != nil {
+		return fmt.Errorf("idempotency setResponse: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoIdempotencyRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := r.col.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("idempotency delete: %w", err)
+	}
+	return nil
+}
+
+// EnsureIdempotencyIndexes makes keys unique per user and expires records at expires_at.
+func EnsureIdempotencyIndexes(ctx context.Context, db *mongo.Database) error {
+	col := db.Collection(idempotencyCollection)
+	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}}, Options: options.Index().SetUnique(true)},
+	})
+	return err
+}
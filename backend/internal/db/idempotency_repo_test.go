@@ -0,0 +1,60 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestIdempotencyRepo_KeysAreUniquePerUser(t *testing.T) {
+	database := testDB(t)
+	if err := db.EnsureIdempotencyIndexes(context.Background(), database); err != nil {
+		t.Fatalf("EnsureIdempotencyIndexes: %v", err)
+	}
+	repo := db.NewIdempotencyRepository(database)
+	ctx := context.Background()
+
+	userID := primitive.NewObjectID()
+	record := func(uid primitive.ObjectID) *models.IdempotencyRecord {
+		return &models.IdempotencyRecord{UserID: uid, Key: "k1", RequestHash: "h", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	}
+	created, err := repo.Create(ctx, record(userID))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := repo.Create(ctx, record(userID)); err != db.ErrDuplicate {
+		t.Errorf("expected ErrDuplicate for a reused key, got %v", err)
+	}
+	if _, err := repo.Create(ctx, record(primitive.NewObjectID())); err != nil {
+		t.Errorf("expected another user to be able to use the key, got %v", err)
+	}
+
+	resp := models.StoredResponse{Status: 201, Header: map[string]string{"ETag": `"1"`}, Body: []byte(`{"data":{}}`)}
+	if err := repo.SetResponse(ctx, created.ID, resp); err != nil {
+		t.Fatalf("SetResponse: %v", err)
+	}
+	found, err := repo.FindByKey(ctx, userID, "k1")
+	if err != nil || found == nil {
+		t.Fatalf("FindByKey: %v, %v", found, err)
+	}
+	if found.Response == nil || found.Response.Status != 201 || string(found.Response.Body) != `{"data":{}}` || found.Response.Header["ETag"] != `"1"` {
+		t.Errorf("unexpected stored response %+v", found.Response)
+	}
+
+	if err := repo.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if found, _ := repo.FindByKey(ctx, userID, "k1"); found != nil {
+		t.Error("expected the record to be gone")
+	}
+	if err := repo.SetResponse(ctx, created.ID, resp); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound for a deleted record, got %v", err)
+	}
+}
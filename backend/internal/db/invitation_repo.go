@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const invitationsCollection = "invitations"
+
+type mongoInvitationRepo struct {
+	col *mongo.Collection
+}
+
+// NewInvitationRepository returns a MongoDB-backed InvitationRepository.
+func NewInvitationRepository(db *mongo.Database) InvitationRepository {
+	return &mongoInvitationRepo{col: db.Collection(invitationsCollection)}
+}
+
+// pendingAt matches invitations that have not been accepted or revoked and are
+// unexpired at now.
+func pendingAt(now time.Time) bson.M {
+	return bson.M{
+		"accepted_at": bson.M{"$exists": false},
+		"revoked_at":  bson.M{"$exists": false},
+		"expires_at":  bson.M{"$gt": now},
+	}
+}
+
+func (r *mongoInvitationRepo) Create(ctx context.Context, inv *models.Invitation) (*models.Invitation, error) {
+	inv.ID = primitive.NewObjectID()
+	if _, err := r.col.InsertOne(ctx, inv); err != nil {
+		return nil, fmt.Errorf("invitation create: %w", err)
+	}
+	return inv, nil
+}
+
+func (r *mongoInvitationRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Invitation, error) {
+	var inv models.Invitation
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&inv)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invitation findByID: %w", err)
+	}
+	return &inv, nil
+}
+
+func (r *mongoInvitationRepo) FindPendingByLedger(ctx context.Context, ledgerID primitive.ObjectID, now time.Time) ([]*models.Invitation, error) {
+	filter := pendingAt(now)
+	filter["ledger_id"] = ledgerID
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("invitation findPendingByLedger: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var invs []*models.Invitation
+	if err := cursor.All(ctx, &invs); err != nil {
+		return nil, fmt.Errorf("invitation decode list: %w", err)
+	}
+	return invs, nil
+}
+
+func (r *mongoInvitationRepo) MarkAccepted(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) (*models.Invitation, error) {
+	filter := pendingAt(at)
+	filter["_id"] = id
+	update := bson.M{"$set": bson.M{"accepted_at": at, "accepted_by": userID}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var inv models.Invitation
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&inv)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invitation markAccepted: %w", err)
+	}
+	return &inv, nil
+}
+
+func (r *mongoInvitationRepo) UnmarkAccepted(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) error {
+	filter := bson.M{"_id": id, "accepted_by": userID, "accepted_at": at}
+	update := bson.M{"$unset": bson.M{"accepted_at": "", "accepted_by": ""}}
+	if _, err := r.col.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("invitation unmarkAccepted: %w", err)
+	}
+	return nil
+}
+
+func (r *mongoInvitationRepo) Revoke(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, at time.Time) error {
+	filter := pendingAt(at)
+	filter["_id"] = id
+	filter["ledger_id"] = ledgerID
+
+	result, err := r.col.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"revoked_at": at}})
+	if err != nil {
+		return fmt.Errorf("invitation revoke: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EnsureInvitationIndexes indexes invitations by ledger for listing them.
+func EnsureInvitationIndexes(ctx context.Context, db *mongo.Database) error {
+	col := db.Collection(invitationsCollection)
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	return err
+}
@@ -0,0 +1,110 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestInvitationRepo_AcceptOnce(t *testing.T) {
+	repo := db.NewInvitationRepository(testDB(t))
+	ctx := context.Background()
+	ledgerID := primitive.NewObjectID()
+	now := time.Now()
+
+	inv, err := repo.Create(ctx, &models.Invitation{
+		LedgerID: ledgerID, Email: "sam@example.com", Role: models.RoleViewer,
+		CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	pending, err := repo.FindPendingByLedger(ctx, ledgerID, now)
+	if err != nil || len(pending) != 1 {
+		t.Fatalf("FindPendingByLedger: got %v, %v", pending, err)
+	}
+
+	userID := primitive.NewObjectID()
+	accepted, err := repo.MarkAccepted(ctx, inv.ID, userID, now)
+	if err != nil {
+		t.Fatalf("MarkAccepted: %v", err)
+	}
+	if accepted.AcceptedBy == nil || *accepted.AcceptedBy != userID || accepted.Status(now) != models.InvitationAccepted {
+		t.Errorf("unexpected accepted invitation: %+v", accepted)
+	}
+	if _, err := repo.MarkAccepted(ctx, inv.ID, primitive.NewObjectID(), now); err != db.ErrNotFound {
+		t.Errorf("accepting twice: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.Revoke(ctx, inv.ID, ledgerID, now); err != db.ErrNotFound {
+		t.Errorf("revoking an accepted invitation: expected ErrNotFound, got %v", err)
+	}
+	if pending, _ := repo.FindPendingByLedger(ctx, ledgerID, now); len(pending) != 0 {
+		t.Errorf("accepted invitations are not pending, got %v", pending)
+	}
+}
+
+func TestInvitationRepo_RevokeAndExpiry(t *testing.T) {
+	repo := db.NewInvitationRepository(testDB(t))
+	ctx := context.Background()
+	ledgerID := primitive.NewObjectID()
+	now := time.Now()
+
+	inv, _ := repo.Create(ctx, &models.Invitation{LedgerID: ledgerID, Email: "a@example.com", Role: models.RoleEditor, CreatedAt: now, ExpiresAt: now.Add(time.Hour)})
+	expired, _ := repo.Create(ctx, &models.Invitation{LedgerID: ledgerID, Email: "b@example.com", Role: models.RoleEditor, CreatedAt: now, ExpiresAt: now.Add(-time.Minute)})
+
+	if err := repo.Revoke(ctx, inv.ID, primitive.NewObjectID(), now); err != db.ErrNotFound {
+		t.Errorf("revoking through another ledger: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.Revoke(ctx, inv.ID, ledgerID, now); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := repo.MarkAccepted(ctx, inv.ID, primitive.NewObjectID(), now); err != db.ErrNotFound {
+		t.Errorf("accepting a revoked invitation: expected ErrNotFound, got %v", err)
+	}
+	if _, err := repo.MarkAccepted(ctx, expired.ID, primitive.NewObjectID(), now); err != db.ErrNotFound {
+		t.Errorf("accepting an expired invitation: expected ErrNotFound, got %v", err)
+	}
+	if pending, _ := repo.FindPendingByLedger(ctx, ledgerID, now); len(pending) != 0 {
+		t.Errorf("expected no pending invitations, got %v", pending)
+	}
+}
+
+func TestInvitationRepo_UnmarkAccepted(t *testing.T) {
+	repo := db.NewInvitationRepository(testDB(t))
+	ctx := context.Background()
+	now := time.Now()
+
+	inv, err := repo.Create(ctx, &models.Invitation{
+		LedgerID: primitive.NewObjectID(), Email: "sam@example.com", Role: models.RoleViewer,
+		CreatedAt: now, ExpiresAt: now.Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	userID := primitive.NewObjectID()
+	if _, err := repo.MarkAccepted(ctx, inv.ID, userID, now); err != nil {
+		t.Fatalf("MarkAccepted: %v", err)
+	}
+
+	// Only the claim being undone is released.
+	if err := repo.UnmarkAccepted(ctx, inv.ID, primitive.NewObjectID(), now); err != nil {
+		t.Fatalf("UnmarkAccepted: %v", err)
+	}
+	if got, _ := repo.FindByID(ctx, inv.ID); got.Status(now) != models.InvitationAccepted {
+		t.Errorf("another user's reset should not apply, got %s", got.Status(now))
+	}
+	if err := repo.UnmarkAccepted(ctx, inv.ID, userID, now); err != nil {
+		t.Fatalf("UnmarkAccepted: %v", err)
+	}
+	if got, _ := repo.FindByID(ctx, inv.ID); got.Status(now) != models.InvitationPending {
+		t.Errorf("expected the invitation to be pending again, got %s", got.Status(now))
+	}
+}
@@ -0,0 +1,272 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ledgersCollection = "ledgers"
+
+type mongoLedgerRepo struct {
+	col *mongo.Collection
+}
+
+// NewLedgerRepository returns a MongoDB-backed LedgerRepository.
+func NewLedgerRepository(db *mongo.Database) LedgerRepository {
+	return &mongoLedgerRepo{col: db.Collection(ledgersCollection)}
+}
+
+func (r *mongoLedgerRepo) Create(ctx context.Context, ledger *models.Ledger) (*models.Ledger, error) {
+	ledger.ID = primitive.NewObjectID()
+	now := time.Now()
+	ledger.CreatedAt = now
+	ledger.UpdatedAt = now
+
+	if _, err := r.col.InsertOne(ctx, ledger); err != nil {
+		return nil, fmt.Errorf("ledger create: %w", err)
+	}
+	return ledger, nil
+}
+
+// UpsertPersonal returns the user's personal ledger, creating it if there is none. The
+// unique personal_owner index makes concurrent calls settle on one ledger.
+func (r *mongoLedgerRepo) UpsertPersonal(ctx context.Context, userID primitive.ObjectID) (*models.Ledger, error) {
+	now := time.Now()
+	filter := bson.M{"personal_owner": userID}
+	update := bson.M{"$setOnInsert": bson.M{
+		"name":       models.PersonalLedgerName,
+		"members":    []models.LedgerMember{{UserID: userID, Role: models.RoleOwner, AddedAt: now}},
+		"created_at": now,
+		"updated_at": now,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var ledger models.Ledger
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ledger)
+	if mongo.IsDuplicateKeyError(err) {
+		// Another call inserted the ledger first; this time the filter finds it.
+		err = r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ledger)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ledger upsertPersonal: %w", err)
+	}
+	return &ledger, nil
+}
+
+func (r *mongoLedgerRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Ledger, error) {
+	var ledger models.Ledger
+	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&ledger)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ledger findByID: %w", err)
+	}
+	return &ledger, nil
+}
+
+// FindByMember returns the ledgers the user belongs to, oldest first.
+func (r *mongoLedgerRepo) FindByMember(ctx context.Context, userID primitive.ObjectID) ([]*models.Ledger, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := r.col.Find(ctx, bson.M{"members.user_id": userID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("ledger findByMember: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var ledgers []*models.Ledger
+	if err := cursor.All(ctx, &ledgers); err != nil {
+		return nil, fmt.Errorf("ledger decode list: %w", err)
+	}
+	return ledgers, nil
+}
+
+func (r *mongoLedgerRepo) Rename(ctx context.Context, id primitive.ObjectID, name string) (*models.Ledger, error) {
+	update := bson.M{"$set": bson.M{"name": name, "updated_at": time.Now()}}
+	return r.findOneAndUpdate(ctx, bson.M{"_id": id}, update, "ledger rename")
+}
+
+func (r *mongoLedgerRepo) AddMember(ctx context.Context, id primitive.ObjectID, member models.LedgerMember) (*models.Ledger, error) {
+	filter := bson.M{"_id": id, "members.user_id": bson.M{"$ne": member.UserID}}
+	update := bson.M{
+		"$push": bson.M{"members": member},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	ledger, err := r.findOneAndUpdate(ctx, filter, update, "ledger addMember")
+	if errors.Is(err, ErrNotFound) {
+		// Tell a missing ledger apart from an existing member.
+		if existing, findErr := r.FindByID(ctx, id); findErr == nil && existing != nil {
+			return nil, ErrDuplicate
+		}
+	}
+	return ledger, err
+}
+
+func (r *mongoLedgerRepo) SetMemberRole(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, role string) (*models.Ledger, error) {
+	filter := bson.M{"_id": id, "members.user_id": userID}
+	if role != models.RoleOwner {
+		filter = keepsOwner(id, userID)
+	}
+	// The positional $ would be ambiguous with two conditions on members, so the member is
+	// picked with an array filter instead.
+	update := bson.M{"$set": bson.M{"members.$[m].role": role, "updated_at": time.Now()}}
+	opts := options.FindOneAndUpdate().SetArrayFilters(options.ArrayFilters{
+		Filters: bson.A{bson.M{"m.user_id": userID}},
+	})
+	ledger, err := r.findOneAndUpdate(ctx, filter, update, "ledger setMemberRole", opts)
+	if errors.Is(err, ErrNotFound) {
+		return nil, r.missedMemberWrite(ctx, id, userID)
+	}
+	if err != nil || role == models.RoleOwner {
+		return ledger, err
+	}
+	return r.releasePersonal(ctx, ledger, userID)
+}
+
+func (r *mongoLedgerRepo) RemoveMember(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) (*models.Ledger, error) {
+	update := bson.M{
+		"$pull": bson.M{"members": bson.M{"user_id": userID}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	ledger, err := r.findOneAndUpdate(ctx, keepsOwner(id, userID), update, "ledger removeMember")
+	if errors.Is(err, ErrNotFound) {
+		return nil, r.missedMemberWrite(ctx, id, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.releasePersonal(ctx, ledger, userID)
+}
+
+// keepsOwner matches the ledger if userID is a member and someone else owns it, so that
+// demoting or removing userID in the same write cannot leave the ledger without an owner.
+func keepsOwner(id, userID primitive.ObjectID) bson.M {
+	return bson.M{"_id": id, "$and": bson.A{
+		bson.M{"members.user_id": userID},
+		bson.M{"members": bson.M{"$elemMatch": bson.M{
+			"role":    models.RoleOwner,
+			"user_id": bson.M{"$ne": userID},
+		}}},
+	}}
+}
+
+// missedMemberWrite explains why a member write matched nothing: ErrNotFound if the ledger
+// or the member is gone, ErrLastOwner if the member is the only owner.
+func (r *mongoLedgerRepo) missedMemberWrite(ctx context.Context, id, userID primitive.ObjectID) error {
+	ledger, err := r.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if ledger == nil || ledger.RoleOf(userID) == "" {
+		return ErrNotFound
+	}
+	return ErrLastOwner
+}
+
+// releasePersonal clears personal_owner once that user no longer owns the ledger, so that
+// UpsertPersonal gives them a new personal ledger should they come to own none.
+func (r *mongoLedgerRepo) releasePersonal(ctx context.Context, ledger *models.Ledger, userID primitive.ObjectID) (*models.Ledger, error) {
+	if ledger.PersonalOwner == nil || *ledger.PersonalOwner != userID {
+		return ledger, nil
+	}
+	filter := bson.M{"_id": ledger.ID, "personal_owner": userID}
+	if _, err := r.col.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"personal_owner": ""}}); err != nil {
+		return nil, fmt.Errorf("ledger releasePersonal: %w", err)
+	}
+	ledger.PersonalOwner = nil
+	return ledger, nil
+}
+
+func (r *mongoLedgerRepo) findOneAndUpdate(ctx context.Context, filter, update bson.M, op string, extra ...*options.FindOneAndUpdateOptions) (*models.Ledger, error) {
+	opts := append([]*options.FindOneAndUpdateOptions{options.FindOneAndUpdate().SetReturnDocument(options.After)}, extra...)
+
+	var result models.Ledger
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts...).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return &result, nil
+}
+
+// MigratePersonalLedgers moves transactions and custom categories created before ledgers,
+// which carry only a user_id, into their user's oldest owned ledger, creating a personal
+// ledger where needed. It returns how many users had data moved and is a no-op once done.
+func MigratePersonalLedgers(ctx context.Context, db *mongo.Database) (int64, error) {
+	txCol := db.Collection(transactionsCollection)
+	catCol := db.Collection(categoriesCollection)
+	ledgerCol := db.Collection(ledgersCollection)
+
+	unmigrated := bson.M{"ledger_id": bson.M{"$exists": false}, "user_id": bson.M{"$exists": true}}
+	txUsers, err := txCol.Distinct(ctx, "user_id", unmigrated)
+	if err != nil {
+		return 0, fmt.Errorf("ledger migrate: listing transaction owners: %w", err)
+	}
+	catUsers, err := catCol.Distinct(ctx, "user_id", unmigrated)
+	if err != nil {
+		return 0, fmt.Errorf("ledger migrate: listing category owners: %w", err)
+	}
+
+	seen := make(map[primitive.ObjectID]bool)
+	var migrated int64
+	for _, v := range append(txUsers, catUsers...) {
+		uid, ok := v.(primitive.ObjectID)
+		if !ok || seen[uid] {
+			continue
+		}
+		seen[uid] = true
+
+		var ledger models.Ledger
+		ownerFilter := bson.M{"members": bson.M{"$elemMatch": bson.M{"user_id": uid, "role": models.RoleOwner}}}
+		opts := options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}})
+		err := ledgerCol.FindOne(ctx, ownerFilter, opts).Decode(&ledger)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			now := time.Now()
+			ledger = models.Ledger{
+				ID:            primitive.NewObjectID(),
+				Name:          models.PersonalLedgerName,
+				Members:       []models.LedgerMember{{UserID: uid, Role: models.RoleOwner, AddedAt: now}},
+				CreatedAt:     now,
+				UpdatedAt:     now,
+				PersonalOwner: &uid,
+			}
+			_, err = ledgerCol.InsertOne(ctx, ledger)
+		}
+		if err != nil {
+			return migrated, fmt.Errorf("ledger migrate: personal ledger for %s: %w", uid.Hex(), err)
+		}
+
+		filter := bson.M{"user_id": uid, "ledger_id": bson.M{"$exists": false}}
+		set := bson.M{"$set": bson.M{"ledger_id": ledger.ID}}
+		if _, err := txCol.UpdateMany(ctx, filter, set); err != nil {
+			return migrated, fmt.Errorf("ledger migrate: transactions of %s: %w", uid.Hex(), err)
+		}
+		if _, err := catCol.UpdateMany(ctx, filter, set); err != nil {
+			return migrated, fmt.Errorf("ledger migrate: categories of %s: %w", uid.Hex(), err)
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// EnsureLedgerIndexes indexes ledgers by member for listing a user's ledgers, and allows
+// each user at most one personal ledger.
+func EnsureLedgerIndexes(ctx context.Context, db *mongo.Database) error {
+	col := db.Collection(ledgersCollection)
+	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "members.user_id", Value: 1}}},
+		{Keys: bson.D{{Key: "personal_owner", Value: 1}}, Options: options.Index().SetUnique(true).SetSparse(true)},
+	})
+	return err
+}
@@ -0,0 +1,201 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestLedgerRepo_Members(t *testing.T) {
+	repo := db.NewLedgerRepository(testDB(t))
+	ctx := context.Background()
+	ownerID := primitive.NewObjectID()
+	memberID := primitive.NewObjectID()
+
+	ledger, err := repo.Create(ctx, &models.Ledger{
+		Name:    "House",
+		Members: []models.LedgerMember{{UserID: ownerID, Role: models.RoleOwner}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := repo.AddMember(ctx, ledger.ID, models.LedgerMember{UserID: memberID, Role: models.RoleViewer}); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	if _, err := repo.AddMember(ctx, ledger.ID, models.LedgerMember{UserID: memberID, Role: models.RoleEditor}); err != db.ErrDuplicate {
+		t.Errorf("adding a member twice: expected ErrDuplicate, got %v", err)
+	}
+
+	updated, err := repo.SetMemberRole(ctx, ledger.ID, memberID, models.RoleEditor)
+	if err != nil {
+		t.Fatalf("SetMemberRole: %v", err)
+	}
+	if updated.RoleOf(memberID) != models.RoleEditor || updated.RoleOf(ownerID) != models.RoleOwner {
+		t.Errorf("unexpected members after role change: %+v", updated.Members)
+	}
+
+	mine, err := repo.FindByMember(ctx, memberID)
+	if err != nil {
+		t.Fatalf("FindByMember: %v", err)
+	}
+	if len(mine) != 1 || mine[0].ID != ledger.ID {
+		t.Errorf("FindByMember: got %v", mine)
+	}
+
+	removed, err := repo.RemoveMember(ctx, ledger.ID, memberID)
+	if err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	if removed.RoleOf(memberID) != "" || len(removed.Members) != 1 {
+		t.Errorf("member should be gone: %+v", removed.Members)
+	}
+	if _, err := repo.RemoveMember(ctx, ledger.ID, memberID); err != db.ErrNotFound {
+		t.Errorf("removing a non-member: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLedgerRepo_KeepsLastOwner(t *testing.T) {
+	repo := db.NewLedgerRepository(testDB(t))
+	ctx := context.Background()
+	ownerID := primitive.NewObjectID()
+	otherID := primitive.NewObjectID()
+
+	ledger, err := repo.Create(ctx, &models.Ledger{
+		Name: "House",
+		Members: []models.LedgerMember{
+			{UserID: ownerID, Role: models.RoleOwner},
+			{UserID: otherID, Role: models.RoleOwner},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Two owners demoting each other at once: only one write may succeed.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, id := range []primitive.ObjectID{ownerID, otherID} {
+		wg.Add(1)
+		go func(i int, id primitive.ObjectID) {
+			defer wg.Done()
+			_, errs[i] = repo.SetMemberRole(ctx, ledger.ID, id, models.RoleEditor)
+		}(i, id)
+	}
+	wg.Wait()
+	if (errs[0] == nil) == (errs[1] == nil) || (errs[0] != db.ErrLastOwner && errs[1] != db.ErrLastOwner) {
+		t.Fatalf("expected exactly one demotion to fail with ErrLastOwner, got %v", errs)
+	}
+
+	after, _ := repo.FindByID(ctx, ledger.ID)
+	lastOwner := ownerID
+	if after.RoleOf(ownerID) != models.RoleOwner {
+		lastOwner = otherID
+	}
+	if after.RoleOf(lastOwner) != models.RoleOwner {
+		t.Fatalf("expected one owner left, got %+v", after.Members)
+	}
+	if _, err := repo.RemoveMember(ctx, ledger.ID, lastOwner); err != db.ErrLastOwner {
+		t.Errorf("removing the last owner: expected ErrLastOwner, got %v", err)
+	}
+	if _, err := repo.SetMemberRole(ctx, ledger.ID, primitive.NewObjectID(), models.RoleViewer); err != db.ErrNotFound {
+		t.Errorf("changing a non-member: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestLedgerRepo_UpsertPersonal(t *testing.T) {
+	database := testDB(t)
+	if err := db.EnsureLedgerIndexes(context.Background(), database); err != nil {
+		t.Fatalf("EnsureLedgerIndexes: %v", err)
+	}
+	repo := db.NewLedgerRepository(database)
+	ctx := context.Background()
+	uid := primitive.NewObjectID()
+
+	// Concurrent first requests settle on a single ledger.
+	var wg sync.WaitGroup
+	ids := make([]primitive.ObjectID, 8)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if ledger, err := repo.UpsertPersonal(ctx, uid); err == nil {
+				ids[i] = ledger.ID
+			} else {
+				t.Errorf("UpsertPersonal: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, id := range ids {
+		if id != ids[0] {
+			t.Fatalf("expected one personal ledger, got %v", ids)
+		}
+	}
+	ledgers, _ := repo.FindByMember(ctx, uid)
+	if len(ledgers) != 1 || ledgers[0].Name != models.PersonalLedgerName || ledgers[0].RoleOf(uid) != models.RoleOwner {
+		t.Fatalf("expected one owned personal ledger, got %+v", ledgers)
+	}
+
+	// Once the user stops owning it, the next upsert makes them a new one.
+	otherID := primitive.NewObjectID()
+	if _, err := repo.AddMember(ctx, ids[0], models.LedgerMember{UserID: otherID, Role: models.RoleOwner}); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	released, err := repo.SetMemberRole(ctx, ids[0], uid, models.RoleEditor)
+	if err != nil || released.PersonalOwner != nil {
+		t.Fatalf("expected the ledger to be released, got %+v, %v", released, err)
+	}
+	fresh, err := repo.UpsertPersonal(ctx, uid)
+	if err != nil || fresh.ID == ids[0] {
+		t.Errorf("expected a new personal ledger, got %+v, %v", fresh, err)
+	}
+}
+
+func TestMigratePersonalLedgers(t *testing.T) {
+	database := testDB(t)
+	ctx := context.Background()
+	uid := primitive.NewObjectID()
+
+	// Documents written before ledgers existed carry only a user_id.
+	if _, err := database.Collection("transactions").InsertOne(ctx, bson.M{"user_id": uid, "amount": 10, "date": time.Now()}); err != nil {
+		t.Fatalf("inserting legacy transaction: %v", err)
+	}
+	if _, err := database.Collection("categories").InsertOne(ctx, bson.M{"user_id": uid, "name": "Coffee"}); err != nil {
+		t.Fatalf("inserting legacy category: %v", err)
+	}
+
+	n, err := db.MigratePersonalLedgers(ctx, database)
+	if err != nil {
+		t.Fatalf("MigratePersonalLedgers: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("migrated users: got %d, want 1", n)
+	}
+
+	ledgers, err := db.NewLedgerRepository(database).FindByMember(ctx, uid)
+	if err != nil || len(ledgers) != 1 || ledgers[0].RoleOf(uid) != models.RoleOwner {
+		t.Fatalf("expected one owned personal ledger, got %v, %v", ledgers, err)
+	}
+	txs, total, err := db.NewTransactionRepository(database).FindByLedgerID(ctx, ledgers[0].ID, 1, 10)
+	if err != nil || total != 1 || len(txs) != 1 {
+		t.Errorf("transaction not moved into the ledger: %d, %v", total, err)
+	}
+	cats, err := db.NewCategoryRepository(database).FindByLedgerID(ctx, ledgers[0].ID)
+	if err != nil || len(cats) != 1 {
+		t.Errorf("category not moved into the ledger: %v, %v", cats, err)
+	}
+
+	if again, err := db.MigratePersonalLedgers(ctx, database); err != nil || again != 0 {
+		t.Errorf("second run should be a no-op, got %d, %v", again, err)
+	}
+}
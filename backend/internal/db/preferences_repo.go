@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const preferencesCollection = "preferences"
+
+type mongoPreferencesRepo struct {
+	col *mongo.Collection
+}
+
+// NewPreferencesRepository returns a MongoDB-backed PreferencesRepository.
+func NewPreferencesRepository(db *mongo.Database) PreferencesRepository {
+	return &mongoPreferencesRepo{col: db.Collection(preferencesCollection)}
+}
+
+func (r *mongoPreferencesRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Preferences, error) {
+	var prefs models.Preferences
+	err := r.col.FindOne(ctx, bson.M{"_id": userID}).Decode(&prefs)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("preferences findByUserID: %w", err)
+	}
+	return &prefs, nil
+}
+
+// Upsert replaces the user's preferences document, creating it on first save.
+func (r *mongoPreferencesRepo) Upsert(ctx context.Context, prefs *models.Preferences) (*models.Preferences, error) {
+	prefs.UpdatedAt = time.Now()
+	opts := options.Replace().SetUpsert(true)
+	if _, err := r.col.ReplaceOne(ctx, bson.M{"_id": prefs.UserID}, prefs, opts); err != nil {
+		return nil, fmt.Errorf("preferences upsert: %w", err)
+	}
+	return prefs, nil
+}
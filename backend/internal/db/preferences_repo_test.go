@@ -0,0 +1,39 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPreferencesRepo_UpsertAndFind(t *testing.T) {
+	repo := db.NewPreferencesRepository(testDB(t))
+	ctx := context.Background()
+	uid := primitive.NewObjectID()
+
+	missing, err := repo.FindByUserID(ctx, uid)
+	if err != nil || missing != nil {
+		t.Fatalf("expected nil before first save, got %v, %v", missing, err)
+	}
+
+	if _, err := repo.Upsert(ctx, &models.Preferences{UserID: uid, Currency: "EUR", DefaultPageSize: 50}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if _, err := repo.Upsert(ctx, &models.Preferences{UserID: uid, Currency: "GBP", DefaultPageSize: 10}); err != nil {
+		t.Fatalf("second Upsert: %v", err)
+	}
+
+	found, err := repo.FindByUserID(ctx, uid)
+	if err != nil {
+		t.Fatalf("FindByUserID: %v", err)
+	}
+	if found == nil || found.Currency != "GBP" || found.DefaultPageSize != 10 || found.UpdatedAt.IsZero() {
+		t.Errorf("expected the second save to replace the first, got %+v", found)
+	}
+}
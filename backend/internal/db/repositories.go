@@ -17,45 +17,247 @@ type MonthlyAgg struct {
 	Outflow float64
 }
 
-// CategoryAgg holds the total outflow for a single category.
+// CategoryAgg holds the total for a single category and transaction type.
 type CategoryAgg struct {
 	CategoryID primitive.ObjectID
 	Total      float64
 }
 
+// CategoryMonthlyAgg holds the total for a single category, transaction type and calendar month.
+type CategoryMonthlyAgg struct {
+	CategoryID primitive.ObjectID
+	Type       string
+	Year       int
+	Month      int
+	Total      float64
+}
+
+// CategoryAmountStats describes the distribution of individual transaction amounts in a category.
+type CategoryAmountStats struct {
+	CategoryID primitive.ObjectID
+	Count      int
+	Mean       float64
+	StdDev     float64
+}
+
 // UserRepository defines persistence operations for users.
 type UserRepository interface {
-	FindByGoogleID(ctx context.Context, googleID string) (*models.User, error)
+	FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error)
+	// FindByEmail matches email case-insensitively, returning the oldest such user.
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	// FindByVerifiedEmail is like FindByEmail but matches only identity emails their
+	// provider verified, never the user's self-asserted profile email.
+	FindByVerifiedEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id primitive.ObjectID) (*models.User, error)
-	Upsert(ctx context.Context, user *models.User) (*models.User, error)
+	// Create inserts a new user owning identity, or returns ErrDuplicate if a user holds it.
+	Create(ctx context.Context, identity models.Identity, profile *models.User) (*models.User, error)
+	// Upsert creates the user owning identity if none exists, or refreshes their profile fields.
+	Upsert(ctx context.Context, identity models.Identity, profile *models.User) (*models.User, error)
+	UpdateTimeZone(ctx context.Context, id primitive.ObjectID, timeZone string) (*models.User, error)
+	// AddIdentity attaches identity to the user. It returns ErrDuplicate if another user holds it.
+	AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.Identity) (*models.User, error)
+	// RemoveIdentity detaches an identity, refusing (ErrNotFound) to remove the user's last one.
+	RemoveIdentity(ctx context.Context, id primitive.ObjectID, provider, subject string) (*models.User, error)
+}
+
+// InvitationRepository defines persistence operations for ledger invitations.
+type InvitationRepository interface {
+	Create(ctx context.Context, inv *models.Invitation) (*models.Invitation, error)
+	// FindByID returns nil if the invitation does not exist.
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Invitation, error)
+	// FindPendingByLedger returns the ledger's unanswered, unexpired invitations, newest first.
+	FindPendingByLedger(ctx context.Context, ledgerID primitive.ObjectID, now time.Time) ([]*models.Invitation, error)
+	// MarkAccepted records that userID accepted a pending invitation. It returns ErrNotFound
+	// if the invitation has already been accepted, revoked or has expired, so each
+	// invitation is used at most once.
+	MarkAccepted(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) (*models.Invitation, error)
+	// UnmarkAccepted makes an invitation pending again if it is still marked accepted by
+	// userID at at, undoing a MarkAccepted whose follow-up failed.
+	UnmarkAccepted(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) error
+	// Revoke withdraws a pending invitation of the given ledger.
+	Revoke(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, at time.Time) error
+}
+
+// GroupExpenseRepository defines persistence operations for group expenses.
+type GroupExpenseRepository interface {
+	Create(ctx context.Context, expense *models.GroupExpense) (*models.GroupExpense, error)
+	FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.GroupExpense, error)
+	Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error
+}
+
+// SettlementRepository defines persistence operations for settle-up payments.
+type SettlementRepository interface {
+	Create(ctx context.Context, settlement *models.Settlement) (*models.Settlement, error)
+	FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Settlement, error)
+	Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error
+}
+
+// ExpenseReportRepository defines persistence operations for expense reports.
+type ExpenseReportRepository interface {
+	Create(ctx context.Context, report *models.ExpenseReport) (*models.ExpenseReport, error)
+	// FindByID returns nil if the report does not exist in the ledger.
+	FindByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.ExpenseReport, error)
+	// FindByLedgerID returns the ledger's reports, newest first, optionally only those in status.
+	FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID, status string) ([]*models.ExpenseReport, error)
+	// FindClaiming returns the reports other than exclude that claim any of the transactions;
+	// rejected reports claim none.
+	FindClaiming(ctx context.Context, ledgerID primitive.ObjectID, txIDs []primitive.ObjectID, exclude primitive.ObjectID) ([]*models.ExpenseReport, error)
+	// UpdateDraft replaces the title and transactions of a draft. It returns ErrNotFound if
+	// the report is no longer a draft.
+	UpdateDraft(ctx context.Context, report *models.ExpenseReport) (*models.ExpenseReport, error)
+	// Transition moves a report from status from to report.Status, saving its total and
+	// reimbursement and appending entry to its history. It returns ErrNotFound if the
+	// report is not in status from, so concurrent transitions cannot both succeed.
+	Transition(ctx context.Context, report *models.ExpenseReport, from string, entry models.ReportTransition) (*models.ExpenseReport, error)
+	// DeleteDraft removes a report that is still a draft.
+	DeleteDraft(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error
+}
+
+// AuditFilter selects audit entries. Zero fields match everything.
+type AuditFilter struct {
+	LedgerID   primitive.ObjectID
+	EntityType string
+	EntityID   primitive.ObjectID
+	Since      time.Time // inclusive
+	Until      time.Time // exclusive
+	Limit      int
+}
+
+// AuditRepository stores the append-only audit log; entries are never changed or removed.
+type AuditRepository interface {
+	Create(ctx context.Context, entry *models.AuditEntry) (*models.AuditEntry, error)
+	// Find returns matching entries, newest first.
+	Find(ctx context.Context, filter AuditFilter) ([]*models.AuditEntry, error)
+}
+
+// IdempotencyRepository stores the idempotency keys of create requests, unique per user.
+type IdempotencyRepository interface {
+	// Create returns ErrDuplicate if the user already has a record for the key.
+	Create(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	FindByKey(ctx context.Context, userID primitive.ObjectID, key string) (*models.IdempotencyRecord, error)
+	SetResponse(ctx context.Context, id primitive.ObjectID, resp models.StoredResponse) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+// PreferencesRepository defines persistence operations for user preferences.
+type PreferencesRepository interface {
+	// FindByUserID returns nil if the user has never saved preferences.
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Preferences, error)
+	Upsert(ctx context.Context, prefs *models.Preferences) (*models.Preferences, error)
 }
 
 // SessionRepository defines persistence operations for sessions.
+// Methods take the raw session token; implementations store and match only its hash.
 type SessionRepository interface {
 	Create(ctx context.Context, session *models.Session) (*models.Session, error)
 	FindByToken(ctx context.Context, token string) (*models.Session, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Session, error)
+	TouchLastSeen(ctx context.Context, token string, at time.Time, staleBefore time.Time) error
+	Renew(ctx context.Context, token string, expiresAt time.Time, at time.Time) error
 	Delete(ctx context.Context, token string) error
+	DeleteByID(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
+	DeleteOthers(ctx context.Context, userID primitive.ObjectID, keepToken string) (int64, error)
 	DeleteExpired(ctx context.Context) error
 }
 
+// APITokenRepository defines persistence operations for personal access tokens.
+type APITokenRepository interface {
+	Create(ctx context.Context, token *models.APIToken) (*models.APIToken, error)
+	FindByHash(ctx context.Context, hash string) (*models.APIToken, error)
+	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.APIToken, error)
+	TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
+}
+
+// LedgerRepository defines persistence operations for ledgers and their members.
+type LedgerRepository interface {
+	Create(ctx context.Context, ledger *models.Ledger) (*models.Ledger, error)
+	// UpsertPersonal returns the user's personal ledger, creating it if there is none; no
+	// matter how many calls race, the user gets one.
+	UpsertPersonal(ctx context.Context, userID primitive.ObjectID) (*models.Ledger, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Ledger, error)
+	// FindByMember returns the ledgers the user belongs to, oldest first.
+	FindByMember(ctx context.Context, userID primitive.ObjectID) ([]*models.Ledger, error)
+	Rename(ctx context.Context, id primitive.ObjectID, name string) (*models.Ledger, error)
+	// AddMember returns ErrDuplicate if the user is already a member.
+	AddMember(ctx context.Context, id primitive.ObjectID, member models.LedgerMember) (*models.Ledger, error)
+	// SetMemberRole and RemoveMember release a personal ledger its user no longer owns. They
+	// return ErrNotFound if the user is not a member and ErrLastOwner if demoting or removing
+	// them would leave the ledger without an owner.
+	SetMemberRole(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, role string) (*models.Ledger, error)
+	RemoveMember(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) (*models.Ledger, error)
+}
+
 // CategoryRepository defines persistence operations for categories.
+// Lookups by ID only see default categories and those of the given ledger. Delete moves a
+// custom category to the trash, which every other query ignores.
 type CategoryRepository interface {
 	FindDefaultCategories(ctx context.Context) ([]*models.Category, error)
-	FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Category, error)
-	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error)
-	FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Category, error)
+	FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Category, error)
+	FindByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Category, error)
+	FindByIDs(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Category, error)
 	Create(ctx context.Context, category *models.Category) (*models.Category, error)
-	Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
+	// Delete returns ErrConflict if the category is no longer at the given version.
+	Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error
+	FindDeleted(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Category, error)
+	// Restore returns ErrNotFound unless the category is in the ledger's trash.
+	Restore(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) (*models.Category, error)
+	// PurgeDeleted removes categories trashed before the given time, across all ledgers.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+// TransactionPatch holds the transaction fields a partial update changes; nil fields are
+// left as they are.
+type TransactionPatch struct {
+	CategoryID  *primitive.ObjectID
+	Type        *string
+	Amount      *float64
+	Description *string
+	Date        *time.Time
+	Tags        *[]string
+}
+
+// TransactionFilter selects transactions of a ledger for bulk operations. Zero fields
+// match everything; the date range is [Since, Until).
+type TransactionFilter struct {
+	CategoryID *primitive.ObjectID
+	Type       string
+	Tag        string
+	Since      time.Time
+	Until      time.Time
 }
 
 // TransactionRepository defines persistence operations for transactions.
+// Every query is scoped to a single ledger. Delete moves a transaction to the trash, which
+// every other query and aggregation ignores.
 type TransactionRepository interface {
 	Create(ctx context.Context, tx *models.Transaction) (*models.Transaction, error)
-	FindByID(ctx context.Context, id primitive.ObjectID) (*models.Transaction, error)
-	FindByUserID(ctx context.Context, userID primitive.ObjectID, page, pageSize int) ([]*models.Transaction, int64, error)
+	FindByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Transaction, error)
+	FindByIDs(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Transaction, error)
+	FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID, page, pageSize int) ([]*models.Transaction, int64, error)
+	// Update and Delete return ErrConflict if the transaction is no longer at the version
+	// given (tx.Version for Update).
 	Update(ctx context.Context, tx *models.Transaction) (*models.Transaction, error)
-	Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
-	ExistsByCategoryID(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID) (bool, error)
-	GetMonthlySummary(ctx context.Context, userID primitive.ObjectID, since, until time.Time) ([]*MonthlyAgg, error)
-	GetCategoryTotals(ctx context.Context, userID primitive.ObjectID, txType string, since, until time.Time) ([]*CategoryAgg, error)
+	// Patch changes only the fields set in patch; like Update it returns ErrConflict if the
+	// transaction is no longer at version.
+	Patch(ctx context.Context, id, ledgerID primitive.ObjectID, version int64, patch TransactionPatch) (*models.Transaction, error)
+	Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error
+	// Purge removes a live transaction outright, bypassing the trash, to undo a write that
+	// never took effect. Like Delete it returns ErrConflict for another version.
+	Purge(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error
+	// FindByFilter returns up to limit matching transactions of the ledger, date-descending.
+	FindByFilter(ctx context.Context, ledgerID primitive.ObjectID, filter TransactionFilter, limit int64) ([]*models.Transaction, error)
+	FindDeleted(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Transaction, error)
+	// FindDeletedByID returns nil unless the transaction is in the ledger's trash.
+	FindDeletedByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Transaction, error)
+	// Restore returns ErrNotFound unless the transaction is in the ledger's trash.
+	Restore(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) (*models.Transaction, error)
+	// PurgeDeleted removes transactions trashed before the given time, across all ledgers.
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	ExistsByCategoryID(ctx context.Context, ledgerID primitive.ObjectID, categoryID primitive.ObjectID) (bool, error)
+	GetMonthlySummary(ctx context.Context, ledgerID primitive.ObjectID, since, until time.Time, timeZone string) ([]*MonthlyAgg, error)
+	GetCategoryTotals(ctx context.Context, ledgerID primitive.ObjectID, txType string, since, until time.Time) ([]*CategoryAgg, error)
+	GetCategoryMonthlyTotals(ctx context.Context, ledgerID primitive.ObjectID, since, until time.Time, timeZone string) ([]*CategoryMonthlyAgg, error)
+	GetCategoryAmountStats(ctx context.Context, ledgerID primitive.ObjectID, txType string, since, until time.Time) ([]*CategoryAmountStats, error)
+	FindAmountsAbove(ctx context.Context, ledgerID primitive.ObjectID, txType string, thresholds map[primitive.ObjectID]float64, since, until time.Time) ([]*models.Transaction, error)
 }
@@ -28,6 +28,8 @@ func NewSessionRepository(db *mongo.Database) SessionRepository {
 func (r *mongoSessionRepo) Create(ctx context.Context, session *models.Session) (*models.Session, error) {
 	session.ID = primitive.NewObjectID()
 	session.CreatedAt = time.Now()
+	session.LastSeenAt = session.CreatedAt
+	session.TokenHash = models.HashToken(session.Token)
 
 	if _, err := r.col.InsertOne(ctx, session); err != nil {
 		return nil, fmt.Errorf("session create: %w", err)
@@ -37,7 +39,7 @@ func (r *mongoSessionRepo) Create(ctx context.Context, session *models.Session)
 
 func (r *mongoSessionRepo) FindByToken(ctx context.Context, token string) (*models.Session, error) {
 	var session models.Session
-	err := r.col.FindOne(ctx, bson.M{"token": token}).Decode(&session)
+	err := r.col.FindOne(ctx, bson.M{"token_hash": models.HashToken(token)}).Decode(&session)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, nil
 	}
@@ -47,13 +49,81 @@ func (r *mongoSessionRepo) FindByToken(ctx context.Context, token string) (*mode
 	return &session, nil
 }
 
+// FindByUserID returns the user's unexpired sessions, most recently seen first.
+func (r *mongoSessionRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Session, error) {
+	filter := bson.M{"user_id": userID, "expires_at": bson.M{"$gt": time.Now()}}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("session findByUserID: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, fmt.Errorf("session decode list: %w", err)
+	}
+	return sessions, nil
+}
+
+// TouchLastSeen sets last_seen_at to at, but only if the stored value is older than staleBefore,
+// so busy sessions are written at most once per interval. Sessions created before last-seen
+// tracking have no stored value and are always updated.
+func (r *mongoSessionRepo) TouchLastSeen(ctx context.Context, token string, at time.Time, staleBefore time.Time) error {
+	filter := bson.M{
+		"token_hash": models.HashToken(token),
+		"$or": bson.A{
+			bson.M{"last_seen_at": bson.M{"$lt": staleBefore}},
+			bson.M{"last_seen_at": bson.M{"$exists": false}},
+		},
+	}
+	if _, err := r.col.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"last_seen_at": at}}); err != nil {
+		return fmt.Errorf("session touchLastSeen: %w", err)
+	}
+	return nil
+}
+
+// Renew slides the session's expiry forward and records the activity that triggered it.
+func (r *mongoSessionRepo) Renew(ctx context.Context, token string, expiresAt time.Time, at time.Time) error {
+	update := bson.M{"$set": bson.M{"expires_at": expiresAt, "last_seen_at": at}}
+	result, err := r.col.UpdateOne(ctx, bson.M{"token_hash": models.HashToken(token)}, update)
+	if err != nil {
+		return fmt.Errorf("session renew: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func (r *mongoSessionRepo) Delete(ctx context.Context, token string) error {
-	if _, err := r.col.DeleteOne(ctx, bson.M{"token": token}); err != nil {
+	if _, err := r.col.DeleteOne(ctx, bson.M{"token_hash": models.HashToken(token)}); err != nil {
 		return fmt.Errorf("session delete: %w", err)
 	}
 	return nil
 }
 
+// DeleteByID removes a session only if it belongs to the given user.
+func (r *mongoSessionRepo) DeleteByID(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	result, err := r.col.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+	if err != nil {
+		return fmt.Errorf("session deleteByID: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteOthers removes every session of the user except the one identified by keepToken.
+func (r *mongoSessionRepo) DeleteOthers(ctx context.Context, userID primitive.ObjectID, keepToken string) (int64, error) {
+	result, err := r.col.DeleteMany(ctx, bson.M{"user_id": userID, "token_hash": bson.M{"$ne": models.HashToken(keepToken)}})
+	if err != nil {
+		return 0, fmt.Errorf("session deleteOthers: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
 func (r *mongoSessionRepo) DeleteExpired(ctx context.Context) error {
 	filter := bson.M{"expires_at": bson.M{"$lt": time.Now()}}
 	if _, err := r.col.DeleteMany(ctx, filter); err != nil {
@@ -62,12 +132,25 @@ func (r *mongoSessionRepo) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
-// EnsureIndexes creates the TTL index on sessions so MongoDB auto-expires them.
+// DeletePlaintextSessions removes sessions written before tokens were hashed at rest,
+// so their raw tokens do not linger in the database. Those users simply log in again.
+func DeletePlaintextSessions(ctx context.Context, db *mongo.Database) (int64, error) {
+	col := db.Collection(sessionsCollection)
+	result, err := col.DeleteMany(ctx, bson.M{"token_hash": bson.M{"$exists": false}})
+	if err != nil {
+		return 0, fmt.Errorf("session deletePlaintext: %w", err)
+	}
+	return result.DeletedCount, nil
+}
+
+// EnsureSessionIndexes creates the TTL index on sessions so MongoDB auto-expires them,
+// plus lookup indexes for the token hash and per-user listing.
 func EnsureSessionIndexes(ctx context.Context, db *mongo.Database) error {
 	col := db.Collection(sessionsCollection)
-	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    bson.D{{Key: "expires_at", Value: 1}},
-		Options: options.Index().SetExpireAfterSeconds(0),
+	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
 	})
 	return err
 }
@@ -38,8 +38,8 @@ func TestSessionRepo_CreateAndFind(t *testing.T) {
 	if found == nil {
 		t.Fatal("expected session, got nil")
 	}
-	if found.Token != session.Token {
-		t.Errorf("token mismatch: got %q, want %q", found.Token, session.Token)
+	if found.TokenHash == "" || found.TokenHash == "test-token-123" {
+		t.Errorf("expected only a hash of the token to be stored, got %q", found.TokenHash)
 	}
 }
 
@@ -99,3 +99,96 @@ func TestSessionRepo_DeleteExpired(t *testing.T) {
 		t.Error("expected active session to remain")
 	}
 }
+
+func TestSessionRepo_FindByUserID_SkipsExpired(t *testing.T) {
+	repo := db.NewSessionRepository(testDB(t))
+	ctx := context.Background()
+
+	uid := primitive.NewObjectID()
+	repo.Create(ctx, &models.Session{UserID: uid, Token: "phone", UserAgent: "Safari", IP: "10.0.0.1", ExpiresAt: time.Now().Add(time.Hour)})
+	repo.Create(ctx, &models.Session{UserID: uid, Token: "old", ExpiresAt: time.Now().Add(-time.Hour)})
+	repo.Create(ctx, &models.Session{UserID: primitive.NewObjectID(), Token: "someone-else", ExpiresAt: time.Now().Add(time.Hour)})
+
+	sessions, err := repo.FindByUserID(ctx, uid)
+	if err != nil {
+		t.Fatalf("FindByUserID: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("expected 1 active session, got %d", len(sessions))
+	}
+	if sessions[0].UserAgent != "Safari" || sessions[0].IP != "10.0.0.1" || sessions[0].LastSeenAt.IsZero() {
+		t.Errorf("metadata not stored: %+v", sessions[0])
+	}
+}
+
+func TestSessionRepo_TouchLastSeen(t *testing.T) {
+	repo := db.NewSessionRepository(testDB(t))
+	ctx := context.Background()
+
+	created, _ := repo.Create(ctx, &models.Session{UserID: primitive.NewObjectID(), Token: "touch", ExpiresAt: time.Now().Add(time.Hour)})
+	later := created.LastSeenAt.Add(10 * time.Minute)
+
+	// Recently seen: a stale threshold before the stored value leaves it alone.
+	if err := repo.TouchLastSeen(ctx, "touch", later, created.LastSeenAt.Add(-time.Minute)); err != nil {
+		t.Fatalf("TouchLastSeen: %v", err)
+	}
+	found, _ := repo.FindByToken(ctx, "touch")
+	if found.LastSeenAt.After(created.LastSeenAt.Add(time.Second)) {
+		t.Error("last_seen_at should not change within the interval")
+	}
+
+	if err := repo.TouchLastSeen(ctx, "touch", later, later); err != nil {
+		t.Fatalf("TouchLastSeen: %v", err)
+	}
+	found, _ = repo.FindByToken(ctx, "touch")
+	if found.LastSeenAt.Before(later.Add(-time.Second)) {
+		t.Errorf("last_seen_at: got %v, want about %v", found.LastSeenAt, later)
+	}
+}
+
+func TestSessionRepo_DeleteByIDAndOthers(t *testing.T) {
+	repo := db.NewSessionRepository(testDB(t))
+	ctx := context.Background()
+
+	uid := primitive.NewObjectID()
+	a, _ := repo.Create(ctx, &models.Session{UserID: uid, Token: "a", ExpiresAt: time.Now().Add(time.Hour)})
+	repo.Create(ctx, &models.Session{UserID: uid, Token: "b", ExpiresAt: time.Now().Add(time.Hour)})
+	repo.Create(ctx, &models.Session{UserID: uid, Token: "c", ExpiresAt: time.Now().Add(time.Hour)})
+
+	if err := repo.DeleteByID(ctx, a.ID, primitive.NewObjectID()); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound deleting another user's session, got %v", err)
+	}
+	if err := repo.DeleteByID(ctx, a.ID, uid); err != nil {
+		t.Fatalf("DeleteByID: %v", err)
+	}
+
+	n, err := repo.DeleteOthers(ctx, uid, "b")
+	if err != nil {
+		t.Fatalf("DeleteOthers: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("deleted: got %d, want 1", n)
+	}
+	if kept, _ := repo.FindByToken(ctx, "b"); kept == nil {
+		t.Error("expected the current session to remain")
+	}
+}
+
+func TestSessionRepo_Renew(t *testing.T) {
+	repo := db.NewSessionRepository(testDB(t))
+	ctx := context.Background()
+
+	repo.Create(ctx, &models.Session{UserID: primitive.NewObjectID(), Token: "renew", ExpiresAt: time.Now().Add(time.Hour)})
+	expires := time.Now().Add(48 * time.Hour).Truncate(time.Millisecond)
+	if err := repo.Renew(ctx, "renew", expires, time.Now()); err != nil {
+		t.Fatalf("Renew: %v", err)
+	}
+	found, _ := repo.FindByToken(ctx, "renew")
+	if !found.ExpiresAt.Equal(expires) {
+		t.Errorf("expires_at: got %v, want %v", found.ExpiresAt, expires)
+	}
+
+	if err := repo.Renew(ctx, "missing", expires, time.Now()); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const settlementsCollection = "settlements"
+
+type mongoSettlementRepo struct {
+	col *mongo.Collection
+}
+
+// NewSettlementRepository returns a MongoDB-backed SettlementRepository.
+func NewSettlementRepository(db *mongo.Database) SettlementRepository {
+	return &mongoSettlementRepo{col: db.Collection(settlementsCollection)}
+}
+
+func (r *mongoSettlementRepo) Create(ctx context.Context, settlement *models.Settlement) (*models.Settlement, error) {
+	settlement.ID = primitive.NewObjectID()
+	if _, err := r.col.InsertOne(ctx, settlement); err != nil {
+		return nil, fmt.Errorf("settlement create: %w", err)
+	}
+	return settlement, nil
+}
+
+// FindByLedgerID returns all of the ledger's settlements, newest first.
+func (r *mongoSettlementRepo) FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Settlement, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := r.col.Find(ctx, bson.M{"ledger_id": ledgerID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("settlement findByLedgerID: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var list []*models.Settlement
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, fmt.Errorf("settlement decode list: %w", err)
+	}
+	return list, nil
+}
+
+// Delete removes a settlement only if it belongs to the given ledger.
+func (r *mongoSettlementRepo) Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error {
+	result, err := r.col.DeleteOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID})
+	if err != nil {
+		return fmt.Errorf("settlement delete: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// EnsureSplitIndexes indexes group expenses and settlements by ledger and date.
+func EnsureSplitIndexes(ctx context.Context, db *mongo.Database) error {
+	model := mongo.IndexModel{Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "date", Value: -1}}}
+	for _, name := range []string{groupExpensesCollection, settlementsCollection} {
+		if _, err := db.Collection(name).Indexes().CreateOne(ctx, model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
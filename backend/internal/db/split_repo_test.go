@@ -0,0 +1,72 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGroupExpenseRepo_ScopedToLedger(t *testing.T) {
+	repo := db.NewGroupExpenseRepository(testDB(t))
+	ctx := context.Background()
+	ledgerID := primitive.NewObjectID()
+	payer, other := primitive.NewObjectID(), primitive.NewObjectID()
+	now := time.Now().Truncate(time.Millisecond)
+
+	older, _ := repo.Create(ctx, &models.GroupExpense{LedgerID: ledgerID, PaidBy: payer, Amount: 10, Date: now.Add(-time.Hour), SplitMethod: models.SplitEqual,
+		Shares: []models.ExpenseShare{{UserID: payer, Amount: 5}, {UserID: other, Amount: 5}}})
+	newer, _ := repo.Create(ctx, &models.GroupExpense{LedgerID: ledgerID, PaidBy: other, Amount: 3, Date: now, SplitMethod: models.SplitShares,
+		Shares: []models.ExpenseShare{{UserID: payer, Amount: 1, Shares: 1}, {UserID: other, Amount: 2, Shares: 2}}})
+	repo.Create(ctx, &models.GroupExpense{LedgerID: primitive.NewObjectID(), PaidBy: payer, Amount: 7, Date: now})
+
+	list, err := repo.FindByLedgerID(ctx, ledgerID)
+	if err != nil {
+		t.Fatalf("FindByLedgerID: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != newer.ID || list[1].ID != older.ID {
+		t.Fatalf("expected the ledger's expenses newest first, got %+v", list)
+	}
+	if len(list[0].Shares) != 2 || list[0].Shares[1].Shares != 2 {
+		t.Errorf("shares not round-tripped: %+v", list[0].Shares)
+	}
+
+	if err := repo.Delete(ctx, older.ID, primitive.NewObjectID()); err != db.ErrNotFound {
+		t.Errorf("deleting from another ledger: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.Delete(ctx, older.ID, ledgerID); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+	if list, _ := repo.FindByLedgerID(ctx, ledgerID); len(list) != 1 {
+		t.Errorf("expected 1 expense after delete, got %d", len(list))
+	}
+}
+
+func TestSettlementRepo_ScopedToLedger(t *testing.T) {
+	repo := db.NewSettlementRepository(testDB(t))
+	ctx := context.Background()
+	ledgerID := primitive.NewObjectID()
+
+	s, err := repo.Create(ctx, &models.Settlement{LedgerID: ledgerID, From: primitive.NewObjectID(), To: primitive.NewObjectID(), Amount: 12.5, Date: time.Now()})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if list, _ := repo.FindByLedgerID(ctx, primitive.NewObjectID()); len(list) != 0 {
+		t.Errorf("expected no settlements in another ledger, got %d", len(list))
+	}
+	if err := repo.Delete(ctx, s.ID, primitive.NewObjectID()); err != db.ErrNotFound {
+		t.Errorf("deleting from another ledger: expected ErrNotFound, got %v", err)
+	}
+	if err := repo.Delete(ctx, s.ID, ledgerID); err != nil {
+		t.Errorf("Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, s.ID, ledgerID); err != db.ErrNotFound {
+		t.Errorf("deleting twice: expected ErrNotFound, got %v", err)
+	}
+}
@@ -17,8 +17,16 @@ import (
 
 const transactionsCollection = "transactions"
 
-// ErrNotFound is returned when a document is not found or the caller has no access to it.
-var ErrNotFound = errors.New("not found")
+var (
+	// ErrNotFound is returned when a document is not found or the caller has no access to it.
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate is returned when a write would violate a unique index.
+	ErrDuplicate = errors.New("duplicate")
+	// ErrConflict is returned when a versioned write finds the document at another version.
+	ErrConflict = errors.New("version conflict")
+	// ErrLastOwner is returned when a member write would leave a ledger without an owner.
+	ErrLastOwner = errors.New("last owner")
+)
 
 type mongoTransactionRepo struct {
 	col *mongo.Collection
@@ -34,6 +42,7 @@ func (r *mongoTransactionRepo) Create(ctx context.Context, tx *models.Transactio
 	now := time.Now()
 	tx.CreatedAt = now
 	tx.UpdatedAt = now
+	tx.Version = 1
 
 	if _, err := r.col.InsertOne(ctx, tx); err != nil {
 		return nil, fmt.Errorf("transaction create: %w", err)
@@ -41,9 +50,9 @@ func (r *mongoTransactionRepo) Create(ctx context.Context, tx *models.Transactio
 	return tx, nil
 }
 
-func (r *mongoTransactionRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Transaction, error) {
+func (r *mongoTransactionRepo) FindByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Transaction, error) {
 	var tx models.Transaction
-	err := r.col.FindOne(ctx, bson.M{"_id": id}).Decode(&tx)
+	err := r.col.FindOne(ctx, notDeleted(bson.M{"_id": id, "ledger_id": ledgerID})).Decode(&tx)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, nil
 	}
@@ -53,13 +62,28 @@ func (r *mongoTransactionRepo) FindByID(ctx context.Context, id primitive.Object
 	return &tx, nil
 }
 
-// FindByUserID returns a paginated, date-descending list of transactions for a user.
-func (r *mongoTransactionRepo) FindByUserID(
+// FindByIDs returns those of the given transactions that belong to the ledger.
+func (r *mongoTransactionRepo) FindByIDs(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Transaction, error) {
+	cursor, err := r.col.Find(ctx, notDeleted(bson.M{"_id": bson.M{"$in": ids}, "ledger_id": ledgerID}))
+	if err != nil {
+		return nil, fmt.Errorf("transaction findByIDs: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var txs []*models.Transaction
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("transaction decode list: %w", err)
+	}
+	return txs, nil
+}
+
+// FindByLedgerID returns a paginated, date-descending list of a ledger's transactions.
+func (r *mongoTransactionRepo) FindByLedgerID(
 	ctx context.Context,
-	userID primitive.ObjectID,
+	ledgerID primitive.ObjectID,
 	page, pageSize int,
 ) ([]*models.Transaction, int64, error) {
-	filter := bson.M{"user_id": userID}
+	filter := notDeleted(bson.M{"ledger_id": ledgerID})
 
 	total, err := r.col.CountDocuments(ctx, filter)
 	if err != nil {
@@ -74,7 +98,7 @@ func (r *mongoTransactionRepo) FindByUserID(
 
 	cursor, err := r.col.Find(ctx, filter, opts)
 	if err != nil {
-		return nil, 0, fmt.Errorf("transaction findByUserID: %w", err)
+		return nil, 0, fmt.Errorf("transaction findByLedgerID: %w", err)
 	}
 	defer cursor.Close(ctx)
 
@@ -85,48 +109,168 @@ func (r *mongoTransactionRepo) FindByUserID(
 	return txs, total, nil
 }
 
+// Update overwrites a transaction's fields if it is still at tx.Version, and increments
+// the version.
 func (r *mongoTransactionRepo) Update(ctx context.Context, tx *models.Transaction) (*models.Transaction, error) {
 	tx.UpdatedAt = time.Now()
+	if tx.Tags == nil {
+		tx.Tags = []string{}
+	}
+	set := bson.M{
+		"category_id": tx.CategoryID,
+		"type":        tx.Type,
+		"amount":      tx.Amount,
+		"description": tx.Description,
+		"date":        tx.Date,
+		"tags":        tx.Tags,
+		"updated_at":  tx.UpdatedAt,
+	}
+	return r.versionedSet(ctx, tx.ID, tx.LedgerID, tx.Version, set, "transaction update")
+}
+
+// Patch sets only the fields present in patch if the transaction is still at the given
+// version, and increments the version.
+func (r *mongoTransactionRepo) Patch(ctx context.Context, id, ledgerID primitive.ObjectID, version int64, patch TransactionPatch) (*models.Transaction, error) {
+	return r.versionedSet(ctx, id, ledgerID, version, patchSet(patch), "transaction patch")
+}
 
-	update := bson.M{
-		"$set": bson.M{
-			"category_id": tx.CategoryID,
-			"type":        tx.Type,
-			"amount":      tx.Amount,
-			"description": tx.Description,
-			"date":        tx.Date,
-			"updated_at":  tx.UpdatedAt,
-		},
+// FindByFilter returns up to limit of the ledger's transactions matching filter, newest first.
+func (r *mongoTransactionRepo) FindByFilter(ctx context.Context, ledgerID primitive.ObjectID, filter TransactionFilter, limit int64) ([]*models.Transaction, error) {
+	query := notDeleted(bson.M{"ledger_id": ledgerID})
+	if filter.CategoryID != nil {
+		query["category_id"] = *filter.CategoryID
 	}
+	if filter.Type != "" {
+		query["type"] = filter.Type
+	}
+	if filter.Tag != "" {
+		query["tags"] = filter.Tag
+	}
+	if !filter.Since.IsZero() || !filter.Until.IsZero() {
+		date := bson.M{}
+		if !filter.Since.IsZero() {
+			date["$gte"] = filter.Since
+		}
+		if !filter.Until.IsZero() {
+			date["$lt"] = filter.Until
+		}
+		query["date"] = date
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: -1}}).SetLimit(limit)
+	cursor, err := r.col.Find(ctx, query, opts)
+	if err != nil {
+		return nil, fmt.Errorf("transaction findByFilter: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var txs []*models.Transaction
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("transaction decode list: %w", err)
+	}
+	return txs, nil
+}
+
+// patchSet is the $set document for the fields present in patch.
+func patchSet(patch TransactionPatch) bson.M {
+	set := bson.M{"updated_at": time.Now()}
+	if patch.CategoryID != nil {
+		set["category_id"] = *patch.CategoryID
+	}
+	if patch.Type != nil {
+		set["type"] = *patch.Type
+	}
+	if patch.Amount != nil {
+		set["amount"] = *patch.Amount
+	}
+	if patch.Description != nil {
+		set["description"] = *patch.Description
+	}
+	if patch.Date != nil {
+		set["date"] = *patch.Date
+	}
+	if patch.Tags != nil {
+		set["tags"] = *patch.Tags
+	}
+	return set
+}
+
+// versionedSet applies set to a live transaction of the ledger at the given version and
+// returns the updated document.
+func (r *mongoTransactionRepo) versionedSet(ctx context.Context, id, ledgerID primitive.ObjectID, version int64, set bson.M, op string) (*models.Transaction, error) {
+	update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
 	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
-	filter := bson.M{"_id": tx.ID, "user_id": tx.UserID}
+	filter := atVersion(notDeleted(bson.M{"_id": id, "ledger_id": ledgerID}), version)
 
 	var result models.Transaction
 	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
 	if errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, ErrNotFound
+		return nil, missedWrite(ctx, r.col, bson.M{"_id": id, "ledger_id": ledgerID}, op)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("transaction update: %w", err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	return &result, nil
 }
 
-// Delete removes a transaction only if it belongs to the given user.
-func (r *mongoTransactionRepo) Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
-	result, err := r.col.DeleteOne(ctx, bson.M{"_id": id, "user_id": userID})
+// Delete moves a transaction to the trash only if it belongs to the given ledger and is
+// still at the given version.
+func (r *mongoTransactionRepo) Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error {
+	return trash(ctx, r.col, bson.M{"_id": id, "ledger_id": ledgerID}, version, time.Now(), "transaction delete")
+}
+
+// Purge removes the live transaction at version outright instead of moving it to the trash.
+func (r *mongoTransactionRepo) Purge(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error {
+	result, err := r.col.DeleteOne(ctx, atVersion(notDeleted(bson.M{"_id": id, "ledger_id": ledgerID}), version))
 	if err != nil {
-		return fmt.Errorf("transaction delete: %w", err)
+		return fmt.Errorf("transaction purge: %w", err)
 	}
 	if result.DeletedCount == 0 {
-		return ErrNotFound
+		return missedWrite(ctx, r.col, bson.M{"_id": id, "ledger_id": ledgerID}, "transaction purge")
 	}
 	return nil
 }
 
-// ExistsByCategoryID reports whether the user has any transactions referencing categoryID.
-func (r *mongoTransactionRepo) ExistsByCategoryID(ctx context.Context, userID, categoryID primitive.ObjectID) (bool, error) {
-	count, err := r.col.CountDocuments(ctx, bson.M{"user_id": userID, "category_id": categoryID})
+// FindDeleted returns the ledger's trashed transactions, most recently deleted first.
+func (r *mongoTransactionRepo) FindDeleted(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Transaction, error) {
+	var txs []*models.Transaction
+	if err := findTrashed(ctx, r.col, bson.M{"ledger_id": ledgerID}, &txs, "transaction findDeleted"); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// FindDeletedByID returns nil unless the transaction is in the ledger's trash.
+func (r *mongoTransactionRepo) FindDeletedByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Transaction, error) {
+	var tx models.Transaction
+	err := r.col.FindOne(ctx, inTrash(bson.M{"_id": id, "ledger_id": ledgerID})).Decode(&tx)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("transaction findDeletedByID: %w", err)
+	}
+	return &tx, nil
+}
+
+// Restore takes a transaction of the ledger out of the trash.
+func (r *mongoTransactionRepo) Restore(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) (*models.Transaction, error) {
+	var tx models.Transaction
+	if err := untrash(ctx, r.col, bson.M{"_id": id, "ledger_id": ledgerID}, &tx, "transaction restore"); err != nil {
+		return nil, err
+	}
+	return &tx, nil
+}
+
+// PurgeDeleted permanently removes transactions trashed before the given time and
+// returns how many were removed.
+func (r *mongoTransactionRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	return purgeTrashed(ctx, r.col, before, "transaction purge")
+}
+
+// ExistsByCategoryID reports whether the ledger has any transactions referencing categoryID.
+func (r *mongoTransactionRepo) ExistsByCategoryID(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (bool, error) {
+	count, err := r.col.CountDocuments(ctx, notDeleted(bson.M{"ledger_id": ledgerID, "category_id": categoryID}))
 	if err != nil {
 		return false, fmt.Errorf("transaction existsByCategoryID: %w", err)
 	}
@@ -134,21 +278,24 @@ func (r *mongoTransactionRepo) ExistsByCategoryID(ctx context.Context, userID, c
 }
 
 // GetMonthlySummary aggregates inflow and outflow totals by calendar month in [since, until).
-// A zero until means no upper bound.
-func (r *mongoTransactionRepo) GetMonthlySummary(ctx context.Context, userID primitive.ObjectID, since, until time.Time) ([]*MonthlyAgg, error) {
+// Months are bucketed in timeZone (an IANA name; empty means UTC). A zero until means no upper bound.
+func (r *mongoTransactionRepo) GetMonthlySummary(ctx context.Context, ledgerID primitive.ObjectID, since, until time.Time, timeZone string) ([]*MonthlyAgg, error) {
 	dateFilter := bson.M{"$gte": since}
 	if !until.IsZero() {
 		dateFilter["$lt"] = until
 	}
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"user_id": userID,
-			"date":    dateFilter,
-		}}},
+		{{Key: "$match", Value: notDeleted(bson.M{
+			"ledger_id": ledgerID,
+			"date":      dateFilter,
+		})}},
 		{{Key: "$group", Value: bson.M{
 			"_id": bson.M{
-				"year":  bson.M{"$year": "$date"},
-				"month": bson.M{"$month": "$date"},
+				"year":  bson.M{"$year": bson.M{"date": "$date", "timezone": timeZone}},
+				"month": bson.M{"$month": bson.M{"date": "$date", "timezone": timeZone}},
 				"type":  "$type",
 			},
 			"total": bson.M{"$sum": "$amount"},
@@ -206,17 +353,17 @@ func (r *mongoTransactionRepo) GetMonthlySummary(ctx context.Context, userID pri
 
 // GetCategoryTotals aggregates spending totals by category for the given type in [since, until),
 // sorted descending by total. A zero until means no upper bound.
-func (r *mongoTransactionRepo) GetCategoryTotals(ctx context.Context, userID primitive.ObjectID, txType string, since, until time.Time) ([]*CategoryAgg, error) {
+func (r *mongoTransactionRepo) GetCategoryTotals(ctx context.Context, ledgerID primitive.ObjectID, txType string, since, until time.Time) ([]*CategoryAgg, error) {
 	dateFilter := bson.M{"$gte": since}
 	if !until.IsZero() {
 		dateFilter["$lt"] = until
 	}
 	pipeline := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"user_id": userID,
-			"type":    txType,
-			"date":    dateFilter,
-		}}},
+		{{Key: "$match", Value: notDeleted(bson.M{
+			"ledger_id": ledgerID,
+			"type":      txType,
+			"date":      dateFilter,
+		})}},
 		{{Key: "$group", Value: bson.M{
 			"_id":   "$category_id",
 			"total": bson.M{"$sum": "$amount"},
@@ -249,11 +396,159 @@ func (r *mongoTransactionRepo) GetCategoryTotals(ctx context.Context, userID pri
 	return result, nil
 }
 
+// GetCategoryMonthlyTotals aggregates totals by category, type and calendar month in [since, until),
+// bucketing months in timeZone (empty means UTC). Results are sorted by year and month ascending.
+// A zero until means no upper bound.
+func (r *mongoTransactionRepo) GetCategoryMonthlyTotals(ctx context.Context, ledgerID primitive.ObjectID, since, until time.Time, timeZone string) ([]*CategoryMonthlyAgg, error) {
+	dateFilter := bson.M{"$gte": since}
+	if !until.IsZero() {
+		dateFilter["$lt"] = until
+	}
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: notDeleted(bson.M{
+			"ledger_id": ledgerID,
+			"date":      dateFilter,
+		})}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"category_id": "$category_id",
+				"type":        "$type",
+				"year":        bson.M{"$year": bson.M{"date": "$date", "timezone": timeZone}},
+				"month":       bson.M{"$month": bson.M{"date": "$date", "timezone": timeZone}},
+			},
+			"total": bson.M{"$sum": "$amount"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.year", Value: 1}, {Key: "_id.month", Value: 1}}}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("GetCategoryMonthlyTotals aggregate: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	type aggResult struct {
+		ID struct {
+			CategoryID primitive.ObjectID `bson:"category_id"`
+			Type       string             `bson:"type"`
+			Year       int                `bson:"year"`
+			Month      int                `bson:"month"`
+		} `bson:"_id"`
+		Total float64 `bson:"total"`
+	}
+
+	var result []*CategoryMonthlyAgg
+	for cursor.Next(ctx) {
+		var doc aggResult
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("GetCategoryMonthlyTotals decode: %w", err)
+		}
+		result = append(result, &CategoryMonthlyAgg{
+			CategoryID: doc.ID.CategoryID,
+			Type:       doc.ID.Type,
+			Year:       doc.ID.Year,
+			Month:      doc.ID.Month,
+			Total:      doc.Total,
+		})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("GetCategoryMonthlyTotals cursor: %w", err)
+	}
+	return result, nil
+}
+
+// GetCategoryAmountStats computes the count, mean and population standard deviation of individual
+// transaction amounts per category for the given type in [since, until). A zero until means no upper bound.
+func (r *mongoTransactionRepo) GetCategoryAmountStats(ctx context.Context, ledgerID primitive.ObjectID, txType string, since, until time.Time) ([]*CategoryAmountStats, error) {
+	dateFilter := bson.M{"$gte": since}
+	if !until.IsZero() {
+		dateFilter["$lt"] = until
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: notDeleted(bson.M{
+			"ledger_id": ledgerID,
+			"type":      txType,
+			"date":      dateFilter,
+		})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$category_id",
+			"count":  bson.M{"$sum": 1},
+			"mean":   bson.M{"$avg": "$amount"},
+			"stddev": bson.M{"$stdDevPop": "$amount"},
+		}}},
+	}
+
+	cursor, err := r.col.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("GetCategoryAmountStats aggregate: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	type aggResult struct {
+		ID     primitive.ObjectID `bson:"_id"`
+		Count  int                `bson:"count"`
+		Mean   float64            `bson:"mean"`
+		StdDev float64            `bson:"stddev"`
+	}
+
+	var result []*CategoryAmountStats
+	for cursor.Next(ctx) {
+		var doc aggResult
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("GetCategoryAmountStats decode: %w", err)
+		}
+		result = append(result, &CategoryAmountStats{CategoryID: doc.ID, Count: doc.Count, Mean: doc.Mean, StdDev: doc.StdDev})
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("GetCategoryAmountStats cursor: %w", err)
+	}
+	return result, nil
+}
+
+// FindAmountsAbove returns transactions of the given type in [since, until) whose amount exceeds the
+// threshold for their category, sorted by amount descending. Categories without a threshold are ignored.
+// A zero until means no upper bound.
+func (r *mongoTransactionRepo) FindAmountsAbove(ctx context.Context, ledgerID primitive.ObjectID, txType string, thresholds map[primitive.ObjectID]float64, since, until time.Time) ([]*models.Transaction, error) {
+	if len(thresholds) == 0 {
+		return nil, nil
+	}
+	dateFilter := bson.M{"$gte": since}
+	if !until.IsZero() {
+		dateFilter["$lt"] = until
+	}
+	or := make(bson.A, 0, len(thresholds))
+	for catID, threshold := range thresholds {
+		or = append(or, bson.M{"category_id": catID, "amount": bson.M{"$gt": threshold}})
+	}
+	filter := notDeleted(bson.M{
+		"ledger_id": ledgerID,
+		"type":      txType,
+		"date":      dateFilter,
+		"$or":       or,
+	})
+	opts := options.Find().SetSort(bson.D{{Key: "amount", Value: -1}})
+
+	cursor, err := r.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("transaction findAmountsAbove: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var txs []*models.Transaction
+	if err := cursor.All(ctx, &txs); err != nil {
+		return nil, fmt.Errorf("transaction decode list: %w", err)
+	}
+	return txs, nil
+}
+
 // EnsureTransactionIndexes creates indexes for efficient query patterns.
 func EnsureTransactionIndexes(ctx context.Context, db *mongo.Database) error {
 	col := db.Collection(transactionsCollection)
 	_, err := col.Indexes().CreateMany(ctx, []mongo.IndexModel{
-		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: -1}}},
+		{Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "date", Value: -1}}},
 	})
 	return err
 }
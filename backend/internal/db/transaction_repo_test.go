@@ -13,9 +13,9 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func makeTransaction(userID, catID primitive.ObjectID, amount float64, date time.Time) *models.Transaction {
+func makeTransaction(ledgerID, catID primitive.ObjectID, amount float64, date time.Time) *models.Transaction {
 	return &models.Transaction{
-		UserID:      userID,
+		LedgerID:    ledgerID,
 		CategoryID:  catID,
 		Type:        "outflow",
 		Amount:      amount,
@@ -28,9 +28,9 @@ func TestTransactionRepo_Create(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 	catID := primitive.NewObjectID()
-	tx := makeTransaction(uid, catID, 42.50, time.Now())
+	tx := makeTransaction(lid, catID, 42.50, time.Now())
 
 	created, err := repo.Create(ctx, tx)
 	if err != nil {
@@ -48,11 +48,11 @@ func TestTransactionRepo_FindByID(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 	catID := primitive.NewObjectID()
-	created, _ := repo.Create(ctx, makeTransaction(uid, catID, 10, time.Now()))
+	created, _ := repo.Create(ctx, makeTransaction(lid, catID, 10, time.Now()))
 
-	found, err := repo.FindByID(ctx, created.ID)
+	found, err := repo.FindByID(ctx, lid, created.ID)
 	if err != nil {
 		t.Fatalf("FindByID: %v", err)
 	}
@@ -60,7 +60,7 @@ func TestTransactionRepo_FindByID(t *testing.T) {
 		t.Error("FindByID returned wrong or nil result")
 	}
 
-	missing, err := repo.FindByID(ctx, primitive.NewObjectID())
+	missing, err := repo.FindByID(ctx, lid, primitive.NewObjectID())
 	if err != nil {
 		t.Fatalf("FindByID missing: %v", err)
 	}
@@ -69,22 +69,22 @@ func TestTransactionRepo_FindByID(t *testing.T) {
 	}
 }
 
-func TestTransactionRepo_FindByUserID_Pagination(t *testing.T) {
+func TestTransactionRepo_FindByLedgerID_Pagination(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 	catID := primitive.NewObjectID()
 
 	// Insert 5 transactions on different days.
 	for i := 0; i < 5; i++ {
-		repo.Create(ctx, makeTransaction(uid, catID, float64(i+1)*10, time.Now().Add(time.Duration(-i)*24*time.Hour)))
+		repo.Create(ctx, makeTransaction(lid, catID, float64(i+1)*10, time.Now().Add(time.Duration(-i)*24*time.Hour)))
 	}
 
 	// Page 1, size 3 → 3 items, total 5.
-	page1, total, err := repo.FindByUserID(ctx, uid, 1, 3)
+	page1, total, err := repo.FindByLedgerID(ctx, lid, 1, 3)
 	if err != nil {
-		t.Fatalf("FindByUserID page 1: %v", err)
+		t.Fatalf("FindByLedgerID page 1: %v", err)
 	}
 	if total != 5 {
 		t.Errorf("total: got %d, want 5", total)
@@ -94,9 +94,9 @@ func TestTransactionRepo_FindByUserID_Pagination(t *testing.T) {
 	}
 
 	// Page 2, size 3 → 2 items.
-	page2, _, err := repo.FindByUserID(ctx, uid, 2, 3)
+	page2, _, err := repo.FindByLedgerID(ctx, lid, 2, 3)
 	if err != nil {
-		t.Fatalf("FindByUserID page 2: %v", err)
+		t.Fatalf("FindByLedgerID page 2: %v", err)
 	}
 	if len(page2) != 2 {
 		t.Errorf("page2 items: got %d, want 2", len(page2))
@@ -108,20 +108,20 @@ func TestTransactionRepo_FindByUserID_Pagination(t *testing.T) {
 	}
 }
 
-func TestTransactionRepo_FindByUserID_OtherUserIsolation(t *testing.T) {
+func TestTransactionRepo_FindByLedgerID_OtherLedgerIsolation(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
-	uid1 := primitive.NewObjectID()
-	uid2 := primitive.NewObjectID()
+	lid1 := primitive.NewObjectID()
+	lid2 := primitive.NewObjectID()
 	catID := primitive.NewObjectID()
 
-	repo.Create(ctx, makeTransaction(uid1, catID, 100, time.Now()))
-	repo.Create(ctx, makeTransaction(uid2, catID, 200, time.Now()))
+	repo.Create(ctx, makeTransaction(lid1, catID, 100, time.Now()))
+	repo.Create(ctx, makeTransaction(lid2, catID, 200, time.Now()))
 
-	txs, total, _ := repo.FindByUserID(ctx, uid1, 1, 20)
+	txs, total, _ := repo.FindByLedgerID(ctx, lid1, 1, 20)
 	if total != 1 || len(txs) != 1 {
-		t.Errorf("user isolation failed: got %d transactions for uid1", len(txs))
+		t.Errorf("ledger isolation failed: got %d transactions for lid1", len(txs))
 	}
 }
 
@@ -129,9 +129,9 @@ func TestTransactionRepo_Update(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 	catID := primitive.NewObjectID()
-	created, _ := repo.Create(ctx, makeTransaction(uid, catID, 50, time.Now()))
+	created, _ := repo.Create(ctx, makeTransaction(lid, catID, 50, time.Now()))
 
 	created.Amount = 99.99
 	created.Description = "updated"
@@ -147,7 +147,7 @@ func TestTransactionRepo_Update(t *testing.T) {
 	}
 }
 
-func TestTransactionRepo_Update_WrongUser(t *testing.T) {
+func TestTransactionRepo_Update_WrongLedger(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
@@ -156,11 +156,105 @@ func TestTransactionRepo_Update_WrongUser(t *testing.T) {
 	catID := primitive.NewObjectID()
 
 	created, _ := repo.Create(ctx, makeTransaction(ownerID, catID, 50, time.Now()))
-	created.UserID = otherID // Impersonate another user.
+	created.LedgerID = otherID // Move it to a ledger it does not belong to.
 
 	_, err := repo.Update(ctx, created)
 	if err != db.ErrNotFound {
-		t.Errorf("expected ErrNotFound when updating another user's transaction, got %v", err)
+		t.Errorf("expected ErrNotFound when updating a transaction through another ledger, got %v", err)
+	}
+}
+
+func TestTransactionRepo_Update_StaleVersion(t *testing.T) {
+	repo := db.NewTransactionRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	created, _ := repo.Create(ctx, makeTransaction(lid, primitive.NewObjectID(), 50, time.Now()))
+	if created.Version != 1 {
+		t.Fatalf("expected a new transaction at version 1, got %d", created.Version)
+	}
+
+	first := *created
+	first.Amount = 60
+	updated, err := repo.Update(ctx, &first)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Version != 2 {
+		t.Errorf("expected version 2 after an update, got %d", updated.Version)
+	}
+
+	// A second writer still holding version 1 loses.
+	second := *created
+	second.Amount = 70
+	if _, err := repo.Update(ctx, &second); err != db.ErrConflict {
+		t.Errorf("expected ErrConflict for a stale version, got %v", err)
+	}
+	if err := repo.Delete(ctx, created.ID, lid, created.Version); err != db.ErrConflict {
+		t.Errorf("expected ErrConflict deleting a stale version, got %v", err)
+	}
+	found, _ := repo.FindByID(ctx, lid, created.ID)
+	if found == nil || found.Amount != 60 {
+		t.Errorf("expected the first update to survive, got %+v", found)
+	}
+}
+
+func TestTransactionRepo_Patch_SetsOnlyGivenFields(t *testing.T) {
+	repo := db.NewTransactionRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	date := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	created, _ := repo.Create(ctx, makeTransaction(lid, primitive.NewObjectID(), 50, date))
+
+	desc := "groceries"
+	patched, err := repo.Patch(ctx, created.ID, lid, created.Version, db.TransactionPatch{Description: &desc})
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if patched.Description != "groceries" || patched.Amount != 50 || !patched.Date.Equal(date) || patched.CategoryID != created.CategoryID {
+		t.Errorf("expected only the description to change, got %+v", patched)
+	}
+	if patched.Version != 2 {
+		t.Errorf("expected version 2 after a patch, got %d", patched.Version)
+	}
+
+	amount := 70.0
+	if _, err := repo.Patch(ctx, created.ID, lid, created.Version, db.TransactionPatch{Amount: &amount}); err != db.ErrConflict {
+		t.Errorf("expected ErrConflict for a stale version, got %v", err)
+	}
+	if _, err := repo.Patch(ctx, created.ID, primitive.NewObjectID(), patched.Version, db.TransactionPatch{Amount: &amount}); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound through another ledger, got %v", err)
+	}
+}
+
+func TestTransactionRepo_FindByFilter(t *testing.T) {
+	repo := db.NewTransactionRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	catID := primitive.NewObjectID()
+	date := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	a, _ := repo.Create(ctx, makeTransaction(lid, catID, 10, date))
+	b, _ := repo.Create(ctx, makeTransaction(lid, catID, 20, date.AddDate(0, 1, 0)))
+	other := makeTransaction(primitive.NewObjectID(), catID, 30, date)
+	other.Tags = []string{"imported"}
+	_, _ = repo.Create(ctx, other)
+
+	found, err := repo.FindByFilter(ctx, lid, db.TransactionFilter{CategoryID: &catID, Since: date.AddDate(0, 0, 1)}, 10)
+	if err != nil {
+		t.Fatalf("FindByFilter: %v", err)
+	}
+	if len(found) != 1 || found[0].ID != b.ID {
+		t.Errorf("expected only the later transaction, got %+v", found)
+	}
+
+	tags := []string{"imported"}
+	if _, err := repo.Patch(ctx, a.ID, lid, a.Version, db.TransactionPatch{Tags: &tags}); err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if tagged, _ := repo.FindByFilter(ctx, lid, db.TransactionFilter{Tag: "imported"}, 10); len(tagged) != 1 || tagged[0].ID != a.ID {
+		t.Errorf("expected only the ledger's tagged transaction, got %+v", tagged)
 	}
 }
 
@@ -168,20 +262,41 @@ func TestTransactionRepo_Delete(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 	catID := primitive.NewObjectID()
-	created, _ := repo.Create(ctx, makeTransaction(uid, catID, 30, time.Now()))
+	created, _ := repo.Create(ctx, makeTransaction(lid, catID, 30, time.Now()))
 
-	if err := repo.Delete(ctx, created.ID, uid); err != nil {
+	if err := repo.Delete(ctx, created.ID, lid, created.Version); err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
-	found, _ := repo.FindByID(ctx, created.ID)
+	found, _ := repo.FindByID(ctx, lid, created.ID)
 	if found != nil {
 		t.Error("expected transaction to be deleted")
 	}
 }
 
-func TestTransactionRepo_Delete_WrongUser(t *testing.T) {
+func TestTransactionRepo_Purge(t *testing.T) {
+	repo := db.NewTransactionRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	created, _ := repo.Create(ctx, makeTransaction(lid, primitive.NewObjectID(), 30, time.Now()))
+
+	if err := repo.Purge(ctx, created.ID, lid, created.Version+1); err != db.ErrConflict {
+		t.Errorf("expected ErrConflict purging a stale version, got %v", err)
+	}
+	if err := repo.Purge(ctx, created.ID, lid, created.Version); err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if trashed, _ := repo.FindDeletedByID(ctx, lid, created.ID); trashed != nil {
+		t.Error("a purged transaction should not be in the trash")
+	}
+	if err := repo.Purge(ctx, created.ID, lid, created.Version); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound purging it again, got %v", err)
+	}
+}
+
+func TestTransactionRepo_Delete_WrongLedger(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
@@ -190,7 +305,7 @@ func TestTransactionRepo_Delete_WrongUser(t *testing.T) {
 	catID := primitive.NewObjectID()
 	created, _ := repo.Create(ctx, makeTransaction(ownerID, catID, 30, time.Now()))
 
-	err := repo.Delete(ctx, created.ID, otherID)
+	err := repo.Delete(ctx, created.ID, otherID, created.Version)
 	if err != db.ErrNotFound {
 		t.Errorf("expected ErrNotFound, got %v", err)
 	}
@@ -200,27 +315,27 @@ func TestTransactionRepo_GetMonthlySummary(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 	catID := primitive.NewObjectID()
 
 	// Insert inflow and outflow across two months.
 	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
 	feb := time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)
 
-	inflow := makeTransaction(uid, catID, 500, jan)
+	inflow := makeTransaction(lid, catID, 500, jan)
 	inflow.Type = "inflow"
 	repo.Create(ctx, inflow)
 
-	outflow := makeTransaction(uid, catID, 200, jan)
+	outflow := makeTransaction(lid, catID, 200, jan)
 	outflow.Type = "outflow"
 	repo.Create(ctx, outflow)
 
-	outflow2 := makeTransaction(uid, catID, 300, feb)
+	outflow2 := makeTransaction(lid, catID, 300, feb)
 	outflow2.Type = "outflow"
 	repo.Create(ctx, outflow2)
 
 	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
-	aggs, err := repo.GetMonthlySummary(ctx, uid, since, time.Time{})
+	aggs, err := repo.GetMonthlySummary(ctx, lid, since, time.Time{}, "")
 	if err != nil {
 		t.Fatalf("GetMonthlySummary: %v", err)
 	}
@@ -238,35 +353,67 @@ func TestTransactionRepo_GetMonthlySummary(t *testing.T) {
 	}
 }
 
+func TestTransactionRepo_GetMonthlySummary_TimeZone(t *testing.T) {
+	repo := db.NewTransactionRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	catID := primitive.NewObjectID()
+
+	// 11pm on Jan 31 in California is already Feb 1 in UTC.
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("loading location: %v", err)
+	}
+	repo.Create(ctx, makeTransaction(lid, catID, 80, time.Date(2024, 1, 31, 23, 0, 0, 0, la)))
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, la)
+	utcAggs, err := repo.GetMonthlySummary(ctx, lid, since, time.Time{}, "")
+	if err != nil {
+		t.Fatalf("GetMonthlySummary UTC: %v", err)
+	}
+	if len(utcAggs) != 1 || utcAggs[0].Month != 2 {
+		t.Errorf("UTC: expected the transaction in February, got %+v", utcAggs)
+	}
+
+	laAggs, err := repo.GetMonthlySummary(ctx, lid, since, time.Time{}, "America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("GetMonthlySummary LA: %v", err)
+	}
+	if len(laAggs) != 1 || laAggs[0].Month != 1 || laAggs[0].Outflow != 80 {
+		t.Errorf("LA: expected the transaction in January, got %+v", laAggs)
+	}
+}
+
 func TestTransactionRepo_GetCategoryTotals(t *testing.T) {
 	repo := db.NewTransactionRepository(testDB(t))
 	ctx := context.Background()
 
-	uid := primitive.NewObjectID()
+	lid := primitive.NewObjectID()
 	catA := primitive.NewObjectID()
 	catB := primitive.NewObjectID()
 
 	now := time.Now()
 	// catA gets 150, catB gets 300.
-	tx1 := makeTransaction(uid, catA, 100, now)
+	tx1 := makeTransaction(lid, catA, 100, now)
 	tx1.Type = "outflow"
 	repo.Create(ctx, tx1)
 
-	tx2 := makeTransaction(uid, catA, 50, now)
+	tx2 := makeTransaction(lid, catA, 50, now)
 	tx2.Type = "outflow"
 	repo.Create(ctx, tx2)
 
-	tx3 := makeTransaction(uid, catB, 300, now)
+	tx3 := makeTransaction(lid, catB, 300, now)
 	tx3.Type = "outflow"
 	repo.Create(ctx, tx3)
 
 	// Inflow should be excluded.
-	tx4 := makeTransaction(uid, catA, 999, now)
+	tx4 := makeTransaction(lid, catA, 999, now)
 	tx4.Type = "inflow"
 	repo.Create(ctx, tx4)
 
 	since := now.AddDate(0, -1, 0)
-	aggs, err := repo.GetCategoryTotals(ctx, uid, "outflow", since, time.Time{})
+	aggs, err := repo.GetCategoryTotals(ctx, lid, "outflow", since, time.Time{})
 	if err != nil {
 		t.Fatalf("GetCategoryTotals: %v", err)
 	}
@@ -281,3 +428,83 @@ func TestTransactionRepo_GetCategoryTotals(t *testing.T) {
 		t.Errorf("second agg mismatch: %+v", aggs[1])
 	}
 }
+
+func TestTransactionRepo_GetCategoryMonthlyTotals(t *testing.T) {
+	repo := db.NewTransactionRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	food := primitive.NewObjectID()
+	salary := primitive.NewObjectID()
+
+	jan := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 10, 0, 0, 0, 0, time.UTC)
+
+	repo.Create(ctx, makeTransaction(lid, food, 40, jan))
+	repo.Create(ctx, makeTransaction(lid, food, 60, jan))
+	repo.Create(ctx, makeTransaction(lid, food, 25, feb))
+
+	pay := makeTransaction(lid, salary, 3000, jan)
+	pay.Type = "inflow"
+	repo.Create(ctx, pay)
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	aggs, err := repo.GetCategoryMonthlyTotals(ctx, lid, since, time.Time{}, "")
+	if err != nil {
+		t.Fatalf("GetCategoryMonthlyTotals: %v", err)
+	}
+	if len(aggs) != 3 {
+		t.Fatalf("expected 3 buckets, got %d", len(aggs))
+	}
+
+	found := map[string]float64{}
+	for _, a := range aggs {
+		found[a.CategoryID.Hex()+a.Type+time.Month(a.Month).String()] = a.Total
+	}
+	if found[food.Hex()+"outflow"+"January"] != 100 {
+		t.Errorf("food January: got %v, want 100", found[food.Hex()+"outflow"+"January"])
+	}
+	if found[food.Hex()+"outflow"+"February"] != 25 {
+		t.Errorf("food February: got %v, want 25", found[food.Hex()+"outflow"+"February"])
+	}
+	if found[salary.Hex()+"inflow"+"January"] != 3000 {
+		t.Errorf("salary January: got %v, want 3000", found[salary.Hex()+"inflow"+"January"])
+	}
+	// Results are month-ordered.
+	if aggs[len(aggs)-1].Month != 2 {
+		t.Errorf("expected February last, got month %d", aggs[len(aggs)-1].Month)
+	}
+}
+
+func TestTransactionRepo_AmountStatsAndOutliers(t *testing.T) {
+	repo := db.NewTransactionRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	catID := primitive.NewObjectID()
+	now := time.Now()
+
+	for _, amt := range []float64{10, 20, 30} {
+		repo.Create(ctx, makeTransaction(lid, catID, amt, now))
+	}
+
+	since := now.AddDate(0, -1, 0)
+	stats, err := repo.GetCategoryAmountStats(ctx, lid, "outflow", since, time.Time{})
+	if err != nil {
+		t.Fatalf("GetCategoryAmountStats: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Count != 3 || stats[0].Mean != 20 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if stats[0].StdDev < 8.16 || stats[0].StdDev > 8.17 {
+		t.Errorf("stddev: got %v, want about 8.165", stats[0].StdDev)
+	}
+
+	above, err := repo.FindAmountsAbove(ctx, lid, "outflow", map[primitive.ObjectID]float64{catID: 15}, since, time.Time{})
+	if err != nil {
+		t.Fatalf("FindAmountsAbove: %v", err)
+	}
+	if len(above) != 2 || above[0].Amount != 30 {
+		t.Errorf("expected 30 and 20 sorted descending, got %d results", len(above))
+	}
+}
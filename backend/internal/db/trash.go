@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Deleting a transaction or custom category moves it to the trash by setting deleted_at.
+// Every other query of those collections adds notDeleted to its filter, so trashed
+// documents stay out of lists, lookups and aggregations until they are restored or purged.
+
+// notDeleted adds the condition that matches documents outside the trash to filter.
+func notDeleted(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": false}
+	return filter
+}
+
+// inTrash adds the condition that matches trashed documents to filter.
+func inTrash(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": true}
+	return filter
+}
+
+// trash sets deleted_at on the single live document matching filter if it is at the given
+// version, and increments the version.
+func trash(ctx context.Context, col *mongo.Collection, filter bson.M, version int64, at time.Time, op string) error {
+	match := bson.M{}
+	for k, v := range filter {
+		match[k] = v
+	}
+	update := bson.M{"$set": bson.M{"deleted_at": at}, "$inc": bson.M{"version": 1}}
+	result, err := col.UpdateOne(ctx, atVersion(notDeleted(match), version), update)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.MatchedCount == 0 {
+		return missedWrite(ctx, col, filter, op)
+	}
+	return nil
+}
+
+// untrash clears deleted_at on the single trashed document matching filter, increments its
+// version and decodes the restored document into v.
+func untrash(ctx context.Context, col *mongo.Collection, filter bson.M, v any, op string) error {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	update := bson.M{"$unset": bson.M{"deleted_at": ""}, "$inc": bson.M{"version": 1}}
+	err := col.FindOneAndUpdate(ctx, inTrash(filter), update, opts).Decode(v)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// findTrashed decodes the trashed documents matching filter into v, most recently deleted first.
+func findTrashed(ctx context.Context, col *mongo.Collection, filter bson.M, v any, op string) error {
+	opts := options.Find().SetSort(bson.D{{Key: "deleted_at", Value: -1}})
+	cursor, err := col.Find(ctx, inTrash(filter), opts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, v); err != nil {
+		return fmt.Errorf("%s decode: %w", op, err)
+	}
+	return nil
+}
+
+// purgeTrashed permanently removes documents deleted before the given time, across all ledgers.
+func purgeTrashed(ctx context.Context, col *mongo.Collection, before time.Time, op string) (int64, error) {
+	result, err := col.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	return result.DeletedCount, nil
+}
+
+// EnsureTrashIndexes creates the sparse deleted_at indexes the trash purge uses.
+func EnsureTrashIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, name := range []string{transactionsCollection, categoriesCollection} {
+		_, err := db.Collection(name).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "deleted_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
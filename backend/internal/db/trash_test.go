@@ -0,0 +1,137 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTransactionRepo_TrashAndRestore(t *testing.T) {
+	repo := db.NewTransactionRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	catID := primitive.NewObjectID()
+	date := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	kept, _ := repo.Create(ctx, makeTransaction(lid, catID, 20, date))
+	trashed, _ := repo.Create(ctx, makeTransaction(lid, catID, 80, date))
+
+	if err := repo.Delete(ctx, trashed.ID, lid, trashed.Version); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := repo.Delete(ctx, trashed.ID, lid, trashed.Version); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound deleting a trashed transaction again, got %v", err)
+	}
+
+	// Trashed transactions drop out of lookups, lists and aggregations.
+	if found, _ := repo.FindByID(ctx, lid, trashed.ID); found != nil {
+		t.Error("expected FindByID to skip the trashed transaction")
+	}
+	if _, total, _ := repo.FindByLedgerID(ctx, lid, 1, 10); total != 1 {
+		t.Errorf("expected 1 listed transaction, got %d", total)
+	}
+	totals, _ := repo.GetCategoryTotals(ctx, lid, "outflow", date.AddDate(0, -1, 0), time.Time{})
+	if len(totals) != 1 || totals[0].Total != 20 {
+		t.Errorf("expected only the kept amount in the totals, got %+v", totals)
+	}
+	trashed.Amount = 90
+	if _, err := repo.Update(ctx, trashed); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound updating a trashed transaction, got %v", err)
+	}
+
+	inTrash, err := repo.FindDeleted(ctx, lid)
+	if err != nil {
+		t.Fatalf("FindDeleted: %v", err)
+	}
+	if len(inTrash) != 1 || inTrash[0].ID != trashed.ID || inTrash[0].DeletedAt == nil {
+		t.Fatalf("expected the trashed transaction with its deletion time, got %+v", inTrash)
+	}
+	if found, err := repo.FindDeletedByID(ctx, lid, trashed.ID); err != nil || found == nil || found.DeletedAt == nil {
+		t.Errorf("FindDeletedByID: expected the trashed transaction, got %+v, %v", found, err)
+	}
+	if found, _ := repo.FindDeletedByID(ctx, lid, kept.ID); found != nil {
+		t.Error("expected FindDeletedByID to skip a live transaction")
+	}
+	if found, _ := repo.FindDeletedByID(ctx, primitive.NewObjectID(), trashed.ID); found != nil {
+		t.Error("expected FindDeletedByID to stay within the ledger")
+	}
+
+	if _, err := repo.Restore(ctx, trashed.ID, primitive.NewObjectID()); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound restoring through another ledger, got %v", err)
+	}
+	restored, err := repo.Restore(ctx, trashed.ID, lid)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Amount != 80 {
+		t.Errorf("unexpected restored transaction %+v", restored)
+	}
+	if _, err := repo.Restore(ctx, kept.ID, lid); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound restoring a transaction that is not in the trash, got %v", err)
+	}
+}
+
+func TestTransactionRepo_PurgeDeleted(t *testing.T) {
+	repo := db.NewTransactionRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	catID := primitive.NewObjectID()
+	old, _ := repo.Create(ctx, makeTransaction(lid, catID, 10, time.Now()))
+	repo.Create(ctx, makeTransaction(lid, catID, 20, time.Now()))
+	repo.Delete(ctx, old.ID, lid, old.Version)
+
+	n, err := repo.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	if err != nil || n != 0 {
+		t.Fatalf("expected nothing purged within the retention window, got %d, %v", n, err)
+	}
+	n, err = repo.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	if err != nil || n != 1 {
+		t.Fatalf("expected the trashed transaction purged, got %d, %v", n, err)
+	}
+	if inTrash, _ := repo.FindDeleted(ctx, lid); len(inTrash) != 0 {
+		t.Errorf("expected an empty trash, got %+v", inTrash)
+	}
+	if _, total, _ := repo.FindByLedgerID(ctx, lid, 1, 10); total != 1 {
+		t.Errorf("expected the live transaction to survive the purge, got %d", total)
+	}
+}
+
+func TestCategoryRepo_TrashAndRestore(t *testing.T) {
+	repo := db.NewCategoryRepository(testDB(t))
+	ctx := context.Background()
+
+	lid := primitive.NewObjectID()
+	cat, _ := repo.Create(ctx, &models.Category{LedgerID: &lid, Name: "Hobbies", Icon: "x", Color: "#000"})
+
+	if err := repo.Delete(ctx, cat.ID, lid, cat.Version); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if found, _ := repo.FindByID(ctx, lid, cat.ID); found != nil {
+		t.Error("expected FindByID to skip the trashed category")
+	}
+	if listed, _ := repo.FindByLedgerID(ctx, lid); len(listed) != 0 {
+		t.Errorf("expected no listed categories, got %d", len(listed))
+	}
+	if inTrash, _ := repo.FindDeleted(ctx, lid); len(inTrash) != 1 || inTrash[0].ID != cat.ID {
+		t.Fatalf("expected the category in the trash, got %+v", inTrash)
+	}
+
+	restored, err := repo.Restore(ctx, cat.ID, lid)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if restored.DeletedAt != nil || restored.Name != "Hobbies" {
+		t.Errorf("unexpected restored category %+v", restored)
+	}
+	if found, _ := repo.FindByID(ctx, lid, cat.ID); found == nil {
+		t.Error("expected the restored category to be found")
+	}
+}
@@ -25,14 +25,49 @@ func NewUserRepository(db *mongo.Database) UserRepository {
 	return &mongoUserRepo{col: db.Collection(usersCollection)}
 }
 
-func (r *mongoUserRepo) FindByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
+func (r *mongoUserRepo) FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
 	var user models.User
-	err := r.col.FindOne(ctx, bson.M{"google_id": googleID}).Decode(&user)
+	err := r.col.FindOne(ctx, identityFilter(provider, subject)).Decode(&user)
 	if errors.Is(err, mongo.ErrNoDocuments) {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("user findByGoogleID: %w", err)
+		return nil, fmt.Errorf("user findByIdentity: %w", err)
+	}
+	return &user, nil
+}
+
+func (r *mongoUserRepo) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	opts := options.FindOne().
+		SetCollation(&options.Collation{Locale: "en", Strength: 2}).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	var user models.User
+	err := r.col.FindOne(ctx, bson.M{"email": email}, opts).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("user findByEmail: %w", err)
+	}
+	return &user, nil
+}
+
+// FindByVerifiedEmail returns the oldest user with an identity whose provider verified
+// email, matched case-insensitively.
+func (r *mongoUserRepo) FindByVerifiedEmail(ctx context.Context, email string) (*models.User, error) {
+	opts := options.FindOne().
+		SetCollation(&options.Collation{Locale: "en", Strength: 2}).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	filter := bson.M{"identities": bson.M{"$elemMatch": bson.M{"email": email, "email_verified": true}}}
+	var user models.User
+	err := r.col.FindOne(ctx, filter, opts).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("user findByVerifiedEmail: %w", err)
 	}
 	return &user, nil
 }
@@ -49,21 +84,54 @@ func (r *mongoUserRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*m
 	return &user, nil
 }
 
-// Upsert creates the user if they don't exist, or updates their profile fields.
-func (r *mongoUserRepo) Upsert(ctx context.Context, user *models.User) (*models.User, error) {
+// Upsert creates the user owning identity if they don't exist, or updates their profile
+// fields and the identity's email as the provider now asserts it.
+// Create inserts a new user owning identity. It returns ErrDuplicate if any user already
+// holds the identity; unlike Upsert it never returns an existing user.
+func (r *mongoUserRepo) Create(ctx context.Context, identity models.Identity, profile *models.User) (*models.User, error) {
 	now := time.Now()
-	user.UpdatedAt = now
+	identity.LinkedAt = now
+	user := &models.User{
+		ID:         primitive.NewObjectID(),
+		Identities: []models.Identity{identity},
+		Email:      profile.Email,
+		Name:       profile.Name,
+		Picture:    profile.Picture,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if _, err := r.col.InsertOne(ctx, user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDuplicate
+		}
+		return nil, fmt.Errorf("user create: %w", err)
+	}
+	return user, nil
+}
 
-	filter := bson.M{"google_id": user.GoogleID}
+func (r *mongoUserRepo) Upsert(ctx context.Context, identity models.Identity, profile *models.User) (*models.User, error) {
+	now := time.Now()
+	identity.LinkedAt = now
+
+	filter := identityFilter(identity.Provider, identity.Subject)
+	// The positional operator cannot be combined with an upsert, so an existing identity is
+	// refreshed first; for a new user this matches nothing.
+	refresh := bson.M{"$set": bson.M{
+		"identities.$.email":          identity.Email,
+		"identities.$.email_verified": identity.EmailVerified,
+	}}
+	if _, err := r.col.UpdateOne(ctx, filter, refresh); err != nil {
+		return nil, fmt.Errorf("user upsert identity: %w", err)
+	}
 	update := bson.M{
 		"$set": bson.M{
-			"email":      user.Email,
-			"name":       user.Name,
-			"picture":    user.Picture,
+			"email":      profile.Email,
+			"name":       profile.Name,
+			"picture":    profile.Picture,
 			"updated_at": now,
 		},
 		"$setOnInsert": bson.M{
-			"google_id":  user.GoogleID,
+			"identities": []models.Identity{identity},
 			"created_at": now,
 		},
 	}
@@ -77,3 +145,109 @@ func (r *mongoUserRepo) Upsert(ctx context.Context, user *models.User) (*models.
 	}
 	return &result, nil
 }
+
+// UpdateTimeZone sets the user's IANA time zone and returns the updated user.
+func (r *mongoUserRepo) UpdateTimeZone(ctx context.Context, id primitive.ObjectID, timeZone string) (*models.User, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"time_zone":  timeZone,
+			"updated_at": time.Now(),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var result models.User
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("user updateTimeZone: %w", err)
+	}
+	return &result, nil
+}
+
+func (r *mongoUserRepo) AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.Identity) (*models.User, error) {
+	identity.LinkedAt = time.Now()
+	update := bson.M{
+		"$push": bson.M{"identities": identity},
+		"$set":  bson.M{"updated_at": identity.LinkedAt},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var result models.User
+	err := r.col.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, fmt.Errorf("user addIdentity: %w", err)
+	}
+	return &result, nil
+}
+
+func (r *mongoUserRepo) RemoveIdentity(ctx context.Context, id primitive.ObjectID, provider, subject string) (*models.User, error) {
+	// Requiring a second array element keeps every user able to log in.
+	filter := bson.M{"_id": id, "identities.1": bson.M{"$exists": true}}
+	for k, v := range identityFilter(provider, subject) {
+		filter[k] = v
+	}
+	update := bson.M{
+		"$pull": bson.M{"identities": bson.M{"provider": provider, "subject": subject}},
+		"$set":  bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var result models.User
+	err := r.col.FindOneAndUpdate(ctx, filter, update, opts).Decode(&result)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("user removeIdentity: %w", err)
+	}
+	return &result, nil
+}
+
+// identityFilter matches the user holding the (provider, subject) identity. $elemMatch keeps
+// both conditions on the same array element.
+func identityFilter(provider, subject string) bson.M {
+	return bson.M{"identities": bson.M{"$elemMatch": bson.M{"provider": provider, "subject": subject}}}
+}
+
+// MigrateGoogleIdentities converts users created before multi-provider login, which were
+// keyed by google_id alone, to a "google" identity. It is a no-op once every user is migrated.
+func MigrateGoogleIdentities(ctx context.Context, db *mongo.Database) (int64, error) {
+	col := db.Collection(usersCollection)
+	filter := bson.M{"google_id": bson.M{"$exists": true}, "identities": bson.M{"$exists": false}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"identities": bson.A{bson.M{
+			"provider":  "google",
+			"subject":   "$google_id",
+			"email":     "$email",
+			"linked_at": "$created_at",
+		}}}}},
+		{{Key: "$unset", Value: "google_id"}},
+	}
+	result, err := col.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, fmt.Errorf("user migrateGoogleIdentities: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// EnsureUserIndexes creates the unique index that makes each (provider, subject) identity
+// belong to at most one user.
+func EnsureUserIndexes(ctx context.Context, db *mongo.Database) error {
+	col := db.Collection(usersCollection)
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+		Options: options.Index().
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"identities": bson.M{"$exists": true}}),
+	})
+	return err
+}
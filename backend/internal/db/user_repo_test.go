@@ -16,13 +16,12 @@ func TestUserRepo_Upsert_NewUser(t *testing.T) {
 	ctx := context.Background()
 
 	user := &models.User{
-		GoogleID: "google-123",
-		Email:    "alice@example.com",
-		Name:     "Alice",
-		Picture:  "https://example.com/alice.jpg",
+		Email:   "alice@example.com",
+		Name:    "Alice",
+		Picture: "https://example.com/alice.jpg",
 	}
 
-	saved, err := repo.Upsert(ctx, user)
+	saved, err := repo.Upsert(ctx, googleIdentity("google-123"), user)
 	if err != nil {
 		t.Fatalf("Upsert: %v", err)
 	}
@@ -38,13 +37,13 @@ func TestUserRepo_Upsert_ExistingUser(t *testing.T) {
 	repo := db.NewUserRepository(testDB(t))
 	ctx := context.Background()
 
-	user := &models.User{GoogleID: "google-456", Email: "bob@example.com", Name: "Bob"}
-	first, _ := repo.Upsert(ctx, user)
+	user := &models.User{Email: "bob@example.com", Name: "Bob"}
+	first, _ := repo.Upsert(ctx, googleIdentity("google-456"), user)
 
 	// Simulate a profile update.
 	user.Name = "Bobby"
 	user.Email = "bobby@example.com"
-	second, err := repo.Upsert(ctx, user)
+	second, err := repo.Upsert(ctx, googleIdentity("google-456"), user)
 	if err != nil {
 		t.Fatalf("second Upsert: %v", err)
 	}
@@ -56,15 +55,15 @@ func TestUserRepo_Upsert_ExistingUser(t *testing.T) {
 	}
 }
 
-func TestUserRepo_FindByGoogleID(t *testing.T) {
+func TestUserRepo_FindByIdentity(t *testing.T) {
 	repo := db.NewUserRepository(testDB(t))
 	ctx := context.Background()
 
-	repo.Upsert(ctx, &models.User{GoogleID: "gid-abc", Email: "c@example.com", Name: "Carol"})
+	repo.Upsert(ctx, googleIdentity("gid-abc"), &models.User{Email: "c@example.com", Name: "Carol"})
 
-	found, err := repo.FindByGoogleID(ctx, "gid-abc")
+	found, err := repo.FindByIdentity(ctx, "google", "gid-abc")
 	if err != nil {
-		t.Fatalf("FindByGoogleID: %v", err)
+		t.Fatalf("FindByIdentity: %v", err)
 	}
 	if found == nil {
 		t.Fatal("expected user, got nil")
@@ -73,9 +72,9 @@ func TestUserRepo_FindByGoogleID(t *testing.T) {
 		t.Errorf("email: got %q, want c@example.com", found.Email)
 	}
 
-	missing, err := repo.FindByGoogleID(ctx, "does-not-exist")
+	missing, err := repo.FindByIdentity(ctx, "google", "does-not-exist")
 	if err != nil {
-		t.Fatalf("FindByGoogleID missing: %v", err)
+		t.Fatalf("FindByIdentity missing: %v", err)
 	}
 	if missing != nil {
 		t.Error("expected nil for missing user")
@@ -86,7 +85,7 @@ func TestUserRepo_FindByID(t *testing.T) {
 	repo := db.NewUserRepository(testDB(t))
 	ctx := context.Background()
 
-	saved, _ := repo.Upsert(ctx, &models.User{GoogleID: "gid-xyz", Email: "d@example.com", Name: "Dave"})
+	saved, _ := repo.Upsert(ctx, googleIdentity("gid-xyz"), &models.User{Email: "d@example.com", Name: "Dave"})
 
 	found, err := repo.FindByID(ctx, saved.ID)
 	if err != nil {
@@ -102,9 +101,153 @@ func TestUserRepo_FindByID_Timestamps(t *testing.T) {
 	ctx := context.Background()
 
 	before := time.Now().Add(-time.Second)
-	saved, _ := repo.Upsert(ctx, &models.User{GoogleID: "gid-ts", Email: "ts@example.com", Name: "Timmy"})
+	saved, _ := repo.Upsert(ctx, googleIdentity("gid-ts"), &models.User{Email: "ts@example.com", Name: "Timmy"})
 
 	if saved.CreatedAt.Before(before) {
 		t.Error("created_at should be set to roughly now")
 	}
 }
+
+func TestUserRepo_UpdateTimeZone(t *testing.T) {
+	repo := db.NewUserRepository(testDB(t))
+	ctx := context.Background()
+
+	saved, _ := repo.Upsert(ctx, googleIdentity("gid-tz"), &models.User{Email: "tz@example.com", Name: "Zed"})
+
+	updated, err := repo.UpdateTimeZone(ctx, saved.ID, "Europe/Berlin")
+	if err != nil {
+		t.Fatalf("UpdateTimeZone: %v", err)
+	}
+	if updated.TimeZone != "Europe/Berlin" {
+		t.Errorf("time_zone: got %q, want Europe/Berlin", updated.TimeZone)
+	}
+
+	// A later login must not reset the stored time zone.
+	again, _ := repo.Upsert(ctx, googleIdentity("gid-tz"), &models.User{Email: "tz@example.com", Name: "Zed"})
+	if again.TimeZone != "Europe/Berlin" {
+		t.Errorf("time_zone after upsert: got %q, want Europe/Berlin", again.TimeZone)
+	}
+}
+
+func TestUserRepo_FindByIdentity_ProviderScoped(t *testing.T) {
+	repo := db.NewUserRepository(testDB(t))
+	ctx := context.Background()
+
+	// The same subject at two providers belongs to two different people.
+	google, _ := repo.Upsert(ctx, googleIdentity("42"), &models.User{Email: "g@example.com"})
+	keycloak, _ := repo.Upsert(ctx, models.Identity{Provider: "keycloak", Subject: "42"}, &models.User{Email: "k@example.com"})
+	if google.ID == keycloak.ID {
+		t.Fatal("expected separate users for the same subject at different providers")
+	}
+
+	found, err := repo.FindByIdentity(ctx, "keycloak", "42")
+	if err != nil {
+		t.Fatalf("FindByIdentity: %v", err)
+	}
+	if found == nil || found.ID != keycloak.ID {
+		t.Errorf("expected the keycloak user, got %+v", found)
+	}
+	if len(found.Identities) != 1 || found.Identities[0].LinkedAt.IsZero() {
+		t.Errorf("expected one identity with a link time, got %+v", found.Identities)
+	}
+}
+
+func TestUserRepo_FindByEmail_CaseInsensitive(t *testing.T) {
+	repo := db.NewUserRepository(testDB(t))
+	ctx := context.Background()
+
+	saved, _ := repo.Upsert(ctx, googleIdentity("gid-mail"), &models.User{Email: "Frank@Example.com"})
+	found, err := repo.FindByEmail(ctx, "frank@example.com")
+	if err != nil {
+		t.Fatalf("FindByEmail: %v", err)
+	}
+	if found == nil || found.ID != saved.ID {
+		t.Errorf("expected to find user %v, got %+v", saved.ID, found)
+	}
+}
+
+func TestUserRepo_FindByVerifiedEmail(t *testing.T) {
+	repo := db.NewUserRepository(testDB(t))
+	ctx := context.Background()
+
+	// The profile email alone never matches, nor does an unverified identity email.
+	_, _ = repo.Upsert(ctx, googleIdentity("gid-profile"), &models.User{Email: "gina@example.com"})
+	unverified := googleIdentity("gid-unverified")
+	unverified.Email = "gina@example.com"
+	_, _ = repo.Upsert(ctx, unverified, &models.User{Email: "gina@example.com"})
+	if found, err := repo.FindByVerifiedEmail(ctx, "gina@example.com"); err != nil || found != nil {
+		t.Fatalf("expected no match without a verified identity email, got %+v, %v", found, err)
+	}
+
+	// A later login that verifies the email refreshes the stored identity.
+	verified := unverified
+	verified.EmailVerified = true
+	saved, _ := repo.Upsert(ctx, verified, &models.User{Email: "gina@example.com"})
+	found, err := repo.FindByVerifiedEmail(ctx, "Gina@Example.com")
+	if err != nil {
+		t.Fatalf("FindByVerifiedEmail: %v", err)
+	}
+	if found == nil || found.ID != saved.ID {
+		t.Errorf("expected to find user %v, got %+v", saved.ID, found)
+	}
+}
+
+func TestUserRepo_AddAndRemoveIdentity(t *testing.T) {
+	database := testDB(t)
+	if err := db.EnsureUserIndexes(context.Background(), database); err != nil {
+		t.Fatalf("EnsureUserIndexes: %v", err)
+	}
+	repo := db.NewUserRepository(database)
+	ctx := context.Background()
+
+	user, _ := repo.Upsert(ctx, googleIdentity("gid-link"), &models.User{Email: "g@example.com"})
+	other, _ := repo.Upsert(ctx, googleIdentity("gid-other"), &models.User{Email: "o@example.com"})
+
+	keycloak := models.Identity{Provider: "keycloak", Subject: "kc-1"}
+	linked, err := repo.AddIdentity(ctx, user.ID, keycloak)
+	if err != nil {
+		t.Fatalf("AddIdentity: %v", err)
+	}
+	if len(linked.Identities) != 2 {
+		t.Fatalf("expected 2 identities, got %d", len(linked.Identities))
+	}
+	if found, _ := repo.FindByIdentity(ctx, "keycloak", "kc-1"); found == nil || found.ID != user.ID {
+		t.Error("linked identity should resolve to the user")
+	}
+
+	if _, err := repo.AddIdentity(ctx, other.ID, keycloak); err != db.ErrDuplicate {
+		t.Errorf("expected ErrDuplicate linking a taken identity, got %v", err)
+	}
+
+	if _, err := repo.RemoveIdentity(ctx, user.ID, "keycloak", "kc-1"); err != nil {
+		t.Fatalf("RemoveIdentity: %v", err)
+	}
+	if _, err := repo.RemoveIdentity(ctx, user.ID, "google", "gid-link"); err != db.ErrNotFound {
+		t.Errorf("expected ErrNotFound removing the last identity, got %v", err)
+	}
+}
+
+func TestUserRepo_Create_RejectsTakenIdentity(t *testing.T) {
+	database := testDB(t)
+	if err := db.EnsureUserIndexes(context.Background(), database); err != nil {
+		t.Fatalf("EnsureUserIndexes: %v", err)
+	}
+	repo := db.NewUserRepository(database)
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, googleIdentity("gid-create"), &models.User{Email: "c@example.com", Name: "Cleo"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if found, _ := repo.FindByIdentity(ctx, "google", "gid-create"); found == nil || found.ID != created.ID {
+		t.Error("created identity should resolve to the new user")
+	}
+
+	if _, err := repo.Create(ctx, googleIdentity("gid-create"), &models.User{Email: "c@example.com"}); err != db.ErrDuplicate {
+		t.Errorf("expected ErrDuplicate creating a user for a taken identity, got %v", err)
+	}
+}
+
+func googleIdentity(subject string) models.Identity {
+	return models.Identity{Provider: "google", Subject: subject}
+}
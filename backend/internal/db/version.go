@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactions and categories carry a version that every write increments. Writes on
+// behalf of a client match the version the client last read, so a concurrent change makes
+// them fail with ErrConflict instead of silently overwriting it.
+
+// atVersion adds the condition that the document is at the given version to filter.
+// Documents written before versioning have no version field and count as version 0.
+func atVersion(filter bson.M, version int64) bson.M {
+	if version == 0 {
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	} else {
+		filter["version"] = version
+	}
+	return filter
+}
+
+// missedWrite explains why a versioned write matched nothing: ErrConflict if the live
+// document matching filter still exists at another version, ErrNotFound otherwise.
+func missedWrite(ctx context.Context, col *mongo.Collection, filter bson.M, op string) error {
+	n, err := col.CountDocuments(ctx, notDeleted(filter))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if n > 0 {
+		return ErrConflict
+	}
+	return ErrNotFound
+}
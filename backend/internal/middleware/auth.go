@@ -3,7 +3,10 @@ package middleware
 import (
 	"context"
 	"errors"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
 	"expensify/internal/models"
 	"expensify/internal/services"
@@ -11,22 +14,43 @@ import (
 
 type contextKey string
 
-const UserContextKey contextKey = "user"
+const (
+	UserContextKey  contextKey = "user"
+	TokenContextKey contextKey = "api_token"
+)
 
-// Authenticate validates the session cookie and injects the user into the request context.
-// Requests without a valid, non-expired session are rejected with 401.
-func Authenticate(authSvc services.AuthService) func(http.Handler) http.Handler {
+// Authenticate validates an `Authorization: Bearer` API token or, failing that, the session cookie,
+// and injects the user (and token, if any) into the request context.
+// Requests without a valid, non-expired credential are rejected with 401.
+// When a session's expiry slides forward the cookie is reissued with the new lifetime.
+func Authenticate(authSvc services.AuthService, tokenSvc services.TokenService, secureCookies bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			cookie, err := r.Cookie("session")
-			if err != nil {
-				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
-				return
-			}
+			var (
+				user  *models.User
+				token *models.APIToken
+				err   error
+			)
 
-			user, err := authSvc.GetCurrentUser(r.Context(), cookie.Value)
+			if raw, ok := bearerToken(r); ok {
+				user, token, err = tokenSvc.Authenticate(r.Context(), raw)
+			} else {
+				cookie, cookieErr := r.Cookie("session")
+				if cookieErr != nil {
+					http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+					return
+				}
+				var auth *services.SessionAuth
+				auth, err = authSvc.AuthenticateSession(r.Context(), cookie.Value)
+				if err == nil {
+					user = auth.User
+					if auth.Renewed {
+						SetSessionCookie(w, cookie.Value, auth.Session.ExpiresAt, secureCookies)
+					}
+				}
+			}
 			if err != nil {
-				if errors.Is(err, services.ErrSessionExpired) || errors.Is(err, services.ErrNotFound) {
+				if errors.Is(err, services.ErrSessionExpired) || errors.Is(err, services.ErrTokenExpired) || errors.Is(err, services.ErrNotFound) {
 					http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
 					return
 				}
@@ -35,13 +59,113 @@ func Authenticate(authSvc services.AuthService) func(http.Handler) http.Handler
 			}
 
 			ctx := context.WithValue(r.Context(), UserContextKey, user)
+			if token != nil {
+				ctx = context.WithValue(ctx, TokenContextKey, token)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// RequireScope rejects API-token requests whose token lacks scope with 403.
+// Cookie sessions are interactive logins and are not scope-restricted.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token := TokenFromContext(r.Context()); token != nil && !token.HasScope(scope) {
+				http.Error(w, `{"error":"insufficient scope"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireSession rejects API-token requests with 403, for routes that must only be reachable
+// from an interactive login (such as managing the tokens themselves).
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if TokenFromContext(r.Context()) != nil {
+			http.Error(w, `{"error":"this endpoint requires a session login"}`, http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CSRFHeader carries the session's anti-CSRF token, issued by GET /auth/me.
+const CSRFHeader = "X-CSRF-Token"
+
+// RequireCSRF rejects state-changing requests that authenticate with the session cookie
+// unless they carry the session's token in CSRFHeader. A cross-site form can make the
+// browser send the cookie but cannot read the token. Bearer-token requests never use the
+// cookie and are exempt, as are requests without a session cookie.
+func RequireCSRF(authSvc services.AuthService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				next.ServeHTTP(w, r)
+				return
+			}
+			if _, ok := bearerToken(r); ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cookie, err := r.Cookie("session")
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !authSvc.ValidCSRFToken(cookie.Value, r.Header.Get(CSRFHeader)) {
+				http.Error(w, `{"error":"missing or invalid csrf token"}`, http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// SetSessionCookie writes the session cookie so that it lives exactly as long as the session.
+func SetSessionCookie(w http.ResponseWriter, token string, expiresAt time.Time, secure bool) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "session",
+		Value:    token,
+		MaxAge:   int(time.Until(expiresAt) / time.Second),
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+		Path:     "/",
+	})
+}
+
 // UserFromContext retrieves the authenticated user from the request context.
 func UserFromContext(ctx context.Context) *models.User {
 	user, _ := ctx.Value(UserContextKey).(*models.User)
 	return user
 }
+
+// TokenFromContext retrieves the API token used to authenticate, or nil for cookie sessions.
+func TokenFromContext(ctx context.Context) *models.APIToken {
+	token, _ := ctx.Value(TokenContextKey).(*models.APIToken)
+	return token
+}
+
+// ClientIP returns the IP address of the direct peer, without the port. Behind a trusted
+// proxy the router runs chi's RealIP first, so this is the client's address.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	h := r.Header.Get("Authorization")
+	if len(h) < len("Bearer ") || !strings.EqualFold(h[:len("Bearer ")], "Bearer ") {
+		return "", false
+	}
+	raw := strings.TrimSpace(h[len("Bearer "):])
+	return raw, raw != ""
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"expensify/internal/models"
+	"expensify/internal/services"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// IdempotencyKeyHeader names a client-chosen key that makes a create request safe to retry.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set to "true" on responses replayed for a repeated key.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	maxIdempotentBodyBytes  = 1 << 20
+)
+
+// replayedHeaders are the response headers stored with an idempotent response.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// Idempotent makes a create route safe to retry. A request with an Idempotency-Key header
+// runs once per user and key; repeating it within 24 hours replays the stored response
+// with Idempotent-Replayed: true. Reusing a key for a different request (method, path,
+// ledger or body) is rejected with 422, and a repeat that arrives while the first request
+// is still running with 409. Server errors are not stored, so the request can be retried.
+// Requests without the header pass straight through. It must run after Authenticate.
+func Idempotent(svc services.IdempotencyService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, `{"error":"idempotency key is too long"}`, http.StatusBadRequest)
+				return
+			}
+			user := UserFromContext(r.Context())
+			if user == nil {
+				http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBodyBytes+1))
+			if err != nil {
+				http.Error(w, `{"error":"failed to read request body"}`, http.StatusBadRequest)
+				return
+			}
+			if len(body) > maxIdempotentBodyBytes {
+				http.Error(w, `{"error":"request body too large"}`, http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			rec, err := svc.Begin(r.Context(), user.ID.Hex(), key, requestHash(r, body))
+			switch {
+			case errors.Is(err, services.ErrIdempotencyKeyReused):
+				http.Error(w, `{"error":"idempotency key was used for a different request"}`, http.StatusUnprocessableEntity)
+				return
+			case errors.Is(err, services.ErrIdempotencyInProgress):
+				http.Error(w, `{"error":"a request with this idempotency key is in progress"}`, http.StatusConflict)
+				return
+			case err != nil:
+				http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
+				return
+			}
+			if rec.Response != nil {
+				replay(w, rec.Response)
+				return
+			}
+
+			var captured bytes.Buffer
+			ww := chimiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&captured)
+			completed := false
+			// The key is released if the handler panics or fails, so the client can retry.
+			// The request context may be cancelled by then, hence WithoutCancel.
+			ctx := context.WithoutCancel(r.Context())
+			defer func() {
+				if !completed {
+					if err := svc.Abandon(ctx, rec); err != nil {
+						log.Printf("idempotency abandon: %v", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+			resp := models.StoredResponse{Status: status, Header: map[string]string{}, Body: captured.Bytes()}
+			for _, name := range replayedHeaders {
+				if v := ww.Header().Get(name); v != "" {
+					resp.Header[name] = v
+				}
+			}
+			if err := svc.Complete(ctx, rec, resp); err != nil {
+				log.Printf("idempotency complete: %v", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// requestHash identifies a request for comparing it with the one a key was first used for.
+// The ledger header is included because the same body creates different things in
+// different ledgers.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{r.Method, r.URL.Path, r.Header.Get("X-Ledger-ID")} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, resp *models.StoredResponse) {
+	for name, v := range resp.Header {
+		w.Header().Set(name, v)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
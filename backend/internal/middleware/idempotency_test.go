@@ -0,0 +1,109 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"expensify/internal/middleware"
+	"expensify/internal/models"
+	"expensify/internal/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeIdempotencyService keeps one record per key, ignoring users.
+type fakeIdempotencyService struct {
+	records   map[string]*models.IdempotencyRecord
+	abandoned int
+}
+
+func (s *fakeIdempotencyService) Begin(_ context.Context, _, key, hash string) (*models.IdempotencyRecord, error) {
+	if rec, ok := s.records[key]; ok {
+		switch {
+		case rec.RequestHash != hash:
+			return nil, services.ErrIdempotencyKeyReused
+		case rec.Response == nil:
+			return nil, services.ErrIdempotencyInProgress
+		}
+		return rec, nil
+	}
+	rec := &models.IdempotencyRecord{Key: key, RequestHash: hash}
+	s.records[key] = rec
+	return rec, nil
+}
+
+func (s *fakeIdempotencyService) Complete(_ context.Context, rec *models.IdempotencyRecord, resp models.StoredResponse) error {
+	rec.Response = &resp
+	return nil
+}
+
+func (s *fakeIdempotencyService) Abandon(_ context.Context, rec *models.IdempotencyRecord) error {
+	delete(s.records, rec.Key)
+	s.abandoned++
+	return nil
+}
+
+func TestIdempotent_ReplaysResponse(t *testing.T) {
+	svc := &fakeIdempotencyService{records: map[string]*models.IdempotencyRecord{}}
+	calls := 0
+	handler := middleware.Idempotent(svc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"id":"abc"}}`))
+	}))
+	user := &models.User{ID: primitive.NewObjectID()}
+
+	request := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/transactions", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, user))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := request("k1", `{"amount":5}`)
+	if first.Code != http.StatusCreated || first.Header().Get(middleware.IdempotentReplayedHeader) != "" {
+		t.Fatalf("first request: got %d %v", first.Code, first.Header())
+	}
+	second := request("k1", `{"amount":5}`)
+	if calls != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != `{"data":{"id":"abc"}}` {
+		t.Errorf("expected the original response, got %d %q", second.Code, second.Body.String())
+	}
+	if second.Header().Get(middleware.IdempotentReplayedHeader) != "true" || second.Header().Get("ETag") != `"1"` {
+		t.Errorf("unexpected replay headers %v", second.Header())
+	}
+
+	if reused := request("k1", `{"amount":6}`); reused.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for a different body, got %d", reused.Code)
+	}
+	request("", `{"amount":5}`)
+	if calls != 2 {
+		t.Errorf("expected requests without a key to pass through, got %d calls", calls)
+	}
+}
+
+func TestIdempotent_ServerErrorsReleaseKey(t *testing.T) {
+	svc := &fakeIdempotencyService{records: map[string]*models.IdempotencyRecord{}}
+	handler := middleware.Idempotent(svc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"boom"}`, http.StatusInternalServerError)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/categories", strings.NewReader(`{}`))
+	req.Header.Set(middleware.IdempotencyKeyHeader, "k1")
+	req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, &models.User{ID: primitive.NewObjectID()}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if svc.abandoned != 1 || len(svc.records) != 0 {
+		t.Errorf("expected the key to be abandoned, got %d abandoned and %d records", svc.abandoned, len(svc.records))
+	}
+}
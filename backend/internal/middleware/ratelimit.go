@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimit allows Requests per Per on average, with bursts of up to Requests.
+// A zero RateLimit disables limiting.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+}
+
+func (l RateLimit) enabled() bool { return l.Requests > 0 && l.Per > 0 }
+
+// rate is the number of tokens added to a bucket per second.
+func (l RateLimit) rate() float64 { return float64(l.Requests) / l.Per.Seconds() }
+
+// RateLimitResult is the state of a bucket after taking a token from it.
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until a token is available; zero when Allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+// RateLimitStore holds token buckets. The in-memory store suits a single instance;
+// a shared store (such as Redis) lets several instances enforce one limit.
+type RateLimitStore interface {
+	// Take removes a token from key's bucket, creating a full bucket for an unknown key.
+	Take(ctx context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	fullAt  time.Time
+}
+
+// MemoryRateLimitStore keeps buckets in process memory. Buckets that have refilled
+// completely are dropped, since a new bucket starts full anyway.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+const rateLimitSweepInterval = time.Minute
+
+// NewMemoryRateLimitStore returns an empty in-memory store.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*bucket{}}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= rateLimitSweepInterval {
+		for k, b := range s.buckets {
+			if !now.Before(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	capacity := float64(limit.Requests)
+	rate := limit.rate()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+
+	var result RateLimitResult
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - b.tokens) / rate)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = secondsToDuration((capacity - b.tokens) / rate)
+	b.fullAt = now.Add(result.Reset)
+	return result, nil
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RateLimiter limits requests with one token bucket per client within group. Clients are
+// the authenticated user when Authenticate has run, otherwise the peer IP. Every response
+// carries X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the
+// bucket is full); rejected requests get 429 with Retry-After. If the store fails the
+// request is let through rather than taking the API down with it.
+func RateLimiter(store RateLimitStore, group string, limit RateLimit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limit.enabled() || store == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := group + ":ip:" + ClientIP(r)
+			if user := UserFromContext(r.Context()); user != nil {
+				key = group + ":user:" + user.ID.Hex()
+			}
+
+			result, err := store.Take(r.Context(), key, limit, time.Now())
+			if err != nil {
+				log.Printf("rate limit store: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+			if !result.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				http.Error(w, `{"error":"rate limit exceeded"}`, http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,69 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"expensify/internal/middleware"
+)
+
+func TestMemoryRateLimitStore_RefillsOverTime(t *testing.T) {
+	store := middleware.NewMemoryRateLimitStore()
+	limit := middleware.RateLimit{Requests: 2, Per: time.Minute}
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if res, _ := store.Take(context.Background(), "k", limit, now); !res.Allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+	}
+	res, _ := store.Take(context.Background(), "k", limit, now)
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("third request should be limited, got %+v", res)
+	}
+	if res.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter: got %s, want 30s", res.RetryAfter)
+	}
+
+	// One token is back after half the window.
+	if res, _ := store.Take(context.Background(), "k", limit, now.Add(30*time.Second)); !res.Allowed {
+		t.Error("a token should have refilled")
+	}
+	// Other keys have their own bucket.
+	if res, _ := store.Take(context.Background(), "other", limit, now); !res.Allowed {
+		t.Error("buckets must be per key")
+	}
+}
+
+func TestRateLimiter_RejectsWithHeaders(t *testing.T) {
+	limiter := middleware.RateLimiter(middleware.NewMemoryRateLimitStore(), "auth", middleware.RateLimit{Requests: 1, Per: time.Minute})
+	handler := limiter(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	request := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/auth/providers", nil)
+		req.RemoteAddr = ip + ":4000"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := request("198.51.100.1")
+	if first.Code != http.StatusNoContent || first.Header().Get("X-RateLimit-Limit") != "1" || first.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("first request: got %d %v", first.Code, first.Header())
+	}
+	second := request("198.51.100.1")
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", second.Code)
+	}
+	if second.Header().Get("Retry-After") != "60" || second.Header().Get("X-RateLimit-Reset") != "60" {
+		t.Errorf("unexpected headers: %v", second.Header())
+	}
+	if other := request("198.51.100.2"); other.Code != http.StatusNoContent {
+		t.Errorf("another IP should not be limited, got %d", other.Code)
+	}
+}
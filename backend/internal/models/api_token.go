@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Scopes that can be granted to an API token. ScopeWrite implies ScopeRead.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+type APIToken struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"          json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id"                json:"user_id"`
+	Name       string             `bson:"name"                   json:"name"`
+	TokenHash  string             `bson:"token_hash"             json:"-"`
+	Prefix     string             `bson:"prefix"                 json:"prefix"`
+	Scopes     []string           `bson:"scopes"                 json:"scopes"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty"   json:"expires_at,omitempty"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at"             json:"created_at"`
+}
+
+// HasScope reports whether the token grants scope.
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope || (s == ScopeWrite && scope == ScopeRead) {
+			return true
+		}
+	}
+	return false
+}
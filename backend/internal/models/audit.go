@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Audited kinds of change.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore" // taken back out of the trash
+)
+
+// Audited entity types.
+const (
+	AuditEntityTransaction = "transaction"
+	AuditEntityCategory    = "category"
+)
+
+// AuditEntry records one change to a ledger's data. Entries are only ever appended.
+type AuditEntry struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	LedgerID   primitive.ObjectID `bson:"ledger_id"     json:"ledger_id"`
+	ActorID    primitive.ObjectID `bson:"actor_id"      json:"actor_id"`
+	Action     string             `bson:"action"        json:"action"`
+	EntityType string             `bson:"entity_type"   json:"entity_type"`
+	EntityID   primitive.ObjectID `bson:"entity_id"     json:"entity_id"`
+	// Changes lists the fields that differ: all of them, with no before value, for a
+	// create, and all of them, with no after value, for a delete.
+	Changes   []FieldChange `bson:"changes"              json:"changes"`
+	RequestID string        `bson:"request_id,omitempty" json:"request_id,omitempty"`
+	At        time.Time     `bson:"at"                   json:"at"`
+}
+
+// FieldChange is a field's value before and after a change.
+type FieldChange struct {
+	Field  string `bson:"field"            json:"field"`
+	Before any    `bson:"before,omitempty" json:"before,omitempty"`
+	After  any    `bson:"after,omitempty"  json:"after,omitempty"`
+}
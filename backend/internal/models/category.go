@@ -7,11 +7,14 @@ import (
 )
 
 type Category struct {
-	ID        primitive.ObjectID  `bson:"_id,omitempty"      json:"id"`
-	UserID    *primitive.ObjectID `bson:"user_id,omitempty"  json:"user_id,omitempty"`
-	Name      string              `bson:"name"               json:"name"`
-	Icon      string              `bson:"icon"               json:"icon"`
-	Color     string              `bson:"color"              json:"color"`
-	IsDefault bool                `bson:"is_default"         json:"is_default"`
-	CreatedAt time.Time           `bson:"created_at"         json:"created_at"`
+	ID        primitive.ObjectID  `bson:"_id,omitempty"       json:"id"`
+	LedgerID  *primitive.ObjectID `bson:"ledger_id,omitempty" json:"ledger_id,omitempty"` // nil for default categories
+	UserID    *primitive.ObjectID `bson:"user_id,omitempty"   json:"user_id,omitempty"`   // who created it
+	Name      string              `bson:"name"                json:"name"`
+	Icon      string              `bson:"icon"                json:"icon"`
+	Color     string              `bson:"color"               json:"color"`
+	IsDefault bool                `bson:"is_default"          json:"is_default"`
+	CreatedAt time.Time           `bson:"created_at"          json:"created_at"`
+	Version   int64               `bson:"version"             json:"version"`               // incremented by every write
+	DeletedAt *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // set while in the trash
 }
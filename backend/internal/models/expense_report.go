@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Expense report statuses. A report moves draft → submitted → approved or rejected, and an
+// approved report → reimbursed.
+const (
+	ReportDraft      = "draft"
+	ReportSubmitted  = "submitted"
+	ReportApproved   = "approved"
+	ReportRejected   = "rejected"
+	ReportReimbursed = "reimbursed"
+)
+
+// ExpenseReport groups outflow transactions a member paid for and claims back from the
+// ledger. Only drafts can be changed; every later change of status is an approval step.
+type ExpenseReport struct {
+	ID             primitive.ObjectID   `bson:"_id,omitempty"   json:"id"`
+	LedgerID       primitive.ObjectID   `bson:"ledger_id"       json:"ledger_id"`
+	UserID         primitive.ObjectID   `bson:"user_id"         json:"user_id"` // who files the report and is reimbursed
+	Title          string               `bson:"title"           json:"title"`
+	TransactionIDs []primitive.ObjectID `bson:"transaction_ids" json:"transaction_ids"`
+	// Total is the sum of the transactions, fixed when the report is submitted.
+	Total  float64 `bson:"total"  json:"total"`
+	Status string  `bson:"status" json:"status"`
+	// ReimbursementID is the inflow transaction recorded when the report was reimbursed.
+	ReimbursementID *primitive.ObjectID `bson:"reimbursement_id,omitempty" json:"reimbursement_id,omitempty"`
+	History         []ReportTransition  `bson:"history"                    json:"history"`
+	CreatedAt       time.Time           `bson:"created_at"                 json:"created_at"`
+	UpdatedAt       time.Time           `bson:"updated_at"                 json:"updated_at"`
+}
+
+// ReportTransition records who moved a report between statuses, and when. The first entry
+// of every report's history is its creation, from "" to draft.
+type ReportTransition struct {
+	From string             `bson:"from"           json:"from"`
+	To   string             `bson:"to"             json:"to"`
+	By   primitive.ObjectID `bson:"by"             json:"by"`
+	At   time.Time          `bson:"at"             json:"at"`
+	Note string             `bson:"note,omitempty" json:"note,omitempty"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdempotencyRecord remembers a create request sent with an Idempotency-Key, so that a
+// retry with the same key gets the original response instead of creating a duplicate.
+type IdempotencyRecord struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	UserID      primitive.ObjectID `bson:"user_id"`
+	Key         string             `bson:"key"`
+	RequestHash string             `bson:"request_hash"` // SHA-256 of the method, path, ledger and body
+	// Response is nil while the first request with the key is still being handled.
+	Response *StoredResponse `bson:"response,omitempty"`
+	// LockedUntil bounds how long a record without a Response holds the key; after it
+	// the request is taken to have died and the key can be claimed again.
+	LockedUntil time.Time `bson:"locked_until"`
+	CreatedAt   time.Time `bson:"created_at"`
+	ExpiresAt   time.Time `bson:"expires_at"` // removed by a TTL index
+}
+
+// StoredResponse is the response an idempotent request is answered with on replay.
+type StoredResponse struct {
+	Status int               `bson:"status"`
+	Header map[string]string `bson:"header,omitempty"`
+	Body   []byte            `bson:"body"`
+}
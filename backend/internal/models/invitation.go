@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Invitation status values, derived from an invitation's timestamps.
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation asks the holder of an email address to join a ledger with a role. The
+// emailed link carries a signed token naming the invitation; accepting it records
+// which user took it up.
+type Invitation struct {
+	ID         primitive.ObjectID  `bson:"_id,omitempty"         json:"id"`
+	LedgerID   primitive.ObjectID  `bson:"ledger_id"             json:"ledger_id"`
+	Email      string              `bson:"email"                 json:"email"`
+	Role       string              `bson:"role"                  json:"role"`
+	InvitedBy  primitive.ObjectID  `bson:"invited_by"            json:"invited_by"`
+	CreatedAt  time.Time           `bson:"created_at"            json:"created_at"`
+	ExpiresAt  time.Time           `bson:"expires_at"            json:"expires_at"`
+	AcceptedAt *time.Time          `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	AcceptedBy *primitive.ObjectID `bson:"accepted_by,omitempty" json:"accepted_by,omitempty"`
+	RevokedAt  *time.Time          `bson:"revoked_at,omitempty"  json:"revoked_at,omitempty"`
+}
+
+// Status reports whether the invitation can still be accepted at now.
+func (i *Invitation) Status(now time.Time) string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case !now.Before(i.ExpiresAt):
+		return InvitationExpired
+	}
+	return InvitationPending
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ledger member roles, from most to least privileged.
+const (
+	RoleOwner    = "owner"    // everything an approver can do, plus managing the ledger and its members
+	RoleApprover = "approver" // everything an editor can do, plus approving and reimbursing expense reports
+	RoleEditor   = "editor"   // add, change and delete transactions and custom categories
+	RoleViewer   = "viewer"   // read only
+)
+
+// PersonalLedgerName names the ledger every user gets for data that is not shared.
+const PersonalLedgerName = "Personal"
+
+var roleRank = map[string]int{RoleViewer: 1, RoleEditor: 2, RoleApprover: 3, RoleOwner: 4}
+
+// ValidRole reports whether role is one of the ledger roles.
+func ValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// RoleAtLeast reports whether role grants everything need does.
+func RoleAtLeast(role, need string) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[need]
+}
+
+// Ledger is a book of transactions and custom categories shared by its members.
+type Ledger struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name      string             `bson:"name"          json:"name"`
+	Members   []LedgerMember     `bson:"members"       json:"members"`
+	CreatedAt time.Time          `bson:"created_at"    json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"    json:"updated_at"`
+	// PersonalOwner marks the ledger created for a user who owned none, so that it is only
+	// created once. It is cleared when that user stops owning the ledger.
+	PersonalOwner *primitive.ObjectID `bson:"personal_owner,omitempty" json:"-"`
+}
+
+// LedgerMember grants a user a role in a ledger.
+type LedgerMember struct {
+	UserID  primitive.ObjectID `bson:"user_id"  json:"user_id"`
+	Role    string             `bson:"role"     json:"role"`
+	AddedAt time.Time          `bson:"added_at" json:"added_at"`
+}
+
+// RoleOf returns the user's role in the ledger, or "" if they are not a member.
+func (l *Ledger) RoleOf(userID primitive.ObjectID) string {
+	for _, m := range l.Members {
+		if m.UserID == userID {
+			return m.Role
+		}
+	}
+	return ""
+}
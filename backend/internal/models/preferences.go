@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Preferences are a user's display settings and input defaults, one document per user.
+// TimeZone lives on the User, where reporting already reads it, and is copied in on read.
+type Preferences struct {
+	UserID                 primitive.ObjectID `bson:"_id"                      json:"-"`
+	Currency               string             `bson:"currency"                 json:"currency"` // ISO 4217 code, e.g. "USD"
+	TimeZone               string             `bson:"-"                        json:"time_zone"`
+	Locale                 string             `bson:"locale"                   json:"locale"`            // BCP 47 tag; selects number and date formats
+	FirstDayOfWeek         int                `bson:"first_day_of_week"        json:"first_day_of_week"` // 0 = Sunday … 6 = Saturday
+	DefaultTransactionType string             `bson:"default_transaction_type" json:"default_transaction_type"`
+	DefaultPageSize        int                `bson:"default_page_size"        json:"default_page_size"`
+	UpdatedAt              time.Time          `bson:"updated_at"               json:"updated_at"`
+}
@@ -6,10 +6,16 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Session is a browser login. Only TokenHash is persisted; Token holds the raw
+// cookie value on a freshly created session and is empty on sessions read back.
 type Session struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID    primitive.ObjectID `bson:"user_id"       json:"user_id"`
-	Token     string             `bson:"token"         json:"token"`
-	ExpiresAt time.Time          `bson:"expires_at"    json:"expires_at"`
-	CreatedAt time.Time          `bson:"created_at"    json:"created_at"`
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     primitive.ObjectID `bson:"user_id"       json:"user_id"`
+	Token      string             `bson:"-"             json:"-"`
+	TokenHash  string             `bson:"token_hash"    json:"-"`
+	UserAgent  string             `bson:"user_agent"    json:"user_agent"`
+	IP         string             `bson:"ip"            json:"ip"`
+	ExpiresAt  time.Time          `bson:"expires_at"    json:"expires_at"`
+	LastSeenAt time.Time          `bson:"last_seen_at"  json:"last_seen_at"`
+	CreatedAt  time.Time          `bson:"created_at"    json:"created_at"`
 }
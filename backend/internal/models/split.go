@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ways a group expense can be divided among participants.
+const (
+	SplitEqual  = "equal"  // everyone owes the same, to the cent
+	SplitShares = "shares" // in proportion to each participant's shares
+	SplitExact  = "exact"  // each participant's amount is given
+)
+
+// GroupExpense is a cost one ledger member paid on behalf of several members.
+type GroupExpense struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	LedgerID    primitive.ObjectID `bson:"ledger_id"     json:"ledger_id"`
+	PaidBy      primitive.ObjectID `bson:"paid_by"       json:"paid_by"`
+	Amount      float64            `bson:"amount"        json:"amount"`
+	Description string             `bson:"description"   json:"description"`
+	Date        time.Time          `bson:"date"          json:"date"`
+	SplitMethod string             `bson:"split_method"  json:"split_method"`
+	// Shares always add up to Amount.
+	Shares    []ExpenseShare     `bson:"shares"     json:"shares"`
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// ExpenseShare is what one participant owes towards a group expense.
+type ExpenseShare struct {
+	UserID primitive.ObjectID `bson:"user_id"          json:"user_id"`
+	Amount float64            `bson:"amount"           json:"amount"`
+	Shares float64            `bson:"shares,omitempty" json:"shares,omitempty"` // for SplitShares
+}
+
+// Settlement records a payment from one member to another that pays down a debt.
+type Settlement struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	LedgerID  primitive.ObjectID `bson:"ledger_id"     json:"ledger_id"`
+	From      primitive.ObjectID `bson:"from"          json:"from"`
+	To        primitive.ObjectID `bson:"to"            json:"to"`
+	Amount    float64            `bson:"amount"        json:"amount"`
+	Date      time.Time          `bson:"date"          json:"date"`
+	Note      string             `bson:"note"          json:"note"`
+	CreatedBy primitive.ObjectID `bson:"created_by"    json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at"    json:"created_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// HashToken returns the hex SHA-256 of a secret token, the only form in which session
+// and API tokens are stored.
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
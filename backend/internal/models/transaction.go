@@ -8,12 +8,16 @@ import (
 
 type Transaction struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty"  json:"id"`
-	UserID      primitive.ObjectID `bson:"user_id"        json:"user_id"`
+	LedgerID    primitive.ObjectID `bson:"ledger_id"      json:"ledger_id"`
+	UserID      primitive.ObjectID `bson:"user_id"        json:"user_id"` // who recorded it
 	CategoryID  primitive.ObjectID `bson:"category_id"    json:"category_id"`
 	Type        string             `bson:"type"           json:"type"`
 	Amount      float64            `bson:"amount"         json:"amount"`
 	Description string             `bson:"description"    json:"description"`
 	Date        time.Time          `bson:"date"           json:"date"`
+	Tags        []string           `bson:"tags,omitempty" json:"tags,omitempty"`
 	CreatedAt   time.Time          `bson:"created_at"     json:"created_at"`
 	UpdatedAt   time.Time          `bson:"updated_at"     json:"updated_at"`
+	Version     int64              `bson:"version"        json:"version"`                    // incremented by every write
+	DeletedAt   *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"` // set while in the trash
 }
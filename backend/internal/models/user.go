@@ -7,11 +7,24 @@ import (
 )
 
 type User struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	GoogleID  string             `bson:"google_id"     json:"google_id"`
-	Email     string             `bson:"email"         json:"email"`
-	Name      string             `bson:"name"          json:"name"`
-	Picture   string             `bson:"picture"       json:"picture"`
-	CreatedAt time.Time          `bson:"created_at"    json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at"    json:"updated_at"`
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Identities []Identity         `bson:"identities"    json:"identities"`
+	Email      string             `bson:"email"         json:"email"`
+	Name       string             `bson:"name"          json:"name"`
+	Picture    string             `bson:"picture"       json:"picture"`
+	TimeZone   string             `bson:"time_zone"     json:"time_zone"` // IANA name; empty means UTC
+	CreatedAt  time.Time          `bson:"created_at"    json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at"    json:"updated_at"`
+}
+
+// Identity is a login at an external identity provider. A user is found by the
+// (Provider, Subject) pair, which the provider guarantees to be stable. Email and
+// EmailVerified are as the provider last asserted them; only a verified email may be
+// used to match the identity's user by email, since User.Email is whatever any login said.
+type Identity struct {
+	Provider      string    `bson:"provider"       json:"provider"`
+	Subject       string    `bson:"subject"        json:"subject"`
+	Email         string    `bson:"email"          json:"email"`
+	EmailVerified bool      `bson:"email_verified" json:"email_verified"`
+	LinkedAt      time.Time `bson:"linked_at"      json:"linked_at"`
 }
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 500
+)
+
+// AuditQuery selects audit entries of a ledger. Zero fields match everything; the time
+// range is [Since, Until).
+type AuditQuery struct {
+	EntityType string
+	EntityID   string
+	Since      time.Time
+	Until      time.Time
+	Limit      int // defaults to 100, at most 500
+}
+
+// AuditService reads a ledger's audit log, which needs the viewer role.
+type AuditService interface {
+	// List returns matching entries, newest first.
+	List(ctx context.Context, userID, ledgerID string, q AuditQuery) ([]*models.AuditEntry, error)
+}
+
+type auditService struct {
+	ledgerAccess
+	auditRepo db.AuditRepository
+}
+
+// NewAuditService creates a new AuditService.
+func NewAuditService(auditRepo db.AuditRepository, ledgerRepo db.LedgerRepository) AuditService {
+	return &auditService{ledgerAccess: ledgerAccess{ledgerRepo: ledgerRepo}, auditRepo: auditRepo}
+}
+
+func (s *auditService) List(ctx context.Context, userID, ledgerID string, q AuditQuery) ([]*models.AuditEntry, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	filter := db.AuditFilter{LedgerID: lid, Since: q.Since, Until: q.Until, Limit: q.Limit}
+
+	switch q.EntityType {
+	case "", models.AuditEntityTransaction, models.AuditEntityCategory:
+		filter.EntityType = q.EntityType
+	default:
+		return nil, fmt.Errorf("%w: entity must be transaction or category", ErrInvalidFilter)
+	}
+	if q.EntityID != "" {
+		if filter.EntityID, err = primitive.ObjectIDFromHex(q.EntityID); err != nil {
+			return nil, ErrInvalidID
+		}
+	}
+	if !q.Since.IsZero() && !q.Until.IsZero() && !q.Since.Before(q.Until) {
+		return nil, ErrInvalidPeriod
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	entries, err := s.auditRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("fetching audit log: %w", err)
+	}
+	if entries == nil {
+		entries = []*models.AuditEntry{}
+	}
+	return entries, nil
+}
+
+// auditLog appends audit entries for the changes a service makes.
+type auditLog struct {
+	auditRepo db.AuditRepository
+}
+
+// record appends an entry for a change of one entity, diffing its fields before and after;
+// before is nil for a create and after is nil for a delete. The request ID is taken from
+// chi's RequestID middleware. The change has already been made by the time it is
+// recorded, so a failure to record it is logged rather than returned.
+func (a auditLog) record(ctx context.Context, ledgerID, actorID primitive.ObjectID, action, entityType string, entityID primitive.ObjectID, before, after map[string]any) {
+	changes := diffFields(before, after)
+	if action == models.AuditUpdate && len(changes) == 0 {
+		return
+	}
+	entry := &models.AuditEntry{
+		LedgerID:   ledgerID,
+		ActorID:    actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+		RequestID:  chimiddleware.GetReqID(ctx),
+		At:         time.Now(),
+	}
+	if _, err := a.auditRepo.Create(ctx, entry); err != nil {
+		log.Printf("audit: could not record %s of %s %s: %v", action, entityType, entityID.Hex(), err)
+	}
+}
+
+// diffFields lists the fields whose values differ between before and after, by name.
+func diffFields(before, after map[string]any) []models.FieldChange {
+	names := make(map[string]bool, len(before)+len(after))
+	for k := range before {
+		names[k] = true
+	}
+	for k := range after {
+		names[k] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for k := range names {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	changes := []models.FieldChange{}
+	for _, k := range sorted {
+		b, inBefore := before[k]
+		a, inAfter := after[k]
+		if inBefore && inAfter && sameValue(b, a) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: k, Before: b, After: a})
+	}
+	return changes
+}
+
+// sameValue compares field values, treating times as equal at the millisecond precision
+// MongoDB stores.
+func sameValue(a, b any) bool {
+	ta, aIsTime := a.(time.Time)
+	tb, bIsTime := b.(time.Time)
+	if aIsTime && bIsTime {
+		return ta.Truncate(time.Millisecond).Equal(tb.Truncate(time.Millisecond))
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// transactionFields is the audited view of a transaction.
+func transactionFields(tx *models.Transaction) map[string]any {
+	fields := map[string]any{
+		"category_id": tx.CategoryID.Hex(),
+		"type":        tx.Type,
+		"amount":      tx.Amount,
+		"description": tx.Description,
+		"date":        tx.Date.UTC(),
+	}
+	if len(tx.Tags) > 0 {
+		fields["tags"] = tx.Tags
+	}
+	return fields
+}
+
+// categoryFields is the audited view of a custom category.
+func categoryFields(c *models.Category) map[string]any {
+	return map[string]any{
+		"name":  c.Name,
+		"icon":  c.Icon,
+		"color": c.Color,
+	}
+}
@@ -0,0 +1,201 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordingAuditRepo keeps the entries appended to it.
+func recordingAuditRepo(entries *[]*models.AuditEntry) *testutil.MockAuditRepo {
+	return &testutil.MockAuditRepo{
+		CreateFn: func(_ context.Context, e *models.AuditEntry) (*models.AuditEntry, error) {
+			*entries = append(*entries, e)
+			return e, nil
+		},
+	}
+}
+
+func TestTransactionService_Update_AuditsChangedFields(t *testing.T) {
+	userID := primitive.NewObjectID()
+	catID := primitive.NewObjectID()
+	txID := primitive.NewObjectID()
+	date := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	before := &models.Transaction{ID: txID, LedgerID: testLedgerID, CategoryID: catID, Type: "outflow", Amount: 40, Description: "groceries", Date: date}
+	txRepo := &testutil.MockTransactionRepo{
+		FindByIDFn: func(context.Context, primitive.ObjectID, primitive.ObjectID) (*models.Transaction, error) {
+			copied := *before
+			return &copied, nil
+		},
+		UpdateFn: func(_ context.Context, tx *models.Transaction) (*models.Transaction, error) { return tx, nil },
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Category, error) {
+			return &models.Category{ID: id}, nil
+		},
+	}
+	var entries []*models.AuditEntry
+	svc := services.NewTransactionService(txRepo, catRepo, testutil.OwnLedgerRepo(testLedgerID), recordingAuditRepo(&entries))
+
+	ctx := context.WithValue(context.Background(), chimiddleware.RequestIDKey, "req-1")
+	_, err := svc.Update(ctx, userID.Hex(), "", txID.Hex(), []int64{0}, services.UpdateTransactionRequest{
+		CategoryID: catID.Hex(), Type: "outflow", Amount: 45.5, Description: "groceries", Date: date,
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Action != models.AuditUpdate || e.EntityType != models.AuditEntityTransaction || e.EntityID != txID ||
+		e.ActorID != userID || e.LedgerID != testLedgerID || e.RequestID != "req-1" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	if len(e.Changes) != 1 || e.Changes[0].Field != "amount" || e.Changes[0].Before != 40.0 || e.Changes[0].After != 45.5 {
+		t.Errorf("expected only the amount to change from 40 to 45.5, got %+v", e.Changes)
+	}
+
+	// Saving the same values again changes nothing and records nothing.
+	before.Amount = 45.5
+	if _, err := svc.Update(ctx, userID.Hex(), "", txID.Hex(), []int64{0}, services.UpdateTransactionRequest{
+		CategoryID: catID.Hex(), Type: "outflow", Amount: 45.5, Description: "groceries", Date: date,
+	}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected no entry for an unchanged transaction, got %d entries", len(entries))
+	}
+}
+
+func TestTransactionService_Delete_AuditsPreviousValues(t *testing.T) {
+	userID := primitive.NewObjectID()
+	txID := primitive.NewObjectID()
+
+	txRepo := &testutil.MockTransactionRepo{
+		FindByIDFn: func(_ context.Context, lid, id primitive.ObjectID) (*models.Transaction, error) {
+			return &models.Transaction{ID: id, LedgerID: lid, Type: "inflow", Amount: 1200, Description: "salary"}, nil
+		},
+	}
+	var entries []*models.AuditEntry
+	svc := services.NewTransactionService(txRepo, &testutil.MockCategoryRepo{}, testutil.OwnLedgerRepo(testLedgerID), recordingAuditRepo(&entries))
+
+	if err := svc.Delete(context.Background(), userID.Hex(), "", txID.Hex(), []int64{0}); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != models.AuditDelete {
+		t.Fatalf("expected a delete entry, got %+v", entries)
+	}
+	changes := map[string]models.FieldChange{}
+	for _, c := range entries[0].Changes {
+		changes[c.Field] = c
+	}
+	if c := changes["amount"]; c.Before != 1200.0 || c.After != nil {
+		t.Errorf("expected amount 1200 before and nothing after, got %+v", c)
+	}
+	if len(changes) != 5 {
+		t.Errorf("expected every audited field, got %+v", entries[0].Changes)
+	}
+}
+
+func TestTransactionService_AuditFailureDoesNotFailTheChange(t *testing.T) {
+	txRepo := &testutil.MockTransactionRepo{
+		CreateFn: func(_ context.Context, tx *models.Transaction) (*models.Transaction, error) {
+			tx.ID = primitive.NewObjectID()
+			return tx, nil
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Category, error) {
+			return &models.Category{ID: id}, nil
+		},
+	}
+	auditRepo := &testutil.MockAuditRepo{
+		CreateFn: func(context.Context, *models.AuditEntry) (*models.AuditEntry, error) {
+			return nil, errors.New("db down")
+		},
+	}
+	svc := services.NewTransactionService(txRepo, catRepo, testutil.OwnLedgerRepo(testLedgerID), auditRepo)
+
+	_, err := svc.Create(context.Background(), primitive.NewObjectID().Hex(), "", services.CreateTransactionRequest{
+		CategoryID: primitive.NewObjectID().Hex(), Type: "outflow", Amount: 5,
+	})
+	if err != nil {
+		t.Errorf("expected the transaction to be created, got %v", err)
+	}
+}
+
+func TestCategoryService_CreateCategory_Audited(t *testing.T) {
+	userID := primitive.NewObjectID()
+	repo := &testutil.MockCategoryRepo{
+		CreateFn: func(_ context.Context, c *models.Category) (*models.Category, error) {
+			c.ID = primitive.NewObjectID()
+			return c, nil
+		},
+	}
+	var entries []*models.AuditEntry
+	svc := services.NewCategoryService(repo, &testutil.MockTransactionRepo{}, testutil.OwnLedgerRepo(testLedgerID), recordingAuditRepo(&entries))
+
+	cat, err := svc.CreateCategory(context.Background(), userID.Hex(), "", services.CreateCategoryRequest{Name: "Hobbies", Icon: "🎨", Color: "#123456"})
+	if err != nil {
+		t.Fatalf("CreateCategory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Action != models.AuditCreate || e.EntityType != models.AuditEntityCategory || e.EntityID != cat.ID || len(e.Changes) != 3 {
+		t.Errorf("unexpected entry %+v", e)
+	}
+	for _, c := range e.Changes {
+		if c.Before != nil {
+			t.Errorf("a create has no before values, got %+v", c)
+		}
+	}
+}
+
+func TestAuditService_List(t *testing.T) {
+	userID := primitive.NewObjectID()
+	entityID := primitive.NewObjectID()
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var got db.AuditFilter
+	repo := &testutil.MockAuditRepo{
+		FindFn: func(_ context.Context, f db.AuditFilter) ([]*models.AuditEntry, error) {
+			got = f
+			return nil, nil
+		},
+	}
+	svc := services.NewAuditService(repo, testutil.OwnLedgerRepo(testLedgerID))
+
+	entries, err := svc.List(context.Background(), userID.Hex(), "", services.AuditQuery{
+		EntityType: models.AuditEntityTransaction, EntityID: entityID.Hex(), Since: since, Limit: 10000,
+	})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if entries == nil {
+		t.Error("expected an empty list, got nil")
+	}
+	if got.LedgerID != testLedgerID || got.EntityType != models.AuditEntityTransaction || got.EntityID != entityID || !got.Since.Equal(since) || got.Limit != 500 {
+		t.Errorf("unexpected filter %+v", got)
+	}
+
+	if _, err := svc.List(context.Background(), userID.Hex(), "", services.AuditQuery{EntityType: "user"}); !errors.Is(err, services.ErrInvalidFilter) {
+		t.Errorf("expected ErrInvalidFilter for an unknown entity, got %v", err)
+	}
+	if _, err := svc.List(context.Background(), userID.Hex(), "", services.AuditQuery{Since: since, Until: since}); !errors.Is(err, services.ErrInvalidPeriod) {
+		t.Errorf("expected ErrInvalidPeriod for an empty range, got %v", err)
+	}
+}
@@ -2,83 +2,330 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/hmac"
+	"encoding/base64"
 	"fmt"
-	"net/http"
+	"strings"
 	"time"
+	"unicode"
 
 	"expensify/internal/db"
 	"expensify/internal/models"
 
 	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-const sessionDuration = 30 * 24 * time.Hour
+const (
+	// lastSeenInterval limits how often a session's last_seen_at is written.
+	lastSeenInterval = 5 * time.Minute
+	// pendingLoginTTL is how long a user has to answer an existing-account prompt.
+	pendingLoginTTL   = 10 * time.Minute
+	pendingLoginToken = "pending-login"
 
-// GoogleUserInfo holds the profile data returned by Google's userinfo endpoint.
-type GoogleUserInfo struct {
-	ID      string `json:"id"`
-	Email   string `json:"email"`
-	Name    string `json:"name"`
-	Picture string `json:"picture"`
+	// DevProvider is the identity provider name of users created by DevLogin.
+	DevProvider          = "dev"
+	maxDevUserNameLength = 64
+)
+
+// SessionPolicy controls how long sessions live.
+//
+// A session is issued for TTL. Once RefreshAfter (a fraction of TTL, 0–1) has
+// elapsed since it was last issued, the next request slides ExpiresAt to a full
+// TTL again, so active users stay logged in. No session outlives MaxLifetime
+// from its creation, however active it is.
+type SessionPolicy struct {
+	TTL          time.Duration
+	RefreshAfter float64
+	MaxLifetime  time.Duration
+}
+
+// DefaultSessionPolicy returns 30-day sessions renewed after half their lifetime, capped at 90 days.
+func DefaultSessionPolicy() SessionPolicy {
+	return SessionPolicy{TTL: 30 * 24 * time.Hour, RefreshAfter: 0.5, MaxLifetime: 90 * 24 * time.Hour}
+}
+
+// expiryFrom returns the expiry for a session issued at now, capped at the absolute lifetime.
+func (p SessionPolicy) expiryFrom(createdAt, now time.Time) time.Time {
+	expires := now.Add(p.TTL)
+	if limit := createdAt.Add(p.MaxLifetime); p.MaxLifetime > 0 && expires.After(limit) {
+		return limit
+	}
+	return expires
+}
+
+// dueForRenewal reports whether the session has used up RefreshAfter of its current lifetime.
+func (p SessionPolicy) dueForRenewal(session *models.Session, now time.Time) bool {
+	issuedAt := session.ExpiresAt.Add(-p.TTL)
+	return now.Sub(issuedAt) >= time.Duration(p.RefreshAfter*float64(p.TTL))
+}
+
+// SessionAuth is the outcome of authenticating a session cookie. Renewed is set when
+// the session's expiry moved and the cookie should be reissued with the new lifetime.
+type SessionAuth struct {
+	User    *models.User
+	Session *models.Session
+	Renewed bool
+}
+
+// SessionMeta describes the client a session was created from.
+type SessionMeta struct {
+	UserAgent string
+	IP        string
+}
+
+// SessionResponse is the client view of a session; Current marks the caller's own session.
+type SessionResponse struct {
+	*models.Session
+	Current bool `json:"current"`
 }
 
-// AuthService handles Google OAuth and session management.
+// LoginResult is the outcome of an external login. Exactly one of Session and
+// PendingToken is set: PendingToken means the identity is new but the provider verified
+// an email that another account's identity also verified. The user either signs in to
+// that account and links the new login from there, or keeps a separate account
+// (ResolvePendingLogin). A login is never attached to an account on email alone.
+type LoginResult struct {
+	Session      *models.Session
+	PendingToken string
+	// ExistingEmail is the matching account's email, for the prompt.
+	ExistingEmail string
+}
+
+// AuthService handles external logins and session management.
 type AuthService interface {
-	GetGoogleUserInfo(ctx context.Context, accessToken string) (*GoogleUserInfo, error)
-	HandleCallback(ctx context.Context, info *GoogleUserInfo) (*models.Session, error)
+	// HandleCallback finds or creates the user owning identity and starts a session for them,
+	// unless the user must first be told an account with their email already exists.
+	HandleCallback(ctx context.Context, identity *ExternalIdentity, meta SessionMeta) (*LoginResult, error)
+	// ResolvePendingLogin completes a held-back login by creating a separate user for it.
+	ResolvePendingLogin(ctx context.Context, pendingToken string, meta SessionMeta) (*models.Session, error)
+	// LinkIdentity attaches an additional provider identity to a logged-in user.
+	LinkIdentity(ctx context.Context, userID string, identity *ExternalIdentity) (*models.User, error)
+	// DevLogin signs in as a named local test user, creating it on first use. Callers must
+	// only expose it in development.
+	DevLogin(ctx context.Context, name string, meta SessionMeta) (*models.Session, error)
+	// UnlinkIdentity detaches a provider identity; a user's last identity cannot be removed.
+	UnlinkIdentity(ctx context.Context, userID string, provider, subject string) (*models.User, error)
 	GetCurrentUser(ctx context.Context, token string) (*models.User, error)
+	// AuthenticateSession validates the session token, records that it was just used
+	// (writes are throttled) and slides its expiry forward when due.
+	AuthenticateSession(ctx context.Context, token string) (*SessionAuth, error)
 	Logout(ctx context.Context, token string) error
+	// CSRFToken returns the anti-CSRF token bound to a session. It is stable for the
+	// session's lifetime, so the browser can fetch it once and send it with every mutation.
+	CSRFToken(sessionToken string) string
+	// ValidCSRFToken reports whether csrfToken was issued for the session.
+	ValidCSRFToken(sessionToken, csrfToken string) bool
+	// ListSessions returns the user's active sessions, marking the one identified by currentToken.
+	ListSessions(ctx context.Context, userID string, currentToken string) ([]*SessionResponse, error)
+	RevokeSession(ctx context.Context, userID string, sessionID string) error
+	// RevokeOtherSessions logs the user out everywhere except the session identified by currentToken.
+	RevokeOtherSessions(ctx context.Context, userID string, currentToken string) (int64, error)
+	// UpdateTimeZone validates and stores the user's IANA time zone.
+	UpdateTimeZone(ctx context.Context, userID string, timeZone string) (*models.User, error)
 }
 
 type authService struct {
 	userRepo    db.UserRepository
 	sessionRepo db.SessionRepository
+	policy      SessionPolicy
+	signingKey  []byte
 }
 
-// NewAuthService creates a new AuthService.
-func NewAuthService(userRepo db.UserRepository, sessionRepo db.SessionRepository) AuthService {
+// NewAuthService creates a new AuthService. signingKey authenticates the short-lived
+// tokens handed to the browser during account merging and the CSRF tokens of sessions.
+func NewAuthService(userRepo db.UserRepository, sessionRepo db.SessionRepository, policy SessionPolicy, signingKey []byte) AuthService {
 	return &authService{
 		userRepo:    userRepo,
 		sessionRepo: sessionRepo,
+		policy:      policy,
+		signingKey:  signingKey,
 	}
 }
 
-func (s *authService) GetGoogleUserInfo(ctx context.Context, accessToken string) (*GoogleUserInfo, error) {
-	resp, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + accessToken)
+// HandleCallback upserts the user and creates a new session recording the client's metadata.
+// A first login whose verified email matches another account's verified identity email is held
+// back; unverified and profile emails are never used to match accounts.
+func (s *authService) HandleCallback(ctx context.Context, identity *ExternalIdentity, meta SessionMeta) (*LoginResult, error) {
+	if identity.EmailVerified && identity.Email != "" {
+		owner, err := s.userRepo.FindByIdentity(ctx, identity.Provider, identity.Subject)
+		if err != nil {
+			return nil, fmt.Errorf("finding user: %w", err)
+		}
+		if owner == nil {
+			match, err := s.userRepo.FindByVerifiedEmail(ctx, identity.Email)
+			if err != nil {
+				return nil, fmt.Errorf("finding user by email: %w", err)
+			}
+			if match != nil {
+				token, err := signToken(s.signingKey, pendingLoginToken, identity, pendingLoginTTL)
+				if err != nil {
+					return nil, fmt.Errorf("signing pending login: %w", err)
+				}
+				return &LoginResult{PendingToken: token, ExistingEmail: identity.Email}, nil
+			}
+		}
+	}
+
+	saved, err := s.upsertUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	session, err := s.startSession(ctx, saved.ID, meta)
 	if err != nil {
-		return nil, fmt.Errorf("fetching google user info: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return &LoginResult{Session: session}, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("google userinfo returned status %d", resp.StatusCode)
+// ResolvePendingLogin only ever creates a separate user: the signed token proves the
+// identity, not control of the account with the same email, so joining that account goes
+// through LinkIdentity from a session on it. An identity that already has a user is
+// refused with ErrIdentityInUse rather than signed in, so a pending token can never stand
+// in for a provider login to an existing account.
+func (s *authService) ResolvePendingLogin(ctx context.Context, pendingToken string, meta SessionMeta) (*models.Session, error) {
+	var identity ExternalIdentity
+	if err := verifyToken(s.signingKey, pendingLoginToken, pendingToken, &identity); err != nil {
+		return nil, err
 	}
+	created, err := s.userRepo.Create(ctx, storedIdentity(&identity), &models.User{
+		Email:   identity.Email,
+		Name:    identity.Name,
+		Picture: identity.Picture,
+	})
+	if err == db.ErrDuplicate {
+		return nil, ErrIdentityInUse
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating user: %w", err)
+	}
+	return s.startSession(ctx, created.ID, meta)
+}
 
-	var info GoogleUserInfo
-	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
-		return nil, fmt.Errorf("decoding google user info: %w", err)
+func (s *authService) DevLogin(ctx context.Context, name string, meta SessionMeta) (*models.Session, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxDevUserNameLength {
+		return nil, ErrInvalidName
 	}
-	return &info, nil
+	slug := devUserSlug(name)
+	if slug == "" {
+		return nil, ErrInvalidName
+	}
+
+	// The email is unverified, so a dev user never matches a real account.
+	saved, err := s.upsertUser(ctx, &ExternalIdentity{
+		Provider: DevProvider,
+		Subject:  slug,
+		Email:    slug + "@dev.localhost",
+		Name:     name,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.startSession(ctx, saved.ID, meta)
 }
 
-// HandleCallback upserts the user and creates a new session.
-func (s *authService) HandleCallback(ctx context.Context, info *GoogleUserInfo) (*models.Session, error) {
-	user := &models.User{
-		GoogleID: info.ID,
-		Email:    info.Email,
-		Name:     info.Name,
-		Picture:  info.Picture,
+// devUserSlug lower-cases name and joins its letters and digits with dashes, so
+// "Alice Smith" and "alice smith" are the same test user.
+func devUserSlug(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
+
+func (s *authService) LinkIdentity(ctx context.Context, userID string, identity *ExternalIdentity) (*models.User, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return s.attachIdentity(ctx, uid, identity)
+}
+
+func (s *authService) UnlinkIdentity(ctx context.Context, userID string, provider, subject string) (*models.User, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	user, err := s.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("finding user: %w", err)
+	}
+	if user == nil || !hasIdentity(user, provider, subject) {
+		return nil, ErrNotFound
+	}
+	if len(user.Identities) == 1 {
+		return nil, ErrLastIdentity
+	}
+
+	updated, err := s.userRepo.RemoveIdentity(ctx, uid, provider, subject)
+	if err != nil {
+		if err == db.ErrNotFound {
+			// Lost a race with another unlink: the identity is now the last one, or gone.
+			return nil, ErrLastIdentity
+		}
+		return nil, fmt.Errorf("unlinking identity: %w", err)
 	}
-	saved, err := s.userRepo.Upsert(ctx, user)
+	return updated, nil
+}
+
+// attachIdentity links identity to the user, succeeding without change if it is already
+// theirs and failing with ErrIdentityInUse if another user holds it.
+func (s *authService) attachIdentity(ctx context.Context, uid primitive.ObjectID, identity *ExternalIdentity) (*models.User, error) {
+	owner, err := s.userRepo.FindByIdentity(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("finding user: %w", err)
+	}
+	if owner != nil {
+		if owner.ID == uid {
+			return owner, nil
+		}
+		return nil, ErrIdentityInUse
+	}
+
+	user, err := s.userRepo.AddIdentity(ctx, uid, storedIdentity(identity))
+	if err != nil {
+		switch err {
+		case db.ErrDuplicate:
+			return nil, ErrIdentityInUse
+		case db.ErrNotFound:
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("linking identity: %w", err)
+	}
+	return user, nil
+}
+
+func (s *authService) upsertUser(ctx context.Context, identity *ExternalIdentity) (*models.User, error) {
+	profile := &models.User{
+		Email:   identity.Email,
+		Name:    identity.Name,
+		Picture: identity.Picture,
+	}
+	saved, err := s.userRepo.Upsert(ctx, storedIdentity(identity), profile)
 	if err != nil {
 		return nil, fmt.Errorf("upserting user: %w", err)
 	}
+	return saved, nil
+}
+
+func storedIdentity(identity *ExternalIdentity) models.Identity {
+	return models.Identity{
+		Provider:      identity.Provider,
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+	}
+}
 
+func (s *authService) startSession(ctx context.Context, userID primitive.ObjectID, meta SessionMeta) (*models.Session, error) {
+	now := time.Now()
 	session := &models.Session{
-		UserID:    saved.ID,
+		UserID:    userID,
 		Token:     uuid.New().String(),
-		ExpiresAt: time.Now().Add(sessionDuration),
+		UserAgent: meta.UserAgent,
+		IP:        meta.IP,
+		ExpiresAt: s.policy.expiryFrom(now, now),
 	}
 	created, err := s.sessionRepo.Create(ctx, session)
 	if err != nil {
@@ -89,25 +336,65 @@ func (s *authService) HandleCallback(ctx context.Context, info *GoogleUserInfo)
 
 // GetCurrentUser validates the session token and returns the associated user.
 func (s *authService) GetCurrentUser(ctx context.Context, token string) (*models.User, error) {
+	user, _, err := s.validateSession(ctx, token, time.Now())
+	return user, err
+}
+
+func (s *authService) AuthenticateSession(ctx context.Context, token string) (*SessionAuth, error) {
+	now := time.Now()
+	user, session, err := s.validateSession(ctx, token, now)
+	if err != nil {
+		return nil, err
+	}
+	auth := &SessionAuth{User: user, Session: session}
+
+	if s.policy.dueForRenewal(session, now) {
+		expires := s.policy.expiryFrom(session.CreatedAt, now)
+		if expires.After(session.ExpiresAt) {
+			if err := s.sessionRepo.Renew(ctx, token, expires, now); err != nil {
+				if err == db.ErrNotFound {
+					// Logged out or revoked since the lookup.
+					return nil, ErrNotFound
+				}
+				return nil, fmt.Errorf("renewing session: %w", err)
+			}
+			session.ExpiresAt = expires
+			session.LastSeenAt = now
+			auth.Renewed = true
+			return auth, nil
+		}
+	}
+
+	// Last-seen tracking is best-effort and must not fail the request.
+	_ = s.sessionRepo.TouchLastSeen(ctx, token, now, now.Add(-lastSeenInterval))
+	return auth, nil
+}
+
+// validateSession looks up the session and its user, rejecting sessions past their
+// expiry or their absolute lifetime.
+func (s *authService) validateSession(ctx context.Context, token string, now time.Time) (*models.User, *models.Session, error) {
 	session, err := s.sessionRepo.FindByToken(ctx, token)
 	if err != nil {
-		return nil, fmt.Errorf("finding session: %w", err)
+		return nil, nil, fmt.Errorf("finding session: %w", err)
 	}
 	if session == nil {
-		return nil, ErrNotFound
+		return nil, nil, ErrNotFound
 	}
-	if time.Now().After(session.ExpiresAt) {
-		return nil, ErrSessionExpired
+	if now.After(session.ExpiresAt) {
+		return nil, nil, ErrSessionExpired
+	}
+	if s.policy.MaxLifetime > 0 && now.After(session.CreatedAt.Add(s.policy.MaxLifetime)) {
+		return nil, nil, ErrSessionExpired
 	}
 
 	user, err := s.userRepo.FindByID(ctx, session.UserID)
 	if err != nil {
-		return nil, fmt.Errorf("finding user: %w", err)
+		return nil, nil, fmt.Errorf("finding user: %w", err)
 	}
 	if user == nil {
-		return nil, ErrNotFound
+		return nil, nil, ErrNotFound
 	}
-	return user, nil
+	return user, session, nil
 }
 
 func (s *authService) Logout(ctx context.Context, token string) error {
@@ -116,3 +403,107 @@ func (s *authService) Logout(ctx context.Context, token string) error {
 	}
 	return nil
 }
+
+func (s *authService) CSRFToken(sessionToken string) string {
+	return base64.RawURLEncoding.EncodeToString(tokenMAC(s.signingKey, "csrf:"+models.HashToken(sessionToken)))
+}
+
+func (s *authService) ValidCSRFToken(sessionToken, csrfToken string) bool {
+	if sessionToken == "" || csrfToken == "" {
+		return false
+	}
+	return hmac.Equal([]byte(csrfToken), []byte(s.CSRFToken(sessionToken)))
+}
+
+func (s *authService) ListSessions(ctx context.Context, userID string, currentToken string) ([]*SessionResponse, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	sessions, err := s.sessionRepo.FindByUserID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching sessions: %w", err)
+	}
+	currentHash := models.HashToken(currentToken)
+	responses := make([]*SessionResponse, len(sessions))
+	for i, sess := range sessions {
+		responses[i] = &SessionResponse{Session: sess, Current: sess.TokenHash == currentHash}
+	}
+	return responses, nil
+}
+
+func (s *authService) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	sid, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	// The repo enforces ownership: it only deletes when user_id matches.
+	if err := s.sessionRepo.DeleteByID(ctx, sid, uid); err != nil {
+		if err == db.ErrNotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("revoking session: %w", err)
+	}
+	return nil
+}
+
+func (s *authService) RevokeOtherSessions(ctx context.Context, userID string, currentToken string) (int64, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return 0, ErrInvalidID
+	}
+	n, err := s.sessionRepo.DeleteOthers(ctx, uid, currentToken)
+	if err != nil {
+		return 0, fmt.Errorf("revoking other sessions: %w", err)
+	}
+	return n, nil
+}
+
+func (s *authService) UpdateTimeZone(ctx context.Context, userID string, timeZone string) (*models.User, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if _, err := LoadUserLocation(timeZone); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.UpdateTimeZone(ctx, uid, timeZone)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("updating time zone: %w", err)
+	}
+	return user, nil
+}
+
+func hasIdentity(user *models.User, provider, subject string) bool {
+	for _, id := range user.Identities {
+		if id.Provider == provider && id.Subject == subject {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadUserLocation resolves a stored user time zone. An empty name means UTC;
+// "Local" is rejected because it depends on the server's configuration.
+func LoadUserLocation(timeZone string) (*time.Location, error) {
+	if timeZone == "" {
+		return time.UTC, nil
+	}
+	if timeZone == "Local" {
+		return nil, ErrInvalidTimeZone
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, ErrInvalidTimeZone
+	}
+	return loc, nil
+}
@@ -2,9 +2,11 @@ package services_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"expensify/internal/db"
 	"expensify/internal/models"
 	"expensify/internal/services"
 	"expensify/internal/testutil"
@@ -13,13 +15,15 @@ import (
 )
 
 func newAuthSvc(userRepo *testutil.MockUserRepo, sessionRepo *testutil.MockSessionRepo) services.AuthService {
-	return services.NewAuthService(userRepo, sessionRepo)
+	return services.NewAuthService(userRepo, sessionRepo, services.DefaultSessionPolicy(), []byte("test-secret"))
 }
 
 func TestAuthService_HandleCallback_NewUser(t *testing.T) {
 	userID := primitive.NewObjectID()
+	var upserted models.Identity
 	userRepo := &testutil.MockUserRepo{
-		UpsertFn: func(_ context.Context, u *models.User) (*models.User, error) {
+		UpsertFn: func(_ context.Context, identity models.Identity, u *models.User) (*models.User, error) {
+			upserted = identity
 			u.ID = userID
 			return u, nil
 		},
@@ -32,17 +36,20 @@ func TestAuthService_HandleCallback_NewUser(t *testing.T) {
 	}
 
 	svc := newAuthSvc(userRepo, sessionRepo)
-	info := &services.GoogleUserInfo{
-		ID:      "google-1",
-		Email:   "alice@example.com",
-		Name:    "Alice",
-		Picture: "https://example.com/pic.jpg",
+	info := &services.ExternalIdentity{
+		Provider: "keycloak",
+		Subject:  "f3a1-42",
+		Email:    "alice@example.com",
+		Name:     "Alice",
+		Picture:  "https://example.com/pic.jpg",
 	}
 
-	session, err := svc.HandleCallback(context.Background(), info)
+	meta := services.SessionMeta{UserAgent: "Mozilla/5.0", IP: "203.0.113.7"}
+	result, err := svc.HandleCallback(context.Background(), info, meta)
 	if err != nil {
 		t.Fatalf("HandleCallback: %v", err)
 	}
+	session := result.Session
 	if session == nil {
 		t.Fatal("expected a session, got nil")
 	}
@@ -52,6 +59,12 @@ func TestAuthService_HandleCallback_NewUser(t *testing.T) {
 	if session.UserID != userID {
 		t.Errorf("session.UserID: got %v, want %v", session.UserID, userID)
 	}
+	if session.UserAgent != meta.UserAgent || session.IP != meta.IP {
+		t.Errorf("session metadata not recorded: got %q / %q", session.UserAgent, session.IP)
+	}
+	if upserted.Provider != "keycloak" || upserted.Subject != "f3a1-42" {
+		t.Errorf("user should be keyed by provider and subject, got %+v", upserted)
+	}
 }
 
 func TestAuthService_HandleCallback_ExistingUser(t *testing.T) {
@@ -59,7 +72,7 @@ func TestAuthService_HandleCallback_ExistingUser(t *testing.T) {
 	callCount := 0
 
 	userRepo := &testutil.MockUserRepo{
-		UpsertFn: func(_ context.Context, u *models.User) (*models.User, error) {
+		UpsertFn: func(_ context.Context, _ models.Identity, u *models.User) (*models.User, error) {
 			callCount++
 			u.ID = existingID
 			return u, nil
@@ -73,14 +86,15 @@ func TestAuthService_HandleCallback_ExistingUser(t *testing.T) {
 	}
 
 	svc := newAuthSvc(userRepo, sessionRepo)
-	info := &services.GoogleUserInfo{ID: "google-2", Email: "bob@example.com", Name: "Bob"}
+	info := &services.ExternalIdentity{Provider: "google", Subject: "google-2", Email: "bob@example.com", Name: "Bob"}
 
 	// Call twice to simulate returning user.
-	svc.HandleCallback(context.Background(), info)
-	session, err := svc.HandleCallback(context.Background(), info)
+	svc.HandleCallback(context.Background(), info, services.SessionMeta{})
+	result, err := svc.HandleCallback(context.Background(), info, services.SessionMeta{})
 	if err != nil {
 		t.Fatalf("second HandleCallback: %v", err)
 	}
+	session := result.Session
 	if session.UserID != existingID {
 		t.Errorf("session.UserID mismatch: got %v, want %v", session.UserID, existingID)
 	}
@@ -97,8 +111,8 @@ func TestAuthService_GetCurrentUser_Valid(t *testing.T) {
 		FindByTokenFn: func(_ context.Context, token string) (*models.Session, error) {
 			return &models.Session{
 				UserID:    userID,
-				Token:     token,
 				ExpiresAt: time.Now().Add(time.Hour),
+				CreatedAt: time.Now().Add(-time.Hour),
 			}, nil
 		},
 	}
@@ -125,8 +139,8 @@ func TestAuthService_GetCurrentUser_ExpiredSession(t *testing.T) {
 	sessionRepo := &testutil.MockSessionRepo{
 		FindByTokenFn: func(_ context.Context, token string) (*models.Session, error) {
 			return &models.Session{
-				Token:     token,
 				ExpiresAt: time.Now().Add(-time.Hour), // already expired
+				CreatedAt: time.Now().Add(-48 * time.Hour),
 			}, nil
 		},
 	}
@@ -171,3 +185,446 @@ func TestAuthService_Logout(t *testing.T) {
 		t.Error("expected session to be deleted")
 	}
 }
+
+func TestAuthService_UpdateTimeZone(t *testing.T) {
+	userID := primitive.NewObjectID()
+	var stored string
+	userRepo := &testutil.MockUserRepo{
+		UpdateTimeZoneFn: func(_ context.Context, id primitive.ObjectID, tz string) (*models.User, error) {
+			stored = tz
+			return &models.User{ID: id, TimeZone: tz}, nil
+		},
+	}
+
+	svc := newAuthSvc(userRepo, &testutil.MockSessionRepo{})
+	user, err := svc.UpdateTimeZone(context.Background(), userID.Hex(), "America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("UpdateTimeZone: %v", err)
+	}
+	if stored != "America/Los_Angeles" || user.TimeZone != "America/Los_Angeles" {
+		t.Errorf("time zone not stored: repo got %q, user has %q", stored, user.TimeZone)
+	}
+}
+
+func TestAuthService_UpdateTimeZone_Invalid(t *testing.T) {
+	svc := newAuthSvc(&testutil.MockUserRepo{}, &testutil.MockSessionRepo{})
+
+	for _, tz := range []string{"Mars/Olympus_Mons", "Local"} {
+		if _, err := svc.UpdateTimeZone(context.Background(), primitive.NewObjectID().Hex(), tz); err != services.ErrInvalidTimeZone {
+			t.Errorf("%q: expected ErrInvalidTimeZone, got %v", tz, err)
+		}
+	}
+	if _, err := svc.UpdateTimeZone(context.Background(), "bad", "UTC"); err != services.ErrInvalidID {
+		t.Errorf("expected ErrInvalidID, got %v", err)
+	}
+}
+
+func TestAuthService_ListSessions_MarksCurrent(t *testing.T) {
+	userID := primitive.NewObjectID()
+	sessionRepo := &testutil.MockSessionRepo{
+		FindByUserIDFn: func(_ context.Context, uid primitive.ObjectID) ([]*models.Session, error) {
+			return []*models.Session{
+				{ID: primitive.NewObjectID(), UserID: uid, TokenHash: models.HashToken("laptop")},
+				{ID: primitive.NewObjectID(), UserID: uid, TokenHash: models.HashToken("library-pc")},
+			}, nil
+		},
+	}
+
+	svc := newAuthSvc(&testutil.MockUserRepo{}, sessionRepo)
+	sessions, err := svc.ListSessions(context.Background(), userID.Hex(), "laptop")
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 2 || !sessions[0].Current || sessions[1].Current {
+		t.Errorf("expected only the first session to be current, got %+v, %+v", sessions[0], sessions[1])
+	}
+}
+
+func TestAuthService_RevokeSession(t *testing.T) {
+	userID := primitive.NewObjectID()
+	sessionID := primitive.NewObjectID()
+	sessionRepo := &testutil.MockSessionRepo{
+		DeleteByIDFn: func(_ context.Context, id, uid primitive.ObjectID) error {
+			if id == sessionID && uid == userID {
+				return nil
+			}
+			return db.ErrNotFound
+		},
+	}
+
+	svc := newAuthSvc(&testutil.MockUserRepo{}, sessionRepo)
+	if err := svc.RevokeSession(context.Background(), userID.Hex(), sessionID.Hex()); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	err := svc.RevokeSession(context.Background(), primitive.NewObjectID().Hex(), sessionID.Hex())
+	if err != services.ErrNotFound {
+		t.Errorf("expected ErrNotFound for another user's session, got %v", err)
+	}
+}
+
+func TestAuthService_RevokeOtherSessions_KeepsCurrent(t *testing.T) {
+	userID := primitive.NewObjectID()
+	var kept string
+	sessionRepo := &testutil.MockSessionRepo{
+		DeleteOthersFn: func(_ context.Context, _ primitive.ObjectID, keepToken string) (int64, error) {
+			kept = keepToken
+			return 3, nil
+		},
+	}
+
+	svc := newAuthSvc(&testutil.MockUserRepo{}, sessionRepo)
+	n, err := svc.RevokeOtherSessions(context.Background(), userID.Hex(), "current-token")
+	if err != nil {
+		t.Fatalf("RevokeOtherSessions: %v", err)
+	}
+	if n != 3 || kept != "current-token" {
+		t.Errorf("got n=%d kept=%q, want 3 and current-token", n, kept)
+	}
+}
+
+func TestAuthService_AuthenticateSession_TouchesWhenFresh(t *testing.T) {
+	policy := services.DefaultSessionPolicy()
+	userID := primitive.NewObjectID()
+	var touched, renewed bool
+	sessionRepo := &testutil.MockSessionRepo{
+		FindByTokenFn: func(_ context.Context, _ string) (*models.Session, error) {
+			// Issued an hour ago: well inside the refresh fraction.
+			return &models.Session{
+				UserID:    userID,
+				ExpiresAt: time.Now().Add(policy.TTL - time.Hour),
+				CreatedAt: time.Now().Add(-time.Hour),
+			}, nil
+		},
+		TouchLastSeenFn: func(_ context.Context, _ string, at, staleBefore time.Time) error {
+			touched = staleBefore.Before(at)
+			return nil
+		},
+		RenewFn: func(_ context.Context, _ string, _, _ time.Time) error {
+			renewed = true
+			return nil
+		},
+	}
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(_ context.Context, _ primitive.ObjectID) (*models.User, error) {
+			return &models.User{ID: userID}, nil
+		},
+	}
+
+	svc := services.NewAuthService(userRepo, sessionRepo, policy, []byte("test-secret"))
+	auth, err := svc.AuthenticateSession(context.Background(), "tok")
+	if err != nil {
+		t.Fatalf("AuthenticateSession: %v", err)
+	}
+	if auth.Renewed || renewed {
+		t.Error("a fresh session should not be renewed")
+	}
+	if !touched {
+		t.Error("expected a throttled last-seen touch")
+	}
+}
+
+func TestAuthService_AuthenticateSession_SlidesExpiry(t *testing.T) {
+	policy := services.SessionPolicy{TTL: 10 * 24 * time.Hour, RefreshAfter: 0.5, MaxLifetime: 90 * 24 * time.Hour}
+	userID := primitive.NewObjectID()
+	var newExpiry time.Time
+	sessionRepo := &testutil.MockSessionRepo{
+		FindByTokenFn: func(_ context.Context, _ string) (*models.Session, error) {
+			// Six of ten days used.
+			return &models.Session{
+				UserID:    userID,
+				ExpiresAt: time.Now().Add(4 * 24 * time.Hour),
+				CreatedAt: time.Now().Add(-6 * 24 * time.Hour),
+			}, nil
+		},
+		RenewFn: func(_ context.Context, _ string, expiresAt, _ time.Time) error {
+			newExpiry = expiresAt
+			return nil
+		},
+	}
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(_ context.Context, _ primitive.ObjectID) (*models.User, error) {
+			return &models.User{ID: userID}, nil
+		},
+	}
+
+	svc := services.NewAuthService(userRepo, sessionRepo, policy, []byte("test-secret"))
+	auth, err := svc.AuthenticateSession(context.Background(), "tok")
+	if err != nil {
+		t.Fatalf("AuthenticateSession: %v", err)
+	}
+	if !auth.Renewed {
+		t.Fatal("expected the session to be renewed")
+	}
+	if d := time.Until(newExpiry); d < policy.TTL-time.Minute || d > policy.TTL {
+		t.Errorf("renewed expiry should be a full TTL away, got %v", d)
+	}
+	if !auth.Session.ExpiresAt.Equal(newExpiry) {
+		t.Errorf("returned session expiry %v, want %v", auth.Session.ExpiresAt, newExpiry)
+	}
+}
+
+func TestAuthService_AuthenticateSession_RevokedDuringRenewal(t *testing.T) {
+	policy := services.SessionPolicy{TTL: 10 * 24 * time.Hour, RefreshAfter: 0.5}
+	userID := primitive.NewObjectID()
+	sessionRepo := &testutil.MockSessionRepo{
+		FindByTokenFn: func(_ context.Context, _ string) (*models.Session, error) {
+			return &models.Session{UserID: userID, ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now().Add(-9 * 24 * time.Hour)}, nil
+		},
+		// The session was deleted between the lookup and the renewal.
+		RenewFn: func(context.Context, string, time.Time, time.Time) error {
+			return db.ErrNotFound
+		},
+	}
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(_ context.Context, _ primitive.ObjectID) (*models.User, error) {
+			return &models.User{ID: userID}, nil
+		},
+	}
+
+	svc := services.NewAuthService(userRepo, sessionRepo, policy, []byte("test-secret"))
+	if _, err := svc.AuthenticateSession(context.Background(), "tok"); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestAuthService_AuthenticateSession_CappedAtMaxLifetime(t *testing.T) {
+	policy := services.SessionPolicy{TTL: 10 * 24 * time.Hour, RefreshAfter: 0.5, MaxLifetime: 30 * 24 * time.Hour}
+	userID := primitive.NewObjectID()
+	createdAt := time.Now().Add(-27 * 24 * time.Hour)
+	var newExpiry time.Time
+	sessionRepo := &testutil.MockSessionRepo{
+		FindByTokenFn: func(_ context.Context, _ string) (*models.Session, error) {
+			return &models.Session{UserID: userID, ExpiresAt: time.Now().Add(time.Hour), CreatedAt: createdAt}, nil
+		},
+		RenewFn: func(_ context.Context, _ string, expiresAt, _ time.Time) error {
+			newExpiry = expiresAt
+			return nil
+		},
+	}
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(_ context.Context, _ primitive.ObjectID) (*models.User, error) {
+			return &models.User{ID: userID}, nil
+		},
+	}
+
+	svc := services.NewAuthService(userRepo, sessionRepo, policy, []byte("test-secret"))
+	if _, err := svc.AuthenticateSession(context.Background(), "tok"); err != nil {
+		t.Fatalf("AuthenticateSession: %v", err)
+	}
+	if want := createdAt.Add(policy.MaxLifetime); !newExpiry.Equal(want) {
+		t.Errorf("expiry: got %v, want capped at %v", newExpiry, want)
+	}
+}
+
+func TestAuthService_AuthenticateSession_PastMaxLifetime(t *testing.T) {
+	policy := services.SessionPolicy{TTL: 10 * 24 * time.Hour, RefreshAfter: 0.5, MaxLifetime: 30 * 24 * time.Hour}
+	sessionRepo := &testutil.MockSessionRepo{
+		FindByTokenFn: func(_ context.Context, _ string) (*models.Session, error) {
+			// Expiry was written under a longer limit; the absolute cap still applies.
+			return &models.Session{ExpiresAt: time.Now().Add(time.Hour), CreatedAt: time.Now().Add(-31 * 24 * time.Hour)}, nil
+		},
+	}
+
+	svc := services.NewAuthService(&testutil.MockUserRepo{}, sessionRepo, policy, []byte("test-secret"))
+	if _, err := svc.AuthenticateSession(context.Background(), "tok"); err != services.ErrSessionExpired {
+		t.Errorf("expected ErrSessionExpired, got %v", err)
+	}
+}
+
+func newSessionRepoStub() *testutil.MockSessionRepo {
+	return &testutil.MockSessionRepo{
+		CreateFn: func(_ context.Context, s *models.Session) (*models.Session, error) {
+			s.ID = primitive.NewObjectID()
+			return s, nil
+		},
+	}
+}
+
+func TestAuthService_HandleCallback_HoldsBackLoginForVerifiedEmail(t *testing.T) {
+	existing := &models.User{ID: primitive.NewObjectID(), Email: "Dana@example.com"}
+	upserts := 0
+	var stored models.Identity
+	userRepo := &testutil.MockUserRepo{
+		FindByEmailFn: func(_ context.Context, _ string) (*models.User, error) {
+			t.Error("the mutable profile email must not be used to match accounts")
+			return nil, nil
+		},
+		FindByVerifiedEmailFn: func(_ context.Context, email string) (*models.User, error) {
+			return existing, nil
+		},
+		UpsertFn: func(_ context.Context, identity models.Identity, u *models.User) (*models.User, error) {
+			upserts++
+			stored = identity
+			u.ID = primitive.NewObjectID()
+			return u, nil
+		},
+	}
+
+	svc := newAuthSvc(userRepo, newSessionRepoStub())
+	info := &services.ExternalIdentity{Provider: "keycloak", Subject: "k-1", Email: "dana@example.com", EmailVerified: true}
+	result, err := svc.HandleCallback(context.Background(), info, services.SessionMeta{})
+	if err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	if result.Session != nil || result.PendingToken == "" {
+		t.Fatalf("expected a pending login instead of a session, got %+v", result)
+	}
+	if result.ExistingEmail != info.Email {
+		t.Errorf("ExistingEmail: got %q, want %q", result.ExistingEmail, info.Email)
+	}
+	if upserts != 0 {
+		t.Error("no user should be created while the login is pending")
+	}
+
+	userRepo.FindByVerifiedEmailFn = nil
+	if _, err := svc.HandleCallback(context.Background(), info, services.SessionMeta{}); err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	if !stored.EmailVerified || stored.Email != info.Email {
+		t.Errorf("the identity should be stored with its verified email, got %+v", stored)
+	}
+}
+
+func TestAuthService_HandleCallback_UnverifiedEmailNeverMatches(t *testing.T) {
+	lookup := func(_ context.Context, _ string) (*models.User, error) {
+		t.Error("an unverified email must not be used to look up accounts")
+		return nil, nil
+	}
+	userRepo := &testutil.MockUserRepo{
+		FindByEmailFn:         lookup,
+		FindByVerifiedEmailFn: lookup,
+		UpsertFn: func(_ context.Context, _ models.Identity, u *models.User) (*models.User, error) {
+			u.ID = primitive.NewObjectID()
+			return u, nil
+		},
+	}
+
+	svc := newAuthSvc(userRepo, newSessionRepoStub())
+	info := &services.ExternalIdentity{Provider: "keycloak", Subject: "k-2", Email: "dana@example.com"}
+	result, err := svc.HandleCallback(context.Background(), info, services.SessionMeta{})
+	if err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	if result.Session == nil {
+		t.Error("expected a new session for a separate account")
+	}
+}
+
+func TestAuthService_ResolvePendingLogin_CreatesSeparateAccount(t *testing.T) {
+	existing := &models.User{ID: primitive.NewObjectID(), Email: "dana@example.com"}
+	var created primitive.ObjectID
+	userRepo := &testutil.MockUserRepo{
+		FindByVerifiedEmailFn: func(_ context.Context, _ string) (*models.User, error) { return existing, nil },
+		AddIdentityFn: func(_ context.Context, _ primitive.ObjectID, _ models.Identity) (*models.User, error) {
+			t.Error("a pending login must never be attached to the existing account")
+			return nil, nil
+		},
+		CreateFn: func(_ context.Context, _ models.Identity, u *models.User) (*models.User, error) {
+			u.ID = primitive.NewObjectID()
+			created = u.ID
+			return u, nil
+		},
+		UpsertFn: func(_ context.Context, _ models.Identity, _ *models.User) (*models.User, error) {
+			t.Error("a pending login must never upsert onto an existing user")
+			return nil, nil
+		},
+	}
+	svc := newAuthSvc(userRepo, newSessionRepoStub())
+	info := &services.ExternalIdentity{Provider: "keycloak", Subject: "k-1", Email: "dana@example.com", EmailVerified: true}
+
+	pending, _ := svc.HandleCallback(context.Background(), info, services.SessionMeta{})
+	session, err := svc.ResolvePendingLogin(context.Background(), pending.PendingToken, services.SessionMeta{})
+	if err != nil {
+		t.Fatalf("ResolvePendingLogin: %v", err)
+	}
+	if created.IsZero() || session.UserID != created {
+		t.Errorf("expected a session for a new separate user, got session for %v", session.UserID)
+	}
+
+	tampered := "x" + pending.PendingToken[1:]
+	if pending.PendingToken[0] == 'x' {
+		tampered = "y" + pending.PendingToken[1:]
+	}
+	if _, err := svc.ResolvePendingLogin(context.Background(), tampered, services.SessionMeta{}); err != services.ErrInvalidSignedToken {
+		t.Errorf("expected ErrInvalidSignedToken for a tampered token, got %v", err)
+	}
+}
+
+func TestAuthService_ResolvePendingLogin_RejectsIdentityWithAccount(t *testing.T) {
+	userRepo := &testutil.MockUserRepo{
+		FindByVerifiedEmailFn: func(_ context.Context, _ string) (*models.User, error) {
+			return &models.User{ID: primitive.NewObjectID()}, nil
+		},
+		CreateFn: func(_ context.Context, _ models.Identity, _ *models.User) (*models.User, error) {
+			return nil, db.ErrDuplicate
+		},
+	}
+	svc := newAuthSvc(userRepo, newSessionRepoStub())
+	info := &services.ExternalIdentity{Provider: "keycloak", Subject: "k-1", Email: "dana@example.com", EmailVerified: true}
+
+	pending, _ := svc.HandleCallback(context.Background(), info, services.SessionMeta{})
+	if _, err := svc.ResolvePendingLogin(context.Background(), pending.PendingToken, services.SessionMeta{}); err != services.ErrIdentityInUse {
+		t.Errorf("expected ErrIdentityInUse for an identity that already has a user, got %v", err)
+	}
+}
+
+func TestAuthService_LinkIdentity_InUseByAnotherUser(t *testing.T) {
+	userID := primitive.NewObjectID()
+	userRepo := &testutil.MockUserRepo{
+		FindByIdentityFn: func(_ context.Context, _, _ string) (*models.User, error) {
+			return &models.User{ID: primitive.NewObjectID()}, nil
+		},
+	}
+
+	svc := newAuthSvc(userRepo, &testutil.MockSessionRepo{})
+	_, err := svc.LinkIdentity(context.Background(), userID.Hex(), &services.ExternalIdentity{Provider: "google", Subject: "g"})
+	if err != services.ErrIdentityInUse {
+		t.Errorf("expected ErrIdentityInUse, got %v", err)
+	}
+}
+
+func TestAuthService_UnlinkIdentity_KeepsLastIdentity(t *testing.T) {
+	userID := primitive.NewObjectID()
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(_ context.Context, _ primitive.ObjectID) (*models.User, error) {
+			return &models.User{ID: userID, Identities: []models.Identity{{Provider: "google", Subject: "g"}}}, nil
+		},
+		RemoveIdentityFn: func(_ context.Context, _ primitive.ObjectID, _, _ string) (*models.User, error) {
+			t.Error("RemoveIdentity should not be called for the last identity")
+			return nil, nil
+		},
+	}
+
+	svc := newAuthSvc(userRepo, &testutil.MockSessionRepo{})
+	if _, err := svc.UnlinkIdentity(context.Background(), userID.Hex(), "google", "g"); err != services.ErrLastIdentity {
+		t.Errorf("expected ErrLastIdentity, got %v", err)
+	}
+	if _, err := svc.UnlinkIdentity(context.Background(), userID.Hex(), "keycloak", "nope"); err != services.ErrNotFound {
+		t.Errorf("expected ErrNotFound for an identity the user does not have, got %v", err)
+	}
+}
+
+func TestAuthService_DevLogin_RejectsBlankName(t *testing.T) {
+	svc := newAuthSvc(&testutil.MockUserRepo{}, &testutil.MockSessionRepo{})
+
+	for _, name := range []string{"", "   ", "!!!"} {
+		if _, err := svc.DevLogin(context.Background(), name, services.SessionMeta{}); !errors.Is(err, services.ErrInvalidName) {
+			t.Errorf("DevLogin(%q): expected ErrInvalidName, got %v", name, err)
+		}
+	}
+}
+
+func TestAuthService_CSRFToken_BoundToSession(t *testing.T) {
+	svc := newAuthSvc(&testutil.MockUserRepo{}, &testutil.MockSessionRepo{})
+
+	token := svc.CSRFToken("session-a")
+	if !svc.ValidCSRFToken("session-a", token) {
+		t.Error("token should be valid for its own session")
+	}
+	if svc.ValidCSRFToken("session-b", token) {
+		t.Error("token must not be valid for another session")
+	}
+	if svc.ValidCSRFToken("session-a", "") {
+		t.Error("an empty token must not be valid")
+	}
+}
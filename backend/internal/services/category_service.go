@@ -19,28 +19,36 @@ type CreateCategoryRequest struct {
 	Color string `json:"color"`
 }
 
-// CategoryService manages spending categories.
+// CategoryService manages spending categories. Custom categories belong to a ledger
+// (the user's default ledger if ledgerID is empty); creating and deleting them needs
+// the editor role.
 type CategoryService interface {
-	// GetCategories returns all default categories plus any the user created.
-	GetCategories(ctx context.Context, userID string) ([]*models.Category, error)
-	CreateCategory(ctx context.Context, userID string, req CreateCategoryRequest) (*models.Category, error)
-	DeleteCategory(ctx context.Context, userID string, categoryID string) error
+	// GetCategories returns all default categories plus the ledger's custom ones.
+	GetCategories(ctx context.Context, userID, ledgerID string) ([]*models.Category, error)
+	CreateCategory(ctx context.Context, userID, ledgerID string, req CreateCategoryRequest) (*models.Category, error)
+	// DeleteCategory moves an unused custom category to the trash. It takes the versions the
+	// caller accepts, as for TransactionService.Update, and fails with ErrVersionMismatch if
+	// the category is at none of them.
+	DeleteCategory(ctx context.Context, userID, ledgerID string, categoryID string, versions []int64) error
 }
 
 type categoryService struct {
+	ledgerAccess
+	auditLog
 	repo   db.CategoryRepository
 	txRepo db.TransactionRepository
 }
 
-// NewCategoryService creates a new CategoryService.
-func NewCategoryService(repo db.CategoryRepository, txRepo db.TransactionRepository) CategoryService {
-	return &categoryService{repo: repo, txRepo: txRepo}
+// NewCategoryService creates a new CategoryService. Every change it makes is recorded in
+// the audit log.
+func NewCategoryService(repo db.CategoryRepository, txRepo db.TransactionRepository, ledgerRepo db.LedgerRepository, auditRepo db.AuditRepository) CategoryService {
+	return &categoryService{ledgerAccess: ledgerAccess{ledgerRepo: ledgerRepo}, auditLog: auditLog{auditRepo: auditRepo}, repo: repo, txRepo: txRepo}
 }
 
-func (s *categoryService) GetCategories(ctx context.Context, userID string) ([]*models.Category, error) {
-	uid, err := primitive.ObjectIDFromHex(userID)
+func (s *categoryService) GetCategories(ctx context.Context, userID, ledgerID string) ([]*models.Category, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
 	if err != nil {
-		return nil, ErrInvalidID
+		return nil, err
 	}
 
 	defaults, err := s.repo.FindDefaultCategories(ctx)
@@ -48,9 +56,9 @@ func (s *categoryService) GetCategories(ctx context.Context, userID string) ([]*
 		return nil, fmt.Errorf("fetching default categories: %w", err)
 	}
 
-	custom, err := s.repo.FindByUserID(ctx, uid)
+	custom, err := s.repo.FindByLedgerID(ctx, lid)
 	if err != nil {
-		return nil, fmt.Errorf("fetching user categories: %w", err)
+		return nil, fmt.Errorf("fetching ledger categories: %w", err)
 	}
 
 	all := append(defaults, custom...)
@@ -66,13 +74,14 @@ func (s *categoryService) GetCategories(ctx context.Context, userID string) ([]*
 	return all, nil
 }
 
-func (s *categoryService) CreateCategory(ctx context.Context, userID string, req CreateCategoryRequest) (*models.Category, error) {
-	uid, err := primitive.ObjectIDFromHex(userID)
+func (s *categoryService) CreateCategory(ctx context.Context, userID, ledgerID string, req CreateCategoryRequest) (*models.Category, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
 	if err != nil {
-		return nil, ErrInvalidID
+		return nil, err
 	}
 
 	cat := &models.Category{
+		LedgerID:  &lid,
 		UserID:    &uid,
 		Name:      req.Name,
 		Icon:      req.Icon,
@@ -85,21 +94,33 @@ func (s *categoryService) CreateCategory(ctx context.Context, userID string, req
 	if err != nil {
 		return nil, fmt.Errorf("creating category: %w", err)
 	}
+	s.record(ctx, lid, uid, models.AuditCreate, models.AuditEntityCategory, created.ID, nil, categoryFields(created))
 	return created, nil
 }
 
-func (s *categoryService) DeleteCategory(ctx context.Context, userID string, categoryID string) error {
-	uid, err := primitive.ObjectIDFromHex(userID)
+func (s *categoryService) DeleteCategory(ctx context.Context, userID, ledgerID string, categoryID string, versions []int64) error {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
 	if err != nil {
-		return ErrInvalidID
+		return err
 	}
 	catID, err := primitive.ObjectIDFromHex(categoryID)
 	if err != nil {
 		return ErrInvalidID
 	}
 
-	// Block deletion if the user has any transactions referencing this category.
-	hasTransactions, err := s.txRepo.ExistsByCategoryID(ctx, uid, catID)
+	before, err := s.repo.FindByID(ctx, lid, catID)
+	if err != nil {
+		return fmt.Errorf("fetching category: %w", err)
+	}
+	if before == nil || before.IsDefault {
+		return ErrNotFound
+	}
+	if err := checkVersion(before.Version, versions); err != nil {
+		return err
+	}
+
+	// Block deletion if the ledger has any transactions referencing this category.
+	hasTransactions, err := s.txRepo.ExistsByCategoryID(ctx, lid, catID)
 	if err != nil {
 		return fmt.Errorf("checking category transactions: %w", err)
 	}
@@ -107,12 +128,10 @@ func (s *categoryService) DeleteCategory(ctx context.Context, userID string, cat
 		return ErrCategoryInUse
 	}
 
-	// The repo enforces ownership: it only deletes when user_id matches.
-	if err := s.repo.Delete(ctx, catID, uid); err != nil {
-		if err == db.ErrNotFound {
-			return ErrNotFound
-		}
-		return fmt.Errorf("deleting category: %w", err)
+	// The repo enforces ownership: it only deletes when ledger_id matches.
+	if err := s.repo.Delete(ctx, catID, lid, before.Version); err != nil {
+		return versionedWriteError(err, "deleting category")
 	}
+	s.record(ctx, lid, uid, models.AuditDelete, models.AuditEntityCategory, catID, categoryFields(before), nil)
 	return nil
 }
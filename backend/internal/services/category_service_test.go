@@ -25,11 +25,11 @@ func TestCategoryService_GetCategories_CombinesDefaultAndCustom(t *testing.T) {
 
 	repo := &testutil.MockCategoryRepo{
 		FindDefaultCategoriesFn: func(_ context.Context) ([]*models.Category, error) { return defaults, nil },
-		FindByUserIDFn:          func(_ context.Context, _ primitive.ObjectID) ([]*models.Category, error) { return custom, nil },
+		FindByLedgerIDFn:        func(_ context.Context, _ primitive.ObjectID) ([]*models.Category, error) { return custom, nil },
 	}
 
-	svc := services.NewCategoryService(repo)
-	cats, err := svc.GetCategories(context.Background(), userID.Hex())
+	svc := services.NewCategoryService(repo, &testutil.MockTransactionRepo{}, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
+	cats, err := svc.GetCategories(context.Background(), userID.Hex(), "")
 	if err != nil {
 		t.Fatalf("GetCategories: %v", err)
 	}
@@ -39,8 +39,8 @@ func TestCategoryService_GetCategories_CombinesDefaultAndCustom(t *testing.T) {
 }
 
 func TestCategoryService_GetCategories_InvalidUserID(t *testing.T) {
-	svc := services.NewCategoryService(&testutil.MockCategoryRepo{})
-	_, err := svc.GetCategories(context.Background(), "not-an-object-id")
+	svc := services.NewCategoryService(&testutil.MockCategoryRepo{}, &testutil.MockTransactionRepo{}, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
+	_, err := svc.GetCategories(context.Background(), "not-an-object-id", "")
 	if err != services.ErrInvalidID {
 		t.Errorf("expected ErrInvalidID, got %v", err)
 	}
@@ -56,10 +56,10 @@ func TestCategoryService_CreateCategory(t *testing.T) {
 		},
 	}
 
-	svc := services.NewCategoryService(repo)
+	svc := services.NewCategoryService(repo, &testutil.MockTransactionRepo{}, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
 	req := services.CreateCategoryRequest{Name: "Gym", Icon: "🏋", Color: "#ff0000"}
 
-	created, err := svc.CreateCategory(context.Background(), userID.Hex(), req)
+	created, err := svc.CreateCategory(context.Background(), userID.Hex(), "", req)
 	if err != nil {
 		t.Fatalf("CreateCategory: %v", err)
 	}
@@ -72,6 +72,9 @@ func TestCategoryService_CreateCategory(t *testing.T) {
 	if created.UserID == nil || *created.UserID != userID {
 		t.Error("user_id should be set to the requesting user")
 	}
+	if created.LedgerID == nil || *created.LedgerID != testLedgerID {
+		t.Error("ledger_id should be set to the user's default ledger")
+	}
 }
 
 func TestCategoryService_DeleteCategory_Success(t *testing.T) {
@@ -80,8 +83,11 @@ func TestCategoryService_DeleteCategory_Success(t *testing.T) {
 	deleted := false
 
 	repo := &testutil.MockCategoryRepo{
-		DeleteFn: func(_ context.Context, id, uid primitive.ObjectID) error {
-			if id == catID && uid == userID {
+		FindByIDFn: func(_ context.Context, lid, id primitive.ObjectID) (*models.Category, error) {
+			return &models.Category{ID: id, LedgerID: &lid, Name: "Hobbies"}, nil
+		},
+		DeleteFn: func(_ context.Context, id, lid primitive.ObjectID, _ int64) error {
+			if id == catID && lid == testLedgerID {
 				deleted = true
 				return nil
 			}
@@ -89,8 +95,8 @@ func TestCategoryService_DeleteCategory_Success(t *testing.T) {
 		},
 	}
 
-	svc := services.NewCategoryService(repo)
-	if err := svc.DeleteCategory(context.Background(), userID.Hex(), catID.Hex()); err != nil {
+	svc := services.NewCategoryService(repo, &testutil.MockTransactionRepo{}, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
+	if err := svc.DeleteCategory(context.Background(), userID.Hex(), "", catID.Hex(), []int64{0}); err != nil {
 		t.Fatalf("DeleteCategory: %v", err)
 	}
 	if !deleted {
@@ -103,23 +109,23 @@ func TestCategoryService_DeleteCategory_NotOwned(t *testing.T) {
 	catID := primitive.NewObjectID()
 
 	repo := &testutil.MockCategoryRepo{
-		DeleteFn: func(_ context.Context, _, _ primitive.ObjectID) error { return db.ErrNotFound },
+		DeleteFn: func(_ context.Context, _, _ primitive.ObjectID, _ int64) error { return db.ErrNotFound },
 	}
 
-	svc := services.NewCategoryService(repo)
-	err := svc.DeleteCategory(context.Background(), userID.Hex(), catID.Hex())
+	svc := services.NewCategoryService(repo, &testutil.MockTransactionRepo{}, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
+	err := svc.DeleteCategory(context.Background(), userID.Hex(), "", catID.Hex(), []int64{0})
 	if err != services.ErrNotFound {
 		t.Errorf("expected ErrNotFound, got %v", err)
 	}
 }
 
 func TestCategoryService_DeleteCategory_InvalidIDs(t *testing.T) {
-	svc := services.NewCategoryService(&testutil.MockCategoryRepo{})
+	svc := services.NewCategoryService(&testutil.MockCategoryRepo{}, &testutil.MockTransactionRepo{}, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
 
-	if err := svc.DeleteCategory(context.Background(), "bad", primitive.NewObjectID().Hex()); err != services.ErrInvalidID {
+	if err := svc.DeleteCategory(context.Background(), "bad", "", primitive.NewObjectID().Hex(), []int64{0}); err != services.ErrInvalidID {
 		t.Errorf("expected ErrInvalidID for bad userID, got %v", err)
 	}
-	if err := svc.DeleteCategory(context.Background(), primitive.NewObjectID().Hex(), "bad"); err != services.ErrInvalidID {
+	if err := svc.DeleteCategory(context.Background(), primitive.NewObjectID().Hex(), "", "bad", []int64{0}); err != services.ErrInvalidID {
 		t.Errorf("expected ErrInvalidID for bad catID, got %v", err)
 	}
 }
@@ -13,4 +13,78 @@ var (
 	ErrSessionExpired = errors.New("session expired")
 	// ErrCategoryInUse is returned when a category cannot be deleted because transactions reference it.
 	ErrCategoryInUse = errors.New("category in use")
+	// ErrInvalidTimeZone is returned when a time zone is not a known IANA location name.
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+	// ErrInvalidPeriod is returned when a reporting period is empty or its start is not before its end.
+	ErrInvalidPeriod = errors.New("invalid period")
+	// ErrInvalidTransactionType is returned when a transaction type is neither "inflow" nor "outflow".
+	ErrInvalidTransactionType = errors.New("invalid transaction type")
+	// ErrInvalidPreferences is returned when a preference value is out of range or malformed.
+	ErrInvalidPreferences = errors.New("invalid preferences")
+	// ErrTokenExpired is returned when an API token has passed its expiry time.
+	ErrTokenExpired = errors.New("token expired")
+	// ErrInvalidTokenName is returned when an API token name is empty or too long.
+	ErrInvalidTokenName = errors.New("invalid token name")
+	// ErrInvalidScope is returned when an API token requests no scopes or an unknown scope.
+	ErrInvalidScope = errors.New("invalid scope")
+	// ErrInvalidExpiry is returned when an API token expiry is not in the future.
+	ErrInvalidExpiry = errors.New("invalid expiry")
+	// ErrInvalidIDToken is returned when an OpenID Connect ID token fails verification.
+	ErrInvalidIDToken = errors.New("invalid id token")
+	// ErrInvalidSignedToken is returned when a signed token is malformed, tampered with or expired.
+	ErrInvalidSignedToken = errors.New("invalid or expired token")
+	// ErrIdentityInUse is returned when linking a login identity that belongs to another user.
+	ErrIdentityInUse = errors.New("identity linked to another user")
+	// ErrInvalidName is returned when a name (of a dev login user or a ledger) is empty or too long.
+	ErrInvalidName = errors.New("invalid name")
+	// ErrLedgerNotFound is returned when a ledger does not exist or the caller is not a member.
+	ErrLedgerNotFound = errors.New("ledger not found")
+	// ErrInvalidRole is returned when a ledger role is not owner, approver, editor or viewer.
+	ErrInvalidRole = errors.New("invalid role")
+	// ErrAlreadyMember is returned when adding a user who already belongs to the ledger.
+	ErrAlreadyMember = errors.New("already a member")
+	// ErrLastOwner is returned when removing or demoting a ledger's only owner.
+	ErrLastOwner = errors.New("a ledger must keep at least one owner")
+	// ErrUnknownCategory is returned when a transaction references a category outside its ledger.
+	ErrUnknownCategory = errors.New("unknown category")
+	// ErrInvalidEmail is returned when an email address cannot be parsed.
+	ErrInvalidEmail = errors.New("invalid email address")
+	// ErrInvitationsDisabled is returned when inviting while no mailer is configured.
+	ErrInvitationsDisabled = errors.New("invitations are disabled")
+	// ErrInvitationUnavailable is returned when an invitation has been accepted, revoked or has expired.
+	ErrInvitationUnavailable = errors.New("invitation is no longer available")
+	// ErrInvitationEmailMismatch is returned when accepting an invitation sent to an address the user has no login for.
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to another email address")
+	// ErrInvalidSplit is returned when a group expense or settlement is malformed, e.g. its
+	// exact amounts do not add up or a participant is not a member of the ledger.
+	ErrInvalidSplit = errors.New("invalid split")
+	// ErrInvalidReport is returned when an expense report is malformed, e.g. it claims a
+	// transaction that is not the author's outflow or is already on another report.
+	ErrInvalidReport = errors.New("invalid expense report")
+	// ErrReportStatus is returned when an expense report is not in a status that allows the
+	// requested change.
+	ErrReportStatus = errors.New("expense report status does not allow this")
+	// ErrSelfApproval is returned when an approver acts on their own expense report.
+	ErrSelfApproval = errors.New("cannot approve your own expense report")
+	// ErrInvalidFilter is returned when a list filter names an unknown field or value.
+	ErrInvalidFilter = errors.New("invalid filter")
+	// ErrVersionMismatch is returned when a write names a version of a transaction or
+	// category other than its current one, i.e. the caller's copy is stale.
+	ErrVersionMismatch = errors.New("version does not match")
+	// ErrEditConflict is returned when a concurrent write changes a transaction or category
+	// while it is being written.
+	ErrEditConflict = errors.New("modified concurrently")
+	// ErrIdempotencyKeyReused is returned when an Idempotency-Key is sent again with a
+	// different request than the one it was first used for.
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+	// ErrIdempotencyInProgress is returned when a request arrives while the first request
+	// with the same Idempotency-Key is still being handled.
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is in progress")
+	// ErrInvalidTags is returned when transaction tags are too many or too long.
+	ErrInvalidTags = errors.New("invalid tags")
+	// ErrInvalidBulkOperation is returned when a bulk transaction request names an unknown
+	// operation, lacks its operand, or does not give exactly one of ids and filter.
+	ErrInvalidBulkOperation = errors.New("invalid bulk operation")
+	// ErrLastIdentity is returned when unlinking would leave a user with no way to log in.
+	ErrLastIdentity = errors.New("cannot remove the last login identity")
 )
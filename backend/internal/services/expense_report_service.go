@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxReportTitleLen = 200
+
+// reimbursementCategory is the default category of reimbursement inflows when the approver
+// names none.
+const reimbursementCategory = "Other"
+
+// ExpenseReportRequest holds the fields of a new or changed draft report.
+type ExpenseReportRequest struct {
+	Title          string   `json:"title"`
+	TransactionIDs []string `json:"transaction_ids"`
+}
+
+// ReportActionRequest is the optional note recorded with a transition.
+type ReportActionRequest struct {
+	Note string `json:"note"`
+}
+
+// ReimburseReportRequest records a reimbursement. CategoryID is the category of the inflow
+// transaction and defaults to Other.
+type ReimburseReportRequest struct {
+	CategoryID string `json:"category_id"`
+	Note       string `json:"note"`
+}
+
+// reportStep is one edge of the report state machine.
+type reportStep struct {
+	from, to string
+	need     string // role needed to take the step
+}
+
+var (
+	stepSubmit    = reportStep{from: models.ReportDraft, to: models.ReportSubmitted, need: models.RoleEditor}
+	stepApprove   = reportStep{from: models.ReportSubmitted, to: models.ReportApproved, need: models.RoleApprover}
+	stepReject    = reportStep{from: models.ReportSubmitted, to: models.ReportRejected, need: models.RoleApprover}
+	stepReimburse = reportStep{from: models.ReportApproved, to: models.ReportReimbursed, need: models.RoleApprover}
+)
+
+// ExpenseReportService files outflow transactions for reimbursement. A member drafts a
+// report of transactions they recorded and submits it; an approver (or owner) other than
+// its author approves or rejects it and, once paid, marks it reimbursed, which records the
+// matching inflow. Every transition is kept in the report's history. Reads need the viewer
+// role, drafting and submitting editor.
+type ExpenseReportService interface {
+	Create(ctx context.Context, userID, ledgerID string, req ExpenseReportRequest) (*models.ExpenseReport, error)
+	// List returns the ledger's reports, newest first, optionally only those in status.
+	List(ctx context.Context, userID, ledgerID, status string) ([]*models.ExpenseReport, error)
+	Get(ctx context.Context, userID, ledgerID, reportID string) (*models.ExpenseReport, error)
+	// Update and Delete are limited to the author's drafts.
+	Update(ctx context.Context, userID, ledgerID, reportID string, req ExpenseReportRequest) (*models.ExpenseReport, error)
+	Delete(ctx context.Context, userID, ledgerID, reportID string) error
+	Submit(ctx context.Context, userID, ledgerID, reportID string, req ReportActionRequest) (*models.ExpenseReport, error)
+	Approve(ctx context.Context, userID, ledgerID, reportID string, req ReportActionRequest) (*models.ExpenseReport, error)
+	Reject(ctx context.Context, userID, ledgerID, reportID string, req ReportActionRequest) (*models.ExpenseReport, error)
+	Reimburse(ctx context.Context, userID, ledgerID, reportID string, req ReimburseReportRequest) (*models.ExpenseReport, error)
+}
+
+type expenseReportService struct {
+	ledgerAccess
+	auditLog
+	reportRepo db.ExpenseReportRepository
+	txRepo     db.TransactionRepository
+	catRepo    db.CategoryRepository
+}
+
+// NewExpenseReportService creates a new ExpenseReportService.
+func NewExpenseReportService(reportRepo db.ExpenseReportRepository, txRepo db.TransactionRepository, catRepo db.CategoryRepository, ledgerRepo db.LedgerRepository, auditRepo db.AuditRepository) ExpenseReportService {
+	return &expenseReportService{ledgerAccess: ledgerAccess{ledgerRepo: ledgerRepo}, auditLog: auditLog{auditRepo: auditRepo}, reportRepo: reportRepo, txRepo: txRepo, catRepo: catRepo}
+}
+
+func (s *expenseReportService) Create(ctx context.Context, userID, ledgerID string, req ExpenseReportRequest) (*models.ExpenseReport, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	report := &models.ExpenseReport{LedgerID: lid, UserID: uid, Status: models.ReportDraft}
+	if err := s.fill(ctx, report, req); err != nil {
+		return nil, err
+	}
+	report.History = []models.ReportTransition{{To: models.ReportDraft, By: uid, At: time.Now()}}
+
+	created, err := s.reportRepo.Create(ctx, report)
+	if err != nil {
+		return nil, fmt.Errorf("creating expense report: %w", err)
+	}
+	return created, nil
+}
+
+func (s *expenseReportService) List(ctx context.Context, userID, ledgerID, status string) ([]*models.ExpenseReport, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", models.ReportDraft, models.ReportSubmitted, models.ReportApproved, models.ReportRejected, models.ReportReimbursed:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidReport, status)
+	}
+	reports, err := s.reportRepo.FindByLedgerID(ctx, lid, status)
+	if err != nil {
+		return nil, fmt.Errorf("fetching expense reports: %w", err)
+	}
+	return reports, nil
+}
+
+func (s *expenseReportService) Get(ctx context.Context, userID, ledgerID, reportID string) (*models.ExpenseReport, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	return s.find(ctx, lid, reportID)
+}
+
+func (s *expenseReportService) Update(ctx context.Context, userID, ledgerID, reportID string, req ExpenseReportRequest) (*models.ExpenseReport, error) {
+	report, err := s.authorDraft(ctx, userID, ledgerID, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fill(ctx, report, req); err != nil {
+		return nil, err
+	}
+	updated, err := s.reportRepo.UpdateDraft(ctx, report)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return nil, ErrReportStatus
+		}
+		return nil, fmt.Errorf("updating expense report: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *expenseReportService) Delete(ctx context.Context, userID, ledgerID, reportID string) error {
+	report, err := s.authorDraft(ctx, userID, ledgerID, reportID)
+	if err != nil {
+		return err
+	}
+	if err := s.reportRepo.DeleteDraft(ctx, report.ID, report.LedgerID); err != nil {
+		if err == db.ErrNotFound {
+			return ErrReportStatus
+		}
+		return fmt.Errorf("deleting expense report: %w", err)
+	}
+	return nil
+}
+
+func (s *expenseReportService) Submit(ctx context.Context, userID, ledgerID, reportID string, req ReportActionRequest) (*models.ExpenseReport, error) {
+	uid, report, err := s.begin(ctx, userID, ledgerID, reportID, stepSubmit)
+	if err != nil {
+		return nil, err
+	}
+	if report.UserID != uid {
+		return nil, ErrUnauthorized
+	}
+	// The transactions may have changed since the draft was saved; fix the total now.
+	txs, err := s.reportTransactions(ctx, report)
+	if err != nil {
+		return nil, err
+	}
+	report.Total = totalOf(txs)
+	return s.advance(ctx, uid, report, stepSubmit, req.Note)
+}
+
+func (s *expenseReportService) Approve(ctx context.Context, userID, ledgerID, reportID string, req ReportActionRequest) (*models.ExpenseReport, error) {
+	uid, report, err := s.begin(ctx, userID, ledgerID, reportID, stepApprove)
+	if err != nil {
+		return nil, err
+	}
+	return s.advance(ctx, uid, report, stepApprove, req.Note)
+}
+
+func (s *expenseReportService) Reject(ctx context.Context, userID, ledgerID, reportID string, req ReportActionRequest) (*models.ExpenseReport, error) {
+	uid, report, err := s.begin(ctx, userID, ledgerID, reportID, stepReject)
+	if err != nil {
+		return nil, err
+	}
+	return s.advance(ctx, uid, report, stepReject, req.Note)
+}
+
+func (s *expenseReportService) Reimburse(ctx context.Context, userID, ledgerID, reportID string, req ReimburseReportRequest) (*models.ExpenseReport, error) {
+	uid, report, err := s.begin(ctx, userID, ledgerID, reportID, stepReimburse)
+	if err != nil {
+		return nil, err
+	}
+	cat, err := s.reimbursementCategory(ctx, report.LedgerID, req.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	inflow, err := s.txRepo.Create(ctx, &models.Transaction{
+		LedgerID:    report.LedgerID,
+		UserID:      report.UserID,
+		CategoryID:  cat.ID,
+		Type:        "inflow",
+		Amount:      report.Total,
+		Description: "Reimbursement: " + report.Title,
+		Date:        time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("recording reimbursement: %w", err)
+	}
+	s.record(ctx, report.LedgerID, uid, models.AuditCreate, models.AuditEntityTransaction, inflow.ID, nil, transactionFields(inflow))
+	report.ReimbursementID = &inflow.ID
+
+	updated, err := s.advance(ctx, uid, report, stepReimburse, req.Note)
+	if err != nil {
+		// Another approver got there first; drop the duplicate inflow for good rather than
+		// leave it in the trash to be restored.
+		if delErr := s.txRepo.Purge(ctx, inflow.ID, report.LedgerID, inflow.Version); delErr != nil {
+			return nil, fmt.Errorf("%w (and removing the reimbursement failed: %v)", err, delErr)
+		}
+		s.record(ctx, report.LedgerID, uid, models.AuditDelete, models.AuditEntityTransaction, inflow.ID, transactionFields(inflow), nil)
+		return nil, err
+	}
+	return updated, nil
+}
+
+// begin authorizes the caller for step and loads the report, checking it is in the
+// step's starting status. Approvers may not act on their own reports.
+func (s *expenseReportService) begin(ctx context.Context, userID, ledgerID, reportID string, step reportStep) (primitive.ObjectID, *models.ExpenseReport, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, step.need)
+	if err != nil {
+		return uid, nil, err
+	}
+	report, err := s.find(ctx, lid, reportID)
+	if err != nil {
+		return uid, nil, err
+	}
+	if report.Status != step.from {
+		return uid, nil, fmt.Errorf("%w: the report is %s", ErrReportStatus, report.Status)
+	}
+	if step.need == models.RoleApprover && report.UserID == uid {
+		return uid, nil, ErrSelfApproval
+	}
+	return uid, report, nil
+}
+
+// advance saves the step, failing with ErrReportStatus if the report changed status since
+// it was loaded.
+func (s *expenseReportService) advance(ctx context.Context, uid primitive.ObjectID, report *models.ExpenseReport, step reportStep, note string) (*models.ExpenseReport, error) {
+	report.Status = step.to
+	entry := models.ReportTransition{From: step.from, To: step.to, By: uid, At: time.Now(), Note: strings.TrimSpace(note)}
+	updated, err := s.reportRepo.Transition(ctx, report, step.from, entry)
+	if err != nil {
+		if err == db.ErrNotFound {
+			return nil, ErrReportStatus
+		}
+		return nil, fmt.Errorf("updating expense report: %w", err)
+	}
+	return updated, nil
+}
+
+func (s *expenseReportService) find(ctx context.Context, lid primitive.ObjectID, reportID string) (*models.ExpenseReport, error) {
+	id, err := primitive.ObjectIDFromHex(reportID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	report, err := s.reportRepo.FindByID(ctx, lid, id)
+	if err != nil {
+		return nil, fmt.Errorf("fetching expense report: %w", err)
+	}
+	if report == nil {
+		return nil, ErrNotFound
+	}
+	return report, nil
+}
+
+// authorDraft loads a draft the caller wrote and may still edit.
+func (s *expenseReportService) authorDraft(ctx context.Context, userID, ledgerID, reportID string) (*models.ExpenseReport, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.find(ctx, lid, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.UserID != uid {
+		return nil, ErrUnauthorized
+	}
+	if report.Status != models.ReportDraft {
+		return nil, fmt.Errorf("%w: the report is %s", ErrReportStatus, report.Status)
+	}
+	return report, nil
+}
+
+// fill validates req and applies it to a draft.
+func (s *expenseReportService) fill(ctx context.Context, report *models.ExpenseReport, req ExpenseReportRequest) error {
+	title := strings.TrimSpace(req.Title)
+	if title == "" || len(title) > maxReportTitleLen {
+		return fmt.Errorf("%w: title must be 1-%d characters", ErrInvalidReport, maxReportTitleLen)
+	}
+	if len(req.TransactionIDs) == 0 {
+		return fmt.Errorf("%w: at least one transaction is required", ErrInvalidReport)
+	}
+	ids := make([]primitive.ObjectID, 0, len(req.TransactionIDs))
+	seen := make(map[primitive.ObjectID]bool)
+	for _, hex := range req.TransactionIDs {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return ErrInvalidID
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	report.Title = title
+	report.TransactionIDs = ids
+
+	txs, err := s.reportTransactions(ctx, report)
+	if err != nil {
+		return err
+	}
+	claiming, err := s.reportRepo.FindClaiming(ctx, report.LedgerID, ids, report.ID)
+	if err != nil {
+		return fmt.Errorf("fetching expense reports: %w", err)
+	}
+	if len(claiming) > 0 {
+		return fmt.Errorf("%w: some transactions are already on report %q", ErrInvalidReport, claiming[0].Title)
+	}
+	report.Total = totalOf(txs)
+	return nil
+}
+
+// reportTransactions fetches the report's transactions, which must all still exist and be
+// outflows recorded by the report's author.
+func (s *expenseReportService) reportTransactions(ctx context.Context, report *models.ExpenseReport) ([]*models.Transaction, error) {
+	txs, err := s.txRepo.FindByIDs(ctx, report.LedgerID, report.TransactionIDs)
+	if err != nil {
+		return nil, fmt.Errorf("fetching transactions: %w", err)
+	}
+	if len(txs) != len(report.TransactionIDs) {
+		return nil, fmt.Errorf("%w: some transactions do not exist in the ledger", ErrInvalidReport)
+	}
+	for _, tx := range txs {
+		if tx.Type != "outflow" || tx.UserID != report.UserID {
+			return nil, fmt.Errorf("%w: only outflows you recorded can be claimed", ErrInvalidReport)
+		}
+	}
+	return txs, nil
+}
+
+// reimbursementCategory resolves the category of a reimbursement inflow.
+func (s *expenseReportService) reimbursementCategory(ctx context.Context, lid primitive.ObjectID, categoryID string) (*models.Category, error) {
+	if categoryID != "" {
+		id, err := primitive.ObjectIDFromHex(categoryID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		cat, err := s.catRepo.FindByID(ctx, lid, id)
+		if err != nil {
+			return nil, fmt.Errorf("fetching category: %w", err)
+		}
+		if cat == nil {
+			return nil, ErrUnknownCategory
+		}
+		return cat, nil
+	}
+
+	defaults, err := s.catRepo.FindDefaultCategories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("fetching default categories: %w", err)
+	}
+	for _, c := range defaults {
+		if c.Name == reimbursementCategory {
+			return c, nil
+		}
+	}
+	return nil, ErrUnknownCategory
+}
+
+func totalOf(txs []*models.Transaction) float64 {
+	var cents int64
+	for _, tx := range txs {
+		cents += toCents(tx.Amount)
+	}
+	return fromCents(cents)
+}
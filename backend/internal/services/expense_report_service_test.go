@@ -0,0 +1,304 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// reportFixture is a ledger with an owner, an approver and an editor, the editor's
+// transactions, and expense reports kept in memory.
+type reportFixture struct {
+	svc      services.ExpenseReportService
+	ledger   *models.Ledger
+	owner    primitive.ObjectID
+	approver primitive.ObjectID
+	editor   primitive.ObjectID
+	other    *models.Category
+	txs      map[primitive.ObjectID]*models.Transaction
+	reports  map[primitive.ObjectID]*models.ExpenseReport
+	purged   []primitive.ObjectID // transactions removed outright
+	audits   []*models.AuditEntry
+	// transitionErr, if set, makes the next Transition fail.
+	transitionErr error
+}
+
+func newReportFixture(t *testing.T) *reportFixture {
+	t.Helper()
+	f := &reportFixture{
+		owner:    primitive.NewObjectID(),
+		approver: primitive.NewObjectID(),
+		editor:   primitive.NewObjectID(),
+		other:    &models.Category{ID: primitive.NewObjectID(), Name: "Other", IsDefault: true},
+		txs:      map[primitive.ObjectID]*models.Transaction{},
+		reports:  map[primitive.ObjectID]*models.ExpenseReport{},
+	}
+	f.ledger = &models.Ledger{ID: primitive.NewObjectID(), Name: "Team", Members: []models.LedgerMember{
+		{UserID: f.owner, Role: models.RoleOwner},
+		{UserID: f.approver, Role: models.RoleApprover},
+		{UserID: f.editor, Role: models.RoleEditor},
+	}}
+
+	txRepo := &testutil.MockTransactionRepo{
+		CreateFn: func(_ context.Context, tx *models.Transaction) (*models.Transaction, error) {
+			tx.ID = primitive.NewObjectID()
+			f.txs[tx.ID] = tx
+			return tx, nil
+		},
+		FindByIDsFn: func(_ context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Transaction, error) {
+			var out []*models.Transaction
+			for _, id := range ids {
+				if tx, ok := f.txs[id]; ok && tx.LedgerID == ledgerID {
+					out = append(out, tx)
+				}
+			}
+			return out, nil
+		},
+		DeleteFn: func(context.Context, primitive.ObjectID, primitive.ObjectID, int64) error {
+			t.Error("a dropped reimbursement must be purged, not moved to the trash")
+			return nil
+		},
+		PurgeFn: func(_ context.Context, id primitive.ObjectID, _ primitive.ObjectID, _ int64) error {
+			delete(f.txs, id)
+			f.purged = append(f.purged, id)
+			return nil
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindDefaultCategoriesFn: func(context.Context) ([]*models.Category, error) {
+			return []*models.Category{{ID: primitive.NewObjectID(), Name: "Food & Dining", IsDefault: true}, f.other}, nil
+		},
+	}
+	reportRepo := &testutil.MockExpenseReportRepo{
+		CreateFn: func(_ context.Context, r *models.ExpenseReport) (*models.ExpenseReport, error) {
+			r.ID = primitive.NewObjectID()
+			f.reports[r.ID] = r
+			return r, nil
+		},
+		FindByIDFn: func(_ context.Context, _ primitive.ObjectID, id primitive.ObjectID) (*models.ExpenseReport, error) {
+			if r, ok := f.reports[id]; ok {
+				copied := *r
+				return &copied, nil
+			}
+			return nil, nil
+		},
+		FindClaimingFn: func(_ context.Context, _ primitive.ObjectID, txIDs []primitive.ObjectID, exclude primitive.ObjectID) ([]*models.ExpenseReport, error) {
+			var out []*models.ExpenseReport
+			for _, r := range f.reports {
+				if r.ID == exclude || r.Status == models.ReportRejected {
+					continue
+				}
+				for _, claimed := range r.TransactionIDs {
+					for _, id := range txIDs {
+						if claimed == id {
+							out = append(out, r)
+						}
+					}
+				}
+			}
+			return out, nil
+		},
+		UpdateDraftFn: func(_ context.Context, r *models.ExpenseReport) (*models.ExpenseReport, error) {
+			f.reports[r.ID] = r
+			return r, nil
+		},
+		TransitionFn: func(_ context.Context, r *models.ExpenseReport, from string, entry models.ReportTransition) (*models.ExpenseReport, error) {
+			if err := f.transitionErr; err != nil {
+				f.transitionErr = nil
+				return nil, err
+			}
+			if f.reports[r.ID].Status != from {
+				return nil, db.ErrNotFound
+			}
+			r.History = append(r.History, entry)
+			f.reports[r.ID] = r
+			return r, nil
+		},
+	}
+	auditRepo := &testutil.MockAuditRepo{
+		CreateFn: func(_ context.Context, e *models.AuditEntry) (*models.AuditEntry, error) {
+			f.audits = append(f.audits, e)
+			return e, nil
+		},
+	}
+	f.svc = services.NewExpenseReportService(reportRepo, txRepo, catRepo, sharedLedgerRepo(f.ledger), auditRepo)
+	return f
+}
+
+// spend records a transaction by the given user and returns its ID.
+func (f *reportFixture) spend(userID primitive.ObjectID, txType string, amount float64) string {
+	tx := &models.Transaction{ID: primitive.NewObjectID(), LedgerID: f.ledger.ID, UserID: userID, Type: txType, Amount: amount}
+	f.txs[tx.ID] = tx
+	return tx.ID.Hex()
+}
+
+func (f *reportFixture) draft(t *testing.T, txIDs ...string) *models.ExpenseReport {
+	t.Helper()
+	r, err := f.svc.Create(context.Background(), f.editor.Hex(), f.ledger.ID.Hex(), services.ExpenseReportRequest{Title: "Conference", TransactionIDs: txIDs})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return r
+}
+
+func (f *reportFixture) submit(t *testing.T, r *models.ExpenseReport) {
+	t.Helper()
+	if _, err := f.svc.Submit(context.Background(), f.editor.Hex(), f.ledger.ID.Hex(), r.ID.Hex(), services.ReportActionRequest{}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+}
+
+func TestExpenseReportService_Workflow(t *testing.T) {
+	f := newReportFixture(t)
+	ctx := context.Background()
+	lid := f.ledger.ID.Hex()
+
+	r := f.draft(t, f.spend(f.editor, "outflow", 120.10), f.spend(f.editor, "outflow", 19.95))
+	if r.Status != models.ReportDraft || r.Total != 140.05 {
+		t.Fatalf("expected a 140.05 draft, got %s %.2f", r.Status, r.Total)
+	}
+	f.submit(t, r)
+
+	r, err := f.svc.Approve(ctx, f.approver.Hex(), lid, r.ID.Hex(), services.ReportActionRequest{Note: " looks good "})
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if r.Status != models.ReportApproved {
+		t.Fatalf("expected approved, got %s", r.Status)
+	}
+
+	r, err = f.svc.Reimburse(ctx, f.approver.Hex(), lid, r.ID.Hex(), services.ReimburseReportRequest{})
+	if err != nil {
+		t.Fatalf("Reimburse: %v", err)
+	}
+	if r.Status != models.ReportReimbursed || r.ReimbursementID == nil {
+		t.Fatalf("expected a reimbursed report with its inflow, got %+v", r)
+	}
+	inflow := f.txs[*r.ReimbursementID]
+	if inflow == nil || inflow.Type != "inflow" || inflow.Amount != 140.05 || inflow.UserID != f.editor || inflow.CategoryID != f.other.ID {
+		t.Errorf("unexpected reimbursement transaction %+v", inflow)
+	}
+	if len(f.audits) != 1 || f.audits[0].Action != models.AuditCreate || f.audits[0].EntityID != inflow.ID || f.audits[0].ActorID != f.approver {
+		t.Errorf("expected the inflow's creation to be audited, got %+v", f.audits)
+	}
+
+	want := []models.ReportTransition{
+		{From: "", To: models.ReportDraft, By: f.editor},
+		{From: models.ReportDraft, To: models.ReportSubmitted, By: f.editor},
+		{From: models.ReportSubmitted, To: models.ReportApproved, By: f.approver, Note: "looks good"},
+		{From: models.ReportApproved, To: models.ReportReimbursed, By: f.approver},
+	}
+	if len(r.History) != len(want) {
+		t.Fatalf("expected %d history entries, got %+v", len(want), r.History)
+	}
+	for i, w := range want {
+		got := r.History[i]
+		if got.From != w.From || got.To != w.To || got.By != w.By || got.Note != w.Note || got.At.IsZero() {
+			t.Errorf("history[%d]: expected %+v, got %+v", i, w, got)
+		}
+	}
+}
+
+func TestExpenseReportService_Roles(t *testing.T) {
+	f := newReportFixture(t)
+	ctx := context.Background()
+	lid := f.ledger.ID.Hex()
+	r := f.draft(t, f.spend(f.editor, "outflow", 10))
+
+	if _, err := f.svc.Submit(ctx, f.owner.Hex(), lid, r.ID.Hex(), services.ReportActionRequest{}); !errors.Is(err, services.ErrUnauthorized) {
+		t.Errorf("submitting another member's report: expected ErrUnauthorized, got %v", err)
+	}
+	f.submit(t, r)
+	if _, err := f.svc.Approve(ctx, f.editor.Hex(), lid, r.ID.Hex(), services.ReportActionRequest{}); !errors.Is(err, services.ErrUnauthorized) {
+		t.Errorf("approving as an editor: expected ErrUnauthorized, got %v", err)
+	}
+	if _, err := f.svc.Reject(ctx, f.owner.Hex(), lid, r.ID.Hex(), services.ReportActionRequest{Note: "no receipts"}); err != nil {
+		t.Errorf("owners can reject: %v", err)
+	}
+
+	own, err := f.svc.Create(ctx, f.approver.Hex(), lid, services.ExpenseReportRequest{Title: "Mine", TransactionIDs: []string{f.spend(f.approver, "outflow", 5)}})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := f.svc.Submit(ctx, f.approver.Hex(), lid, own.ID.Hex(), services.ReportActionRequest{}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err := f.svc.Approve(ctx, f.approver.Hex(), lid, own.ID.Hex(), services.ReportActionRequest{}); !errors.Is(err, services.ErrSelfApproval) {
+		t.Errorf("approving your own report: expected ErrSelfApproval, got %v", err)
+	}
+}
+
+func TestExpenseReportService_StatusGuards(t *testing.T) {
+	f := newReportFixture(t)
+	ctx := context.Background()
+	lid := f.ledger.ID.Hex()
+	txID := f.spend(f.editor, "outflow", 10)
+	r := f.draft(t, txID)
+
+	if _, err := f.svc.Approve(ctx, f.approver.Hex(), lid, r.ID.Hex(), services.ReportActionRequest{}); !errors.Is(err, services.ErrReportStatus) {
+		t.Errorf("approving a draft: expected ErrReportStatus, got %v", err)
+	}
+	f.submit(t, r)
+	if _, err := f.svc.Reimburse(ctx, f.approver.Hex(), lid, r.ID.Hex(), services.ReimburseReportRequest{}); !errors.Is(err, services.ErrReportStatus) {
+		t.Errorf("reimbursing before approval: expected ErrReportStatus, got %v", err)
+	}
+	if _, err := f.svc.Update(ctx, f.editor.Hex(), lid, r.ID.Hex(), services.ExpenseReportRequest{Title: "Changed", TransactionIDs: []string{txID}}); !errors.Is(err, services.ErrReportStatus) {
+		t.Errorf("editing a submitted report: expected ErrReportStatus, got %v", err)
+	}
+	if err := f.svc.Delete(ctx, f.editor.Hex(), lid, r.ID.Hex()); !errors.Is(err, services.ErrReportStatus) {
+		t.Errorf("deleting a submitted report: expected ErrReportStatus, got %v", err)
+	}
+}
+
+func TestExpenseReportService_Create_RejectsTransactions(t *testing.T) {
+	f := newReportFixture(t)
+	claimed := f.spend(f.editor, "outflow", 10)
+	f.draft(t, claimed)
+
+	cases := map[string][]string{
+		"an inflow":                       {f.spend(f.editor, "inflow", 10)},
+		"another member's outflow":        {f.spend(f.owner, "outflow", 10)},
+		"a transaction on another report": {claimed},
+		"a missing transaction":           {primitive.NewObjectID().Hex()},
+		"no transactions":                 nil,
+	}
+	for name, ids := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := f.svc.Create(context.Background(), f.editor.Hex(), f.ledger.ID.Hex(), services.ExpenseReportRequest{Title: "Again", TransactionIDs: ids})
+			if !errors.Is(err, services.ErrInvalidReport) {
+				t.Errorf("expected ErrInvalidReport, got %v", err)
+			}
+		})
+	}
+}
+
+func TestExpenseReportService_Reimburse_RollsBackOnConflict(t *testing.T) {
+	f := newReportFixture(t)
+	ctx := context.Background()
+	lid := f.ledger.ID.Hex()
+	r := f.draft(t, f.spend(f.editor, "outflow", 10))
+	f.submit(t, r)
+	if _, err := f.svc.Approve(ctx, f.owner.Hex(), lid, r.ID.Hex(), services.ReportActionRequest{}); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	// Another approver reimburses the report between our read and our write.
+	f.transitionErr = db.ErrNotFound
+	_, err := f.svc.Reimburse(ctx, f.approver.Hex(), lid, r.ID.Hex(), services.ReimburseReportRequest{})
+	if !errors.Is(err, services.ErrReportStatus) {
+		t.Fatalf("expected ErrReportStatus, got %v", err)
+	}
+	if len(f.purged) != 1 {
+		t.Fatalf("expected the duplicate inflow to be purged, got %d purges", len(f.purged))
+	}
+	if len(f.audits) != 2 || f.audits[0].Action != models.AuditCreate || f.audits[1].Action != models.AuditDelete || f.audits[1].EntityID != f.purged[0] {
+		t.Errorf("expected the inflow's creation and removal to be audited, got %+v", f.audits)
+	}
+}
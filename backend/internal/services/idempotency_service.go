@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IdempotencyKeyTTL is how long a response is kept for replay under its Idempotency-Key.
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyLockTimeout is how long a request holds its key before completing. It is well
+// above the server's write timeout, so a record still unfinished after it belongs to a
+// request that crashed or was cut off, and a retry may claim the key again.
+const IdempotencyLockTimeout = time.Minute
+
+// IdempotencyService tracks the Idempotency-Keys of create requests per user, so that a
+// retried request is answered with the original response instead of running twice.
+type IdempotencyService interface {
+	// Begin claims key for a request with the given hash. The returned record has a
+	// Response when the key was already used for the same request, which should then be
+	// replayed; otherwise the caller owns the key and must Complete or Abandon it.
+	// Begin fails with ErrIdempotencyKeyReused if the key was used for another request
+	// and ErrIdempotencyInProgress while the first request is still running, for at most
+	// IdempotencyLockTimeout.
+	Begin(ctx context.Context, userID, key, requestHash string) (*models.IdempotencyRecord, error)
+	// Complete stores the response to replay for the key.
+	Complete(ctx context.Context, rec *models.IdempotencyRecord, resp models.StoredResponse) error
+	// Abandon releases the key so that the request can be retried.
+	Abandon(ctx context.Context, rec *models.IdempotencyRecord) error
+}
+
+type idempotencyService struct {
+	repo db.IdempotencyRepository
+	now  func() time.Time
+}
+
+// NewIdempotencyService creates a new IdempotencyService.
+func NewIdempotencyService(repo db.IdempotencyRepository) IdempotencyService {
+	return &idempotencyService{repo: repo, now: time.Now}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, userID, key, requestHash string) (*models.IdempotencyRecord, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	now := s.now()
+	existing, err := s.repo.FindByKey(ctx, uid, key)
+	if err != nil {
+		return nil, err
+	}
+	// The TTL monitor only runs once a minute, so an expired record may still be there. A
+	// record whose request never finished is dropped once its lock runs out; deleting by ID
+	// means only one of several concurrent retries gets to claim the key afresh.
+	if existing != nil && (!now.Before(existing.ExpiresAt) || existing.Response == nil && !now.Before(existing.LockedUntil)) {
+		if err := s.repo.Delete(ctx, existing.ID); err != nil {
+			return nil, err
+		}
+		existing = nil
+	}
+	if existing == nil {
+		rec, err := s.repo.Create(ctx, &models.IdempotencyRecord{
+			UserID:      uid,
+			Key:         key,
+			RequestHash: requestHash,
+			LockedUntil: now.Add(IdempotencyLockTimeout),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(IdempotencyKeyTTL),
+		})
+		if !errors.Is(err, db.ErrDuplicate) {
+			return rec, err
+		}
+		// A concurrent request claimed the key first.
+		if existing, err = s.repo.FindByKey(ctx, uid, key); err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, ErrIdempotencyInProgress
+		}
+	}
+
+	if existing.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if existing.Response == nil {
+		return nil, ErrIdempotencyInProgress
+	}
+	return existing, nil
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, rec *models.IdempotencyRecord, resp models.StoredResponse) error {
+	if err := s.repo.SetResponse(ctx, rec.ID, resp); err != nil {
+		return fmt.Errorf("store idempotent response: %w", err)
+	}
+	rec.Response = &resp
+	return nil
+}
+
+func (s *idempotencyService) Abandon(ctx context.Context, rec *models.IdempotencyRecord) error {
+	return s.repo.Delete(ctx, rec.ID)
+}
@@ -0,0 +1,182 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memoryIdempotencyRepo keeps records in a map keyed by user and key.
+func memoryIdempotencyRepo() *testutil.MockIdempotencyRepo {
+	records := map[string]*models.IdempotencyRecord{}
+	byID := func(id primitive.ObjectID) string {
+		for k, rec := range records {
+			if rec.ID == id {
+				return k
+			}
+		}
+		return ""
+	}
+	return &testutil.MockIdempotencyRepo{
+		CreateFn: func(_ context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+			k := rec.UserID.Hex() + "/" + rec.Key
+			if records[k] != nil {
+				return nil, db.ErrDuplicate
+			}
+			rec.ID = primitive.NewObjectID()
+			records[k] = rec
+			return rec, nil
+		},
+		FindByKeyFn: func(_ context.Context, userID primitive.ObjectID, key string) (*models.IdempotencyRecord, error) {
+			return records[userID.Hex()+"/"+key], nil
+		},
+		SetResponseFn: func(_ context.Context, id primitive.ObjectID, resp models.StoredResponse) error {
+			k := byID(id)
+			if k == "" {
+				return db.ErrNotFound
+			}
+			records[k].Response = &resp
+			return nil
+		},
+		DeleteFn: func(_ context.Context, id primitive.ObjectID) error {
+			delete(records, byID(id))
+			return nil
+		},
+	}
+}
+
+func TestIdempotencyService_ReplaysCompletedRequests(t *testing.T) {
+	svc := services.NewIdempotencyService(memoryIdempotencyRepo())
+	ctx := context.Background()
+	userID := primitive.NewObjectID().Hex()
+
+	rec, err := svc.Begin(ctx, userID, "k", "hash")
+	if err != nil || rec.Response != nil {
+		t.Fatalf("expected a new claim, got %+v, %v", rec, err)
+	}
+	if rec.ExpiresAt.Sub(rec.CreatedAt) != services.IdempotencyKeyTTL {
+		t.Errorf("expected the record to expire after %s, got %s", services.IdempotencyKeyTTL, rec.ExpiresAt.Sub(rec.CreatedAt))
+	}
+	if _, err := svc.Begin(ctx, userID, "k", "hash"); !errors.Is(err, services.ErrIdempotencyInProgress) {
+		t.Errorf("expected ErrIdempotencyInProgress before completion, got %v", err)
+	}
+
+	if err := svc.Complete(ctx, rec, models.StoredResponse{Status: 201, Body: []byte("created")}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	replay, err := svc.Begin(ctx, userID, "k", "hash")
+	if err != nil || replay.Response == nil || string(replay.Response.Body) != "created" {
+		t.Fatalf("expected the stored response, got %+v, %v", replay, err)
+	}
+
+	if _, err := svc.Begin(ctx, userID, "k", "other"); !errors.Is(err, services.ErrIdempotencyKeyReused) {
+		t.Errorf("expected ErrIdempotencyKeyReused for a different request, got %v", err)
+	}
+	if rec, err := svc.Begin(ctx, primitive.NewObjectID().Hex(), "k", "other"); err != nil || rec.Response != nil {
+		t.Errorf("expected keys to be per user, got %+v, %v", rec, err)
+	}
+}
+
+func TestIdempotencyService_AbandonReleasesKey(t *testing.T) {
+	svc := services.NewIdempotencyService(memoryIdempotencyRepo())
+	ctx := context.Background()
+	userID := primitive.NewObjectID().Hex()
+
+	rec, _ := svc.Begin(ctx, userID, "k", "hash")
+	if err := svc.Abandon(ctx, rec); err != nil {
+		t.Fatalf("Abandon: %v", err)
+	}
+	if rec, err := svc.Begin(ctx, userID, "k", "other"); err != nil || rec.Response != nil {
+		t.Errorf("expected the key to be claimable again, got %+v, %v", rec, err)
+	}
+}
+
+func TestIdempotencyService_ExpiredRecordIsReplaced(t *testing.T) {
+	userID := primitive.NewObjectID()
+	expired := &models.IdempotencyRecord{
+		ID: primitive.NewObjectID(), UserID: userID, Key: "k", RequestHash: "old",
+		Response: &models.StoredResponse{Status: 201}, ExpiresAt: time.Now().Add(-time.Minute),
+	}
+	var deleted primitive.ObjectID
+	repo := &testutil.MockIdempotencyRepo{
+		FindByKeyFn: func(context.Context, primitive.ObjectID, string) (*models.IdempotencyRecord, error) {
+			return expired, nil
+		},
+		DeleteFn: func(_ context.Context, id primitive.ObjectID) error {
+			deleted = id
+			return nil
+		},
+		CreateFn: func(_ context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+			return rec, nil
+		},
+	}
+	svc := services.NewIdempotencyService(repo)
+
+	rec, err := svc.Begin(context.Background(), userID.Hex(), "k", "new")
+	if err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	if deleted != expired.ID {
+		t.Error("expected the expired record to be deleted")
+	}
+	if rec.Response != nil || rec.RequestHash != "new" {
+		t.Errorf("expected a new claim, got %+v", rec)
+	}
+}
+
+func TestIdempotencyService_AbandonedRecordIsReclaimed(t *testing.T) {
+	userID := primitive.NewObjectID()
+	for name, tc := range map[string]struct {
+		lockedUntil time.Time
+		wantErr     error
+	}{
+		"lock held":    {lockedUntil: time.Now().Add(time.Minute), wantErr: services.ErrIdempotencyInProgress},
+		"lock expired": {lockedUntil: time.Now().Add(-time.Second)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			unfinished := &models.IdempotencyRecord{
+				ID: primitive.NewObjectID(), UserID: userID, Key: "k", RequestHash: "hash",
+				LockedUntil: tc.lockedUntil, ExpiresAt: time.Now().Add(services.IdempotencyKeyTTL),
+			}
+			var deleted bool
+			repo := &testutil.MockIdempotencyRepo{
+				FindByKeyFn: func(context.Context, primitive.ObjectID, string) (*models.IdempotencyRecord, error) {
+					return unfinished, nil
+				},
+				DeleteFn: func(_ context.Context, id primitive.ObjectID) error {
+					deleted = id == unfinished.ID
+					return nil
+				},
+				CreateFn: func(_ context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+					return rec, nil
+				},
+			}
+			svc := services.NewIdempotencyService(repo)
+
+			rec, err := svc.Begin(context.Background(), userID.Hex(), "k", "hash")
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("expected %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr != nil {
+				if deleted {
+					t.Error("expected a locked record to be kept")
+				}
+				return
+			}
+			if !deleted {
+				t.Error("expected the abandoned record to be deleted")
+			}
+			if rec.Response != nil || rec.LockedUntil.Sub(rec.CreatedAt) != services.IdempotencyLockTimeout {
+				t.Errorf("expected a new claim locked for %s, got %+v", services.IdempotencyLockTimeout, rec)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	invitationTokenPurpose = "ledger-invitation"
+	// DefaultInvitationTTL is how long an invitation can be accepted when no TTL is configured.
+	DefaultInvitationTTL = 7 * 24 * time.Hour
+)
+
+// CreateInvitationRequest invites an email address to a ledger with a role.
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// InvitationConfig configures an InvitationService.
+type InvitationConfig struct {
+	// SigningKey authenticates invitation tokens.
+	SigningKey []byte
+	// AcceptURL is the page that accepts an invitation; the token is appended as ?token=.
+	AcceptURL string
+	// TTL is how long an invitation can be accepted; zero uses DefaultInvitationTTL.
+	TTL time.Duration
+}
+
+// InvitationService invites people to ledgers by email. Owners invite and revoke; the
+// invitee accepts through a signed link while signed in with the invited address.
+type InvitationService interface {
+	// Create records an invitation and mails the invitee a link to accept it. It returns
+	// ErrInvitationsDisabled when the service has no mailer.
+	Create(ctx context.Context, userID, ledgerID string, req CreateInvitationRequest) (*models.Invitation, error)
+	// List returns the ledger's pending invitations, newest first.
+	List(ctx context.Context, userID, ledgerID string) ([]*models.Invitation, error)
+	Revoke(ctx context.Context, userID, ledgerID, invitationID string) error
+	// Accept adds the user to the invitation's ledger and marks the invitation used by them.
+	// One of the user's logins must have the invited email address, verified by its provider.
+	Accept(ctx context.Context, userID, token string) (*models.Ledger, error)
+}
+
+type invitationService struct {
+	ledgerAccess
+	invRepo  db.InvitationRepository
+	userRepo db.UserRepository
+	mailer   Mailer
+	cfg      InvitationConfig
+}
+
+// invitationToken is the data signed into an invitation link.
+type invitationToken struct {
+	ID string `json:"id"`
+}
+
+// NewInvitationService creates a new InvitationService. A nil mailer disables new
+// invitations; those already sent can still be listed, revoked and accepted.
+func NewInvitationService(invRepo db.InvitationRepository, ledgerRepo db.LedgerRepository, userRepo db.UserRepository, mailer Mailer, cfg InvitationConfig) InvitationService {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultInvitationTTL
+	}
+	return &invitationService{
+		ledgerAccess: ledgerAccess{ledgerRepo: ledgerRepo},
+		invRepo:      invRepo,
+		userRepo:     userRepo,
+		mailer:       mailer,
+		cfg:          cfg,
+	}
+}
+
+func (s *invitationService) Create(ctx context.Context, userID, ledgerID string, req CreateInvitationRequest) (*models.Invitation, error) {
+	if s.mailer == nil {
+		return nil, ErrInvitationsDisabled
+	}
+	if !models.ValidRole(req.Role) {
+		return nil, ErrInvalidRole
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil {
+		return nil, ErrInvalidEmail
+	}
+	email := strings.ToLower(addr.Address)
+
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	ledger, err := s.load(ctx, uid, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if ledger.RoleOf(uid) != models.RoleOwner {
+		return nil, ErrUnauthorized
+	}
+	invitee, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, fmt.Errorf("finding user by email: %w", err)
+	}
+	if invitee != nil && ledger.RoleOf(invitee.ID) != "" {
+		return nil, ErrAlreadyMember
+	}
+
+	now := time.Now()
+	inv, err := s.invRepo.Create(ctx, &models.Invitation{
+		LedgerID:  ledger.ID,
+		Email:     email,
+		Role:      req.Role,
+		InvitedBy: uid,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.TTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating invitation: %w", err)
+	}
+
+	token, err := signToken(s.cfg.SigningKey, invitationTokenPurpose, invitationToken{ID: inv.ID.Hex()}, s.cfg.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("signing invitation: %w", err)
+	}
+	inviter := "Someone"
+	if u, err := s.userRepo.FindByID(ctx, uid); err == nil && u != nil && u.Name != "" {
+		inviter = u.Name
+	}
+	msg := Message{
+		To:      email,
+		Subject: fmt.Sprintf("%s invited you to %s on Expensify", inviter, ledger.Name),
+		Body: fmt.Sprintf("%s invited you to join the ledger %q as %s.\n\nAccept the invitation: %s?token=%s\n\nSign in with %s to accept. The link expires on %s.\n",
+			inviter, ledger.Name, req.Role, s.cfg.AcceptURL, url.QueryEscape(token), email, inv.ExpiresAt.UTC().Format("2 January 2006")),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		// An invitation nobody received should not linger as pending.
+		if revokeErr := s.invRepo.Revoke(ctx, inv.ID, ledger.ID, time.Now()); revokeErr != nil {
+			return nil, fmt.Errorf("sending invitation: %w (revoking it: %v)", err, revokeErr)
+		}
+		return nil, fmt.Errorf("sending invitation: %w", err)
+	}
+	return inv, nil
+}
+
+func (s *invitationService) List(ctx context.Context, userID, ledgerID string) ([]*models.Invitation, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	invs, err := s.invRepo.FindPendingByLedger(ctx, lid, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("fetching invitations: %w", err)
+	}
+	return invs, nil
+}
+
+func (s *invitationService) Revoke(ctx context.Context, userID, ledgerID, invitationID string) error {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleOwner)
+	if err != nil {
+		return err
+	}
+	iid, err := primitive.ObjectIDFromHex(invitationID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if err := s.invRepo.Revoke(ctx, iid, lid, time.Now()); err != nil {
+		if err == db.ErrNotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("revoking invitation: %w", err)
+	}
+	return nil
+}
+
+func (s *invitationService) Accept(ctx context.Context, userID, token string) (*models.Ledger, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	var payload invitationToken
+	if err := verifyToken(s.cfg.SigningKey, invitationTokenPurpose, token, &payload); err != nil {
+		return nil, err
+	}
+	iid, err := primitive.ObjectIDFromHex(payload.ID)
+	if err != nil {
+		return nil, ErrInvalidSignedToken
+	}
+
+	now := time.Now()
+	inv, err := s.invRepo.FindByID(ctx, iid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching invitation: %w", err)
+	}
+	if inv == nil || inv.Status(now) != models.InvitationPending {
+		return nil, ErrInvitationUnavailable
+	}
+	user, err := s.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+	if !hasEmail(user, inv.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+	ledger, err := s.ledgerRepo.FindByID(ctx, inv.LedgerID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching ledger: %w", err)
+	}
+	if ledger == nil {
+		return nil, ErrInvitationUnavailable
+	}
+	if ledger.RoleOf(uid) != "" {
+		return nil, ErrAlreadyMember
+	}
+
+	// Claim the invitation first so a link used twice at once adds the member only once.
+	if _, err := s.invRepo.MarkAccepted(ctx, inv.ID, uid, now); err != nil {
+		if err == db.ErrNotFound {
+			return nil, ErrInvitationUnavailable
+		}
+		return nil, fmt.Errorf("accepting invitation: %w", err)
+	}
+	updated, err := s.ledgerRepo.AddMember(ctx, ledger.ID, models.LedgerMember{UserID: uid, Role: inv.Role, AddedAt: now})
+	if err != nil {
+		// Give the invitation back, so the link still works once the failure is resolved.
+		if uerr := s.invRepo.UnmarkAccepted(ctx, inv.ID, uid, now); uerr != nil {
+			log.Printf("invitation %s: could not reset after failed accept: %v", inv.ID.Hex(), uerr)
+		}
+		return nil, ledgerRepoError("adding member", err)
+	}
+	return updated, nil
+}
+
+// hasEmail reports whether one of the user's logins has email as an address its provider
+// verified. The profile email can be edited and proves nothing. Dev logins only exist
+// with DEV_LOGIN enabled and count as verified for their @dev.localhost address.
+func hasEmail(user *models.User, email string) bool {
+	for _, id := range user.Identities {
+		if (id.EmailVerified || id.Provider == DevProvider) && strings.EqualFold(id.Email, email) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,263 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// invitationFixture is an owned ledger, an invitee with an account, and an invitation
+// service that mails through a fake SMTP server.
+type invitationFixture struct {
+	svc        services.InvitationService
+	smtp       *testutil.FakeSMTPServer
+	ledger     *models.Ledger
+	ledgerRepo *testutil.MockLedgerRepo
+	owner      *models.User
+	invitee    *models.User
+	invs       map[primitive.ObjectID]*models.Invitation
+}
+
+func newInvitationFixture(t *testing.T) *invitationFixture {
+	t.Helper()
+	f := &invitationFixture{
+		smtp:  testutil.NewFakeSMTPServer(t),
+		owner: &models.User{ID: primitive.NewObjectID(), Name: "Alex", Email: "alex@example.com"},
+		invitee: &models.User{ID: primitive.NewObjectID(), Name: "Sam", Email: "sam@example.com", Identities: []models.Identity{
+			{Provider: "google", Subject: "1", Email: "sam@example.com", EmailVerified: true},
+		}},
+		invs: map[primitive.ObjectID]*models.Invitation{},
+	}
+	f.ledger = &models.Ledger{
+		ID:      primitive.NewObjectID(),
+		Name:    "Household",
+		Members: []models.LedgerMember{{UserID: f.owner.ID, Role: models.RoleOwner}},
+	}
+
+	ledgerRepo := sharedLedgerRepo(f.ledger)
+	f.ledgerRepo = ledgerRepo
+	ledgerRepo.AddMemberFn = func(_ context.Context, _ primitive.ObjectID, m models.LedgerMember) (*models.Ledger, error) {
+		f.ledger.Members = append(f.ledger.Members, m)
+		return f.ledger, nil
+	}
+	users := map[primitive.ObjectID]*models.User{f.owner.ID: f.owner, f.invitee.ID: f.invitee}
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(_ context.Context, id primitive.ObjectID) (*models.User, error) { return users[id], nil },
+		FindByEmailFn: func(_ context.Context, email string) (*models.User, error) {
+			for _, u := range users {
+				if u.Email == email {
+					return u, nil
+				}
+			}
+			return nil, nil
+		},
+	}
+	invRepo := &testutil.MockInvitationRepo{
+		CreateFn: func(_ context.Context, inv *models.Invitation) (*models.Invitation, error) {
+			inv.ID = primitive.NewObjectID()
+			f.invs[inv.ID] = inv
+			return inv, nil
+		},
+		FindByIDFn: func(_ context.Context, id primitive.ObjectID) (*models.Invitation, error) { return f.invs[id], nil },
+		MarkAcceptedFn: func(_ context.Context, id, userID primitive.ObjectID, at time.Time) (*models.Invitation, error) {
+			inv := f.invs[id]
+			if inv == nil || inv.Status(at) != models.InvitationPending {
+				return nil, db.ErrNotFound
+			}
+			inv.AcceptedAt, inv.AcceptedBy = &at, &userID
+			return inv, nil
+		},
+		UnmarkAcceptedFn: func(_ context.Context, id, userID primitive.ObjectID, at time.Time) error {
+			if inv := f.invs[id]; inv != nil && inv.AcceptedBy != nil && *inv.AcceptedBy == userID {
+				inv.AcceptedAt, inv.AcceptedBy = nil, nil
+			}
+			return nil
+		},
+		RevokeFn: func(_ context.Context, id, ledgerID primitive.ObjectID, at time.Time) error {
+			inv := f.invs[id]
+			if inv == nil || inv.LedgerID != ledgerID || inv.Status(at) != models.InvitationPending {
+				return db.ErrNotFound
+			}
+			inv.RevokedAt = &at
+			return nil
+		},
+	}
+
+	mailer := services.NewSMTPMailer(services.SMTPConfig{Addr: f.smtp.Addr, From: "no-reply@expensify.test"})
+	f.svc = services.NewInvitationService(invRepo, ledgerRepo, userRepo, mailer, services.InvitationConfig{
+		SigningKey: []byte("test-signing-key"),
+		AcceptURL:  "http://localhost:5173/invitations/accept",
+	})
+	return f
+}
+
+var acceptLink = regexp.MustCompile(`http://localhost:5173/invitations/accept\?token=(\S+)`)
+
+// invite creates an invitation for email and returns the token from the mailed link.
+func (f *invitationFixture) invite(t *testing.T, email string) (*models.Invitation, string) {
+	t.Helper()
+	inv, err := f.svc.Create(context.Background(), f.owner.ID.Hex(), f.ledger.ID.Hex(), services.CreateInvitationRequest{Email: email, Role: models.RoleEditor})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	msgs := f.smtp.Messages()
+	m := acceptLink.FindStringSubmatch(msgs[len(msgs)-1].Data)
+	if m == nil {
+		t.Fatalf("no accept link in mail:\n%s", msgs[len(msgs)-1].Data)
+	}
+	token, err := url.QueryUnescape(m[1])
+	if err != nil {
+		t.Fatalf("unescaping token: %v", err)
+	}
+	return inv, token
+}
+
+func TestInvitationService_MailedLinkAddsMember(t *testing.T) {
+	f := newInvitationFixture(t)
+	inv, token := f.invite(t, "Sam@Example.com")
+
+	if inv.Email != "sam@example.com" || inv.InvitedBy != f.owner.ID || !inv.ExpiresAt.After(time.Now().Add(6*24*time.Hour)) {
+		t.Errorf("unexpected invitation: %+v", inv)
+	}
+	if to := f.smtp.Messages()[0].To; len(to) != 1 || to[0] != "sam@example.com" {
+		t.Errorf("mail sent to %v", to)
+	}
+
+	ledger, err := f.svc.Accept(context.Background(), f.invitee.ID.Hex(), token)
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	if ledger.RoleOf(f.invitee.ID) != models.RoleEditor {
+		t.Errorf("invitee should be an editor, members: %+v", ledger.Members)
+	}
+	if inv.AcceptedBy == nil || *inv.AcceptedBy != f.invitee.ID {
+		t.Error("invitation should record who accepted it")
+	}
+
+	if _, err := f.svc.Accept(context.Background(), f.invitee.ID.Hex(), token); err != services.ErrInvitationUnavailable {
+		t.Errorf("using the link again: expected ErrInvitationUnavailable, got %v", err)
+	}
+}
+
+func TestInvitationService_AcceptChecksInvitee(t *testing.T) {
+	f := newInvitationFixture(t)
+	_, token := f.invite(t, "sam@example.com")
+	stranger := primitive.NewObjectID()
+
+	if _, err := f.svc.Accept(context.Background(), f.owner.ID.Hex(), token); err != services.ErrInvitationEmailMismatch {
+		t.Errorf("another user accepting: expected ErrInvitationEmailMismatch, got %v", err)
+	}
+	if _, err := f.svc.Accept(context.Background(), stranger.Hex(), token); err != services.ErrNotFound {
+		t.Errorf("unknown user accepting: expected ErrNotFound, got %v", err)
+	}
+	if _, err := f.svc.Accept(context.Background(), f.invitee.ID.Hex(), token+"x"); err != services.ErrInvalidSignedToken {
+		t.Errorf("tampered token: expected ErrInvalidSignedToken, got %v", err)
+	}
+
+	// Neither the editable profile email nor an address the provider did not verify counts.
+	f.invitee.Identities = []models.Identity{
+		{Provider: "google", Subject: "1", Email: "sam@work.example", EmailVerified: true},
+		{Provider: "keycloak", Subject: "2", Email: "sam@example.com"},
+	}
+	if _, err := f.svc.Accept(context.Background(), f.invitee.ID.Hex(), token); err != services.ErrInvitationEmailMismatch {
+		t.Errorf("unverified address: expected ErrInvitationEmailMismatch, got %v", err)
+	}
+
+	// A login at another provider with the verified invited address qualifies.
+	f.invitee.Email = "sam@work.example"
+	f.invitee.Identities[1].EmailVerified = true
+	if _, err := f.svc.Accept(context.Background(), f.invitee.ID.Hex(), token); err != nil {
+		t.Errorf("accepting through a linked identity: %v", err)
+	}
+}
+
+func TestInvitationService_AcceptResetsInvitationWhenAddingMemberFails(t *testing.T) {
+	f := newInvitationFixture(t)
+	inv, token := f.invite(t, "sam@example.com")
+	f.ledgerRepo.AddMemberFn = func(context.Context, primitive.ObjectID, models.LedgerMember) (*models.Ledger, error) {
+		return nil, errors.New("connection reset")
+	}
+
+	if _, err := f.svc.Accept(context.Background(), f.invitee.ID.Hex(), token); err == nil {
+		t.Fatal("expected the failure to be returned")
+	}
+	if inv.Status(time.Now()) != models.InvitationPending {
+		t.Errorf("expected the invitation to be pending again, got %s", inv.Status(time.Now()))
+	}
+}
+
+func TestInvitationService_Revoke(t *testing.T) {
+	f := newInvitationFixture(t)
+	inv, token := f.invite(t, "sam@example.com")
+	ctx := context.Background()
+
+	if err := f.svc.Revoke(ctx, f.invitee.ID.Hex(), f.ledger.ID.Hex(), inv.ID.Hex()); err != services.ErrLedgerNotFound {
+		t.Errorf("non-member revoking: expected ErrLedgerNotFound, got %v", err)
+	}
+	if err := f.svc.Revoke(ctx, f.owner.ID.Hex(), f.ledger.ID.Hex(), inv.ID.Hex()); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if err := f.svc.Revoke(ctx, f.owner.ID.Hex(), f.ledger.ID.Hex(), inv.ID.Hex()); err != services.ErrNotFound {
+		t.Errorf("revoking twice: expected ErrNotFound, got %v", err)
+	}
+	if _, err := f.svc.Accept(ctx, f.invitee.ID.Hex(), token); err != services.ErrInvitationUnavailable {
+		t.Errorf("accepting a revoked invitation: expected ErrInvitationUnavailable, got %v", err)
+	}
+}
+
+func TestInvitationService_CreateValidates(t *testing.T) {
+	f := newInvitationFixture(t)
+	ctx := context.Background()
+	create := func(userID primitive.ObjectID, email, role string) error {
+		_, err := f.svc.Create(ctx, userID.Hex(), f.ledger.ID.Hex(), services.CreateInvitationRequest{Email: email, Role: role})
+		return err
+	}
+
+	if err := create(f.owner.ID, "not an address", models.RoleViewer); err != services.ErrInvalidEmail {
+		t.Errorf("bad email: expected ErrInvalidEmail, got %v", err)
+	}
+	if err := create(f.owner.ID, "sam@example.com", "admin"); err != services.ErrInvalidRole {
+		t.Errorf("bad role: expected ErrInvalidRole, got %v", err)
+	}
+	if err := create(f.owner.ID, "alex@example.com", models.RoleViewer); err != services.ErrAlreadyMember {
+		t.Errorf("inviting a member: expected ErrAlreadyMember, got %v", err)
+	}
+	f.ledger.Members = append(f.ledger.Members, models.LedgerMember{UserID: f.invitee.ID, Role: models.RoleEditor})
+	if err := create(f.invitee.ID, "someone@example.com", models.RoleViewer); err != services.ErrUnauthorized {
+		t.Errorf("editor inviting: expected ErrUnauthorized, got %v", err)
+	}
+	if n := len(f.smtp.Messages()); n != 0 {
+		t.Errorf("no mail should be sent for rejected invitations, got %d", n)
+	}
+}
+
+func TestInvitationService_DisabledWithoutMailer(t *testing.T) {
+	ledger := &models.Ledger{
+		ID:      primitive.NewObjectID(),
+		Members: []models.LedgerMember{{UserID: primitive.NewObjectID(), Role: models.RoleOwner}},
+	}
+	invRepo := &testutil.MockInvitationRepo{
+		CreateFn: func(context.Context, *models.Invitation) (*models.Invitation, error) {
+			t.Error("no invitation should be recorded while invitations are disabled")
+			return nil, nil
+		},
+	}
+	svc := services.NewInvitationService(invRepo, sharedLedgerRepo(ledger), &testutil.MockUserRepo{}, nil, services.InvitationConfig{
+		SigningKey: []byte("test-signing-key"),
+	})
+
+	_, err := svc.Create(context.Background(), ledger.Members[0].UserID.Hex(), ledger.ID.Hex(), services.CreateInvitationRequest{Email: "sam@example.com", Role: models.RoleEditor})
+	if err != services.ErrInvitationsDisabled {
+		t.Errorf("expected ErrInvitationsDisabled, got %v", err)
+	}
+}
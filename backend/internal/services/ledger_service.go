@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxLedgerNameLength = 100
+
+// CreateLedgerRequest holds the fields for a new ledger.
+type CreateLedgerRequest struct {
+	Name string `json:"name"`
+}
+
+// AddMemberRequest adds an existing user, found by email, to a ledger.
+type AddMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// LedgerSummary is a ledger together with the caller's role in it.
+type LedgerSummary struct {
+	*models.Ledger
+	Role string `json:"role"`
+}
+
+// LedgerService manages ledgers and their members. Owners manage members; a ledger
+// always keeps at least one owner.
+type LedgerService interface {
+	// List returns the user's ledgers, oldest first, creating their personal ledger on first use.
+	List(ctx context.Context, userID string) ([]*LedgerSummary, error)
+	// Create makes a new ledger owned by the user.
+	Create(ctx context.Context, userID string, req CreateLedgerRequest) (*models.Ledger, error)
+	Get(ctx context.Context, userID string, ledgerID string) (*models.Ledger, error)
+	Rename(ctx context.Context, userID string, ledgerID string, name string) (*models.Ledger, error)
+	AddMember(ctx context.Context, userID string, ledgerID string, req AddMemberRequest) (*models.Ledger, error)
+	SetMemberRole(ctx context.Context, userID string, ledgerID string, memberID string, role string) (*models.Ledger, error)
+	// RemoveMember removes a member; owners may remove anyone and every member may leave.
+	RemoveMember(ctx context.Context, userID string, ledgerID string, memberID string) error
+}
+
+// ledgerAccess resolves the ledger a request acts on and checks the caller's role in it.
+// Every service working on ledger data authorizes through it.
+type ledgerAccess struct {
+	ledgerRepo db.LedgerRepository
+}
+
+// authorize checks that userID holds at least the need role in ledgerID and returns both
+// parsed IDs. An empty ledgerID selects the user's default ledger. Non-members get
+// ErrLedgerNotFound, so ledger IDs cannot be probed; weaker roles get ErrUnauthorized.
+func (a ledgerAccess) authorize(ctx context.Context, userID, ledgerID, need string) (primitive.ObjectID, primitive.ObjectID, error) {
+	uid, ledger, err := a.authorizeLedger(ctx, userID, ledgerID, need)
+	if err != nil {
+		return uid, primitive.NilObjectID, err
+	}
+	return uid, ledger.ID, nil
+}
+
+// authorizeLedger is authorize for callers that need the ledger itself, such as its members.
+func (a ledgerAccess) authorizeLedger(ctx context.Context, userID, ledgerID, need string) (primitive.ObjectID, *models.Ledger, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return uid, nil, ErrInvalidID
+	}
+	ledger, err := a.load(ctx, uid, ledgerID)
+	if err != nil {
+		return uid, nil, err
+	}
+	if !models.RoleAtLeast(ledger.RoleOf(uid), need) {
+		return uid, nil, ErrUnauthorized
+	}
+	return uid, ledger, nil
+}
+
+// load fetches a ledger the user belongs to, or their default ledger if ledgerID is empty.
+func (a ledgerAccess) load(ctx context.Context, uid primitive.ObjectID, ledgerID string) (*models.Ledger, error) {
+	if ledgerID == "" {
+		return a.defaultLedger(ctx, uid)
+	}
+	lid, err := primitive.ObjectIDFromHex(ledgerID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	ledger, err := a.ledgerRepo.FindByID(ctx, lid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching ledger: %w", err)
+	}
+	if ledger == nil || ledger.RoleOf(uid) == "" {
+		return nil, ErrLedgerNotFound
+	}
+	return ledger, nil
+}
+
+// defaultLedger returns the oldest ledger the user owns, creating a personal ledger for
+// users who own none.
+func (a ledgerAccess) defaultLedger(ctx context.Context, uid primitive.ObjectID) (*models.Ledger, error) {
+	ledgers, err := a.ledgerRepo.FindByMember(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching ledgers: %w", err)
+	}
+	for _, l := range ledgers {
+		if l.RoleOf(uid) == models.RoleOwner {
+			return l, nil
+		}
+	}
+	return a.personalLedger(ctx, uid)
+}
+
+// personalLedger returns the personal ledger of a user who owns no ledger, creating it on
+// first use. Concurrent first requests all get the same ledger.
+func (a ledgerAccess) personalLedger(ctx context.Context, uid primitive.ObjectID) (*models.Ledger, error) {
+	ledger, err := a.ledgerRepo.UpsertPersonal(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("creating personal ledger: %w", err)
+	}
+	return ledger, nil
+}
+
+func (a ledgerAccess) create(ctx context.Context, uid primitive.ObjectID, name string) (*models.Ledger, error) {
+	created, err := a.ledgerRepo.Create(ctx, &models.Ledger{
+		Name:    name,
+		Members: []models.LedgerMember{{UserID: uid, Role: models.RoleOwner, AddedAt: time.Now()}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating ledger: %w", err)
+	}
+	return created, nil
+}
+
+type ledgerService struct {
+	ledgerAccess
+	userRepo db.UserRepository
+}
+
+// NewLedgerService creates a new LedgerService.
+func NewLedgerService(ledgerRepo db.LedgerRepository, userRepo db.UserRepository) LedgerService {
+	return &ledgerService{ledgerAccess: ledgerAccess{ledgerRepo: ledgerRepo}, userRepo: userRepo}
+}
+
+func (s *ledgerService) List(ctx context.Context, userID string) ([]*LedgerSummary, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	ledgers, err := s.ledgerRepo.FindByMember(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching ledgers: %w", err)
+	}
+	if !ownsAny(ledgers, uid) {
+		personal, err := s.personalLedger(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+		ledgers = append([]*models.Ledger{personal}, ledgers...)
+	}
+
+	summaries := make([]*LedgerSummary, len(ledgers))
+	for i, l := range ledgers {
+		summaries[i] = &LedgerSummary{Ledger: l, Role: l.RoleOf(uid)}
+	}
+	return summaries, nil
+}
+
+func (s *ledgerService) Create(ctx context.Context, userID string, req CreateLedgerRequest) (*models.Ledger, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	name, err := ledgerName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	return s.create(ctx, uid, name)
+}
+
+func (s *ledgerService) Get(ctx context.Context, userID string, ledgerID string) (*models.Ledger, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	return s.load(ctx, uid, ledgerID)
+}
+
+func (s *ledgerService) Rename(ctx context.Context, userID string, ledgerID string, name string) (*models.Ledger, error) {
+	name, err := ledgerName(name)
+	if err != nil {
+		return nil, err
+	}
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.ledgerRepo.Rename(ctx, lid, name)
+	if err != nil {
+		return nil, ledgerRepoError("renaming ledger", err)
+	}
+	return updated, nil
+}
+
+func (s *ledgerService) AddMember(ctx context.Context, userID string, ledgerID string, req AddMemberRequest) (*models.Ledger, error) {
+	if !models.ValidRole(req.Role) {
+		return nil, ErrInvalidRole
+	}
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	// Only a provider-verified address identifies a user: the profile email is editable, so
+	// matching it would let anyone be added in place of its real owner. An address with no
+	// verified account gets the same ErrNotFound whether or not someone uses it as a profile email.
+	user, err := s.userRepo.FindByVerifiedEmail(ctx, strings.TrimSpace(req.Email))
+	if err != nil {
+		return nil, fmt.Errorf("finding user by email: %w", err)
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+
+	updated, err := s.ledgerRepo.AddMember(ctx, lid, models.LedgerMember{UserID: user.ID, Role: req.Role, AddedAt: time.Now()})
+	if err != nil {
+		return nil, ledgerRepoError("adding member", err)
+	}
+	return updated, nil
+}
+
+func (s *ledgerService) SetMemberRole(ctx context.Context, userID string, ledgerID string, memberID string, role string) (*models.Ledger, error) {
+	if !models.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	mid, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	ledger, err := s.load(ctx, uid, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	if ledger.RoleOf(uid) != models.RoleOwner {
+		return nil, ErrUnauthorized
+	}
+
+	// The repository refuses to demote the last owner in the same write, so two owners
+	// demoting each other at once cannot both succeed.
+	updated, err := s.ledgerRepo.SetMemberRole(ctx, ledger.ID, mid, role)
+	if err != nil {
+		return nil, ledgerRepoError("changing member role", err)
+	}
+	return updated, nil
+}
+
+func (s *ledgerService) RemoveMember(ctx context.Context, userID string, ledgerID string, memberID string) error {
+	mid, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	ledger, err := s.load(ctx, uid, ledgerID)
+	if err != nil {
+		return err
+	}
+	if mid != uid && ledger.RoleOf(uid) != models.RoleOwner {
+		return ErrUnauthorized
+	}
+
+	// As in SetMemberRole, the last-owner rule is enforced by the write itself.
+	if _, err := s.ledgerRepo.RemoveMember(ctx, ledger.ID, mid); err != nil {
+		return ledgerRepoError("removing member", err)
+	}
+	return nil
+}
+
+func ownsAny(ledgers []*models.Ledger, uid primitive.ObjectID) bool {
+	for _, l := range ledgers {
+		if l.RoleOf(uid) == models.RoleOwner {
+			return true
+		}
+	}
+	return false
+}
+
+func ledgerName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxLedgerNameLength {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+func ledgerRepoError(op string, err error) error {
+	switch err {
+	case db.ErrNotFound:
+		return ErrNotFound
+	case db.ErrDuplicate:
+		return ErrAlreadyMember
+	case db.ErrLastOwner:
+		return ErrLastOwner
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
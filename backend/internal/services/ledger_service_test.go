@@ -0,0 +1,191 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// testLedgerID is the personal ledger services resolve to when tests pass no ledger ID.
+var testLedgerID = primitive.NewObjectID()
+
+// sharedLedgerRepo serves a single ledger with the given members.
+func sharedLedgerRepo(ledger *models.Ledger) *testutil.MockLedgerRepo {
+	return &testutil.MockLedgerRepo{
+		FindByIDFn: func(_ context.Context, id primitive.ObjectID) (*models.Ledger, error) {
+			if id == ledger.ID {
+				return ledger, nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+func TestLedgerService_List_CreatesPersonalLedger(t *testing.T) {
+	userID := primitive.NewObjectID()
+	var personal *models.Ledger
+
+	repo := &testutil.MockLedgerRepo{
+		UpsertPersonalFn: func(_ context.Context, uid primitive.ObjectID) (*models.Ledger, error) {
+			if personal == nil {
+				personal = &models.Ledger{
+					ID:            primitive.NewObjectID(),
+					Name:          models.PersonalLedgerName,
+					Members:       []models.LedgerMember{{UserID: uid, Role: models.RoleOwner}},
+					PersonalOwner: &uid,
+				}
+			}
+			return personal, nil
+		},
+		CreateFn: func(context.Context, *models.Ledger) (*models.Ledger, error) {
+			t.Fatal("the personal ledger must be upserted, not created")
+			return nil, nil
+		},
+	}
+
+	svc := services.NewLedgerService(repo, &testutil.MockUserRepo{})
+	ledgers, err := svc.List(context.Background(), userID.Hex())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if personal == nil || personal.RoleOf(userID) != models.RoleOwner {
+		t.Fatalf("expected a personal ledger owned by the user, got %+v", personal)
+	}
+	if len(ledgers) != 1 || ledgers[0].Role != models.RoleOwner {
+		t.Errorf("ledgers: got %+v", ledgers)
+	}
+
+	// A request that also finds no owned ledger resolves to the same one.
+	again, err := svc.Get(context.Background(), userID.Hex(), "")
+	if err != nil || again.ID != personal.ID {
+		t.Errorf("expected the same personal ledger, got %+v, %v", again, err)
+	}
+}
+
+func TestLedgerService_RolesGateLedgerData(t *testing.T) {
+	viewerID := primitive.NewObjectID()
+	ledger := &models.Ledger{
+		ID: primitive.NewObjectID(),
+		Members: []models.LedgerMember{
+			{UserID: primitive.NewObjectID(), Role: models.RoleOwner},
+			{UserID: viewerID, Role: models.RoleViewer},
+		},
+	}
+	var listed primitive.ObjectID
+	txRepo := &testutil.MockTransactionRepo{
+		FindByLedgerIDFn: func(_ context.Context, lid primitive.ObjectID, _, _ int) ([]*models.Transaction, int64, error) {
+			listed = lid
+			return nil, 0, nil
+		},
+	}
+	svc := services.NewTransactionService(txRepo, &testutil.MockCategoryRepo{}, sharedLedgerRepo(ledger), &testutil.MockAuditRepo{})
+
+	if _, err := svc.List(context.Background(), viewerID.Hex(), ledger.ID.Hex(), 1, 20); err != nil {
+		t.Fatalf("viewer List: %v", err)
+	}
+	if listed != ledger.ID {
+		t.Errorf("listed ledger %s, want %s", listed.Hex(), ledger.ID.Hex())
+	}
+
+	_, err := svc.Create(context.Background(), viewerID.Hex(), ledger.ID.Hex(), services.CreateTransactionRequest{
+		CategoryID: primitive.NewObjectID().Hex(), Type: "outflow", Amount: 5,
+	})
+	if err != services.ErrUnauthorized {
+		t.Errorf("viewer Create: expected ErrUnauthorized, got %v", err)
+	}
+
+	outsider := primitive.NewObjectID()
+	if _, err := svc.List(context.Background(), outsider.Hex(), ledger.ID.Hex(), 1, 20); err != services.ErrLedgerNotFound {
+		t.Errorf("non-member List: expected ErrLedgerNotFound, got %v", err)
+	}
+}
+
+func TestLedgerService_KeepsLastOwner(t *testing.T) {
+	ownerID := primitive.NewObjectID()
+	editorID := primitive.NewObjectID()
+	ledger := &models.Ledger{
+		ID: primitive.NewObjectID(),
+		Members: []models.LedgerMember{
+			{UserID: ownerID, Role: models.RoleOwner},
+			{UserID: editorID, Role: models.RoleEditor},
+		},
+	}
+	// The repository enforces the rule in its write; the service maps its error.
+	repo := sharedLedgerRepo(ledger)
+	repo.SetMemberRoleFn = func(context.Context, primitive.ObjectID, primitive.ObjectID, string) (*models.Ledger, error) {
+		return nil, db.ErrLastOwner
+	}
+	repo.RemoveMemberFn = func(context.Context, primitive.ObjectID, primitive.ObjectID) (*models.Ledger, error) {
+		return nil, db.ErrLastOwner
+	}
+	svc := services.NewLedgerService(repo, &testutil.MockUserRepo{})
+	ctx := context.Background()
+
+	if _, err := svc.SetMemberRole(ctx, ownerID.Hex(), ledger.ID.Hex(), ownerID.Hex(), models.RoleEditor); err != services.ErrLastOwner {
+		t.Errorf("demoting the last owner: expected ErrLastOwner, got %v", err)
+	}
+	if err := svc.RemoveMember(ctx, ownerID.Hex(), ledger.ID.Hex(), ownerID.Hex()); err != services.ErrLastOwner {
+		t.Errorf("last owner leaving: expected ErrLastOwner, got %v", err)
+	}
+	if err := svc.RemoveMember(ctx, editorID.Hex(), ledger.ID.Hex(), ownerID.Hex()); err != services.ErrUnauthorized {
+		t.Errorf("editor removing the owner: expected ErrUnauthorized, got %v", err)
+	}
+	if _, err := svc.SetMemberRole(ctx, ownerID.Hex(), ledger.ID.Hex(), editorID.Hex(), "admin"); err != services.ErrInvalidRole {
+		t.Errorf("unknown role: expected ErrInvalidRole, got %v", err)
+	}
+}
+
+func TestLedgerService_AddMember(t *testing.T) {
+	ownerID := primitive.NewObjectID()
+	invitee := &models.User{ID: primitive.NewObjectID(), Email: "sam@example.com"}
+	ledger := &models.Ledger{
+		ID:      primitive.NewObjectID(),
+		Members: []models.LedgerMember{{UserID: ownerID, Role: models.RoleOwner}},
+	}
+
+	var added models.LedgerMember
+	repo := sharedLedgerRepo(ledger)
+	repo.AddMemberFn = func(_ context.Context, _ primitive.ObjectID, m models.LedgerMember) (*models.Ledger, error) {
+		if m.UserID == added.UserID {
+			return nil, db.ErrDuplicate
+		}
+		added = m
+		return ledger, nil
+	}
+	userRepo := &testutil.MockUserRepo{
+		FindByVerifiedEmailFn: func(_ context.Context, email string) (*models.User, error) {
+			if email == invitee.Email {
+				return invitee, nil
+			}
+			return nil, nil
+		},
+		FindByEmailFn: func(_ context.Context, _ string) (*models.User, error) {
+			t.Error("members must be matched on a verified email, not the profile email")
+			return invitee, nil
+		},
+	}
+	svc := services.NewLedgerService(repo, userRepo)
+	ctx := context.Background()
+	req := services.AddMemberRequest{Email: " sam@example.com ", Role: models.RoleEditor}
+
+	if _, err := svc.AddMember(ctx, ownerID.Hex(), ledger.ID.Hex(), req); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	if added.UserID != invitee.ID || added.Role != models.RoleEditor || added.AddedAt.After(time.Now()) {
+		t.Errorf("added member: got %+v", added)
+	}
+	if _, err := svc.AddMember(ctx, ownerID.Hex(), ledger.ID.Hex(), req); err != services.ErrAlreadyMember {
+		t.Errorf("adding twice: expected ErrAlreadyMember, got %v", err)
+	}
+	req.Email = "nobody@example.com"
+	if _, err := svc.AddMember(ctx, ownerID.Hex(), ledger.ID.Hex(), req); err != services.ErrNotFound {
+		t.Errorf("unknown email: expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+)
+
+func TestSMTPMailer_DeliversToServer(t *testing.T) {
+	server := testutil.NewFakeSMTPServer(t)
+	mailer := services.NewSMTPMailer(services.SMTPConfig{Addr: server.Addr, From: "no-reply@expensify.test"})
+
+	err := mailer.Send(context.Background(), services.Message{
+		To:      "sam@example.com",
+		Subject: "Welcome",
+		Body:    "Hello Sam,\n.\nA line that starts with a dot survives.",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	msgs := server.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(msgs))
+	}
+	got := msgs[0]
+	if got.From != "no-reply@expensify.test" || len(got.To) != 1 || got.To[0] != "sam@example.com" {
+		t.Errorf("envelope: got from %q to %v", got.From, got.To)
+	}
+	for _, want := range []string{"Subject: Welcome\r\n", "To: sam@example.com\r\n", "Hello Sam,\r\n.\r\nA line that starts"} {
+		if !strings.Contains(got.Data, want) {
+			t.Errorf("message missing %q:\n%s", want, got.Data)
+		}
+	}
+}
+
+func TestSMTPMailer_RejectsHeaderInjection(t *testing.T) {
+	server := testutil.NewFakeSMTPServer(t)
+	mailer := services.NewSMTPMailer(services.SMTPConfig{Addr: server.Addr, From: "no-reply@expensify.test"})
+
+	err := mailer.Send(context.Background(), services.Message{To: "sam@example.com\r\nBcc: eve@example.com", Subject: "Hi"})
+	if err == nil {
+		t.Fatal("expected an error for a recipient containing a line break")
+	}
+	if n := len(server.Messages()); n != 0 {
+		t.Errorf("nothing should be sent, got %d messages", n)
+	}
+}
@@ -0,0 +1,202 @@
+package services_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"expensify/internal/services"
+)
+
+// fakeIssuer is an in-process OpenID Connect issuer that hands out whatever ID token
+// the test sets, signed with its RSA key.
+type fakeIssuer struct {
+	*httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	f := &fakeIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"jwks_uri":               f.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "k1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     f.sign(t, "k1", f.claims),
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeIssuer) sign(t *testing.T, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("signing: %v", err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (f *fakeIssuer) validClaims(nonce string) map[string]any {
+	return map[string]any{
+		"iss":            f.URL,
+		"sub":            "user-7",
+		"aud":            "expensify",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "erin@example.com",
+		"email_verified": true,
+		"name":           "Erin",
+	}
+}
+
+func newTestOIDCProvider(t *testing.T, f *fakeIssuer) services.IdentityProvider {
+	t.Helper()
+	p, err := services.NewOIDCProvider(context.Background(), services.OIDCConfig{
+		Name:        "keycloak",
+		Issuer:      f.URL,
+		ClientID:    "expensify",
+		RedirectURL: "http://localhost:8080/auth/keycloak/callback",
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCProvider: %v", err)
+	}
+	return p
+}
+
+func TestOIDCProvider_AuthCodeURL(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestOIDCProvider(t, f)
+
+	u, err := url.Parse(p.AuthCodeURL("st", "no"))
+	if err != nil {
+		t.Fatalf("parsing auth URL: %v", err)
+	}
+	q := u.Query()
+	if !strings.HasPrefix(u.String(), f.URL+"/authorize") {
+		t.Errorf("auth URL should use the discovered endpoint, got %s", u)
+	}
+	if q.Get("state") != "st" || q.Get("nonce") != "no" || q.Get("client_id") != "expensify" {
+		t.Errorf("unexpected auth URL query: %v", q)
+	}
+	if q.Get("scope") != "openid email profile" {
+		t.Errorf("scope: got %q", q.Get("scope"))
+	}
+}
+
+func TestOIDCProvider_Exchange_Valid(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestOIDCProvider(t, f)
+	f.claims = f.validClaims("n-1")
+
+	identity, err := p.Exchange(context.Background(), "code", "n-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Provider != "keycloak" || identity.Subject != "user-7" {
+		t.Errorf("identity: got %s/%s", identity.Provider, identity.Subject)
+	}
+	if identity.Email != "erin@example.com" || !identity.EmailVerified || identity.Name != "Erin" {
+		t.Errorf("profile claims not mapped: %+v", identity)
+	}
+}
+
+func TestOIDCProvider_Exchange_RejectsInvalidTokens(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestOIDCProvider(t, f)
+
+	cases := []struct {
+		name   string
+		mutate func(map[string]any)
+		nonce  string
+	}{
+		{"wrong nonce", func(map[string]any) {}, "other"},
+		{"wrong audience", func(c map[string]any) { c["aud"] = "someone-else" }, "n"},
+		{"wrong issuer", func(c map[string]any) { c["iss"] = "https://evil.example.com" }, "n"},
+		{"expired", func(c map[string]any) { c["exp"] = time.Now().Add(-time.Hour).Unix() }, "n"},
+		{"missing subject", func(c map[string]any) { delete(c, "sub") }, "n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := f.validClaims("n")
+			tc.mutate(claims)
+			f.claims = claims
+
+			_, err := p.Exchange(context.Background(), "code", tc.nonce)
+			if !errors.Is(err, services.ErrInvalidIDToken) {
+				t.Errorf("expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestOIDCProvider_Exchange_RejectsForeignSignature(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestOIDCProvider(t, f)
+
+	// Same kid, different key: the signature must not verify.
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	f.key = other
+	f.claims = f.validClaims("n")
+
+	if _, err := p.Exchange(context.Background(), "code", "n"); !errors.Is(err, services.ErrInvalidIDToken) {
+		t.Errorf("expected ErrInvalidIDToken, got %v", err)
+	}
+}
+
+func TestNewOIDCProvider_IssuerMismatch(t *testing.T) {
+	f := newFakeIssuer(t)
+	_, err := services.NewOIDCProvider(context.Background(), services.OIDCConfig{
+		Name:     "keycloak",
+		Issuer:   f.URL + "/realms/other",
+		ClientID: "expensify",
+	})
+	if err == nil {
+		t.Error("expected an error when discovery is not found or the issuer does not match")
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/language"
+)
+
+const maxPageSize = 100
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// UpdatePreferencesRequest replaces all of a user's preferences.
+type UpdatePreferencesRequest struct {
+	Currency               string `json:"currency"`
+	TimeZone               string `json:"time_zone"`
+	Locale                 string `json:"locale"`
+	FirstDayOfWeek         int    `json:"first_day_of_week"`
+	DefaultTransactionType string `json:"default_transaction_type"`
+	DefaultPageSize        int    `json:"default_page_size"`
+}
+
+// PreferencesService manages per-user display settings and input defaults.
+type PreferencesService interface {
+	// Get returns the user's preferences, or the defaults if none have been saved.
+	Get(ctx context.Context, userID string) (*models.Preferences, error)
+	// Update validates and stores the preferences; the time zone is saved on the user.
+	Update(ctx context.Context, userID string, req UpdatePreferencesRequest) (*models.Preferences, error)
+}
+
+type preferencesService struct {
+	repo     db.PreferencesRepository
+	userRepo db.UserRepository
+}
+
+// NewPreferencesService creates a new PreferencesService.
+func NewPreferencesService(repo db.PreferencesRepository, userRepo db.UserRepository) PreferencesService {
+	return &preferencesService{repo: repo, userRepo: userRepo}
+}
+
+// DefaultPreferences returns the preferences of a user who has not saved any.
+func DefaultPreferences() *models.Preferences {
+	return &models.Preferences{
+		Currency:               "USD",
+		Locale:                 "en-US",
+		FirstDayOfWeek:         1,
+		DefaultTransactionType: "outflow",
+		DefaultPageSize:        20,
+	}
+}
+
+func (s *preferencesService) Get(ctx context.Context, userID string) (*models.Preferences, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	user, err := s.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+
+	prefs, err := s.repo.FindByUserID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching preferences: %w", err)
+	}
+	if prefs == nil {
+		prefs = DefaultPreferences()
+		prefs.UserID = uid
+	}
+	prefs.TimeZone = user.TimeZone
+	return prefs, nil
+}
+
+func (s *preferencesService) Update(ctx context.Context, userID string, req UpdatePreferencesRequest) (*models.Preferences, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	if err := validatePreferences(req); err != nil {
+		return nil, err
+	}
+	locale, _ := language.Parse(req.Locale)
+
+	user, err := s.userRepo.FindByID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching user: %w", err)
+	}
+	if user == nil {
+		return nil, ErrNotFound
+	}
+	if user.TimeZone != req.TimeZone {
+		if _, err := s.userRepo.UpdateTimeZone(ctx, uid, req.TimeZone); err != nil {
+			return nil, fmt.Errorf("updating time zone: %w", err)
+		}
+	}
+
+	saved, err := s.repo.Upsert(ctx, &models.Preferences{
+		UserID:                 uid,
+		Currency:               req.Currency,
+		Locale:                 locale.String(),
+		FirstDayOfWeek:         req.FirstDayOfWeek,
+		DefaultTransactionType: req.DefaultTransactionType,
+		DefaultPageSize:        req.DefaultPageSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("saving preferences: %w", err)
+	}
+	saved.TimeZone = req.TimeZone
+	return saved, nil
+}
+
+func validatePreferences(req UpdatePreferencesRequest) error {
+	if !currencyCode.MatchString(req.Currency) {
+		return fmt.Errorf("%w: currency must be a three-letter ISO 4217 code", ErrInvalidPreferences)
+	}
+	if _, err := LoadUserLocation(req.TimeZone); err != nil {
+		return err
+	}
+	if _, err := language.Parse(req.Locale); err != nil || req.Locale == "" {
+		return fmt.Errorf("%w: locale must be a BCP 47 language tag", ErrInvalidPreferences)
+	}
+	if req.FirstDayOfWeek < 0 || req.FirstDayOfWeek > 6 {
+		return fmt.Errorf("%w: first_day_of_week must be 0 (Sunday) to 6 (Saturday)", ErrInvalidPreferences)
+	}
+	if req.DefaultTransactionType != "inflow" && req.DefaultTransactionType != "outflow" {
+		return ErrInvalidTransactionType
+	}
+	if req.DefaultPageSize < 1 || req.DefaultPageSize > maxPageSize {
+		return fmt.Errorf("%w: default_page_size must be between 1 and %d", ErrInvalidPreferences, maxPageSize)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func validPreferencesRequest() services.UpdatePreferencesRequest {
+	return services.UpdatePreferencesRequest{
+		Currency:               "EUR",
+		TimeZone:               "Europe/Berlin",
+		Locale:                 "de-DE",
+		FirstDayOfWeek:         1,
+		DefaultTransactionType: "outflow",
+		DefaultPageSize:        50,
+	}
+}
+
+func TestPreferencesService_Get_DefaultsWithUserTimeZone(t *testing.T) {
+	userID := primitive.NewObjectID()
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(_ context.Context, id primitive.ObjectID) (*models.User, error) {
+			return &models.User{ID: id, TimeZone: "America/Chicago"}, nil
+		},
+	}
+	svc := services.NewPreferencesService(&testutil.MockPreferencesRepo{}, userRepo)
+
+	prefs, err := svc.Get(context.Background(), userID.Hex())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if prefs.Currency != "USD" || prefs.DefaultPageSize != 20 || prefs.DefaultTransactionType != "outflow" {
+		t.Errorf("expected defaults, got %+v", prefs)
+	}
+	if prefs.TimeZone != "America/Chicago" {
+		t.Errorf("time zone should come from the user, got %q", prefs.TimeZone)
+	}
+}
+
+func TestPreferencesService_Update_SavesTimeZoneOnUser(t *testing.T) {
+	userID := primitive.NewObjectID()
+	var savedZone string
+	var saved *models.Preferences
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(_ context.Context, id primitive.ObjectID) (*models.User, error) {
+			return &models.User{ID: id}, nil
+		},
+		UpdateTimeZoneFn: func(_ context.Context, id primitive.ObjectID, tz string) (*models.User, error) {
+			savedZone = tz
+			return &models.User{ID: id, TimeZone: tz}, nil
+		},
+	}
+	prefsRepo := &testutil.MockPreferencesRepo{
+		UpsertFn: func(_ context.Context, p *models.Preferences) (*models.Preferences, error) {
+			saved = p
+			return p, nil
+		},
+	}
+	svc := services.NewPreferencesService(prefsRepo, userRepo)
+
+	req := validPreferencesRequest()
+	req.Locale = "de-de"
+	prefs, err := svc.Update(context.Background(), userID.Hex(), req)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if savedZone != "Europe/Berlin" || prefs.TimeZone != "Europe/Berlin" {
+		t.Errorf("time zone should be stored on the user, got %q", savedZone)
+	}
+	if saved == nil || saved.UserID != userID || saved.Currency != "EUR" || saved.DefaultPageSize != 50 {
+		t.Errorf("unexpected stored preferences: %+v", saved)
+	}
+	if saved.Locale != "de-DE" {
+		t.Errorf("locale should be canonicalized, got %q", saved.Locale)
+	}
+}
+
+func TestPreferencesService_Update_Validation(t *testing.T) {
+	cases := []struct {
+		name   string
+		mutate func(*services.UpdatePreferencesRequest)
+		want   error
+	}{
+		{"lowercase currency", func(r *services.UpdatePreferencesRequest) { r.Currency = "eur" }, services.ErrInvalidPreferences},
+		{"unknown time zone", func(r *services.UpdatePreferencesRequest) { r.TimeZone = "Mars/Olympus" }, services.ErrInvalidTimeZone},
+		{"bad locale", func(r *services.UpdatePreferencesRequest) { r.Locale = "not a locale" }, services.ErrInvalidPreferences},
+		{"first day out of range", func(r *services.UpdatePreferencesRequest) { r.FirstDayOfWeek = 7 }, services.ErrInvalidPreferences},
+		{"unknown type", func(r *services.UpdatePreferencesRequest) { r.DefaultTransactionType = "transfer" }, services.ErrInvalidTransactionType},
+		{"page size too large", func(r *services.UpdatePreferencesRequest) { r.DefaultPageSize = 500 }, services.ErrInvalidPreferences},
+	}
+	svc := services.NewPreferencesService(&testutil.MockPreferencesRepo{}, &testutil.MockUserRepo{})
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := validPreferencesRequest()
+			tc.mutate(&req)
+			if _, err := svc.Update(context.Background(), primitive.NewObjectID().Hex(), req); !errors.Is(err, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
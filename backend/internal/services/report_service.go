@@ -0,0 +1,749 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultMoverCount = 5
+
+// Period is a half-open time range [Since, Until).
+type Period struct {
+	Since time.Time `json:"since"`
+	Until time.Time `json:"until"`
+}
+
+// PeriodTotals holds the cashflow totals and monthly breakdown for one period.
+type PeriodTotals struct {
+	Period
+	Inflow  float64         `json:"inflow"`
+	Outflow float64         `json:"outflow"`
+	Net     float64         `json:"net"`
+	Monthly []*MonthlyPoint `json:"monthly"`
+}
+
+// CategoryComparison holds a category's totals in both periods and how they changed.
+// DeltaPct is nil when the previous total is zero and a percentage is undefined.
+type CategoryComparison struct {
+	CategoryID    string   `json:"category_id"`
+	CategoryName  string   `json:"category_name"`
+	CategoryColor string   `json:"category_color"`
+	CategoryIcon  string   `json:"category_icon"`
+	Type          string   `json:"type"`
+	Current       float64  `json:"current"`
+	Previous      float64  `json:"previous"`
+	Delta         float64  `json:"delta"`
+	DeltaPct      *float64 `json:"delta_pct"`
+}
+
+// ComparisonReport compares a current period against a previous one.
+type ComparisonReport struct {
+	Current       *PeriodTotals         `json:"current"`
+	Previous      *PeriodTotals         `json:"previous"`
+	ByCategory    []*CategoryComparison `json:"by_category"`
+	BiggestMovers []*CategoryComparison `json:"biggest_movers"`
+}
+
+// ReportService produces analytical reports over a ledger's transactions. Every method
+// needs the viewer role in ledgerID (the user's default ledger if empty).
+type ReportService interface {
+	// Compare returns per-category totals for both periods with absolute and percent deltas.
+	// Months are bucketed in loc (nil means UTC); movers limits biggest_movers (<= 0 uses the default).
+	Compare(ctx context.Context, userID, ledgerID string, current, previous Period, loc *time.Location, movers int) (*ComparisonReport, error)
+	// Forecast projects per-category inflow and outflow for the rest of asOf's month and the months
+	// after it from historical averages. Months are bucketed in asOf's location.
+	Forecast(ctx context.Context, userID, ledgerID string, asOf time.Time, opts ForecastOptions) (*CashflowForecast, error)
+	// Statistics describes each category's monthly totals over the complete months before asOf and
+	// flags months and transactions that are statistical outliers. Months are bucketed in asOf's location.
+	Statistics(ctx context.Context, userID, ledgerID string, asOf time.Time, opts StatisticsOptions) (*SpendingStatistics, error)
+}
+
+type reportService struct {
+	ledgerAccess
+	txRepo  db.TransactionRepository
+	catRepo db.CategoryRepository
+}
+
+// NewReportService creates a new ReportService.
+func NewReportService(txRepo db.TransactionRepository, catRepo db.CategoryRepository, ledgerRepo db.LedgerRepository) ReportService {
+	return &reportService{ledgerAccess: ledgerAccess{ledgerRepo: ledgerRepo}, txRepo: txRepo, catRepo: catRepo}
+}
+
+func (s *reportService) Compare(ctx context.Context, userID, ledgerID string, current, previous Period, loc *time.Location, movers int) (*ComparisonReport, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	if !validPeriod(current) || !validPeriod(previous) {
+		return nil, ErrInvalidPeriod
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	if movers <= 0 {
+		movers = defaultMoverCount
+	}
+
+	curTotals, err := s.periodTotals(ctx, lid, current, loc)
+	if err != nil {
+		return nil, err
+	}
+	prevTotals, err := s.periodTotals(ctx, lid, previous, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		id  primitive.ObjectID
+		typ string
+	}
+	rows := make(map[key]*CategoryComparison)
+	order := make([]key, 0)
+	ids := make(map[primitive.ObjectID]struct{})
+	for _, txType := range []string{"outflow", "inflow"} {
+		curAggs, err := s.txRepo.GetCategoryTotals(ctx, lid, txType, current.Since, current.Until)
+		if err != nil {
+			return nil, fmt.Errorf("current %s category totals: %w", txType, err)
+		}
+		prevAggs, err := s.txRepo.GetCategoryTotals(ctx, lid, txType, previous.Since, previous.Until)
+		if err != nil {
+			return nil, fmt.Errorf("previous %s category totals: %w", txType, err)
+		}
+
+		row := func(id primitive.ObjectID) *CategoryComparison {
+			k := key{id, txType}
+			if r, ok := rows[k]; ok {
+				return r
+			}
+			r := &CategoryComparison{CategoryID: id.Hex(), Type: txType}
+			rows[k] = r
+			order = append(order, k)
+			ids[id] = struct{}{}
+			return r
+		}
+		for _, a := range curAggs {
+			row(a.CategoryID).Current = a.Total
+		}
+		for _, a := range prevAggs {
+			row(a.CategoryID).Previous = a.Total
+		}
+	}
+
+	catMap := fetchCategoryMap(ctx, s.catRepo, lid, ids)
+	byCategory := make([]*CategoryComparison, 0, len(order))
+	for _, k := range order {
+		r := rows[k]
+		r.Delta = r.Current - r.Previous
+		if r.Previous != 0 {
+			pct := r.Delta / r.Previous * 100
+			r.DeltaPct = &pct
+		}
+		if cat, ok := catMap[k.id]; ok {
+			r.CategoryName = cat.Name
+			r.CategoryColor = cat.Color
+			r.CategoryIcon = cat.Icon
+		}
+		byCategory = append(byCategory, r)
+	}
+
+	// by_category: outflow first, then by current total descending.
+	sort.SliceStable(byCategory, func(i, j int) bool {
+		if byCategory[i].Type != byCategory[j].Type {
+			return byCategory[i].Type == "outflow"
+		}
+		return byCategory[i].Current > byCategory[j].Current
+	})
+
+	biggest := make([]*CategoryComparison, 0, len(byCategory))
+	for _, r := range byCategory {
+		if r.Delta != 0 {
+			biggest = append(biggest, r)
+		}
+	}
+	sort.SliceStable(biggest, func(i, j int) bool {
+		return math.Abs(biggest[i].Delta) > math.Abs(biggest[j].Delta)
+	})
+	if len(biggest) > movers {
+		biggest = biggest[:movers]
+	}
+
+	return &ComparisonReport{
+		Current:       curTotals,
+		Previous:      prevTotals,
+		ByCategory:    byCategory,
+		BiggestMovers: biggest,
+	}, nil
+}
+
+func (s *reportService) periodTotals(ctx context.Context, ledgerID primitive.ObjectID, p Period, loc *time.Location) (*PeriodTotals, error) {
+	aggs, err := s.txRepo.GetMonthlySummary(ctx, ledgerID, p.Since, p.Until, loc.String())
+	if err != nil {
+		return nil, fmt.Errorf("monthly summary: %w", err)
+	}
+	totals := &PeriodTotals{Period: p, Monthly: make([]*MonthlyPoint, len(aggs))}
+	for i, a := range aggs {
+		totals.Monthly[i] = &MonthlyPoint{Year: a.Year, Month: a.Month, Inflow: a.Inflow, Outflow: a.Outflow}
+		totals.Inflow += a.Inflow
+		totals.Outflow += a.Outflow
+	}
+	totals.Net = totals.Inflow - totals.Outflow
+	return totals, nil
+}
+
+func validPeriod(p Period) bool {
+	return !p.Since.IsZero() && !p.Until.IsZero() && p.Since.Before(p.Until)
+}
+
+const (
+	defaultForecastMonths   = 6
+	maxForecastMonths       = 24
+	defaultForecastLookback = 6
+	maxForecastLookback     = 36
+)
+
+// ForecastOptions controls how a cashflow forecast is projected.
+type ForecastOptions struct {
+	// Months is how many months after the current one to project (default 6, max 24).
+	Months int
+	// Lookback is how many complete past months feed the per-category averages (default 6, max 36).
+	Lookback int
+	// Seasonal projects each category from the same calendar month last year, instead of
+	// from the trailing average, when the ledger has any transactions in that month. Months
+	// before the ledger's history starts keep the trailing average.
+	Seasonal bool
+	// IncludeScheduled replaces the estimate for a category and month with the total of
+	// transactions already recorded with a date in that future month (for the current
+	// month, dated from asOf on).
+	IncludeScheduled bool
+	// OpeningBalance is the balance at the start of the first actual month.
+	OpeningBalance float64
+}
+
+// ForecastPoint is a MonthlyPoint with the month's net and the running balance.
+type ForecastPoint struct {
+	MonthlyPoint
+	Net     float64 `json:"net"`
+	Balance float64 `json:"balance"`
+}
+
+// CategoryForecast holds the projected monthly totals for one category and type.
+type CategoryForecast struct {
+	CategoryID    string                `json:"category_id"`
+	CategoryName  string                `json:"category_name"`
+	CategoryColor string                `json:"category_color"`
+	CategoryIcon  string                `json:"category_icon"`
+	Type          string                `json:"type"`
+	Average       float64               `json:"average"`
+	Current       *CategoryMonthPoint   `json:"current"`
+	Projected     []*CategoryMonthPoint `json:"projected"`
+}
+
+// CurrentMonthForecast is the month containing asOf: the transactions recorded before asOf
+// (ToDate) plus a projection for the rest of the month (Remainder).
+type CurrentMonthForecast struct {
+	ForecastPoint
+	ToDate    MonthlyPoint `json:"to_date"`
+	Remainder MonthlyPoint `json:"remainder"`
+}
+
+// CashflowForecast holds actual months from the lookback window, the current month and then
+// projected months, with one running balance across all three.
+type CashflowForecast struct {
+	Actual     []*ForecastPoint      `json:"actual"`
+	Current    *CurrentMonthForecast `json:"current"`
+	Projected  []*ForecastPoint      `json:"projected"`
+	ByCategory []*CategoryForecast   `json:"by_category"`
+}
+
+func (s *reportService) Forecast(ctx context.Context, userID, ledgerID string, asOf time.Time, opts ForecastOptions) (*CashflowForecast, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	opts.Months = clamp(opts.Months, defaultForecastMonths, maxForecastMonths)
+	opts.Lookback = clamp(opts.Lookback, defaultForecastLookback, maxForecastLookback)
+
+	loc := asOf.Location()
+	thisMonth := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, loc)
+	nextMonth := thisMonth.AddDate(0, 1, 0)
+	horizon := nextMonth.AddDate(0, opts.Months, 0)
+	lookbackStart := thisMonth.AddDate(0, -opts.Lookback, 0)
+
+	// The share of the current month still ahead, over which its estimate is prorated.
+	remaining := float64(nextMonth.Sub(asOf)) / float64(nextMonth.Sub(thisMonth))
+
+	// Seasonal projections need the same months last year, which may predate the lookback window.
+	historyStart := lookbackStart
+	if opts.Seasonal {
+		if seasonalStart := thisMonth.AddDate(-1, 0, 0); seasonalStart.Before(historyStart) {
+			historyStart = seasonalStart
+		}
+	}
+
+	// History runs up to asOf, so its last bucket is the current month to date; anything
+	// recorded from asOf on is scheduled.
+	history, err := s.txRepo.GetCategoryMonthlyTotals(ctx, lid, historyStart, asOf, loc.String())
+	if err != nil {
+		return nil, fmt.Errorf("historical category totals: %w", err)
+	}
+	var scheduled []*db.CategoryMonthlyAgg
+	if opts.IncludeScheduled {
+		scheduled, err = s.txRepo.GetCategoryMonthlyTotals(ctx, lid, asOf, horizon, loc.String())
+		if err != nil {
+			return nil, fmt.Errorf("scheduled category totals: %w", err)
+		}
+	}
+
+	type seriesKey struct {
+		id  primitive.ObjectID
+		typ string
+	}
+	histByKey := make(map[seriesKey]map[int]float64)
+	schedByKey := make(map[seriesKey]map[int]float64)
+	// recorded holds the months with any history, to tell a category that had nothing in a
+	// month from a month before anything was recorded.
+	recorded := make(map[int]bool)
+	keys := make([]seriesKey, 0)
+	ids := make(map[primitive.ObjectID]struct{})
+	collect := func(aggs []*db.CategoryMonthlyAgg, into map[seriesKey]map[int]float64) {
+		for _, a := range aggs {
+			k := seriesKey{a.CategoryID, a.Type}
+			if _, ok := histByKey[k]; !ok {
+				if _, ok := schedByKey[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			if into[k] == nil {
+				into[k] = make(map[int]float64)
+			}
+			into[k][monthKey(a.Year, time.Month(a.Month))] += a.Total
+			ids[a.CategoryID] = struct{}{}
+		}
+	}
+	collect(history, histByKey)
+	collect(scheduled, schedByKey)
+	for _, a := range history {
+		recorded[monthKey(a.Year, time.Month(a.Month))] = true
+	}
+
+	projectedMonths := monthRange(nextMonth, opts.Months)
+	projected := make([]*ForecastPoint, len(projectedMonths))
+	for i, m := range projectedMonths {
+		projected[i] = &ForecastPoint{MonthlyPoint: MonthlyPoint{Year: m.Year(), Month: int(m.Month())}}
+	}
+	current := &CurrentMonthForecast{}
+	current.Year, current.Month = thisMonth.Year(), int(thisMonth.Month())
+	current.ToDate = MonthlyPoint{Year: current.Year, Month: current.Month}
+	current.Remainder = MonthlyPoint{Year: current.Year, Month: current.Month}
+	add := func(p *MonthlyPoint, typ string, amount float64) {
+		switch typ {
+		case "inflow":
+			p.Inflow += amount
+		case "outflow":
+			p.Outflow += amount
+		}
+	}
+
+	catMap := fetchCategoryMap(ctx, s.catRepo, lid, ids)
+	byCategory := make([]*CategoryForecast, 0, len(keys))
+	for _, k := range keys {
+		hist := histByKey[k]
+		var sum float64
+		for _, m := range monthRange(lookbackStart, opts.Lookback) {
+			sum += hist[monthKey(m.Year(), m.Month())]
+		}
+		cf := &CategoryForecast{CategoryID: k.id.Hex(), Type: k.typ, Average: sum / float64(opts.Lookback)}
+		if cat, ok := catMap[k.id]; ok {
+			cf.CategoryName = cat.Name
+			cf.CategoryColor = cat.Color
+			cf.CategoryIcon = cat.Icon
+		}
+
+		// estimate is the expected total of month m, before scheduled transactions.
+		estimate := func(m time.Time) float64 {
+			if opts.Seasonal {
+				lastYear := m.AddDate(-1, 0, 0)
+				key := monthKey(lastYear.Year(), lastYear.Month())
+				if !lastYear.Before(historyStart) && lastYear.Before(thisMonth) && recorded[key] {
+					return hist[key]
+				}
+			}
+			return cf.Average
+		}
+
+		toDate := hist[monthKey(thisMonth.Year(), thisMonth.Month())]
+		rest := estimate(thisMonth) * remaining
+		if known, ok := schedByKey[k][monthKey(thisMonth.Year(), thisMonth.Month())]; ok {
+			rest = known
+		}
+		cf.Current = &CategoryMonthPoint{Year: current.Year, Month: current.Month, Total: toDate + rest}
+		add(&current.ToDate, k.typ, toDate)
+		add(&current.Remainder, k.typ, rest)
+
+		for i, m := range projectedMonths {
+			amount := estimate(m)
+			if known, ok := schedByKey[k][monthKey(m.Year(), m.Month())]; ok {
+				amount = known
+			}
+			cf.Projected = append(cf.Projected, &CategoryMonthPoint{Year: m.Year(), Month: int(m.Month()), Total: amount})
+			add(&projected[i].MonthlyPoint, k.typ, amount)
+		}
+		byCategory = append(byCategory, cf)
+	}
+	sort.SliceStable(byCategory, func(i, j int) bool {
+		if byCategory[i].Type != byCategory[j].Type {
+			return byCategory[i].Type == "outflow"
+		}
+		return byCategory[i].Average > byCategory[j].Average
+	})
+
+	// Actual months come from the same history so the two halves share one balance line.
+	actualMonths := monthRange(lookbackStart, opts.Lookback)
+	actual := make([]*ForecastPoint, len(actualMonths))
+	for i, m := range actualMonths {
+		actual[i] = &ForecastPoint{MonthlyPoint: MonthlyPoint{Year: m.Year(), Month: int(m.Month())}}
+	}
+	for _, a := range history {
+		i := monthIndex(lookbackStart, a.Year, time.Month(a.Month))
+		if i < 0 || i >= len(actual) {
+			continue
+		}
+		add(&actual[i].MonthlyPoint, a.Type, a.Total)
+	}
+	current.Inflow = current.ToDate.Inflow + current.Remainder.Inflow
+	current.Outflow = current.ToDate.Outflow + current.Remainder.Outflow
+
+	balance := opts.OpeningBalance
+	points := append(append(append([]*ForecastPoint{}, actual...), &current.ForecastPoint), projected...)
+	for _, p := range points {
+		p.Net = p.Inflow - p.Outflow
+		balance += p.Net
+		p.Balance = balance
+	}
+
+	return &CashflowForecast{Actual: actual, Current: current, Projected: projected, ByCategory: byCategory}, nil
+}
+
+const (
+	defaultStatisticsMonths     = 12
+	maxStatisticsMonths         = 36
+	defaultStatisticsSigma      = 3
+	defaultStatisticsPercentile = 90
+	// minBaselineMonths is the fewest months a category needs before its months can be flagged.
+	minBaselineMonths = 3
+	// minAmountSample is the fewest other transactions a transaction is judged against.
+	minAmountSample = 5
+)
+
+// StatisticsOptions controls the statistics window and outlier thresholds.
+type StatisticsOptions struct {
+	// Months is how many complete past months form each category's norm (default 12, max 36).
+	Months int
+	// Type is the transaction type to analyse (default "outflow").
+	Type string
+	// Sigma is how many standard deviations above the mean a value must be to be flagged (default 3).
+	Sigma float64
+	// Percentile is the percentile of monthly totals to report, in (0, 100] (default 90).
+	Percentile float64
+}
+
+// CategoryStatistics summarises the distribution of a category's monthly totals.
+// Months without transactions count as zero.
+type CategoryStatistics struct {
+	CategoryID    string  `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	CategoryColor string  `json:"category_color"`
+	CategoryIcon  string  `json:"category_icon"`
+	Mean          float64 `json:"mean"`
+	Median        float64 `json:"median"`
+	StdDev        float64 `json:"std_dev"`
+	Percentile    float64 `json:"percentile"`
+	Current       float64 `json:"current"`
+}
+
+// MonthAnomaly flags a category month whose total is far above that category's other months.
+type MonthAnomaly struct {
+	CategoryID    string  `json:"category_id"`
+	CategoryName  string  `json:"category_name"`
+	CategoryColor string  `json:"category_color"`
+	CategoryIcon  string  `json:"category_icon"`
+	Year          int     `json:"year"`
+	Month         int     `json:"month"`
+	Total         float64 `json:"total"`
+	Mean          float64 `json:"mean"`
+	StdDev        float64 `json:"std_dev"`
+	ZScore        float64 `json:"z_score"`
+	Current       bool    `json:"current"`
+}
+
+// TransactionAnomaly flags a transaction whose amount is far above its category's typical amount.
+// Mean and StdDev describe the category's other transactions in the window.
+type TransactionAnomaly struct {
+	Transaction *TransactionResponse `json:"transaction"`
+	Mean        float64              `json:"mean"`
+	StdDev      float64              `json:"std_dev"`
+	ZScore      float64              `json:"z_score"`
+}
+
+// SpendingStatistics is the response for the statistics endpoint.
+type SpendingStatistics struct {
+	Period
+	Type                  string                `json:"type"`
+	Sigma                 float64               `json:"sigma"`
+	PercentileRank        float64               `json:"percentile_rank"`
+	Categories            []*CategoryStatistics `json:"categories"`
+	AnomalousMonths       []*MonthAnomaly       `json:"anomalous_months"`
+	AnomalousTransactions []*TransactionAnomaly `json:"anomalous_transactions"`
+}
+
+func (s *reportService) Statistics(ctx context.Context, userID, ledgerID string, asOf time.Time, opts StatisticsOptions) (*SpendingStatistics, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	switch opts.Type {
+	case "":
+		opts.Type = "outflow"
+	case "inflow", "outflow":
+	default:
+		return nil, ErrInvalidTransactionType
+	}
+	opts.Months = clamp(opts.Months, defaultStatisticsMonths, maxStatisticsMonths)
+	if opts.Sigma <= 0 {
+		opts.Sigma = defaultStatisticsSigma
+	}
+	if opts.Percentile <= 0 {
+		opts.Percentile = defaultStatisticsPercentile
+	}
+	if opts.Percentile > 100 {
+		opts.Percentile = 100
+	}
+
+	loc := asOf.Location()
+	thisMonth := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, loc)
+	nextMonth := thisMonth.AddDate(0, 1, 0)
+	start := thisMonth.AddDate(0, -opts.Months, 0)
+
+	aggs, err := s.txRepo.GetCategoryMonthlyTotals(ctx, lid, start, nextMonth, loc.String())
+	if err != nil {
+		return nil, fmt.Errorf("category monthly totals: %w", err)
+	}
+	amountStats, err := s.txRepo.GetCategoryAmountStats(ctx, lid, opts.Type, start, nextMonth)
+	if err != nil {
+		return nil, fmt.Errorf("category amount stats: %w", err)
+	}
+
+	// series[cat][i] is the total for month start+i; the last slot is the current month.
+	series := make(map[primitive.ObjectID][]float64)
+	order := make([]primitive.ObjectID, 0)
+	ids := make(map[primitive.ObjectID]struct{})
+	for _, a := range aggs {
+		if a.Type != opts.Type {
+			continue
+		}
+		i := monthIndex(start, a.Year, time.Month(a.Month))
+		if i < 0 || i > opts.Months {
+			continue
+		}
+		if _, ok := series[a.CategoryID]; !ok {
+			series[a.CategoryID] = make([]float64, opts.Months+1)
+			order = append(order, a.CategoryID)
+			ids[a.CategoryID] = struct{}{}
+		}
+		series[a.CategoryID][i] += a.Total
+	}
+
+	// Like months, a transaction is judged against the category's other transactions. An
+	// amount x is then an outlier exactly when it is above
+	// mean + sigma·sd·√((n-1)/(n+sigma²)), with mean and sd taken over all n amounts.
+	thresholds := make(map[primitive.ObjectID]float64)
+	amountByCat := make(map[primitive.ObjectID]*db.CategoryAmountStats)
+	for _, st := range amountStats {
+		if st.Count > minAmountSample && st.StdDev > 0 {
+			n := float64(st.Count)
+			thresholds[st.CategoryID] = st.Mean + opts.Sigma*st.StdDev*math.Sqrt((n-1)/(n+opts.Sigma*opts.Sigma))
+			amountByCat[st.CategoryID] = st
+			ids[st.CategoryID] = struct{}{}
+		}
+	}
+	outliers, err := s.txRepo.FindAmountsAbove(ctx, lid, opts.Type, thresholds, start, nextMonth)
+	if err != nil {
+		return nil, fmt.Errorf("finding outlier transactions: %w", err)
+	}
+
+	catMap := fetchCategoryMap(ctx, s.catRepo, lid, ids)
+	months := monthRange(start, opts.Months+1)
+
+	categories := make([]*CategoryStatistics, 0, len(order))
+	anomalousMonths := make([]*MonthAnomaly, 0)
+	for _, id := range order {
+		values := series[id]
+		complete := values[:opts.Months]
+		cs := &CategoryStatistics{
+			CategoryID: id.Hex(),
+			Mean:       mean(complete),
+			Median:     percentile(complete, 50),
+			StdDev:     stdDev(complete),
+			Percentile: percentile(complete, opts.Percentile),
+			Current:    values[opts.Months],
+		}
+		cat := catMap[id]
+		if cat != nil {
+			cs.CategoryName = cat.Name
+			cs.CategoryColor = cat.Color
+			cs.CategoryIcon = cat.Icon
+		}
+		categories = append(categories, cs)
+
+		// Each month is judged against the other complete months, so an outlier does not
+		// inflate the deviation it is measured by.
+		for i, v := range values {
+			baseline := make([]float64, 0, opts.Months)
+			for j, b := range complete {
+				if j != i {
+					baseline = append(baseline, b)
+				}
+			}
+			if len(baseline) < minBaselineMonths {
+				continue
+			}
+			m, sd := mean(baseline), stdDev(baseline)
+			if sd == 0 || (v-m)/sd < opts.Sigma {
+				continue
+			}
+			ma := &MonthAnomaly{
+				CategoryID: id.Hex(),
+				Year:       months[i].Year(),
+				Month:      int(months[i].Month()),
+				Total:      v,
+				Mean:       m,
+				StdDev:     sd,
+				ZScore:     (v - m) / sd,
+				Current:    i == opts.Months,
+			}
+			if cat != nil {
+				ma.CategoryName = cat.Name
+				ma.CategoryColor = cat.Color
+				ma.CategoryIcon = cat.Icon
+			}
+			anomalousMonths = append(anomalousMonths, ma)
+		}
+	}
+	sort.SliceStable(categories, func(i, j int) bool { return categories[i].Mean > categories[j].Mean })
+	sort.SliceStable(anomalousMonths, func(i, j int) bool { return anomalousMonths[i].ZScore > anomalousMonths[j].ZScore })
+
+	anomalousTxs := make([]*TransactionAnomaly, 0, len(outliers))
+	for _, tx := range outliers {
+		st, ok := amountByCat[tx.CategoryID]
+		if !ok {
+			continue
+		}
+		m, sd := withoutAmount(st, tx.Amount)
+		if sd == 0 || (tx.Amount-m)/sd < opts.Sigma {
+			continue
+		}
+		anomalousTxs = append(anomalousTxs, &TransactionAnomaly{
+			Transaction: toResponse(tx, catMap[tx.CategoryID]),
+			Mean:        m,
+			StdDev:      sd,
+			ZScore:      (tx.Amount - m) / sd,
+		})
+	}
+
+	return &SpendingStatistics{
+		Period:                Period{Since: start, Until: nextMonth},
+		Type:                  opts.Type,
+		Sigma:                 opts.Sigma,
+		PercentileRank:        opts.Percentile,
+		Categories:            categories,
+		AnomalousMonths:       anomalousMonths,
+		AnomalousTransactions: anomalousTxs,
+	}, nil
+}
+
+// withoutAmount returns the mean and population standard deviation of a category's
+// amounts with one amount x left out.
+func withoutAmount(st *db.CategoryAmountStats, x float64) (float64, float64) {
+	n := float64(st.Count)
+	m := (n*st.Mean - x) / (n - 1)
+	// The sum of squared deviations drops by n/(n-1)·(x-mean)² without x.
+	ss := n*st.StdDev*st.StdDev - n/(n-1)*(x-st.Mean)*(x-st.Mean)
+	if ss <= 1e-12*n*st.StdDev*st.StdDev { // the rest are equal, up to rounding
+		return m, 0
+	}
+	return m, math.Sqrt(ss / (n - 1))
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// stdDev returns the population standard deviation.
+func stdDev(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	m := mean(values)
+	var sq float64
+	for _, v := range values {
+		sq += (v - m) * (v - m)
+	}
+	return math.Sqrt(sq / float64(len(values)))
+}
+
+// percentile returns the p-th percentile (0-100) using linear interpolation between closest ranks.
+func percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// monthRange returns n consecutive month starts beginning at start.
+func monthRange(start time.Time, n int) []time.Time {
+	months := make([]time.Time, n)
+	for i := range months {
+		months[i] = start.AddDate(0, i, 0)
+	}
+	return months
+}
+
+// monthIndex returns how many months (year, month) is after start.
+func monthIndex(start time.Time, year int, month time.Month) int {
+	return (year-start.Year())*12 + int(month) - int(start.Month())
+}
+
+func monthKey(year int, month time.Month) int {
+	return year*100 + int(month)
+}
+
+// clamp returns def when v is not positive and max when v exceeds it.
+func clamp(v, def, max int) int {
+	if v <= 0 {
+		return def
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
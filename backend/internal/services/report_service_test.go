@@ -0,0 +1,327 @@
+package services_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newReportSvc(txRepo *testutil.MockTransactionRepo, catRepo *testutil.MockCategoryRepo) services.ReportService {
+	return services.NewReportService(txRepo, catRepo, testutil.OwnLedgerRepo(testLedgerID))
+}
+
+func yearPeriod(year int) services.Period {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return services.Period{Since: start, Until: start.AddDate(1, 0, 0)}
+}
+
+func TestReportService_Compare(t *testing.T) {
+	userID := primitive.NewObjectID()
+	dining := primitive.NewObjectID()
+	travel := primitive.NewObjectID()
+	gym := primitive.NewObjectID()
+	salary := primitive.NewObjectID()
+
+	current, previous := yearPeriod(2025), yearPeriod(2024)
+
+	txRepo := &testutil.MockTransactionRepo{
+		GetMonthlySummaryFn: func(_ context.Context, _ primitive.ObjectID, since, _ time.Time, _ string) ([]*db.MonthlyAgg, error) {
+			if since.Equal(current.Since) {
+				return []*db.MonthlyAgg{{Year: 2025, Month: 1, Inflow: 5000, Outflow: 1500}}, nil
+			}
+			return []*db.MonthlyAgg{{Year: 2024, Month: 1, Inflow: 4000, Outflow: 1200}}, nil
+		},
+		GetCategoryTotalsFn: func(_ context.Context, _ primitive.ObjectID, txType string, since, _ time.Time) ([]*db.CategoryAgg, error) {
+			isCurrent := since.Equal(current.Since)
+			switch {
+			case txType == "inflow" && isCurrent:
+				return []*db.CategoryAgg{{CategoryID: salary, Total: 5000}}, nil
+			case txType == "inflow":
+				return []*db.CategoryAgg{{CategoryID: salary, Total: 4000}}, nil
+			case isCurrent:
+				return []*db.CategoryAgg{{CategoryID: dining, Total: 900}, {CategoryID: travel, Total: 600}}, nil
+			default:
+				return []*db.CategoryAgg{{CategoryID: dining, Total: 600}, {CategoryID: gym, Total: 600}}, nil
+			}
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDsFn: func(_ context.Context, _ primitive.ObjectID, _ []primitive.ObjectID) ([]*models.Category, error) {
+			return []*models.Category{
+				{ID: dining, Name: "Dining"},
+				{ID: travel, Name: "Travel"},
+				{ID: gym, Name: "Gym"},
+				{ID: salary, Name: "Salary"},
+			}, nil
+		},
+	}
+
+	svc := newReportSvc(txRepo, catRepo)
+	report, err := svc.Compare(context.Background(), userID.Hex(), "", current, previous, nil, 3)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+
+	if report.Current.Net != 3500 || report.Previous.Net != 2800 {
+		t.Errorf("net: got current %v previous %v, want 3500 and 2800", report.Current.Net, report.Previous.Net)
+	}
+	if len(report.ByCategory) != 4 {
+		t.Fatalf("by_category: got %d rows, want 4", len(report.ByCategory))
+	}
+
+	rows := map[string]*services.CategoryComparison{}
+	for _, r := range report.ByCategory {
+		rows[r.CategoryName] = r
+	}
+	if d := rows["Dining"]; d.Delta != 300 || d.DeltaPct == nil || *d.DeltaPct != 50 {
+		t.Errorf("dining: got %+v", d)
+	}
+	if tr := rows["Travel"]; tr.Previous != 0 || tr.DeltaPct != nil {
+		t.Errorf("travel: a new category should have no percent delta, got %+v", tr)
+	}
+	if g := rows["Gym"]; g.Current != 0 || g.Delta != -600 || *g.DeltaPct != -100 {
+		t.Errorf("gym: got %+v", g)
+	}
+	if rows["Salary"].Type != "inflow" {
+		t.Errorf("salary type: got %q, want inflow", rows["Salary"].Type)
+	}
+
+	if len(report.BiggestMovers) != 3 {
+		t.Fatalf("biggest_movers: got %d, want 3", len(report.BiggestMovers))
+	}
+	if report.BiggestMovers[0].CategoryName != "Salary" {
+		t.Errorf("biggest mover: got %q, want Salary", report.BiggestMovers[0].CategoryName)
+	}
+}
+
+func TestReportService_Compare_InvalidPeriod(t *testing.T) {
+	svc := newReportSvc(&testutil.MockTransactionRepo{}, &testutil.MockCategoryRepo{})
+	p := yearPeriod(2025)
+	backwards := services.Period{Since: p.Until, Until: p.Since}
+
+	_, err := svc.Compare(context.Background(), primitive.NewObjectID().Hex(), "", backwards, p, nil, 0)
+	if err != services.ErrInvalidPeriod {
+		t.Errorf("expected ErrInvalidPeriod, got %v", err)
+	}
+	_, err = svc.Compare(context.Background(), "bad", "", p, p, nil, 0)
+	if err != services.ErrInvalidID {
+		t.Errorf("expected ErrInvalidID, got %v", err)
+	}
+}
+
+func TestReportService_Forecast_Average(t *testing.T) {
+	userID := primitive.NewObjectID()
+	rent := primitive.NewObjectID()
+	salary := primitive.NewObjectID()
+	// Half of April is still ahead.
+	asOf := time.Date(2025, time.April, 16, 0, 0, 0, 0, time.UTC)
+
+	var gotSince, gotUntil time.Time
+	txRepo := &testutil.MockTransactionRepo{
+		GetCategoryMonthlyTotalsFn: func(_ context.Context, _ primitive.ObjectID, since, until time.Time, _ string) ([]*db.CategoryMonthlyAgg, error) {
+			gotSince, gotUntil = since, until
+			return []*db.CategoryMonthlyAgg{
+				{CategoryID: rent, Type: "outflow", Year: 2025, Month: 1, Total: 1000},
+				{CategoryID: salary, Type: "inflow", Year: 2025, Month: 1, Total: 3000},
+				{CategoryID: rent, Type: "outflow", Year: 2025, Month: 2, Total: 1000},
+				{CategoryID: salary, Type: "inflow", Year: 2025, Month: 3, Total: 3000},
+				{CategoryID: rent, Type: "outflow", Year: 2025, Month: 3, Total: 1000},
+				{CategoryID: rent, Type: "outflow", Year: 2025, Month: 4, Total: 400},
+			}, nil
+		},
+	}
+
+	svc := newReportSvc(txRepo, &testutil.MockCategoryRepo{})
+	fc, err := svc.Forecast(context.Background(), userID.Hex(), "", asOf, services.ForecastOptions{Months: 2, Lookback: 3, OpeningBalance: 100})
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+
+	// History covers the three complete months before April and April to date.
+	if !gotSince.Equal(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)) || !gotUntil.Equal(asOf) {
+		t.Errorf("history window: got [%v, %v)", gotSince, gotUntil)
+	}
+	if len(fc.Actual) != 3 || len(fc.Projected) != 2 {
+		t.Fatalf("got %d actual and %d projected months, want 3 and 2", len(fc.Actual), len(fc.Projected))
+	}
+	if fc.Actual[1].Inflow != 0 || fc.Actual[1].Outflow != 1000 {
+		t.Errorf("February actual: got %+v", fc.Actual[1])
+	}
+
+	// April is the 400 spent so far plus half a month of the averages.
+	april := fc.Current
+	if april.Month != 4 || april.ToDate.Outflow != 400 || april.Remainder.Outflow != 500 || april.Remainder.Inflow != 1000 {
+		t.Errorf("April: got %+v", april)
+	}
+	if april.Outflow != 900 || april.Inflow != 1000 || april.Balance != 3200 {
+		t.Errorf("April totals: got %+v, want outflow 900, inflow 1000 and balance 3200", april.ForecastPoint)
+	}
+
+	may := fc.Projected[0]
+	if may.Month != 5 || may.Outflow != 1000 || may.Inflow != 2000 {
+		t.Errorf("May projection: got %+v, want outflow 1000 and inflow 2000", may)
+	}
+	// 100 opening + actual net (2000 - 1000 + 2000) + April net 100 + May net 1000.
+	if may.Balance != 4200 {
+		t.Errorf("May balance: got %v, want 4200", may.Balance)
+	}
+	if len(fc.ByCategory) != 2 || fc.ByCategory[0].Type != "outflow" {
+		t.Errorf("by_category: got %+v", fc.ByCategory)
+	}
+}
+
+func TestReportService_Forecast_SeasonalAndScheduled(t *testing.T) {
+	userID := primitive.NewObjectID()
+	heating := primitive.NewObjectID()
+	asOf := time.Date(2025, time.November, 20, 0, 0, 0, 0, time.UTC)
+
+	txRepo := &testutil.MockTransactionRepo{
+		GetCategoryMonthlyTotalsFn: func(_ context.Context, _ primitive.ObjectID, since, _ time.Time, _ string) ([]*db.CategoryMonthlyAgg, error) {
+			if since.Equal(asOf) {
+				// Bills already recorded for later this month and for January.
+				return []*db.CategoryMonthlyAgg{
+					{CategoryID: heating, Type: "outflow", Year: 2025, Month: 11, Total: 50},
+					{CategoryID: heating, Type: "outflow", Year: 2026, Month: 1, Total: 275},
+				}, nil
+			}
+			return []*db.CategoryMonthlyAgg{
+				{CategoryID: heating, Type: "outflow", Year: 2024, Month: 12, Total: 300},
+				{CategoryID: heating, Type: "outflow", Year: 2025, Month: 2, Total: 80},
+				{CategoryID: heating, Type: "outflow", Year: 2025, Month: 10, Total: 60},
+				{CategoryID: heating, Type: "outflow", Year: 2025, Month: 11, Total: 30},
+				// The ledger recorded something last April, just no heating.
+				{CategoryID: primitive.NewObjectID(), Type: "outflow", Year: 2025, Month: 4, Total: 15},
+			}, nil
+		},
+	}
+
+	svc := newReportSvc(txRepo, &testutil.MockCategoryRepo{})
+	fc, err := svc.Forecast(context.Background(), userID.Hex(), "", asOf, services.ForecastOptions{
+		Months: 13, Lookback: 3, Seasonal: true, IncludeScheduled: true,
+	})
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+
+	if cur := fc.ByCategory[0].Current; cur.Month != 11 || cur.Total != 80 {
+		t.Errorf("November should be the 30 to date plus the scheduled 50: got %+v", cur)
+	}
+	proj := fc.ByCategory[0].Projected
+	if proj[0].Month != 12 || proj[0].Total != 300 {
+		t.Errorf("December should follow last December: got %+v", proj[0])
+	}
+	if proj[1].Month != 1 || proj[1].Total != 275 {
+		t.Errorf("January should use the scheduled bill: got %+v", proj[1])
+	}
+	if proj[2].Month != 2 || proj[2].Total != 80 {
+		t.Errorf("February should follow last February: got %+v", proj[2])
+	}
+	// Nothing at all was recorded last March, so the trailing average (60/3) applies.
+	if proj[3].Month != 3 || proj[3].Total != 20 {
+		t.Errorf("March should fall back to the average: got %+v", proj[3])
+	}
+	if proj[4].Month != 4 || proj[4].Total != 0 {
+		t.Errorf("April should follow last April, which had no heating: got %+v", proj[4])
+	}
+	// Next December's same month last year is the current, incomplete month, so the
+	// trailing average (60/3) applies.
+	if last := proj[12]; last.Year != 2026 || last.Month != 12 || last.Total != 20 {
+		t.Errorf("December 2026 should fall back to the average: got %+v", last)
+	}
+}
+
+func TestReportService_Statistics(t *testing.T) {
+	userID := primitive.NewObjectID()
+	utilities := primitive.NewObjectID()
+	asOf := time.Date(2025, time.July, 15, 0, 0, 0, 0, time.UTC)
+
+	// Six complete months of steady bills, then a spike this month.
+	history := []float64{100, 110, 90, 105, 95, 100}
+	bigBill := &models.Transaction{ID: primitive.NewObjectID(), UserID: userID, CategoryID: utilities, Type: "outflow", Amount: 400}
+
+	var gotThresholds map[primitive.ObjectID]float64
+	txRepo := &testutil.MockTransactionRepo{
+		GetCategoryMonthlyTotalsFn: func(_ context.Context, _ primitive.ObjectID, _, _ time.Time, _ string) ([]*db.CategoryMonthlyAgg, error) {
+			aggs := make([]*db.CategoryMonthlyAgg, 0, len(history)+1)
+			for i, v := range history {
+				aggs = append(aggs, &db.CategoryMonthlyAgg{CategoryID: utilities, Type: "outflow", Year: 2025, Month: i + 1, Total: v})
+			}
+			aggs = append(aggs, &db.CategoryMonthlyAgg{CategoryID: utilities, Type: "outflow", Year: 2025, Month: 7, Total: 400})
+			// Inflows are ignored when analysing outflow.
+			aggs = append(aggs, &db.CategoryMonthlyAgg{CategoryID: primitive.NewObjectID(), Type: "inflow", Year: 2025, Month: 7, Total: 9999})
+			return aggs, nil
+		},
+		GetCategoryAmountStatsFn: func(_ context.Context, _ primitive.ObjectID, _ string, _, _ time.Time) ([]*db.CategoryAmountStats, error) {
+			// One bill a month: the history and the big bill. Taken with the rest, the big
+			// bill is within 3 standard deviations of the mean (143 ± 3·105).
+			return []*db.CategoryAmountStats{{CategoryID: utilities, Count: 7, Mean: 1000.0 / 7, StdDev: math.Sqrt(220250.0/7 - (1000.0/7)*(1000.0/7))}}, nil
+		},
+		FindAmountsAboveFn: func(_ context.Context, _ primitive.ObjectID, _ string, thresholds map[primitive.ObjectID]float64, _, _ time.Time) ([]*models.Transaction, error) {
+			gotThresholds = thresholds
+			return []*models.Transaction{bigBill}, nil
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDsFn: func(_ context.Context, _ primitive.ObjectID, _ []primitive.ObjectID) ([]*models.Category, error) {
+			return []*models.Category{{ID: utilities, Name: "Utilities"}}, nil
+		},
+	}
+
+	svc := newReportSvc(txRepo, catRepo)
+	stats, err := svc.Statistics(context.Background(), userID.Hex(), "", asOf, services.StatisticsOptions{Months: 6})
+	if err != nil {
+		t.Fatalf("Statistics: %v", err)
+	}
+
+	if len(stats.Categories) != 1 {
+		t.Fatalf("categories: got %d, want 1", len(stats.Categories))
+	}
+	cs := stats.Categories[0]
+	if cs.Mean != 100 || cs.Median != 100 || cs.Current != 400 {
+		t.Errorf("utilities stats: got %+v", cs)
+	}
+	if cs.StdDev < 6.4 || cs.StdDev > 6.5 {
+		t.Errorf("std_dev: got %v, want about 6.45", cs.StdDev)
+	}
+	// p90 of [90 95 100 100 105 110] interpolates between 105 and 110.
+	if cs.Percentile != 107.5 {
+		t.Errorf("p90: got %v, want 107.5", cs.Percentile)
+	}
+
+	if len(stats.AnomalousMonths) != 1 {
+		t.Fatalf("anomalous months: got %+v", stats.AnomalousMonths)
+	}
+	am := stats.AnomalousMonths[0]
+	if !am.Current || am.Month != 7 || am.CategoryName != "Utilities" {
+		t.Errorf("expected the current Utilities month to be flagged, got %+v", am)
+	}
+
+	// Left out of its own baseline, the big bill is flagged: the threshold is where an
+	// amount lies 3 standard deviations above the other amounts.
+	if th := gotThresholds[utilities]; th < 335 || th > 337 {
+		t.Errorf("threshold: got %v, want about 336", th)
+	}
+	if len(stats.AnomalousTransactions) != 1 || stats.AnomalousTransactions[0].Transaction.ID != bigBill.ID.Hex() {
+		t.Fatalf("anomalous transactions: got %+v", stats.AnomalousTransactions)
+	}
+	ta := stats.AnomalousTransactions[0]
+	if math.Abs(ta.Mean-100) > 1e-9 || math.Abs(ta.StdDev-cs.StdDev) > 1e-9 {
+		t.Errorf("expected the other bills as the baseline (mean 100, std_dev %v), got %v, %v", cs.StdDev, ta.Mean, ta.StdDev)
+	}
+}
+
+func TestReportService_Statistics_InvalidType(t *testing.T) {
+	svc := newReportSvc(&testutil.MockTransactionRepo{}, &testutil.MockCategoryRepo{})
+	_, err := svc.Statistics(context.Background(), primitive.NewObjectID().Hex(), "", time.Now(), services.StatisticsOptions{Type: "sideways"})
+	if err != services.ErrInvalidTransactionType {
+		t.Errorf("expected ErrInvalidTransactionType, got %v", err)
+	}
+}
@@ -0,0 +1,509 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strings"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxExactSettleUp is the most members with a non-zero balance for which the settle-up
+// plan is searched exhaustively; larger groups are settled greedily.
+const maxExactSettleUp = 16
+
+// SplitParticipant is a member taking part in a group expense. Shares is read by the
+// shares method and Amount by the exact method; equal splits ignore both.
+type SplitParticipant struct {
+	UserID string  `json:"user_id"`
+	Shares float64 `json:"shares,omitempty"`
+	Amount float64 `json:"amount,omitempty"`
+}
+
+// CreateGroupExpenseRequest holds the fields for a new group expense.
+type CreateGroupExpenseRequest struct {
+	PaidBy       string             `json:"paid_by"` // defaults to the caller
+	Amount       float64            `json:"amount"`
+	Description  string             `json:"description"`
+	Date         time.Time          `json:"date"`
+	SplitMethod  string             `json:"split_method"` // equal (default), shares or exact
+	Participants []SplitParticipant `json:"participants"`
+}
+
+// CreateSettlementRequest records that From paid To.
+type CreateSettlementRequest struct {
+	From   string    `json:"from"` // defaults to the caller
+	To     string    `json:"to"`
+	Amount float64   `json:"amount"`
+	Date   time.Time `json:"date"`
+	Note   string    `json:"note"`
+}
+
+// MemberBalance is a member's net position: positive if they are owed money, negative if
+// they owe it.
+type MemberBalance struct {
+	UserID string  `json:"user_id"`
+	Net    float64 `json:"net"`
+}
+
+// SettlePayment is one payment of a settle-up plan.
+type SettlePayment struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Amount float64 `json:"amount"`
+}
+
+// GroupBalances is everyone's net balance in a ledger and the fewest payments that would
+// bring every balance to zero.
+type GroupBalances struct {
+	Balances []MemberBalance `json:"balances"`
+	SettleUp []SettlePayment `json:"settle_up"`
+}
+
+// SplitService tracks who owes whom within a ledger: group expenses one member paid for
+// several, and settlements paying those debts down. Reads need the viewer role, writes editor.
+type SplitService interface {
+	CreateExpense(ctx context.Context, userID, ledgerID string, req CreateGroupExpenseRequest) (*models.GroupExpense, error)
+	ListExpenses(ctx context.Context, userID, ledgerID string) ([]*models.GroupExpense, error)
+	DeleteExpense(ctx context.Context, userID, ledgerID, expenseID string) error
+	CreateSettlement(ctx context.Context, userID, ledgerID string, req CreateSettlementRequest) (*models.Settlement, error)
+	ListSettlements(ctx context.Context, userID, ledgerID string) ([]*models.Settlement, error)
+	DeleteSettlement(ctx context.Context, userID, ledgerID, settlementID string) error
+	// Balances nets every expense and settlement in the ledger and plans the settle-up.
+	Balances(ctx context.Context, userID, ledgerID string) (*GroupBalances, error)
+}
+
+type splitService struct {
+	ledgerAccess
+	expenseRepo    db.GroupExpenseRepository
+	settlementRepo db.SettlementRepository
+}
+
+// NewSplitService creates a new SplitService.
+func NewSplitService(expenseRepo db.GroupExpenseRepository, settlementRepo db.SettlementRepository, ledgerRepo db.LedgerRepository) SplitService {
+	return &splitService{ledgerAccess: ledgerAccess{ledgerRepo: ledgerRepo}, expenseRepo: expenseRepo, settlementRepo: settlementRepo}
+}
+
+func (s *splitService) CreateExpense(ctx context.Context, userID, ledgerID string, req CreateGroupExpenseRequest) (*models.GroupExpense, error) {
+	uid, ledger, err := s.authorizeLedger(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	paidBy, err := memberID(ledger, req.PaidBy, uid)
+	if err != nil {
+		return nil, err
+	}
+	total := toCents(req.Amount)
+	if total <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidSplit)
+	}
+	if req.SplitMethod == "" {
+		req.SplitMethod = models.SplitEqual
+	}
+	shares, err := splitAmount(ledger, req.SplitMethod, total, req.Participants)
+	if err != nil {
+		return nil, err
+	}
+	if req.Date.IsZero() {
+		req.Date = time.Now()
+	}
+
+	created, err := s.expenseRepo.Create(ctx, &models.GroupExpense{
+		LedgerID:    ledger.ID,
+		PaidBy:      paidBy,
+		Amount:      fromCents(total),
+		Description: strings.TrimSpace(req.Description),
+		Date:        req.Date,
+		SplitMethod: req.SplitMethod,
+		Shares:      shares,
+		CreatedBy:   uid,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating group expense: %w", err)
+	}
+	return created, nil
+}
+
+func (s *splitService) ListExpenses(ctx context.Context, userID, ledgerID string) ([]*models.GroupExpense, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.expenseRepo.FindByLedgerID(ctx, lid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching group expenses: %w", err)
+	}
+	return expenses, nil
+}
+
+func (s *splitService) DeleteExpense(ctx context.Context, userID, ledgerID, expenseID string) error {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(expenseID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if err := s.expenseRepo.Delete(ctx, id, lid); err != nil {
+		if err == db.ErrNotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("deleting group expense: %w", err)
+	}
+	return nil
+}
+
+func (s *splitService) CreateSettlement(ctx context.Context, userID, ledgerID string, req CreateSettlementRequest) (*models.Settlement, error) {
+	uid, ledger, err := s.authorizeLedger(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	from, err := memberID(ledger, req.From, uid)
+	if err != nil {
+		return nil, err
+	}
+	to, err := memberID(ledger, req.To, primitive.NilObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if from == to {
+		return nil, fmt.Errorf("%w: a settlement needs two different members", ErrInvalidSplit)
+	}
+	amount := toCents(req.Amount)
+	if amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidSplit)
+	}
+	if req.Date.IsZero() {
+		req.Date = time.Now()
+	}
+
+	created, err := s.settlementRepo.Create(ctx, &models.Settlement{
+		LedgerID:  ledger.ID,
+		From:      from,
+		To:        to,
+		Amount:    fromCents(amount),
+		Date:      req.Date,
+		Note:      strings.TrimSpace(req.Note),
+		CreatedBy: uid,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating settlement: %w", err)
+	}
+	return created, nil
+}
+
+func (s *splitService) ListSettlements(ctx context.Context, userID, ledgerID string) ([]*models.Settlement, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	settlements, err := s.settlementRepo.FindByLedgerID(ctx, lid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching settlements: %w", err)
+	}
+	return settlements, nil
+}
+
+func (s *splitService) DeleteSettlement(ctx context.Context, userID, ledgerID, settlementID string) error {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(settlementID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	if err := s.settlementRepo.Delete(ctx, id, lid); err != nil {
+		if err == db.ErrNotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("deleting settlement: %w", err)
+	}
+	return nil
+}
+
+func (s *splitService) Balances(ctx context.Context, userID, ledgerID string) (*GroupBalances, error) {
+	_, ledger, err := s.authorizeLedger(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.expenseRepo.FindByLedgerID(ctx, ledger.ID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching group expenses: %w", err)
+	}
+	settlements, err := s.settlementRepo.FindByLedgerID(ctx, ledger.ID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching settlements: %w", err)
+	}
+
+	// Net positions in cents, so rounding never leaves a stray balance.
+	net := make(map[primitive.ObjectID]int64)
+	for _, m := range ledger.Members {
+		net[m.UserID] += 0
+	}
+	for _, e := range expenses {
+		net[e.PaidBy] += toCents(e.Amount)
+		for _, sh := range e.Shares {
+			net[sh.UserID] -= toCents(sh.Amount)
+		}
+	}
+	for _, st := range settlements {
+		net[st.From] += toCents(st.Amount)
+		net[st.To] -= toCents(st.Amount)
+	}
+
+	result := &GroupBalances{Balances: []MemberBalance{}, SettleUp: settleUp(net)}
+	for id, cents := range net {
+		if cents == 0 && ledger.RoleOf(id) == "" {
+			continue // a former member who is square
+		}
+		result.Balances = append(result.Balances, MemberBalance{UserID: id.Hex(), Net: fromCents(cents)})
+	}
+	sort.Slice(result.Balances, func(i, j int) bool {
+		a, b := result.Balances[i], result.Balances[j]
+		if a.Net != b.Net {
+			return a.Net > b.Net
+		}
+		return a.UserID < b.UserID
+	})
+	return result, nil
+}
+
+// memberID parses id as a member of the ledger; an empty id selects fallback.
+func memberID(ledger *models.Ledger, id string, fallback primitive.ObjectID) (primitive.ObjectID, error) {
+	if id == "" && !fallback.IsZero() {
+		return fallback, nil
+	}
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return oid, ErrInvalidID
+	}
+	if ledger.RoleOf(oid) == "" {
+		return oid, fmt.Errorf("%w: %s is not a member of the ledger", ErrInvalidSplit, id)
+	}
+	return oid, nil
+}
+
+// splitAmount divides total cents among the participants. Every method assigns whole
+// cents that add up to total exactly.
+func splitAmount(ledger *models.Ledger, method string, total int64, participants []SplitParticipant) ([]models.ExpenseShare, error) {
+	if len(participants) == 0 {
+		return nil, fmt.Errorf("%w: at least one participant is required", ErrInvalidSplit)
+	}
+	shares := make([]models.ExpenseShare, len(participants))
+	seen := make(map[primitive.ObjectID]bool)
+	for i, p := range participants {
+		id, err := memberID(ledger, p.UserID, primitive.NilObjectID)
+		if err != nil {
+			return nil, err
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: %s is listed twice", ErrInvalidSplit, p.UserID)
+		}
+		seen[id] = true
+		shares[i].UserID = id
+	}
+
+	cents := make([]int64, len(participants))
+	switch method {
+	case models.SplitEqual:
+		n := int64(len(participants))
+		for i := range cents {
+			cents[i] = total / n
+			if int64(i) < total%n {
+				cents[i]++
+			}
+		}
+	case models.SplitShares:
+		weights := make([]float64, len(participants))
+		for i, p := range participants {
+			if p.Shares <= 0 {
+				return nil, fmt.Errorf("%w: shares must be positive", ErrInvalidSplit)
+			}
+			weights[i] = p.Shares
+			shares[i].Shares = p.Shares
+		}
+		cents = apportion(total, weights)
+	case models.SplitExact:
+		var sum int64
+		for i, p := range participants {
+			cents[i] = toCents(p.Amount)
+			if cents[i] < 0 {
+				return nil, fmt.Errorf("%w: amounts cannot be negative", ErrInvalidSplit)
+			}
+			sum += cents[i]
+		}
+		if sum != total {
+			return nil, fmt.Errorf("%w: amounts add up to %.2f, not %.2f", ErrInvalidSplit, fromCents(sum), fromCents(total))
+		}
+	default:
+		return nil, fmt.Errorf("%w: split_method must be equal, shares or exact", ErrInvalidSplit)
+	}
+
+	for i := range shares {
+		shares[i].Amount = fromCents(cents[i])
+	}
+	return shares, nil
+}
+
+// apportion divides total cents in proportion to weights by the largest remainder
+// method: everyone gets the whole cents of their exact portion, and the cents left over go
+// to the largest fractions, earlier participants first on ties.
+func apportion(total int64, weights []float64) []int64 {
+	var sum float64
+	for _, w := range weights {
+		sum += w
+	}
+	cents := make([]int64, len(weights))
+	fractions := make([]float64, len(weights))
+	var assigned int64
+	for i, w := range weights {
+		exact := float64(total) * w / sum
+		cents[i] = int64(math.Floor(exact))
+		fractions[i] = exact - float64(cents[i])
+		assigned += cents[i]
+	}
+	order := make([]int, len(weights))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return fractions[order[a]] > fractions[order[b]] })
+	for k := int64(0); k < total-assigned; k++ {
+		cents[order[int(k)%len(order)]]++
+	}
+	return cents
+}
+
+// settleUp plans payments that bring every balance to zero. Splitting the members into as
+// many groups as possible that each sum to zero and settling each group on its own needs
+// the fewest payments: a group of k members settles in k-1.
+func settleUp(net map[primitive.ObjectID]int64) []SettlePayment {
+	var ids []primitive.ObjectID
+	for id, cents := range net {
+		if cents != 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].Hex() < ids[j].Hex() })
+	amounts := make([]int64, len(ids))
+	for i, id := range ids {
+		amounts[i] = net[id]
+	}
+
+	groups := [][]int{indexes(len(ids))}
+	if len(ids) <= maxExactSettleUp {
+		groups = zeroSumGroups(amounts)
+	}
+
+	payments := []SettlePayment{}
+	for _, group := range groups {
+		payments = append(payments, settleGroup(ids, amounts, group)...)
+	}
+	return payments
+}
+
+// zeroSumGroups partitions amounts, which sum to zero, into the largest number of groups
+// that each sum to zero. best[mask] is the most zero-sum prefixes any ordering of the
+// members in mask can have; the ordering that reaches best[full] yields the groups.
+func zeroSumGroups(amounts []int64) [][]int {
+	n := len(amounts)
+	if n == 0 {
+		return nil
+	}
+	full := 1<<n - 1
+	sum := make([]int64, full+1)
+	best := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		low := bits.TrailingZeros(uint(mask))
+		sum[mask] = sum[mask&(mask-1)] + amounts[low]
+		best[mask] = -1
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			i := bits.TrailingZeros(uint(rest))
+			if b := best[mask&^(1<<i)]; b > best[mask] {
+				best[mask] = b
+			}
+		}
+		if sum[mask] == 0 {
+			best[mask]++
+		}
+	}
+
+	// Walk back from the full set to recover an optimal ordering, last member first.
+	order := make([]int, 0, n)
+	for mask := full; mask != 0; {
+		zero := 0
+		if sum[mask] == 0 {
+			zero = 1
+		}
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			i := bits.TrailingZeros(uint(rest))
+			if best[mask&^(1<<i)]+zero == best[mask] {
+				order = append(order, i)
+				mask &^= 1 << i
+				break
+			}
+		}
+	}
+
+	var groups [][]int
+	var current []int
+	var running int64
+	for k := len(order) - 1; k >= 0; k-- {
+		current = append(current, order[k])
+		running += amounts[order[k]]
+		if running == 0 {
+			groups = append(groups, current)
+			current = nil
+		}
+	}
+	return groups
+}
+
+// settleGroup settles a zero-sum group by repeatedly having the largest debtor pay the
+// largest creditor; each payment clears at least one of them.
+func settleGroup(ids []primitive.ObjectID, amounts []int64, group []int) []SettlePayment {
+	remaining := make(map[int]int64, len(group))
+	for _, i := range group {
+		remaining[i] = amounts[i]
+	}
+	var payments []SettlePayment
+	for {
+		debtor, creditor := -1, -1
+		for _, i := range group {
+			if r := remaining[i]; r < 0 && (debtor < 0 || r < remaining[debtor]) {
+				debtor = i
+			} else if r > 0 && (creditor < 0 || r > remaining[creditor]) {
+				creditor = i
+			}
+		}
+		if debtor < 0 || creditor < 0 {
+			return payments
+		}
+		amount := min(-remaining[debtor], remaining[creditor])
+		remaining[debtor] += amount
+		remaining[creditor] -= amount
+		payments = append(payments, SettlePayment{From: ids[debtor].Hex(), To: ids[creditor].Hex(), Amount: fromCents(amount)})
+	}
+}
+
+func indexes(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
+
+func toCents(amount float64) int64 { return int64(math.Round(amount * 100)) }
+
+func fromCents(cents int64) float64 { return float64(cents) / 100 }
@@ -0,0 +1,293 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// splitFixture is a ledger of five members whose group expenses and settlements are kept
+// in memory.
+type splitFixture struct {
+	svc         services.SplitService
+	ledger      *models.Ledger
+	members     []primitive.ObjectID // members[3] is the only viewer
+	expenses    []*models.GroupExpense
+	settlements []*models.Settlement
+}
+
+func newSplitFixture(t *testing.T) *splitFixture {
+	t.Helper()
+	f := &splitFixture{ledger: &models.Ledger{ID: primitive.NewObjectID(), Name: "Trip"}}
+	for i, role := range []string{models.RoleOwner, models.RoleEditor, models.RoleEditor, models.RoleViewer, models.RoleEditor} {
+		f.members = append(f.members, primitive.NewObjectID())
+		f.ledger.Members = append(f.ledger.Members, models.LedgerMember{UserID: f.members[i], Role: role})
+	}
+	expenseRepo := &testutil.MockGroupExpenseRepo{
+		CreateFn: func(_ context.Context, e *models.GroupExpense) (*models.GroupExpense, error) {
+			e.ID = primitive.NewObjectID()
+			f.expenses = append(f.expenses, e)
+			return e, nil
+		},
+		FindByLedgerIDFn: func(_ context.Context, _ primitive.ObjectID) ([]*models.GroupExpense, error) {
+			return f.expenses, nil
+		},
+	}
+	settlementRepo := &testutil.MockSettlementRepo{
+		CreateFn: func(_ context.Context, s *models.Settlement) (*models.Settlement, error) {
+			s.ID = primitive.NewObjectID()
+			f.settlements = append(f.settlements, s)
+			return s, nil
+		},
+		FindByLedgerIDFn: func(_ context.Context, _ primitive.ObjectID) ([]*models.Settlement, error) {
+			return f.settlements, nil
+		},
+	}
+	f.svc = services.NewSplitService(expenseRepo, settlementRepo, sharedLedgerRepo(f.ledger))
+	return f
+}
+
+func (f *splitFixture) participants(idx ...int) []services.SplitParticipant {
+	var ps []services.SplitParticipant
+	for _, i := range idx {
+		ps = append(ps, services.SplitParticipant{UserID: f.members[i].Hex()})
+	}
+	return ps
+}
+
+func (f *splitFixture) pay(t *testing.T, payer int, amount float64, idx ...int) {
+	t.Helper()
+	_, err := f.svc.CreateExpense(context.Background(), f.members[0].Hex(), f.ledger.ID.Hex(), services.CreateGroupExpenseRequest{
+		PaidBy:       f.members[payer].Hex(),
+		Amount:       amount,
+		Participants: f.participants(idx...),
+	})
+	if err != nil {
+		t.Fatalf("CreateExpense: %v", err)
+	}
+}
+
+func shareAmounts(e *models.GroupExpense) []float64 {
+	var out []float64
+	for _, s := range e.Shares {
+		out = append(out, s.Amount)
+	}
+	return out
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSplitService_CreateExpense_EqualSpreadsRemainderCents(t *testing.T) {
+	f := newSplitFixture(t)
+
+	e, err := f.svc.CreateExpense(context.Background(), f.members[1].Hex(), f.ledger.ID.Hex(), services.CreateGroupExpenseRequest{
+		Amount:       100,
+		Participants: f.participants(0, 1, 2),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e.PaidBy != f.members[1] {
+		t.Errorf("expected the caller to be the payer, got %s", e.PaidBy.Hex())
+	}
+	if e.SplitMethod != models.SplitEqual {
+		t.Errorf("expected split method %q, got %q", models.SplitEqual, e.SplitMethod)
+	}
+	if got, want := shareAmounts(e), []float64{33.34, 33.33, 33.33}; !equalFloats(got, want) {
+		t.Errorf("expected shares %v, got %v", want, got)
+	}
+}
+
+func TestSplitService_CreateExpense_SharesUseLargestRemainder(t *testing.T) {
+	f := newSplitFixture(t)
+	ps := f.participants(0, 1, 2)
+	ps[0].Shares, ps[1].Shares, ps[2].Shares = 1, 1, 1
+
+	e, err := f.svc.CreateExpense(context.Background(), f.members[0].Hex(), f.ledger.ID.Hex(), services.CreateGroupExpenseRequest{
+		Amount:       10,
+		SplitMethod:  models.SplitShares,
+		Participants: ps,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := shareAmounts(e), []float64{3.34, 3.33, 3.33}; !equalFloats(got, want) {
+		t.Errorf("expected shares %v, got %v", want, got)
+	}
+
+	ps[0].Shares, ps[1].Shares, ps[2].Shares = 1, 2, 2
+	e, err = f.svc.CreateExpense(context.Background(), f.members[0].Hex(), f.ledger.ID.Hex(), services.CreateGroupExpenseRequest{
+		Amount:       0.07,
+		SplitMethod:  models.SplitShares,
+		Participants: ps,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Exact portions are 1.4, 2.8 and 2.8 cents; the two .8 fractions take the spare cents.
+	if got, want := shareAmounts(e), []float64{0.01, 0.03, 0.03}; !equalFloats(got, want) {
+		t.Errorf("expected shares %v, got %v", want, got)
+	}
+}
+
+func TestSplitService_CreateExpense_Rejects(t *testing.T) {
+	f := newSplitFixture(t)
+	outsider := primitive.NewObjectID().Hex()
+
+	exact := f.participants(0, 1)
+	exact[0].Amount, exact[1].Amount = 30, 60
+
+	cases := map[string]services.CreateGroupExpenseRequest{
+		"exact amounts that do not add up": {Amount: 100, SplitMethod: models.SplitExact, Participants: exact},
+		"a non-member participant":         {Amount: 10, Participants: []services.SplitParticipant{{UserID: outsider}}},
+		"a non-member payer":               {PaidBy: outsider, Amount: 10, Participants: f.participants(0)},
+		"a duplicate participant":          {Amount: 10, Participants: f.participants(0, 0)},
+		"no participants":                  {Amount: 10},
+		"a zero amount":                    {Participants: f.participants(0)},
+		"zero shares":                      {Amount: 10, SplitMethod: models.SplitShares, Participants: f.participants(0)},
+		"an unknown method":                {Amount: 10, SplitMethod: "halves", Participants: f.participants(0)},
+	}
+	for name, req := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := f.svc.CreateExpense(context.Background(), f.members[0].Hex(), f.ledger.ID.Hex(), req)
+			if !errors.Is(err, services.ErrInvalidSplit) {
+				t.Errorf("expected ErrInvalidSplit, got %v", err)
+			}
+		})
+	}
+	if len(f.expenses) != 0 {
+		t.Errorf("expected nothing to be stored, got %d expenses", len(f.expenses))
+	}
+}
+
+func TestSplitService_CreateExpense_ViewerForbidden(t *testing.T) {
+	f := newSplitFixture(t)
+
+	_, err := f.svc.CreateExpense(context.Background(), f.members[3].Hex(), f.ledger.ID.Hex(), services.CreateGroupExpenseRequest{
+		Amount:       10,
+		Participants: f.participants(3),
+	})
+	if !errors.Is(err, services.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestSplitService_CreateSettlement_RejectsSelfPayment(t *testing.T) {
+	f := newSplitFixture(t)
+
+	_, err := f.svc.CreateSettlement(context.Background(), f.members[0].Hex(), f.ledger.ID.Hex(), services.CreateSettlementRequest{
+		To:     f.members[0].Hex(),
+		Amount: 5,
+	})
+	if !errors.Is(err, services.ErrInvalidSplit) {
+		t.Errorf("expected ErrInvalidSplit, got %v", err)
+	}
+}
+
+func TestSplitService_Balances_NetsExpensesAndSettlements(t *testing.T) {
+	f := newSplitFixture(t)
+	f.pay(t, 0, 90, 0, 1, 2) // 1 and 2 each owe 0 thirty
+
+	if _, err := f.svc.CreateSettlement(context.Background(), f.members[1].Hex(), f.ledger.ID.Hex(), services.CreateSettlementRequest{
+		To:     f.members[0].Hex(),
+		Amount: 30,
+	}); err != nil {
+		t.Fatalf("CreateSettlement: %v", err)
+	}
+
+	got, err := f.svc.Balances(context.Background(), f.members[3].Hex(), f.ledger.ID.Hex())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	net := map[string]float64{}
+	for _, b := range got.Balances {
+		net[b.UserID] = b.Net
+	}
+	want := map[string]float64{f.members[0].Hex(): 30, f.members[1].Hex(): 0, f.members[2].Hex(): -30, f.members[3].Hex(): 0}
+	for id, w := range want {
+		if net[id] != w {
+			t.Errorf("expected %s to net %.2f, got %.2f", id, w, net[id])
+		}
+	}
+	if len(got.SettleUp) != 1 {
+		t.Fatalf("expected 1 payment, got %+v", got.SettleUp)
+	}
+	if p := got.SettleUp[0]; p.From != f.members[2].Hex() || p.To != f.members[0].Hex() || p.Amount != 30 {
+		t.Errorf("unexpected payment %+v", p)
+	}
+}
+
+func TestSplitService_Balances_CycleNeedsNoPayments(t *testing.T) {
+	f := newSplitFixture(t)
+	f.pay(t, 0, 20, 1)
+	f.pay(t, 1, 20, 2)
+	f.pay(t, 2, 20, 0)
+
+	got, err := f.svc.Balances(context.Background(), f.members[0].Hex(), f.ledger.ID.Hex())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.SettleUp) != 0 {
+		t.Errorf("expected no payments, got %+v", got.SettleUp)
+	}
+}
+
+func TestSplitService_Balances_SettlesZeroSumGroupsSeparately(t *testing.T) {
+	f := newSplitFixture(t)
+	// Nets of +6, -6, +5, +2 and -7. Always paying the largest creditor from the largest
+	// debtor takes four payments; settling {0, 1} and {2, 3, 4} on their own takes three.
+	f.pay(t, 0, 6, 1)
+	f.pay(t, 2, 5, 4)
+	f.pay(t, 3, 2, 4)
+
+	got, err := f.svc.Balances(context.Background(), f.members[0].Hex(), f.ledger.ID.Hex())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.SettleUp) != 3 {
+		t.Fatalf("expected 3 payments, got %+v", got.SettleUp)
+	}
+	net := map[string]float64{}
+	for _, b := range got.Balances {
+		net[b.UserID] = b.Net
+	}
+	for _, p := range got.SettleUp {
+		net[p.From] += p.Amount
+		net[p.To] -= p.Amount
+	}
+	for id, n := range net {
+		if n != 0 {
+			t.Errorf("expected %s to be settled, still nets %.2f", id, n)
+		}
+	}
+	for _, p := range got.SettleUp {
+		if p.From == f.members[1].Hex() && p.To != f.members[0].Hex() {
+			t.Errorf("expected member 1 to pay only member 0, got %+v", p)
+		}
+	}
+}
+
+func TestSplitService_Balances_NonMember(t *testing.T) {
+	f := newSplitFixture(t)
+
+	_, err := f.svc.Balances(context.Background(), primitive.NewObjectID().Hex(), f.ledger.ID.Hex())
+	if !errors.Is(err, services.ErrLedgerNotFound) {
+		t.Errorf("expected ErrLedgerNotFound, got %v", err)
+	}
+}
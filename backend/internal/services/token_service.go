@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// apiTokenPrefix marks expensify personal access tokens so they are easy to recognise in scripts and secret scanners.
+	apiTokenPrefix = "exp_"
+	// tokenTouchInterval limits how often last_used_at is written for a busy token.
+	tokenTouchInterval = time.Minute
+	maxTokenNameLength = 100
+)
+
+// CreateTokenRequest holds the fields for a new personal access token.
+// A nil ExpiresAt creates a token that never expires.
+type CreateTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// CreatedToken is returned once at creation; Token is the only time the secret is visible.
+type CreatedToken struct {
+	*models.APIToken
+	Token string `json:"token"`
+}
+
+// TokenService manages personal access tokens for scripted API access.
+type TokenService interface {
+	CreateToken(ctx context.Context, userID string, req CreateTokenRequest) (*CreatedToken, error)
+	ListTokens(ctx context.Context, userID string) ([]*models.APIToken, error)
+	RevokeToken(ctx context.Context, userID string, tokenID string) error
+	// Authenticate resolves a raw bearer token to its user and token record.
+	Authenticate(ctx context.Context, rawToken string) (*models.User, *models.APIToken, error)
+}
+
+type tokenService struct {
+	tokenRepo db.APITokenRepository
+	userRepo  db.UserRepository
+}
+
+// NewTokenService creates a new TokenService.
+func NewTokenService(tokenRepo db.APITokenRepository, userRepo db.UserRepository) TokenService {
+	return &tokenService{tokenRepo: tokenRepo, userRepo: userRepo}
+}
+
+func (s *tokenService) CreateToken(ctx context.Context, userID string, req CreateTokenRequest) (*CreatedToken, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxTokenNameLength {
+		return nil, ErrInvalidTokenName
+	}
+	if len(req.Scopes) == 0 {
+		return nil, ErrInvalidScope
+	}
+	for _, scope := range req.Scopes {
+		if scope != models.ScopeRead && scope != models.ScopeWrite {
+			return nil, ErrInvalidScope
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+
+	raw, err := generateAPIToken()
+	if err != nil {
+		return nil, fmt.Errorf("generating token: %w", err)
+	}
+
+	token := &models.APIToken{
+		UserID:    uid,
+		Name:      name,
+		TokenHash: models.HashToken(raw),
+		Prefix:    raw[:len(apiTokenPrefix)+6],
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	}
+	created, err := s.tokenRepo.Create(ctx, token)
+	if err != nil {
+		return nil, fmt.Errorf("creating token: %w", err)
+	}
+	return &CreatedToken{APIToken: created, Token: raw}, nil
+}
+
+func (s *tokenService) ListTokens(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	tokens, err := s.tokenRepo.FindByUserID(ctx, uid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (s *tokenService) RevokeToken(ctx context.Context, userID string, tokenID string) error {
+	uid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return ErrInvalidID
+	}
+	tid, err := primitive.ObjectIDFromHex(tokenID)
+	if err != nil {
+		return ErrInvalidID
+	}
+
+	// The repo enforces ownership: it only deletes when user_id matches.
+	if err := s.tokenRepo.Delete(ctx, tid, uid); err != nil {
+		if err == db.ErrNotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("revoking token: %w", err)
+	}
+	return nil
+}
+
+func (s *tokenService) Authenticate(ctx context.Context, rawToken string) (*models.User, *models.APIToken, error) {
+	if !strings.HasPrefix(rawToken, apiTokenPrefix) {
+		return nil, nil, ErrNotFound
+	}
+	token, err := s.tokenRepo.FindByHash(ctx, models.HashToken(rawToken))
+	if err != nil {
+		return nil, nil, fmt.Errorf("finding token: %w", err)
+	}
+	if token == nil {
+		return nil, nil, ErrNotFound
+	}
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, nil, ErrTokenExpired
+	}
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("finding user: %w", err)
+	}
+	if user == nil {
+		return nil, nil, ErrNotFound
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > tokenTouchInterval {
+		// Usage tracking is best-effort and must not fail the request.
+		_ = s.tokenRepo.TouchLastUsed(ctx, token.ID, now)
+		token.LastUsedAt = &now
+	}
+	return user, token, nil
+}
+
+func generateAPIToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiTokenPrefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,143 @@
+package services_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newTokenSvc(tokenRepo *testutil.MockAPITokenRepo, userRepo *testutil.MockUserRepo) services.TokenService {
+	return services.NewTokenService(tokenRepo, userRepo)
+}
+
+func TestTokenService_CreateToken_StoresOnlyHash(t *testing.T) {
+	userID := primitive.NewObjectID()
+	var stored *models.APIToken
+	tokenRepo := &testutil.MockAPITokenRepo{
+		CreateFn: func(_ context.Context, tok *models.APIToken) (*models.APIToken, error) {
+			tok.ID = primitive.NewObjectID()
+			stored = tok
+			return tok, nil
+		},
+	}
+
+	svc := newTokenSvc(tokenRepo, &testutil.MockUserRepo{})
+	expires := time.Now().Add(24 * time.Hour)
+	created, err := svc.CreateToken(context.Background(), userID.Hex(), services.CreateTokenRequest{
+		Name: "budget sheet", Scopes: []string{models.ScopeRead}, ExpiresAt: &expires,
+	})
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+	if !strings.HasPrefix(created.Token, "exp_") {
+		t.Errorf("token should carry the exp_ prefix, got %q", created.Token)
+	}
+	if stored.TokenHash == created.Token || stored.TokenHash != models.HashToken(created.Token) {
+		t.Error("repository must receive the SHA-256 hash, not the plaintext token")
+	}
+	if !strings.HasPrefix(created.Token, stored.Prefix) {
+		t.Errorf("prefix %q should be the start of the token", stored.Prefix)
+	}
+	if stored.UserID != userID {
+		t.Errorf("user_id: got %v, want %v", stored.UserID, userID)
+	}
+}
+
+func TestTokenService_CreateToken_Validation(t *testing.T) {
+	svc := newTokenSvc(&testutil.MockAPITokenRepo{}, &testutil.MockUserRepo{})
+	uid := primitive.NewObjectID().Hex()
+	past := time.Now().Add(-time.Hour)
+
+	cases := []struct {
+		name string
+		req  services.CreateTokenRequest
+		want error
+	}{
+		{"missing name", services.CreateTokenRequest{Scopes: []string{"read"}}, services.ErrInvalidTokenName},
+		{"no scopes", services.CreateTokenRequest{Name: "x"}, services.ErrInvalidScope},
+		{"unknown scope", services.CreateTokenRequest{Name: "x", Scopes: []string{"admin"}}, services.ErrInvalidScope},
+		{"past expiry", services.CreateTokenRequest{Name: "x", Scopes: []string{"read"}, ExpiresAt: &past}, services.ErrInvalidExpiry},
+	}
+	for _, tc := range cases {
+		if _, err := svc.CreateToken(context.Background(), uid, tc.req); err != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestTokenService_Authenticate(t *testing.T) {
+	userID := primitive.NewObjectID()
+	raw := "exp_" + strings.Repeat("ab", 32)
+	touched := false
+
+	tokenRepo := &testutil.MockAPITokenRepo{
+		FindByHashFn: func(_ context.Context, hash string) (*models.APIToken, error) {
+			if hash == models.HashToken(raw) {
+				return &models.APIToken{ID: primitive.NewObjectID(), UserID: userID, Scopes: []string{"read"}}, nil
+			}
+			return nil, nil
+		},
+		TouchLastUsedFn: func(_ context.Context, _ primitive.ObjectID, _ time.Time) error {
+			touched = true
+			return nil
+		},
+	}
+	userRepo := &testutil.MockUserRepo{
+		FindByIDFn: func(_ context.Context, id primitive.ObjectID) (*models.User, error) {
+			return &models.User{ID: id}, nil
+		},
+	}
+
+	svc := newTokenSvc(tokenRepo, userRepo)
+	user, tok, err := svc.Authenticate(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if user.ID != userID || !tok.HasScope(models.ScopeRead) || tok.HasScope(models.ScopeWrite) {
+		t.Errorf("unexpected user/token: %+v %+v", user, tok)
+	}
+	if !touched {
+		t.Error("expected last_used_at to be updated")
+	}
+
+	if _, _, err := svc.Authenticate(context.Background(), "exp_unknown"); err != services.ErrNotFound {
+		t.Errorf("unknown token: expected ErrNotFound, got %v", err)
+	}
+	if _, _, err := svc.Authenticate(context.Background(), "session-uuid"); err != services.ErrNotFound {
+		t.Errorf("non-token value: expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestTokenService_Authenticate_Expired(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	tokenRepo := &testutil.MockAPITokenRepo{
+		FindByHashFn: func(_ context.Context, _ string) (*models.APIToken, error) {
+			return &models.APIToken{ID: primitive.NewObjectID(), ExpiresAt: &past}, nil
+		},
+	}
+
+	svc := newTokenSvc(tokenRepo, &testutil.MockUserRepo{})
+	if _, _, err := svc.Authenticate(context.Background(), "exp_old"); err != services.ErrTokenExpired {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestTokenService_RevokeToken_NotOwned(t *testing.T) {
+	tokenRepo := &testutil.MockAPITokenRepo{
+		DeleteFn: func(_ context.Context, _, _ primitive.ObjectID) error { return db.ErrNotFound },
+	}
+
+	svc := newTokenSvc(tokenRepo, &testutil.MockUserRepo{})
+	err := svc.RevokeToken(context.Background(), primitive.NewObjectID().Hex(), primitive.NewObjectID().Hex())
+	if err != services.ErrNotFound {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
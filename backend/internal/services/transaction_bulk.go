@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bulk transaction operations.
+const (
+	BulkRecategorize = "recategorize" // move to CategoryID
+	BulkRetag        = "retag"        // replace the tags with Tags
+	BulkDelete       = "delete"       // move to the trash
+	BulkChangeDate   = "change-date"  // set the date to Date
+)
+
+// Per-item outcomes of a bulk operation.
+const (
+	BulkItemModified  = "modified"
+	BulkItemDeleted   = "deleted"
+	BulkItemUnchanged = "unchanged" // already had the requested value
+	BulkItemConflict  = "conflict"  // changed by someone else since it was read; not written
+	BulkItemNotFound  = "not_found"
+	BulkItemInvalidID = "invalid_id"
+)
+
+const (
+	// maxBulkTargets caps the transactions one bulk request may touch.
+	maxBulkTargets = 1000
+	maxTags        = 20
+	maxTagLength   = 40
+)
+
+// BulkTransactionFilter selects the transactions of a bulk operation. At least one field
+// must be set; the date range is [Since, Until).
+type BulkTransactionFilter struct {
+	CategoryID string    `json:"category_id"`
+	Type       string    `json:"type"`
+	Tag        string    `json:"tag"`
+	Since      time.Time `json:"since"`
+	Until      time.Time `json:"until"`
+}
+
+// BulkTransactionRequest applies Operation to the transactions named by IDs or matched by
+// Filter; exactly one of the two must be given. The operand field the operation needs
+// (CategoryID, Tags or Date) must be set.
+type BulkTransactionRequest struct {
+	Operation  string                 `json:"operation"`
+	IDs        []string               `json:"ids"`
+	Filter     *BulkTransactionFilter `json:"filter"`
+	CategoryID string                 `json:"category_id"`
+	Tags       []string               `json:"tags"`
+	Date       *time.Time             `json:"date"`
+}
+
+// BulkItemResult is the outcome of a bulk operation for one transaction.
+type BulkItemResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// BulkTransactionResult reports a bulk operation. Matched counts the targets found in the
+// ledger and Modified those the operation wrote (modified or deleted); Items has one result
+// per target, in request order for IDs and newest first for a filter.
+type BulkTransactionResult struct {
+	Operation string           `json:"operation"`
+	Matched   int64            `json:"matched"`
+	Modified  int64            `json:"modified"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+func (s *transactionService) Bulk(ctx context.Context, userID, ledgerID string, req BulkTransactionRequest) (*BulkTransactionResult, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := s.bulkPatch(ctx, lid, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		items []BulkItemResult
+		found []*models.Transaction
+	)
+	switch {
+	case len(req.IDs) > 0 && req.Filter != nil, len(req.IDs) == 0 && req.Filter == nil:
+		return nil, fmt.Errorf("%w: give either ids or filter", ErrInvalidBulkOperation)
+	case len(req.IDs) > 0:
+		items, found, err = s.bulkTargetsByID(ctx, lid, req.IDs)
+	default:
+		items, found, err = s.bulkTargetsByFilter(ctx, lid, *req.Filter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(map[string]string, len(found))
+	result := &BulkTransactionResult{Operation: req.Operation, Matched: int64(len(found)), Items: items}
+	for _, tx := range found {
+		status, err := s.bulkWrite(ctx, lid, uid, tx, req.Operation, patch)
+		if err != nil {
+			return nil, err
+		}
+		if status == BulkItemModified || status == BulkItemDeleted {
+			result.Modified++
+		}
+		statuses[tx.ID.Hex()] = status
+	}
+	for i, item := range result.Items {
+		if item.Status == "" {
+			result.Items[i].Status = statuses[item.ID]
+		}
+	}
+	return result, nil
+}
+
+// bulkWrite applies the operation to one transaction if it is still at the version it was
+// read at, and returns the item status. Only transactions the operation changes are
+// written, so the others keep their version; one changed or deleted since it was read is
+// left alone and reported as a conflict or not found.
+func (s *transactionService) bulkWrite(ctx context.Context, lid, uid primitive.ObjectID, tx *models.Transaction, op string, patch db.TransactionPatch) (string, error) {
+	after := applyBulk(tx, op, patch)
+	if after == nil {
+		return BulkItemUnchanged, nil
+	}
+
+	var err error
+	if op == BulkDelete {
+		err = s.txRepo.Delete(ctx, tx.ID, lid, tx.Version)
+	} else {
+		_, err = s.txRepo.Patch(ctx, tx.ID, lid, tx.Version, patch)
+	}
+	switch {
+	case errors.Is(err, db.ErrConflict):
+		return BulkItemConflict, nil
+	case errors.Is(err, db.ErrNotFound):
+		return BulkItemNotFound, nil
+	case err != nil:
+		return "", fmt.Errorf("bulk %s: %w", op, err)
+	}
+
+	if op == BulkDelete {
+		s.record(ctx, lid, uid, models.AuditDelete, models.AuditEntityTransaction, tx.ID, transactionFields(tx), nil)
+		return BulkItemDeleted, nil
+	}
+	s.record(ctx, lid, uid, models.AuditUpdate, models.AuditEntityTransaction, tx.ID, transactionFields(tx), transactionFields(after))
+	return BulkItemModified, nil
+}
+
+// bulkPatch validates the operation and its operand and returns the fields it sets.
+func (s *transactionService) bulkPatch(ctx context.Context, lid primitive.ObjectID, req BulkTransactionRequest) (db.TransactionPatch, error) {
+	var patch db.TransactionPatch
+	switch req.Operation {
+	case BulkRecategorize:
+		catID, err := primitive.ObjectIDFromHex(req.CategoryID)
+		if err != nil {
+			return patch, fmt.Errorf("%w: recategorize needs a valid category_id", ErrInvalidBulkOperation)
+		}
+		if _, err := s.ledgerCategory(ctx, lid, catID); err != nil {
+			return patch, err
+		}
+		patch.CategoryID = &catID
+	case BulkRetag:
+		if req.Tags == nil {
+			return patch, fmt.Errorf("%w: retag needs tags (an empty list removes them)", ErrInvalidBulkOperation)
+		}
+		tags, err := normalizeTags(req.Tags)
+		if err != nil {
+			return patch, err
+		}
+		if tags == nil {
+			tags = []string{}
+		}
+		patch.Tags = &tags
+	case BulkChangeDate:
+		if req.Date == nil || req.Date.IsZero() {
+			return patch, fmt.Errorf("%w: change-date needs a date", ErrInvalidBulkOperation)
+		}
+		patch.Date = req.Date
+	case BulkDelete:
+	default:
+		return patch, fmt.Errorf("%w: operation must be recategorize, retag, delete or change-date", ErrInvalidBulkOperation)
+	}
+	return patch, nil
+}
+
+// bulkTargetsByID looks up the named transactions. Items are in request order, with
+// duplicates dropped; those of found transactions are left without a status.
+func (s *transactionService) bulkTargetsByID(ctx context.Context, lid primitive.ObjectID, raw []string) ([]BulkItemResult, []*models.Transaction, error) {
+	if len(raw) > maxBulkTargets {
+		return nil, nil, fmt.Errorf("%w: at most %d ids per request", ErrInvalidBulkOperation, maxBulkTargets)
+	}
+	items := make([]BulkItemResult, 0, len(raw))
+	seen := make(map[string]bool, len(raw))
+	var ids []primitive.ObjectID
+	for _, id := range raw {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			items = append(items, BulkItemResult{ID: id, Status: BulkItemInvalidID})
+			continue
+		}
+		ids = append(ids, oid)
+		items = append(items, BulkItemResult{ID: id})
+	}
+	if len(ids) == 0 {
+		return items, nil, nil
+	}
+
+	found, err := s.txRepo.FindByIDs(ctx, lid, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching transactions: %w", err)
+	}
+	exists := make(map[string]bool, len(found))
+	for _, tx := range found {
+		exists[tx.ID.Hex()] = true
+	}
+	for i, item := range items {
+		if item.Status == "" && !exists[item.ID] {
+			items[i].Status = BulkItemNotFound
+		}
+	}
+	return items, found, nil
+}
+
+// bulkTargetsByFilter finds the transactions matching f, failing if there are more than
+// maxBulkTargets so that a request never silently covers only part of its selection.
+func (s *transactionService) bulkTargetsByFilter(ctx context.Context, lid primitive.ObjectID, f BulkTransactionFilter) ([]BulkItemResult, []*models.Transaction, error) {
+	filter := db.TransactionFilter{Type: f.Type, Tag: strings.TrimSpace(f.Tag), Since: f.Since, Until: f.Until}
+	if f.CategoryID != "" {
+		catID, err := primitive.ObjectIDFromHex(f.CategoryID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: invalid category_id", ErrInvalidFilter)
+		}
+		filter.CategoryID = &catID
+	}
+	switch {
+	case filter == (db.TransactionFilter{}):
+		return nil, nil, fmt.Errorf("%w: set at least one of category_id, type, tag, since and until", ErrInvalidFilter)
+	case filter.Type != "" && filter.Type != "inflow" && filter.Type != "outflow":
+		return nil, nil, fmt.Errorf("%w: type must be inflow or outflow", ErrInvalidFilter)
+	case !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until):
+		return nil, nil, fmt.Errorf("%w: since must be before until", ErrInvalidFilter)
+	}
+
+	found, err := s.txRepo.FindByFilter(ctx, lid, filter, maxBulkTargets+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching transactions: %w", err)
+	}
+	if len(found) > maxBulkTargets {
+		return nil, nil, fmt.Errorf("%w: it matches more than %d transactions, narrow it", ErrInvalidFilter, maxBulkTargets)
+	}
+	items := make([]BulkItemResult, len(found))
+	for i, tx := range found {
+		items[i] = BulkItemResult{ID: tx.ID.Hex()}
+	}
+	return items, found, nil
+}
+
+// applyBulk returns tx as the operation leaves it, or nil if the operation does not
+// change it.
+func applyBulk(tx *models.Transaction, op string, patch db.TransactionPatch) *models.Transaction {
+	after := *tx
+	switch op {
+	case BulkRecategorize:
+		if tx.CategoryID == *patch.CategoryID {
+			return nil
+		}
+		after.CategoryID = *patch.CategoryID
+	case BulkRetag:
+		if sameTags(tx.Tags, *patch.Tags) {
+			return nil
+		}
+		after.Tags = *patch.Tags
+	case BulkChangeDate:
+		if tx.Date.Truncate(time.Millisecond).Equal(patch.Date.Truncate(time.Millisecond)) {
+			return nil
+		}
+		after.Date = *patch.Date
+	}
+	return &after
+}
+
+func sameTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeTags trims tags and drops empty and repeated ones, keeping their order.
+func normalizeTags(tags []string) ([]string, error) {
+	var out []string
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxTagLength {
+			return nil, fmt.Errorf("%w: a tag may have at most %d characters", ErrInvalidTags, maxTagLength)
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	if len(out) > maxTags {
+		return nil, fmt.Errorf("%w: at most %d tags per transaction", ErrInvalidTags, maxTags)
+	}
+	return out, nil
+}
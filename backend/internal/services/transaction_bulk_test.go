@@ -0,0 +1,166 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTransactionService_Bulk_RecategorizeByIDs(t *testing.T) {
+	oldCat := primitive.NewObjectID()
+	newCat := primitive.NewObjectID()
+	moved := &models.Transaction{ID: primitive.NewObjectID(), CategoryID: oldCat, Amount: 10, Version: 3}
+	already := &models.Transaction{ID: primitive.NewObjectID(), CategoryID: newCat, Amount: 20}
+	raced := &models.Transaction{ID: primitive.NewObjectID(), CategoryID: oldCat, Amount: 30, Version: 1}
+	missing := primitive.NewObjectID()
+
+	var written []primitive.ObjectID
+	var patch db.TransactionPatch
+	txRepo := &testutil.MockTransactionRepo{
+		FindByIDsFn: func(_ context.Context, lid primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Transaction, error) {
+			if lid != testLedgerID {
+				t.Errorf("looked up in ledger %s", lid.Hex())
+			}
+			return []*models.Transaction{moved, already, raced}, nil
+		},
+		PatchFn: func(_ context.Context, id, _ primitive.ObjectID, version int64, p db.TransactionPatch) (*models.Transaction, error) {
+			if id == raced.ID {
+				// Changed by someone else between the read and the write.
+				return nil, db.ErrConflict
+			}
+			if version != moved.Version {
+				t.Errorf("expected the write conditional on version %d, got %d", moved.Version, version)
+			}
+			written, patch = append(written, id), p
+			return nil, nil
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Category, error) {
+			return &models.Category{ID: id}, nil
+		},
+	}
+	var entries []*models.AuditEntry
+	svc := services.NewTransactionService(txRepo, catRepo, testutil.OwnLedgerRepo(testLedgerID), recordingAuditRepo(&entries))
+
+	result, err := svc.Bulk(context.Background(), primitive.NewObjectID().Hex(), "", services.BulkTransactionRequest{
+		Operation:  services.BulkRecategorize,
+		IDs:        []string{moved.ID.Hex(), "nope", already.ID.Hex(), missing.Hex(), moved.ID.Hex(), raced.ID.Hex()},
+		CategoryID: newCat.Hex(),
+	})
+	if err != nil {
+		t.Fatalf("Bulk: %v", err)
+	}
+	if result.Matched != 3 || result.Modified != 1 {
+		t.Errorf("expected 3 matched and 1 modified, got %d and %d", result.Matched, result.Modified)
+	}
+	want := []services.BulkItemResult{
+		{ID: moved.ID.Hex(), Status: services.BulkItemModified},
+		{ID: "nope", Status: services.BulkItemInvalidID},
+		{ID: already.ID.Hex(), Status: services.BulkItemUnchanged},
+		{ID: missing.Hex(), Status: services.BulkItemNotFound},
+		{ID: raced.ID.Hex(), Status: services.BulkItemConflict},
+	}
+	if len(result.Items) != len(want) {
+		t.Fatalf("expected %d items, got %+v", len(want), result.Items)
+	}
+	for i := range want {
+		if result.Items[i] != want[i] {
+			t.Errorf("item %d: got %+v, want %+v", i, result.Items[i], want[i])
+		}
+	}
+	if len(written) != 1 || written[0] != moved.ID || patch.CategoryID == nil || *patch.CategoryID != newCat {
+		t.Errorf("expected only the moved transaction to be written, got %v with %+v", written, patch)
+	}
+	if len(entries) != 1 || entries[0].EntityID != moved.ID || entries[0].Action != models.AuditUpdate {
+		t.Errorf("expected one update entry, got %+v", entries)
+	}
+}
+
+func TestTransactionService_Bulk_DeleteByFilter(t *testing.T) {
+	catID := primitive.NewObjectID()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	txs := []*models.Transaction{{ID: primitive.NewObjectID()}, {ID: primitive.NewObjectID()}, {ID: primitive.NewObjectID()}}
+	gone := txs[2].ID
+
+	var got db.TransactionFilter
+	var deleted []primitive.ObjectID
+	txRepo := &testutil.MockTransactionRepo{
+		FindByFilterFn: func(_ context.Context, _ primitive.ObjectID, f db.TransactionFilter, _ int64) ([]*models.Transaction, error) {
+			got = f
+			return txs, nil
+		},
+		DeleteFn: func(_ context.Context, id, _ primitive.ObjectID, _ int64) error {
+			if id == gone {
+				// Deleted by someone else between the read and the write.
+				return db.ErrNotFound
+			}
+			deleted = append(deleted, id)
+			return nil
+		},
+	}
+	svc := newTxSvc(txRepo, &testutil.MockCategoryRepo{})
+
+	result, err := svc.Bulk(context.Background(), primitive.NewObjectID().Hex(), "", services.BulkTransactionRequest{
+		Operation: services.BulkDelete,
+		Filter:    &services.BulkTransactionFilter{CategoryID: catID.Hex(), Since: since},
+	})
+	if err != nil {
+		t.Fatalf("Bulk: %v", err)
+	}
+	if got.CategoryID == nil || *got.CategoryID != catID || !got.Since.Equal(since) {
+		t.Errorf("unexpected filter %+v", got)
+	}
+	if len(deleted) != 2 || result.Matched != 3 || result.Modified != 2 {
+		t.Errorf("expected two transactions trashed, got %v and %+v", deleted, result)
+	}
+	for _, item := range result.Items {
+		want := services.BulkItemDeleted
+		if item.ID == gone.Hex() {
+			want = services.BulkItemNotFound
+		}
+		if item.Status != want {
+			t.Errorf("expected %s, got %+v", want, item)
+		}
+	}
+}
+
+func TestTransactionService_Bulk_RejectsBadRequests(t *testing.T) {
+	txRepo := &testutil.MockTransactionRepo{
+		FindByFilterFn: func(context.Context, primitive.ObjectID, db.TransactionFilter, int64) ([]*models.Transaction, error) {
+			return make([]*models.Transaction, 1001), nil
+		},
+	}
+	svc := newTxSvc(txRepo, &testutil.MockCategoryRepo{})
+	id := primitive.NewObjectID().Hex()
+	date := time.Now()
+
+	cases := []struct {
+		name string
+		req  services.BulkTransactionRequest
+		want error
+	}{
+		{"unknown operation", services.BulkTransactionRequest{Operation: "archive", IDs: []string{id}}, services.ErrInvalidBulkOperation},
+		{"no targets", services.BulkTransactionRequest{Operation: services.BulkDelete}, services.ErrInvalidBulkOperation},
+		{"ids and filter", services.BulkTransactionRequest{Operation: services.BulkDelete, IDs: []string{id}, Filter: &services.BulkTransactionFilter{Type: "outflow"}}, services.ErrInvalidBulkOperation},
+		{"no date", services.BulkTransactionRequest{Operation: services.BulkChangeDate, IDs: []string{id}}, services.ErrInvalidBulkOperation},
+		{"no tags", services.BulkTransactionRequest{Operation: services.BulkRetag, IDs: []string{id}}, services.ErrInvalidBulkOperation},
+		{"long tag", services.BulkTransactionRequest{Operation: services.BulkRetag, IDs: []string{id}, Tags: []string{string(make([]byte, 41))}}, services.ErrInvalidTags},
+		{"empty filter", services.BulkTransactionRequest{Operation: services.BulkDelete, Filter: &services.BulkTransactionFilter{}}, services.ErrInvalidFilter},
+		{"bad type", services.BulkTransactionRequest{Operation: services.BulkDelete, Filter: &services.BulkTransactionFilter{Type: "sideways"}}, services.ErrInvalidFilter},
+		{"too many matches", services.BulkTransactionRequest{Operation: services.BulkChangeDate, Date: &date, Filter: &services.BulkTransactionFilter{Type: "outflow"}}, services.ErrInvalidFilter},
+	}
+	for _, tc := range cases {
+		if _, err := svc.Bulk(context.Background(), primitive.NewObjectID().Hex(), "", tc.req); !errors.Is(err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sort"
 	"time"
 
 	"expensify/internal/db"
@@ -19,6 +20,7 @@ type CreateTransactionRequest struct {
 	Amount      float64   `json:"amount"`
 	Description string    `json:"description"`
 	Date        time.Time `json:"date"`
+	Tags        []string  `json:"tags"`
 }
 
 // UpdateTransactionRequest holds updatable transaction fields.
@@ -28,21 +30,38 @@ type UpdateTransactionRequest struct {
 	Amount      float64   `json:"amount"`
 	Description string    `json:"description"`
 	Date        time.Time `json:"date"`
+	Tags        []string  `json:"tags"`
+}
+
+// PatchTransactionRequest holds the transaction fields a partial update changes; nil
+// fields are left as they are.
+type PatchTransactionRequest struct {
+	CategoryID  *string    `json:"category_id"`
+	Type        *string    `json:"type"`
+	Amount      *float64   `json:"amount"`
+	Description *string    `json:"description"`
+	Date        *time.Time `json:"date"`
+	Tags        *[]string  `json:"tags"`
 }
 
 // TransactionResponse is the enriched view of a transaction returned to clients.
 type TransactionResponse struct {
-	ID            string    `json:"id"`
-	CategoryID    string    `json:"category_id"`
-	CategoryName  string    `json:"category_name"`
-	CategoryColor string    `json:"category_color"`
-	CategoryIcon  string    `json:"category_icon"`
-	Type          string    `json:"type"`
-	Amount        float64   `json:"amount"`
-	Description   string    `json:"description"`
-	Date          time.Time `json:"date"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            string     `json:"id"`
+	LedgerID      string     `json:"ledger_id"`
+	UserID        string     `json:"user_id"` // who recorded it
+	CategoryID    string     `json:"category_id"`
+	CategoryName  string     `json:"category_name"`
+	CategoryColor string     `json:"category_color"`
+	CategoryIcon  string     `json:"category_icon"`
+	Type          string     `json:"type"`
+	Amount        float64    `json:"amount"`
+	Description   string     `json:"description"`
+	Date          time.Time  `json:"date"`
+	Tags          []string   `json:"tags"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	Version       int64      `json:"version"`              // sent back in If-Match to update or delete
+	DeletedAt     *time.Time `json:"deleted_at,omitempty"` // set for transactions in the trash
 }
 
 // MonthlyPoint holds aggregated cashflow totals for a single month.
@@ -53,7 +72,7 @@ type MonthlyPoint struct {
 	Outflow float64 `json:"outflow"`
 }
 
-// CategoryPoint holds outflow totals for a category, enriched with category metadata.
+// CategoryPoint holds the total for a category, enriched with category metadata.
 type CategoryPoint struct {
 	CategoryID    string  `json:"category_id"`
 	CategoryName  string  `json:"category_name"`
@@ -62,10 +81,32 @@ type CategoryPoint struct {
 	Total         float64 `json:"total"`
 }
 
+// CategoryMonthPoint holds a category's total for a single month.
+type CategoryMonthPoint struct {
+	Year  int     `json:"year"`
+	Month int     `json:"month"`
+	Total float64 `json:"total"`
+}
+
+// CategorySeries is the month-by-month trend of one category for one transaction type.
+type CategorySeries struct {
+	CategoryID    string                `json:"category_id"`
+	CategoryName  string                `json:"category_name"`
+	CategoryColor string                `json:"category_color"`
+	CategoryIcon  string                `json:"category_icon"`
+	Type          string                `json:"type"`
+	Monthly       []*CategoryMonthPoint `json:"monthly"`
+}
+
 // CashflowSummary is the response for the summary endpoint.
+// ByCategory holds outflow totals; InflowByCategory holds inflow totals.
+// Currency is the user's home currency, set by the handler from their preferences.
 type CashflowSummary struct {
-	Monthly    []*MonthlyPoint  `json:"monthly"`
-	ByCategory []*CategoryPoint `json:"by_category"`
+	Currency         string            `json:"currency"`
+	Monthly          []*MonthlyPoint   `json:"monthly"`
+	ByCategory       []*CategoryPoint  `json:"by_category"`
+	InflowByCategory []*CategoryPoint  `json:"inflow_by_category"`
+	CategorySeries   []*CategorySeries `json:"category_series"`
 }
 
 // PaginatedTransactions wraps a page of transaction responses with metadata.
@@ -77,59 +118,115 @@ type PaginatedTransactions struct {
 	TotalPages int                    `json:"total_pages"`
 }
 
-// TransactionService manages spending transactions.
+// TransactionService manages spending transactions. Every method acts on the ledger
+// ledgerID (the user's default ledger if empty): reads need the viewer role, writes editor.
 type TransactionService interface {
-	Create(ctx context.Context, userID string, req CreateTransactionRequest) (*TransactionResponse, error)
-	List(ctx context.Context, userID string, page, pageSize int) (*PaginatedTransactions, error)
-	Update(ctx context.Context, userID string, txID string, req UpdateTransactionRequest) (*TransactionResponse, error)
-	Delete(ctx context.Context, userID string, txID string) error
-	Summary(ctx context.Context, userID string, since, until time.Time) (*CashflowSummary, error)
+	Create(ctx context.Context, userID, ledgerID string, req CreateTransactionRequest) (*TransactionResponse, error)
+	// Get returns one transaction; its Version is the ETag writes must send back.
+	Get(ctx context.Context, userID, ledgerID string, txID string) (*TransactionResponse, error)
+	List(ctx context.Context, userID, ledgerID string, page, pageSize int) (*PaginatedTransactions, error)
+	// Update and Delete take the versions the caller accepts, usually the one it last read,
+	// or AnyVersion, and fail with ErrVersionMismatch if the transaction is at none of them.
+	Update(ctx context.Context, userID, ledgerID string, txID string, versions []int64, req UpdateTransactionRequest) (*TransactionResponse, error)
+	// Patch changes only the fields set in req, also checked against versions.
+	Patch(ctx context.Context, userID, ledgerID string, txID string, versions []int64, req PatchTransactionRequest) (*TransactionResponse, error)
+	// Delete moves the transaction to the trash, from which TrashService can restore it.
+	Delete(ctx context.Context, userID, ledgerID string, txID string, versions []int64) error
+	// Bulk applies one operation to many transactions, named by ID or selected by a filter.
+	Bulk(ctx context.Context, userID, ledgerID string, req BulkTransactionRequest) (*BulkTransactionResult, error)
+	// Summary aggregates cashflow in [since, until), bucketing months in loc (nil means UTC).
+	Summary(ctx context.Context, userID, ledgerID string, since, until time.Time, loc *time.Location) (*CashflowSummary, error)
 }
 
 type transactionService struct {
+	ledgerAccess
+	auditLog
 	txRepo  db.TransactionRepository
 	catRepo db.CategoryRepository
 }
 
-// NewTransactionService creates a new TransactionService.
-func NewTransactionService(txRepo db.TransactionRepository, catRepo db.CategoryRepository) TransactionService {
-	return &transactionService{txRepo: txRepo, catRepo: catRepo}
+// NewTransactionService creates a new TransactionService. Every change it makes is
+// recorded in the audit log.
+func NewTransactionService(txRepo db.TransactionRepository, catRepo db.CategoryRepository, ledgerRepo db.LedgerRepository, auditRepo db.AuditRepository) TransactionService {
+	return &transactionService{ledgerAccess: ledgerAccess{ledgerRepo: ledgerRepo}, auditLog: auditLog{auditRepo: auditRepo}, txRepo: txRepo, catRepo: catRepo}
 }
 
-func (s *transactionService) Create(ctx context.Context, userID string, req CreateTransactionRequest) (*TransactionResponse, error) {
-	uid, err := primitive.ObjectIDFromHex(userID)
+func (s *transactionService) Create(ctx context.Context, userID, ledgerID string, req CreateTransactionRequest) (*TransactionResponse, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
 	if err != nil {
-		return nil, ErrInvalidID
+		return nil, err
 	}
 	catID, err := primitive.ObjectIDFromHex(req.CategoryID)
 	if err != nil {
 		return nil, ErrInvalidID
 	}
+	cat, err := s.ledgerCategory(ctx, lid, catID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
 
 	tx := &models.Transaction{
+		LedgerID:    lid,
 		UserID:      uid,
 		CategoryID:  catID,
 		Type:        req.Type,
 		Amount:      req.Amount,
 		Description: req.Description,
 		Date:        req.Date,
+		Tags:        tags,
 	}
 	created, err := s.txRepo.Create(ctx, tx)
 	if err != nil {
 		return nil, fmt.Errorf("creating transaction: %w", err)
 	}
-
-	cat, _ := s.catRepo.FindByID(ctx, catID)
+	s.record(ctx, lid, uid, models.AuditCreate, models.AuditEntityTransaction, created.ID, nil, transactionFields(created))
 	return toResponse(created, cat), nil
 }
 
-func (s *transactionService) List(ctx context.Context, userID string, page, pageSize int) (*PaginatedTransactions, error) {
-	uid, err := primitive.ObjectIDFromHex(userID)
+// ledgerCategory returns the category if transactions in the ledger may use it: a default
+// category or one of the ledger's own.
+func (s *transactionService) ledgerCategory(ctx context.Context, ledgerID, catID primitive.ObjectID) (*models.Category, error) {
+	cat, err := s.catRepo.FindByID(ctx, ledgerID, catID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching category: %w", err)
+	}
+	if cat == nil {
+		return nil, ErrUnknownCategory
+	}
+	return cat, nil
+}
+
+func (s *transactionService) Get(ctx context.Context, userID, ledgerID string, txID string) (*TransactionResponse, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	tid, err := primitive.ObjectIDFromHex(txID)
 	if err != nil {
 		return nil, ErrInvalidID
 	}
+	tx, err := s.find(ctx, lid, tid)
+	if err != nil {
+		return nil, err
+	}
+	cat, err := s.catRepo.FindByID(ctx, lid, tx.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching category: %w", err)
+	}
+	return toResponse(tx, cat), nil
+}
 
-	txs, total, err := s.txRepo.FindByUserID(ctx, uid, page, pageSize)
+func (s *transactionService) List(ctx context.Context, userID, ledgerID string, page, pageSize int) (*PaginatedTransactions, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	txs, total, err := s.txRepo.FindByLedgerID(ctx, lid, page, pageSize)
 	if err != nil {
 		return nil, fmt.Errorf("fetching transactions: %w", err)
 	}
@@ -146,7 +243,7 @@ func (s *transactionService) List(ctx context.Context, userID string, page, page
 
 	cats := make(map[primitive.ObjectID]*models.Category)
 	if len(ids) > 0 {
-		fetched, err := s.catRepo.FindByIDs(ctx, ids)
+		fetched, err := s.catRepo.FindByIDs(ctx, lid, ids)
 		if err == nil {
 			for _, c := range fetched {
 				cats[c.ID] = c
@@ -169,10 +266,10 @@ func (s *transactionService) List(ctx context.Context, userID string, page, page
 	}, nil
 }
 
-func (s *transactionService) Update(ctx context.Context, userID string, txID string, req UpdateTransactionRequest) (*TransactionResponse, error) {
-	uid, err := primitive.ObjectIDFromHex(userID)
+func (s *transactionService) Update(ctx context.Context, userID, ledgerID string, txID string, versions []int64, req UpdateTransactionRequest) (*TransactionResponse, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
 	if err != nil {
-		return nil, ErrInvalidID
+		return nil, err
 	}
 	tid, err := primitive.ObjectIDFromHex(txID)
 	if err != nil {
@@ -182,57 +279,149 @@ func (s *transactionService) Update(ctx context.Context, userID string, txID str
 	if err != nil {
 		return nil, ErrInvalidID
 	}
+	cat, err := s.ledgerCategory(ctx, lid, catID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return nil, err
+	}
+	before, err := s.find(ctx, lid, tid)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(before.Version, versions); err != nil {
+		return nil, err
+	}
 
 	tx := &models.Transaction{
 		ID:          tid,
-		UserID:      uid,
+		LedgerID:    lid,
 		CategoryID:  catID,
 		Type:        req.Type,
 		Amount:      req.Amount,
 		Description: req.Description,
 		Date:        req.Date,
+		Tags:        tags,
+		Version:     before.Version,
 	}
 	updated, err := s.txRepo.Update(ctx, tx)
 	if err != nil {
-		if err == db.ErrNotFound {
-			return nil, ErrNotFound
+		return nil, versionedWriteError(err, "updating transaction")
+	}
+	s.record(ctx, lid, uid, models.AuditUpdate, models.AuditEntityTransaction, tid, transactionFields(before), transactionFields(updated))
+	return toResponse(updated, cat), nil
+}
+
+func (s *transactionService) Patch(ctx context.Context, userID, ledgerID string, txID string, versions []int64, req PatchTransactionRequest) (*TransactionResponse, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tid, err := primitive.ObjectIDFromHex(txID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+	patch := db.TransactionPatch{Type: req.Type, Amount: req.Amount, Description: req.Description, Date: req.Date}
+	if req.CategoryID != nil {
+		catID, err := primitive.ObjectIDFromHex(*req.CategoryID)
+		if err != nil {
+			return nil, ErrInvalidID
+		}
+		patch.CategoryID = &catID
+	}
+	if req.Tags != nil {
+		tags, err := normalizeTags(*req.Tags)
+		if err != nil {
+			return nil, err
+		}
+		if tags == nil {
+			tags = []string{}
 		}
-		return nil, fmt.Errorf("updating transaction: %w", err)
+		patch.Tags = &tags
+	}
+	before, err := s.find(ctx, lid, tid)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(before.Version, versions); err != nil {
+		return nil, err
+	}
+	catID := before.CategoryID
+	if patch.CategoryID != nil {
+		catID = *patch.CategoryID
+	}
+	cat, err := s.ledgerCategory(ctx, lid, catID)
+	if err != nil {
+		return nil, err
+	}
+	if patch == (db.TransactionPatch{}) {
+		return toResponse(before, cat), nil
 	}
 
-	cat, _ := s.catRepo.FindByID(ctx, catID)
+	updated, err := s.txRepo.Patch(ctx, tid, lid, before.Version, patch)
+	if err != nil {
+		return nil, versionedWriteError(err, "patching transaction")
+	}
+	s.record(ctx, lid, uid, models.AuditUpdate, models.AuditEntityTransaction, tid, transactionFields(before), transactionFields(updated))
 	return toResponse(updated, cat), nil
 }
 
-func (s *transactionService) Delete(ctx context.Context, userID string, txID string) error {
-	uid, err := primitive.ObjectIDFromHex(userID)
+func (s *transactionService) Delete(ctx context.Context, userID, ledgerID string, txID string, versions []int64) error {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
 	if err != nil {
-		return ErrInvalidID
+		return err
 	}
 	tid, err := primitive.ObjectIDFromHex(txID)
 	if err != nil {
 		return ErrInvalidID
 	}
+	before, err := s.find(ctx, lid, tid)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(before.Version, versions); err != nil {
+		return err
+	}
 
-	if err := s.txRepo.Delete(ctx, tid, uid); err != nil {
-		if err == db.ErrNotFound {
-			return ErrNotFound
-		}
-		return fmt.Errorf("deleting transaction: %w", err)
+	if err := s.txRepo.Delete(ctx, tid, lid, before.Version); err != nil {
+		return versionedWriteError(err, "deleting transaction")
 	}
+	s.record(ctx, lid, uid, models.AuditDelete, models.AuditEntityTransaction, tid, transactionFields(before), nil)
 	return nil
 }
 
+// find fetches a transaction of the ledger, the state an audit entry records as before.
+func (s *transactionService) find(ctx context.Context, lid, tid primitive.ObjectID) (*models.Transaction, error) {
+	tx, err := s.txRepo.FindByID(ctx, lid, tid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching transaction: %w", err)
+	}
+	if tx == nil {
+		return nil, ErrNotFound
+	}
+	return tx, nil
+}
+
 func toResponse(tx *models.Transaction, cat *models.Category) *TransactionResponse {
 	resp := &TransactionResponse{
 		ID:          tx.ID.Hex(),
+		LedgerID:    tx.LedgerID.Hex(),
+		UserID:      tx.UserID.Hex(),
 		CategoryID:  tx.CategoryID.Hex(),
 		Type:        tx.Type,
 		Amount:      tx.Amount,
 		Description: tx.Description,
 		Date:        tx.Date,
+		Tags:        tx.Tags,
 		CreatedAt:   tx.CreatedAt,
 		UpdatedAt:   tx.UpdatedAt,
+		Version:     tx.Version,
+		DeletedAt:   tx.DeletedAt,
+	}
+	if resp.Tags == nil {
+		resp.Tags = []string{}
 	}
 	if cat != nil {
 		resp.CategoryName = cat.Name
@@ -242,44 +431,84 @@ func toResponse(tx *models.Transaction, cat *models.Category) *TransactionRespon
 	return resp
 }
 
-func (s *transactionService) Summary(ctx context.Context, userID string, since, until time.Time) (*CashflowSummary, error) {
-	uid, err := primitive.ObjectIDFromHex(userID)
+func (s *transactionService) Summary(ctx context.Context, userID, ledgerID string, since, until time.Time, loc *time.Location) (*CashflowSummary, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
 	if err != nil {
-		return nil, ErrInvalidID
+		return nil, err
+	}
+	if loc == nil {
+		loc = time.UTC
 	}
 
-	monthlyAggs, err := s.txRepo.GetMonthlySummary(ctx, uid, since, until)
+	monthlyAggs, err := s.txRepo.GetMonthlySummary(ctx, lid, since, until, loc.String())
 	if err != nil {
 		return nil, fmt.Errorf("monthly summary: %w", err)
 	}
 
-	catAggs, err := s.txRepo.GetCategoryTotals(ctx, uid, "outflow", since, until)
+	outflowAggs, err := s.txRepo.GetCategoryTotals(ctx, lid, "outflow", since, until)
+	if err != nil {
+		return nil, fmt.Errorf("outflow category totals: %w", err)
+	}
+
+	inflowAggs, err := s.txRepo.GetCategoryTotals(ctx, lid, "inflow", since, until)
 	if err != nil {
-		return nil, fmt.Errorf("category totals: %w", err)
+		return nil, fmt.Errorf("inflow category totals: %w", err)
+	}
+
+	seriesAggs, err := s.txRepo.GetCategoryMonthlyTotals(ctx, lid, since, until, loc.String())
+	if err != nil {
+		return nil, fmt.Errorf("category monthly totals: %w", err)
 	}
 
 	// Batch-fetch categories for enrichment.
-	catIDs := make([]primitive.ObjectID, 0, len(catAggs))
-	for _, ca := range catAggs {
-		catIDs = append(catIDs, ca.CategoryID)
+	seen := make(map[primitive.ObjectID]struct{})
+	for _, ca := range outflowAggs {
+		seen[ca.CategoryID] = struct{}{}
 	}
-	catMap := make(map[primitive.ObjectID]*models.Category)
-	if len(catIDs) > 0 {
-		fetched, err := s.catRepo.FindByIDs(ctx, catIDs)
-		if err == nil {
-			for _, c := range fetched {
-				catMap[c.ID] = c
-			}
-		}
+	for _, ca := range inflowAggs {
+		seen[ca.CategoryID] = struct{}{}
 	}
+	for _, ca := range seriesAggs {
+		seen[ca.CategoryID] = struct{}{}
+	}
+	catMap := fetchCategoryMap(ctx, s.catRepo, lid, seen)
 
 	monthly := make([]*MonthlyPoint, len(monthlyAggs))
 	for i, a := range monthlyAggs {
 		monthly[i] = &MonthlyPoint{Year: a.Year, Month: a.Month, Inflow: a.Inflow, Outflow: a.Outflow}
 	}
 
-	byCategory := make([]*CategoryPoint, 0, len(catAggs))
-	for _, ca := range catAggs {
+	return &CashflowSummary{
+		Monthly:          monthly,
+		ByCategory:       toCategoryPoints(outflowAggs, catMap),
+		InflowByCategory: toCategoryPoints(inflowAggs, catMap),
+		CategorySeries:   toCategorySeries(seriesAggs, catMap),
+	}, nil
+}
+
+// fetchCategoryMap loads the given categories keyed by ID. Lookup failures are
+// tolerated so a report still renders without category metadata.
+func fetchCategoryMap(ctx context.Context, catRepo db.CategoryRepository, ledgerID primitive.ObjectID, ids map[primitive.ObjectID]struct{}) map[primitive.ObjectID]*models.Category {
+	catMap := make(map[primitive.ObjectID]*models.Category)
+	if len(ids) == 0 {
+		return catMap
+	}
+	list := make([]primitive.ObjectID, 0, len(ids))
+	for id := range ids {
+		list = append(list, id)
+	}
+	fetched, err := catRepo.FindByIDs(ctx, ledgerID, list)
+	if err == nil {
+		for _, c := range fetched {
+			catMap[c.ID] = c
+		}
+	}
+	return catMap
+}
+
+func toCategoryPoints(aggs []*db.CategoryAgg, catMap map[primitive.ObjectID]*models.Category) []*CategoryPoint {
+	points := make([]*CategoryPoint, 0, len(aggs))
+	for _, ca := range aggs {
 		cp := &CategoryPoint{
 			CategoryID: ca.CategoryID.Hex(),
 			Total:      ca.Total,
@@ -289,8 +518,43 @@ func (s *transactionService) Summary(ctx context.Context, userID string, since,
 			cp.CategoryColor = cat.Color
 			cp.CategoryIcon = cat.Icon
 		}
-		byCategory = append(byCategory, cp)
+		points = append(points, cp)
+	}
+	return points
+}
+
+// toCategorySeries groups month-ordered aggregates into one series per (category, type),
+// ordered by type (outflow first) and then by period total descending.
+func toCategorySeries(aggs []*db.CategoryMonthlyAgg, catMap map[primitive.ObjectID]*models.Category) []*CategorySeries {
+	type seriesKey struct {
+		id  primitive.ObjectID
+		typ string
+	}
+	byKey := make(map[seriesKey]*CategorySeries)
+	totals := make(map[*CategorySeries]float64)
+	series := make([]*CategorySeries, 0)
+	for _, a := range aggs {
+		k := seriesKey{a.CategoryID, a.Type}
+		cs, ok := byKey[k]
+		if !ok {
+			cs = &CategorySeries{CategoryID: a.CategoryID.Hex(), Type: a.Type}
+			if cat, ok := catMap[a.CategoryID]; ok {
+				cs.CategoryName = cat.Name
+				cs.CategoryColor = cat.Color
+				cs.CategoryIcon = cat.Icon
+			}
+			byKey[k] = cs
+			series = append(series, cs)
+		}
+		cs.Monthly = append(cs.Monthly, &CategoryMonthPoint{Year: a.Year, Month: a.Month, Total: a.Total})
+		totals[cs] += a.Total
 	}
 
-	return &CashflowSummary{Monthly: monthly, ByCategory: byCategory}, nil
+	sort.SliceStable(series, func(i, j int) bool {
+		if series[i].Type != series[j].Type {
+			return series[i].Type == "outflow"
+		}
+		return totals[series[i]] > totals[series[j]]
+	})
+	return series
 }
@@ -2,6 +2,8 @@ package services_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -14,7 +16,7 @@ import (
 )
 
 func newTxSvc(txRepo *testutil.MockTransactionRepo, catRepo *testutil.MockCategoryRepo) services.TransactionService {
-	return services.NewTransactionService(txRepo, catRepo)
+	return services.NewTransactionService(txRepo, catRepo, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
 }
 
 func TestTransactionService_Create(t *testing.T) {
@@ -32,7 +34,7 @@ func TestTransactionService_Create(t *testing.T) {
 		},
 	}
 	catRepo := &testutil.MockCategoryRepo{
-		FindByIDFn: func(_ context.Context, id primitive.ObjectID) (*models.Category, error) {
+		FindByIDFn: func(_ context.Context, _ primitive.ObjectID, id primitive.ObjectID) (*models.Category, error) {
 			if id == catID {
 				return cat, nil
 			}
@@ -49,7 +51,7 @@ func TestTransactionService_Create(t *testing.T) {
 		Date:        time.Now(),
 	}
 
-	resp, err := svc.Create(context.Background(), userID.Hex(), req)
+	resp, err := svc.Create(context.Background(), userID.Hex(), "", req)
 	if err != nil {
 		t.Fatalf("Create: %v", err)
 	}
@@ -67,18 +69,39 @@ func TestTransactionService_Create(t *testing.T) {
 func TestTransactionService_Create_InvalidIDs(t *testing.T) {
 	svc := newTxSvc(&testutil.MockTransactionRepo{}, &testutil.MockCategoryRepo{})
 
-	_, err := svc.Create(context.Background(), "bad-uid", services.CreateTransactionRequest{CategoryID: primitive.NewObjectID().Hex(), Amount: 10})
+	_, err := svc.Create(context.Background(), "bad-uid", "", services.CreateTransactionRequest{CategoryID: primitive.NewObjectID().Hex(), Amount: 10})
 	if err != services.ErrInvalidID {
 		t.Errorf("expected ErrInvalidID for bad user ID, got %v", err)
 	}
 
 	uid := primitive.NewObjectID()
-	_, err = svc.Create(context.Background(), uid.Hex(), services.CreateTransactionRequest{CategoryID: "bad-cat", Amount: 10})
+	_, err = svc.Create(context.Background(), uid.Hex(), "", services.CreateTransactionRequest{CategoryID: "bad-cat", Amount: 10})
 	if err != services.ErrInvalidID {
 		t.Errorf("expected ErrInvalidID for bad cat ID, got %v", err)
 	}
 }
 
+func TestTransactionService_Create_CategoryOutsideLedger(t *testing.T) {
+	var lookedIn primitive.ObjectID
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDFn: func(_ context.Context, ledgerID, _ primitive.ObjectID) (*models.Category, error) {
+			lookedIn = ledgerID
+			return nil, nil
+		},
+	}
+
+	svc := newTxSvc(&testutil.MockTransactionRepo{}, catRepo)
+	_, err := svc.Create(context.Background(), primitive.NewObjectID().Hex(), "", services.CreateTransactionRequest{
+		CategoryID: primitive.NewObjectID().Hex(), Type: "outflow", Amount: 10,
+	})
+	if err != services.ErrUnknownCategory {
+		t.Errorf("expected ErrUnknownCategory, got %v", err)
+	}
+	if lookedIn != testLedgerID {
+		t.Errorf("category looked up in ledger %s, want %s", lookedIn.Hex(), testLedgerID.Hex())
+	}
+}
+
 func TestTransactionService_List_EnrichesWithCategory(t *testing.T) {
 	userID := primitive.NewObjectID()
 	catID := primitive.NewObjectID()
@@ -91,18 +114,18 @@ func TestTransactionService_List_EnrichesWithCategory(t *testing.T) {
 	}
 
 	txRepo := &testutil.MockTransactionRepo{
-		FindByUserIDFn: func(_ context.Context, _ primitive.ObjectID, _, _ int) ([]*models.Transaction, int64, error) {
+		FindByLedgerIDFn: func(_ context.Context, _ primitive.ObjectID, _, _ int) ([]*models.Transaction, int64, error) {
 			return txs, 1, nil
 		},
 	}
 	catRepo := &testutil.MockCategoryRepo{
-		FindByIDsFn: func(_ context.Context, _ []primitive.ObjectID) ([]*models.Category, error) {
+		FindByIDsFn: func(_ context.Context, _ primitive.ObjectID, _ []primitive.ObjectID) ([]*models.Category, error) {
 			return cats, nil
 		},
 	}
 
 	svc := newTxSvc(txRepo, catRepo)
-	result, err := svc.List(context.Background(), userID.Hex(), 1, 20)
+	result, err := svc.List(context.Background(), userID.Hex(), "", 1, 20)
 	if err != nil {
 		t.Fatalf("List: %v", err)
 	}
@@ -125,14 +148,14 @@ func TestTransactionService_List_Pagination(t *testing.T) {
 	userID := primitive.NewObjectID()
 
 	txRepo := &testutil.MockTransactionRepo{
-		FindByUserIDFn: func(_ context.Context, _ primitive.ObjectID, page, pageSize int) ([]*models.Transaction, int64, error) {
+		FindByLedgerIDFn: func(_ context.Context, _ primitive.ObjectID, page, pageSize int) ([]*models.Transaction, int64, error) {
 			return []*models.Transaction{}, 47, nil
 		},
 	}
 	catRepo := &testutil.MockCategoryRepo{}
 
 	svc := newTxSvc(txRepo, catRepo)
-	result, err := svc.List(context.Background(), userID.Hex(), 1, 20)
+	result, err := svc.List(context.Background(), userID.Hex(), "", 1, 20)
 	if err != nil {
 		t.Fatalf("List: %v", err)
 	}
@@ -153,19 +176,25 @@ func TestTransactionService_Update_Success(t *testing.T) {
 	cat := &models.Category{ID: catID, Name: "Shopping", Icon: "🛍️", Color: "#45b7d1"}
 
 	txRepo := &testutil.MockTransactionRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Transaction, error) {
+			return &models.Transaction{ID: id, UserID: userID, CategoryID: catID, Amount: 50}, nil
+		},
 		UpdateFn: func(_ context.Context, tx *models.Transaction) (*models.Transaction, error) {
+			if len(tx.Tags) != 1 || tx.Tags[0] != "work" {
+				t.Errorf("expected the normalized tags to be written, got %q", tx.Tags)
+			}
 			return updatedTx, nil
 		},
 	}
 	catRepo := &testutil.MockCategoryRepo{
-		FindByIDFn: func(_ context.Context, _ primitive.ObjectID) (*models.Category, error) { return cat, nil },
+		FindByIDFn: func(_ context.Context, _, _ primitive.ObjectID) (*models.Category, error) { return cat, nil },
 	}
 
 	svc := newTxSvc(txRepo, catRepo)
 	req := services.UpdateTransactionRequest{
-		CategoryID: catID.Hex(), Amount: 75, Description: "updated", Date: time.Now(),
+		CategoryID: catID.Hex(), Amount: 75, Description: "updated", Date: time.Now(), Tags: []string{" work ", "work"},
 	}
-	resp, err := svc.Update(context.Background(), userID.Hex(), txID.Hex(), req)
+	resp, err := svc.Update(context.Background(), userID.Hex(), "", txID.Hex(), []int64{0}, req)
 	if err != nil {
 		t.Fatalf("Update: %v", err)
 	}
@@ -180,11 +209,19 @@ func TestTransactionService_Update_NotOwned(t *testing.T) {
 	txID := primitive.NewObjectID()
 
 	txRepo := &testutil.MockTransactionRepo{
-		UpdateFn: func(_ context.Context, _ *models.Transaction) (*models.Transaction, error) { return nil, db.ErrNotFound },
+		UpdateFn: func(_ context.Context, _ *models.Transaction) (*models.Transaction, error) {
+			return nil, db.ErrNotFound
+		},
 	}
 
-	svc := newTxSvc(txRepo, &testutil.MockCategoryRepo{})
-	_, err := svc.Update(context.Background(), userID.Hex(), txID.Hex(), services.UpdateTransactionRequest{
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Category, error) {
+			return &models.Category{ID: id}, nil
+		},
+	}
+
+	svc := newTxSvc(txRepo, catRepo)
+	_, err := svc.Update(context.Background(), userID.Hex(), "", txID.Hex(), []int64{0}, services.UpdateTransactionRequest{
 		CategoryID: catID.Hex(), Amount: 10,
 	})
 	if err != services.ErrNotFound {
@@ -198,8 +235,11 @@ func TestTransactionService_Delete_Success(t *testing.T) {
 	deleted := false
 
 	txRepo := &testutil.MockTransactionRepo{
-		DeleteFn: func(_ context.Context, id, uid primitive.ObjectID) error {
-			if id == txID && uid == userID {
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Transaction, error) {
+			return &models.Transaction{ID: id}, nil
+		},
+		DeleteFn: func(_ context.Context, id, lid primitive.ObjectID, _ int64) error {
+			if id == txID && lid == testLedgerID {
 				deleted = true
 			}
 			return nil
@@ -207,7 +247,7 @@ func TestTransactionService_Delete_Success(t *testing.T) {
 	}
 
 	svc := newTxSvc(txRepo, &testutil.MockCategoryRepo{})
-	if err := svc.Delete(context.Background(), userID.Hex(), txID.Hex()); err != nil {
+	if err := svc.Delete(context.Background(), userID.Hex(), "", txID.Hex(), []int64{0}); err != nil {
 		t.Fatalf("Delete: %v", err)
 	}
 	if !deleted {
@@ -220,22 +260,197 @@ func TestTransactionService_Delete_NotOwned(t *testing.T) {
 	txID := primitive.NewObjectID()
 
 	txRepo := &testutil.MockTransactionRepo{
-		DeleteFn: func(_ context.Context, _, _ primitive.ObjectID) error { return db.ErrNotFound },
+		DeleteFn: func(_ context.Context, _, _ primitive.ObjectID, _ int64) error { return db.ErrNotFound },
 	}
 
 	svc := newTxSvc(txRepo, &testutil.MockCategoryRepo{})
-	err := svc.Delete(context.Background(), userID.Hex(), txID.Hex())
+	err := svc.Delete(context.Background(), userID.Hex(), "", txID.Hex(), []int64{0})
 	if err != services.ErrNotFound {
 		t.Errorf("expected ErrNotFound, got %v", err)
 	}
 }
 
+func TestTransactionService_Update_Versions(t *testing.T) {
+	userID := primitive.NewObjectID()
+	catID := primitive.NewObjectID()
+	txID := primitive.NewObjectID()
+
+	var written int64 = -1
+	txRepo := &testutil.MockTransactionRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Transaction, error) {
+			return &models.Transaction{ID: id, CategoryID: catID, Amount: 50, Version: 3}, nil
+		},
+		UpdateFn: func(_ context.Context, tx *models.Transaction) (*models.Transaction, error) {
+			written = tx.Version
+			return nil, db.ErrConflict
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Category, error) {
+			return &models.Category{ID: id}, nil
+		},
+	}
+	svc := newTxSvc(txRepo, catRepo)
+	req := services.UpdateTransactionRequest{CategoryID: catID.Hex(), Type: "outflow", Amount: 75}
+
+	_, err := svc.Update(context.Background(), userID.Hex(), "", txID.Hex(), []int64{2}, req)
+	if !errors.Is(err, services.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch for a stale version, got %v", err)
+	}
+	if written != -1 {
+		t.Error("expected no write for a stale version")
+	}
+
+	// The version matched when read but a concurrent write got in first.
+	_, err = svc.Update(context.Background(), userID.Hex(), "", txID.Hex(), []int64{3}, req)
+	if !errors.Is(err, services.ErrEditConflict) {
+		t.Errorf("expected ErrEditConflict, got %v", err)
+	}
+	if written != 3 {
+		t.Errorf("expected the write to be conditional on version 3, got %d", written)
+	}
+
+	// If-Match: * accepts whatever is current but still writes at the version read.
+	written = -1
+	_, err = svc.Update(context.Background(), userID.Hex(), "", txID.Hex(), []int64{services.AnyVersion}, req)
+	if !errors.Is(err, services.ErrEditConflict) || written != 3 {
+		t.Errorf("expected a write at version 3 for AnyVersion, got version %d, %v", written, err)
+	}
+
+	// A list of versions matches if the current one is among them.
+	written = -1
+	_, err = svc.Update(context.Background(), userID.Hex(), "", txID.Hex(), []int64{2, 3}, req)
+	if !errors.Is(err, services.ErrEditConflict) || written != 3 {
+		t.Errorf("expected a write at version 3 for a list naming it, got version %d, %v", written, err)
+	}
+}
+
+func TestTransactionService_Get(t *testing.T) {
+	catID := primitive.NewObjectID()
+	txID := primitive.NewObjectID()
+	txRepo := &testutil.MockTransactionRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Transaction, error) {
+			if id != txID {
+				return nil, nil
+			}
+			return &models.Transaction{ID: id, CategoryID: catID, Amount: 50, Version: 4}, nil
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Category, error) {
+			return &models.Category{ID: id, Name: "Food"}, nil
+		},
+	}
+	svc := newTxSvc(txRepo, catRepo)
+	userID := primitive.NewObjectID().Hex()
+
+	tx, err := svc.Get(context.Background(), userID, "", txID.Hex())
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if tx.Version != 4 || tx.CategoryName != "Food" {
+		t.Errorf("unexpected transaction %+v", tx)
+	}
+	if _, err := svc.Get(context.Background(), userID, "", primitive.NewObjectID().Hex()); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestTransactionService_Patch(t *testing.T) {
+	userID := primitive.NewObjectID()
+	catID := primitive.NewObjectID()
+	txID := primitive.NewObjectID()
+
+	current := &models.Transaction{ID: txID, CategoryID: catID, Type: "outflow", Amount: 50, Description: "old", Version: 2}
+	var got db.TransactionPatch
+	patches := 0
+	txRepo := &testutil.MockTransactionRepo{
+		FindByIDFn: func(context.Context, primitive.ObjectID, primitive.ObjectID) (*models.Transaction, error) {
+			return current, nil
+		},
+		PatchFn: func(_ context.Context, id, _ primitive.ObjectID, version int64, patch db.TransactionPatch) (*models.Transaction, error) {
+			patches++
+			got = patch
+			updated := *current
+			updated.Description = *patch.Description
+			updated.Version = version + 1
+			return &updated, nil
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Category, error) {
+			if id == catID {
+				return &models.Category{ID: id, Name: "Food"}, nil
+			}
+			return nil, nil
+		},
+	}
+	var entries []*models.AuditEntry
+	svc := services.NewTransactionService(txRepo, catRepo, testutil.OwnLedgerRepo(testLedgerID), recordingAuditRepo(&entries))
+
+	desc := "new"
+	resp, err := svc.Patch(context.Background(), userID.Hex(), "", txID.Hex(), []int64{2}, services.PatchTransactionRequest{Description: &desc})
+	if err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if got.Description == nil || *got.Description != "new" || got.Amount != nil || got.CategoryID != nil || got.Type != nil || got.Date != nil {
+		t.Errorf("expected only the description in the patch, got %+v", got)
+	}
+	if resp.Description != "new" || resp.Amount != 50 || resp.CategoryName != "Food" || resp.Version != 3 {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	if len(entries) != 1 || entries[0].Action != models.AuditUpdate {
+		t.Errorf("expected an update entry, got %+v", entries)
+	}
+
+	// An empty patch changes nothing.
+	if resp, err := svc.Patch(context.Background(), userID.Hex(), "", txID.Hex(), []int64{2}, services.PatchTransactionRequest{}); err != nil || resp.Version != 2 || patches != 1 {
+		t.Errorf("expected the current transaction without a write, got %+v, %v", resp, err)
+	}
+
+	other := primitive.NewObjectID().Hex()
+	if _, err := svc.Patch(context.Background(), userID.Hex(), "", txID.Hex(), []int64{2}, services.PatchTransactionRequest{CategoryID: &other}); !errors.Is(err, services.ErrUnknownCategory) {
+		t.Errorf("expected ErrUnknownCategory, got %v", err)
+	}
+	if _, err := svc.Patch(context.Background(), userID.Hex(), "", txID.Hex(), []int64{1}, services.PatchTransactionRequest{Description: &desc}); !errors.Is(err, services.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch for a stale version, got %v", err)
+	}
+	tooMany := make([]string, 21)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprint("t", i)
+	}
+	if _, err := svc.Patch(context.Background(), userID.Hex(), "", txID.Hex(), []int64{2}, services.PatchTransactionRequest{Tags: &tooMany}); !errors.Is(err, services.ErrInvalidTags) {
+		t.Errorf("expected ErrInvalidTags, got %v", err)
+	}
+	if patches != 1 {
+		t.Errorf("expected rejected patches not to be written, got %d writes", patches)
+	}
+}
+
+func TestTransactionService_Delete_StaleVersion(t *testing.T) {
+	txRepo := &testutil.MockTransactionRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Transaction, error) {
+			return &models.Transaction{ID: id, Version: 5}, nil
+		},
+		DeleteFn: func(context.Context, primitive.ObjectID, primitive.ObjectID, int64) error {
+			t.Fatal("a stale delete must not reach the repository")
+			return nil
+		},
+	}
+
+	svc := newTxSvc(txRepo, &testutil.MockCategoryRepo{})
+	err := svc.Delete(context.Background(), primitive.NewObjectID().Hex(), "", primitive.NewObjectID().Hex(), []int64{4})
+	if !errors.Is(err, services.ErrVersionMismatch) {
+		t.Errorf("expected ErrVersionMismatch, got %v", err)
+	}
+}
+
 func TestTransactionService_Summary(t *testing.T) {
 	userID := primitive.NewObjectID()
 	catID := primitive.NewObjectID()
 
 	txRepo := &testutil.MockTransactionRepo{
-		GetMonthlySummaryFn: func(_ context.Context, _ primitive.ObjectID, _, _ time.Time) ([]*db.MonthlyAgg, error) {
+		GetMonthlySummaryFn: func(_ context.Context, _ primitive.ObjectID, _, _ time.Time, _ string) ([]*db.MonthlyAgg, error) {
 			return []*db.MonthlyAgg{
 				{Year: 2024, Month: 1, Inflow: 1000, Outflow: 500},
 				{Year: 2024, Month: 2, Inflow: 0, Outflow: 300},
@@ -248,7 +463,7 @@ func TestTransactionService_Summary(t *testing.T) {
 		},
 	}
 	catRepo := &testutil.MockCategoryRepo{
-		FindByIDsFn: func(_ context.Context, _ []primitive.ObjectID) ([]*models.Category, error) {
+		FindByIDsFn: func(_ context.Context, _ primitive.ObjectID, _ []primitive.ObjectID) ([]*models.Category, error) {
 			return []*models.Category{
 				{ID: catID, Name: "Food", Icon: "🍕", Color: "#ff0000"},
 			}, nil
@@ -257,7 +472,7 @@ func TestTransactionService_Summary(t *testing.T) {
 
 	svc := newTxSvc(txRepo, catRepo)
 	since := time.Now().AddDate(0, -6, 0)
-	summary, err := svc.Summary(context.Background(), userID.Hex(), since, time.Time{})
+	summary, err := svc.Summary(context.Background(), userID.Hex(), "", since, time.Time{}, nil)
 	if err != nil {
 		t.Fatalf("Summary: %v", err)
 	}
@@ -277,3 +492,94 @@ func TestTransactionService_Summary(t *testing.T) {
 		t.Errorf("category total: got %v, want 500", summary.ByCategory[0].Total)
 	}
 }
+
+func TestTransactionService_Summary_PassesTimeZone(t *testing.T) {
+	userID := primitive.NewObjectID()
+	var gotTZ string
+
+	txRepo := &testutil.MockTransactionRepo{
+		GetMonthlySummaryFn: func(_ context.Context, _ primitive.ObjectID, _, _ time.Time, tz string) ([]*db.MonthlyAgg, error) {
+			gotTZ = tz
+			return nil, nil
+		},
+	}
+
+	svc := newTxSvc(txRepo, &testutil.MockCategoryRepo{})
+	loc, _ := time.LoadLocation("America/Los_Angeles")
+	if _, err := svc.Summary(context.Background(), userID.Hex(), "", time.Now(), time.Time{}, loc); err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if gotTZ != "America/Los_Angeles" {
+		t.Errorf("timezone: got %q, want America/Los_Angeles", gotTZ)
+	}
+
+	if _, err := svc.Summary(context.Background(), userID.Hex(), "", time.Now(), time.Time{}, nil); err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if gotTZ != "UTC" {
+		t.Errorf("timezone with nil location: got %q, want UTC", gotTZ)
+	}
+}
+
+func TestTransactionService_Summary_InflowAndSeries(t *testing.T) {
+	userID := primitive.NewObjectID()
+	salaryID := primitive.NewObjectID()
+	foodID := primitive.NewObjectID()
+	rentID := primitive.NewObjectID()
+
+	txRepo := &testutil.MockTransactionRepo{
+		GetCategoryTotalsFn: func(_ context.Context, _ primitive.ObjectID, txType string, _, _ time.Time) ([]*db.CategoryAgg, error) {
+			if txType == "inflow" {
+				return []*db.CategoryAgg{{CategoryID: salaryID, Total: 5000}}, nil
+			}
+			return []*db.CategoryAgg{{CategoryID: rentID, Total: 2000}, {CategoryID: foodID, Total: 300}}, nil
+		},
+		GetCategoryMonthlyTotalsFn: func(_ context.Context, _ primitive.ObjectID, _, _ time.Time, _ string) ([]*db.CategoryMonthlyAgg, error) {
+			return []*db.CategoryMonthlyAgg{
+				{CategoryID: foodID, Type: "outflow", Year: 2024, Month: 1, Total: 100},
+				{CategoryID: salaryID, Type: "inflow", Year: 2024, Month: 1, Total: 2500},
+				{CategoryID: rentID, Type: "outflow", Year: 2024, Month: 1, Total: 1000},
+				{CategoryID: foodID, Type: "outflow", Year: 2024, Month: 2, Total: 200},
+				{CategoryID: salaryID, Type: "inflow", Year: 2024, Month: 2, Total: 2500},
+				{CategoryID: rentID, Type: "outflow", Year: 2024, Month: 2, Total: 1000},
+			}, nil
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDsFn: func(_ context.Context, _ primitive.ObjectID, _ []primitive.ObjectID) ([]*models.Category, error) {
+			return []*models.Category{
+				{ID: salaryID, Name: "Salary"},
+				{ID: foodID, Name: "Food"},
+				{ID: rentID, Name: "Housing"},
+			}, nil
+		},
+	}
+
+	svc := newTxSvc(txRepo, catRepo)
+	summary, err := svc.Summary(context.Background(), userID.Hex(), "", time.Now().AddDate(-1, 0, 0), time.Time{}, nil)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+
+	if len(summary.InflowByCategory) != 1 || summary.InflowByCategory[0].CategoryName != "Salary" {
+		t.Errorf("inflow_by_category: got %+v", summary.InflowByCategory)
+	}
+	if len(summary.ByCategory) != 2 || summary.ByCategory[0].CategoryName != "Housing" {
+		t.Errorf("by_category: got %+v", summary.ByCategory)
+	}
+
+	if len(summary.CategorySeries) != 3 {
+		t.Fatalf("category_series: got %d, want 3", len(summary.CategorySeries))
+	}
+	// Outflow series first, largest total first; inflow series last.
+	wantOrder := []string{"Housing", "Food", "Salary"}
+	for i, name := range wantOrder {
+		if summary.CategorySeries[i].CategoryName != name {
+			t.Errorf("series[%d]: got %q, want %q", i, summary.CategorySeries[i].CategoryName, name)
+		}
+	}
+	food := summary.CategorySeries[1]
+	if len(food.Monthly) != 2 || food.Monthly[0].Month != 1 || food.Monthly[1].Total != 200 {
+		t.Errorf("food series: got %+v", food.Monthly)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Trash is the content of a ledger's trash, most recently deleted first.
+type Trash struct {
+	Transactions []*TransactionResponse `json:"transactions"`
+	Categories   []*models.Category     `json:"categories"`
+}
+
+// TrashService lists and restores the transactions and custom categories deleted from a
+// ledger; listing needs the viewer role, restoring editor. Trashed items are purged for
+// good once they are older than the retention window.
+type TrashService interface {
+	List(ctx context.Context, userID, ledgerID string) (*Trash, error)
+	// RestoreTransaction fails with ErrUnknownCategory while the transaction's category is
+	// itself in the trash.
+	RestoreTransaction(ctx context.Context, userID, ledgerID, txID string) (*TransactionResponse, error)
+	RestoreCategory(ctx context.Context, userID, ledgerID, categoryID string) (*models.Category, error)
+	// Purge permanently removes everything deleted before the given time, in every ledger,
+	// and returns how many documents were removed.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type trashService struct {
+	ledgerAccess
+	auditLog
+	txRepo  db.TransactionRepository
+	catRepo db.CategoryRepository
+}
+
+// NewTrashService creates a new TrashService. Restores are recorded in the audit log.
+func NewTrashService(txRepo db.TransactionRepository, catRepo db.CategoryRepository, ledgerRepo db.LedgerRepository, auditRepo db.AuditRepository) TrashService {
+	return &trashService{ledgerAccess: ledgerAccess{ledgerRepo: ledgerRepo}, auditLog: auditLog{auditRepo: auditRepo}, txRepo: txRepo, catRepo: catRepo}
+}
+
+func (s *trashService) List(ctx context.Context, userID, ledgerID string) (*Trash, error) {
+	_, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	txs, err := s.txRepo.FindDeleted(ctx, lid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching deleted transactions: %w", err)
+	}
+	trashedCats, err := s.catRepo.FindDeleted(ctx, lid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching deleted categories: %w", err)
+	}
+
+	// A trashed transaction's category may be live or trashed as well.
+	cats := make(map[primitive.ObjectID]*models.Category)
+	for _, c := range trashedCats {
+		cats[c.ID] = c
+	}
+	var ids []primitive.ObjectID
+	for _, tx := range txs {
+		if _, ok := cats[tx.CategoryID]; !ok {
+			ids = append(ids, tx.CategoryID)
+		}
+	}
+	if len(ids) > 0 {
+		live, err := s.catRepo.FindByIDs(ctx, lid, ids)
+		if err != nil {
+			return nil, fmt.Errorf("fetching categories: %w", err)
+		}
+		for _, c := range live {
+			cats[c.ID] = c
+		}
+	}
+
+	trash := &Trash{
+		Transactions: make([]*TransactionResponse, len(txs)),
+		Categories:   trashedCats,
+	}
+	for i, tx := range txs {
+		trash.Transactions[i] = toResponse(tx, cats[tx.CategoryID])
+	}
+	if trash.Categories == nil {
+		trash.Categories = []*models.Category{}
+	}
+	return trash, nil
+}
+
+func (s *trashService) RestoreTransaction(ctx context.Context, userID, ledgerID, txID string) (*TransactionResponse, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	tid, err := primitive.ObjectIDFromHex(txID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	tx, err := s.txRepo.FindDeletedByID(ctx, lid, tid)
+	if err != nil {
+		return nil, fmt.Errorf("fetching deleted transaction: %w", err)
+	}
+	if tx == nil {
+		return nil, ErrNotFound
+	}
+	cat, err := s.catRepo.FindByID(ctx, lid, tx.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("fetching category: %w", err)
+	}
+	if cat == nil {
+		return nil, fmt.Errorf("%w: restore its category first", ErrUnknownCategory)
+	}
+
+	restored, err := s.txRepo.Restore(ctx, tid, lid)
+	if err == db.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("restoring transaction: %w", err)
+	}
+	s.record(ctx, lid, uid, models.AuditRestore, models.AuditEntityTransaction, tid, nil, transactionFields(restored))
+	return toResponse(restored, cat), nil
+}
+
+func (s *trashService) RestoreCategory(ctx context.Context, userID, ledgerID, categoryID string) (*models.Category, error) {
+	uid, lid, err := s.authorize(ctx, userID, ledgerID, models.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	catID, err := primitive.ObjectIDFromHex(categoryID)
+	if err != nil {
+		return nil, ErrInvalidID
+	}
+
+	restored, err := s.catRepo.Restore(ctx, catID, lid)
+	if err == db.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("restoring category: %w", err)
+	}
+	s.record(ctx, lid, uid, models.AuditRestore, models.AuditEntityCategory, catID, nil, categoryFields(restored))
+	return restored, nil
+}
+
+func (s *trashService) Purge(ctx context.Context, before time.Time) (int64, error) {
+	// Transactions go first so that none is left referencing a purged category.
+	txs, err := s.txRepo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return 0, fmt.Errorf("purging transactions: %w", err)
+	}
+	cats, err := s.catRepo.PurgeDeleted(ctx, before)
+	if err != nil {
+		return txs, fmt.Errorf("purging categories: %w", err)
+	}
+	return txs + cats, nil
+}
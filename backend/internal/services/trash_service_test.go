@@ -0,0 +1,178 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"expensify/internal/db"
+	"expensify/internal/models"
+	"expensify/internal/services"
+	"expensify/internal/testutil"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTrashService_List_NamesCategoriesOfTrashedTransactions(t *testing.T) {
+	now := time.Now()
+	live := &models.Category{ID: primitive.NewObjectID(), Name: "Food"}
+	trashedCat := &models.Category{ID: primitive.NewObjectID(), Name: "Hobbies", DeletedAt: &now}
+
+	txRepo := &testutil.MockTransactionRepo{
+		FindDeletedFn: func(_ context.Context, lid primitive.ObjectID) ([]*models.Transaction, error) {
+			return []*models.Transaction{
+				{ID: primitive.NewObjectID(), LedgerID: lid, CategoryID: live.ID, DeletedAt: &now},
+				{ID: primitive.NewObjectID(), LedgerID: lid, CategoryID: trashedCat.ID, DeletedAt: &now},
+			}, nil
+		},
+	}
+	var looked []primitive.ObjectID
+	catRepo := &testutil.MockCategoryRepo{
+		FindDeletedFn: func(context.Context, primitive.ObjectID) ([]*models.Category, error) {
+			return []*models.Category{trashedCat}, nil
+		},
+		FindByIDsFn: func(_ context.Context, _ primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Category, error) {
+			looked = ids
+			return []*models.Category{live}, nil
+		},
+	}
+	svc := services.NewTrashService(txRepo, catRepo, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
+
+	trash, err := svc.List(context.Background(), primitive.NewObjectID().Hex(), "")
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(trash.Transactions) != 2 || len(trash.Categories) != 1 {
+		t.Fatalf("unexpected trash %+v", trash)
+	}
+	if trash.Transactions[0].CategoryName != "Food" || trash.Transactions[1].CategoryName != "Hobbies" {
+		t.Errorf("expected category names from live and trashed categories, got %q and %q",
+			trash.Transactions[0].CategoryName, trash.Transactions[1].CategoryName)
+	}
+	if trash.Transactions[0].DeletedAt == nil {
+		t.Error("expected the deletion time in the response")
+	}
+	if len(looked) != 1 || looked[0] != live.ID {
+		t.Errorf("expected only the live category to be looked up, got %v", looked)
+	}
+}
+
+func TestTrashService_RestoreTransaction(t *testing.T) {
+	userID := primitive.NewObjectID()
+	catID := primitive.NewObjectID()
+	txID := primitive.NewObjectID()
+	now := time.Now()
+
+	restored := false
+	txRepo := &testutil.MockTransactionRepo{
+		FindDeletedByIDFn: func(_ context.Context, lid, id primitive.ObjectID) (*models.Transaction, error) {
+			if id != txID {
+				return nil, nil
+			}
+			return &models.Transaction{ID: txID, LedgerID: lid, CategoryID: catID, Amount: 12, DeletedAt: &now}, nil
+		},
+		RestoreFn: func(_ context.Context, id, lid primitive.ObjectID) (*models.Transaction, error) {
+			restored = true
+			return &models.Transaction{ID: id, LedgerID: lid, CategoryID: catID, Amount: 12}, nil
+		},
+	}
+	var catTrashed bool
+	catRepo := &testutil.MockCategoryRepo{
+		FindByIDFn: func(_ context.Context, _, id primitive.ObjectID) (*models.Category, error) {
+			if catTrashed {
+				return nil, nil
+			}
+			return &models.Category{ID: id, Name: "Food"}, nil
+		},
+	}
+	var entries []*models.AuditEntry
+	svc := services.NewTrashService(txRepo, catRepo, testutil.OwnLedgerRepo(testLedgerID), recordingAuditRepo(&entries))
+
+	catTrashed = true
+	if _, err := svc.RestoreTransaction(context.Background(), userID.Hex(), "", txID.Hex()); !errors.Is(err, services.ErrUnknownCategory) {
+		t.Fatalf("expected ErrUnknownCategory while the category is trashed, got %v", err)
+	}
+	if restored {
+		t.Fatal("expected the transaction to stay in the trash")
+	}
+
+	catTrashed = false
+	tx, err := svc.RestoreTransaction(context.Background(), userID.Hex(), "", txID.Hex())
+	if err != nil {
+		t.Fatalf("RestoreTransaction: %v", err)
+	}
+	if tx.CategoryName != "Food" || tx.DeletedAt != nil {
+		t.Errorf("unexpected restored transaction %+v", tx)
+	}
+	if len(entries) != 1 || entries[0].Action != models.AuditRestore || entries[0].EntityID != txID {
+		t.Errorf("expected a restore entry, got %+v", entries)
+	}
+
+	if _, err := svc.RestoreTransaction(context.Background(), userID.Hex(), "", primitive.NewObjectID().Hex()); !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a transaction not in the trash, got %v", err)
+	}
+}
+
+func TestTrashService_RestoreCategory_NeedsEditor(t *testing.T) {
+	viewer := primitive.NewObjectID()
+	ledger := &models.Ledger{ID: primitive.NewObjectID(), Members: []models.LedgerMember{{UserID: viewer, Role: models.RoleViewer}}}
+	catRepo := &testutil.MockCategoryRepo{
+		RestoreFn: func(context.Context, primitive.ObjectID, primitive.ObjectID) (*models.Category, error) {
+			t.Fatal("a viewer must not restore")
+			return nil, nil
+		},
+	}
+	svc := services.NewTrashService(&testutil.MockTransactionRepo{}, catRepo, sharedLedgerRepo(ledger), &testutil.MockAuditRepo{})
+
+	_, err := svc.RestoreCategory(context.Background(), viewer.Hex(), ledger.ID.Hex(), primitive.NewObjectID().Hex())
+	if !errors.Is(err, services.ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
+
+func TestTrashService_RestoreCategory_NotInTrash(t *testing.T) {
+	catRepo := &testutil.MockCategoryRepo{
+		RestoreFn: func(context.Context, primitive.ObjectID, primitive.ObjectID) (*models.Category, error) {
+			return nil, db.ErrNotFound
+		},
+	}
+	svc := services.NewTrashService(&testutil.MockTransactionRepo{}, catRepo, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
+
+	_, err := svc.RestoreCategory(context.Background(), primitive.NewObjectID().Hex(), "", primitive.NewObjectID().Hex())
+	if !errors.Is(err, services.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestTrashService_Purge(t *testing.T) {
+	before := time.Now().Add(-30 * 24 * time.Hour)
+	var order []string
+	txRepo := &testutil.MockTransactionRepo{
+		PurgeDeletedFn: func(_ context.Context, b time.Time) (int64, error) {
+			if !b.Equal(before) {
+				t.Errorf("expected cutoff %v, got %v", before, b)
+			}
+			order = append(order, "transactions")
+			return 3, nil
+		},
+	}
+	catRepo := &testutil.MockCategoryRepo{
+		PurgeDeletedFn: func(context.Context, time.Time) (int64, error) {
+			order = append(order, "categories")
+			return 1, nil
+		},
+	}
+	svc := services.NewTrashService(txRepo, catRepo, testutil.OwnLedgerRepo(testLedgerID), &testutil.MockAuditRepo{})
+
+	n, err := svc.Purge(context.Background(), before)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if n != 4 {
+		t.Errorf("expected 4 purged, got %d", n)
+	}
+	if len(order) != 2 || order[0] != "transactions" {
+		t.Errorf("expected transactions to be purged before categories, got %v", order)
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"expensify/internal/db"
+)
+
+// AnyVersion stands for whatever version is current, as sent with If-Match: *. Writes
+// still happen at the version read, so a concurrent change fails with ErrEditConflict.
+const AnyVersion int64 = -1
+
+// checkVersion returns ErrVersionMismatch unless one of expected, the versions the caller
+// accepts (usually just the one it last read), is the current one or AnyVersion. Callers
+// then write at current.
+func checkVersion(current int64, expected []int64) error {
+	for _, v := range expected {
+		if v == AnyVersion || v == current {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: current version is %d", ErrVersionMismatch, current)
+}
+
+// versionedWriteError translates the error of a versioned repository write; a conflict
+// means another write got in between checkVersion and this one.
+func versionedWriteError(err error, op string) error {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		return ErrNotFound
+	case errors.Is(err, db.ErrConflict):
+		return ErrEditConflict
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
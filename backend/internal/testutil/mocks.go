@@ -15,14 +15,20 @@ import (
 // ---- UserRepository mock ----
 
 type MockUserRepo struct {
-	FindByGoogleIDFn func(ctx context.Context, googleID string) (*models.User, error)
-	FindByIDFn       func(ctx context.Context, id primitive.ObjectID) (*models.User, error)
-	UpsertFn         func(ctx context.Context, user *models.User) (*models.User, error)
+	FindByIdentityFn      func(ctx context.Context, provider, subject string) (*models.User, error)
+	FindByIDFn            func(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	CreateFn              func(ctx context.Context, identity models.Identity, profile *models.User) (*models.User, error)
+	UpsertFn              func(ctx context.Context, identity models.Identity, profile *models.User) (*models.User, error)
+	UpdateTimeZoneFn      func(ctx context.Context, id primitive.ObjectID, timeZone string) (*models.User, error)
+	FindByEmailFn         func(ctx context.Context, email string) (*models.User, error)
+	FindByVerifiedEmailFn func(ctx context.Context, email string) (*models.User, error)
+	AddIdentityFn         func(ctx context.Context, id primitive.ObjectID, identity models.Identity) (*models.User, error)
+	RemoveIdentityFn      func(ctx context.Context, id primitive.ObjectID, provider, subject string) (*models.User, error)
 }
 
-func (m *MockUserRepo) FindByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
-	if m.FindByGoogleIDFn != nil {
-		return m.FindByGoogleIDFn(ctx, googleID)
+func (m *MockUserRepo) FindByIdentity(ctx context.Context, provider, subject string) (*models.User, error) {
+	if m.FindByIdentityFn != nil {
+		return m.FindByIdentityFn(ctx, provider, subject)
 	}
 	return nil, nil
 }
@@ -34,9 +40,51 @@ func (m *MockUserRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*mo
 	return nil, nil
 }
 
-func (m *MockUserRepo) Upsert(ctx context.Context, user *models.User) (*models.User, error) {
+func (m *MockUserRepo) Create(ctx context.Context, identity models.Identity, profile *models.User) (*models.User, error) {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, identity, profile)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepo) Upsert(ctx context.Context, identity models.Identity, profile *models.User) (*models.User, error) {
 	if m.UpsertFn != nil {
-		return m.UpsertFn(ctx, user)
+		return m.UpsertFn(ctx, identity, profile)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepo) UpdateTimeZone(ctx context.Context, id primitive.ObjectID, timeZone string) (*models.User, error) {
+	if m.UpdateTimeZoneFn != nil {
+		return m.UpdateTimeZoneFn(ctx, id, timeZone)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepo) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	if m.FindByEmailFn != nil {
+		return m.FindByEmailFn(ctx, email)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepo) FindByVerifiedEmail(ctx context.Context, email string) (*models.User, error) {
+	if m.FindByVerifiedEmailFn != nil {
+		return m.FindByVerifiedEmailFn(ctx, email)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepo) AddIdentity(ctx context.Context, id primitive.ObjectID, identity models.Identity) (*models.User, error) {
+	if m.AddIdentityFn != nil {
+		return m.AddIdentityFn(ctx, id, identity)
+	}
+	return nil, nil
+}
+
+func (m *MockUserRepo) RemoveIdentity(ctx context.Context, id primitive.ObjectID, provider, subject string) (*models.User, error) {
+	if m.RemoveIdentityFn != nil {
+		return m.RemoveIdentityFn(ctx, id, provider, subject)
 	}
 	return nil, nil
 }
@@ -46,7 +94,12 @@ func (m *MockUserRepo) Upsert(ctx context.Context, user *models.User) (*models.U
 type MockSessionRepo struct {
 	CreateFn        func(ctx context.Context, session *models.Session) (*models.Session, error)
 	FindByTokenFn   func(ctx context.Context, token string) (*models.Session, error)
+	FindByUserIDFn  func(ctx context.Context, userID primitive.ObjectID) ([]*models.Session, error)
+	TouchLastSeenFn func(ctx context.Context, token string, at time.Time, staleBefore time.Time) error
+	RenewFn         func(ctx context.Context, token string, expiresAt time.Time, at time.Time) error
 	DeleteFn        func(ctx context.Context, token string) error
+	DeleteByIDFn    func(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
+	DeleteOthersFn  func(ctx context.Context, userID primitive.ObjectID, keepToken string) (int64, error)
 	DeleteExpiredFn func(ctx context.Context) error
 }
 
@@ -64,6 +117,27 @@ func (m *MockSessionRepo) FindByToken(ctx context.Context, token string) (*model
 	return nil, nil
 }
 
+func (m *MockSessionRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Session, error) {
+	if m.FindByUserIDFn != nil {
+		return m.FindByUserIDFn(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockSessionRepo) TouchLastSeen(ctx context.Context, token string, at time.Time, staleBefore time.Time) error {
+	if m.TouchLastSeenFn != nil {
+		return m.TouchLastSeenFn(ctx, token, at, staleBefore)
+	}
+	return nil
+}
+
+func (m *MockSessionRepo) Renew(ctx context.Context, token string, expiresAt time.Time, at time.Time) error {
+	if m.RenewFn != nil {
+		return m.RenewFn(ctx, token, expiresAt, at)
+	}
+	return nil
+}
+
 func (m *MockSessionRepo) Delete(ctx context.Context, token string) error {
 	if m.DeleteFn != nil {
 		return m.DeleteFn(ctx, token)
@@ -71,6 +145,20 @@ func (m *MockSessionRepo) Delete(ctx context.Context, token string) error {
 	return nil
 }
 
+func (m *MockSessionRepo) DeleteByID(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	if m.DeleteByIDFn != nil {
+		return m.DeleteByIDFn(ctx, id, userID)
+	}
+	return nil
+}
+
+func (m *MockSessionRepo) DeleteOthers(ctx context.Context, userID primitive.ObjectID, keepToken string) (int64, error) {
+	if m.DeleteOthersFn != nil {
+		return m.DeleteOthersFn(ctx, userID, keepToken)
+	}
+	return 0, nil
+}
+
 func (m *MockSessionRepo) DeleteExpired(ctx context.Context) error {
 	if m.DeleteExpiredFn != nil {
 		return m.DeleteExpiredFn(ctx)
@@ -78,15 +166,397 @@ func (m *MockSessionRepo) DeleteExpired(ctx context.Context) error {
 	return nil
 }
 
+// ---- APITokenRepository mock ----
+
+type MockAPITokenRepo struct {
+	CreateFn        func(ctx context.Context, token *models.APIToken) (*models.APIToken, error)
+	FindByHashFn    func(ctx context.Context, hash string) (*models.APIToken, error)
+	FindByUserIDFn  func(ctx context.Context, userID primitive.ObjectID) ([]*models.APIToken, error)
+	TouchLastUsedFn func(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	DeleteFn        func(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
+}
+
+func (m *MockAPITokenRepo) Create(ctx context.Context, token *models.APIToken) (*models.APIToken, error) {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, token)
+	}
+	return nil, nil
+}
+
+func (m *MockAPITokenRepo) FindByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	if m.FindByHashFn != nil {
+		return m.FindByHashFn(ctx, hash)
+	}
+	return nil, nil
+}
+
+func (m *MockAPITokenRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.APIToken, error) {
+	if m.FindByUserIDFn != nil {
+		return m.FindByUserIDFn(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockAPITokenRepo) TouchLastUsed(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	if m.TouchLastUsedFn != nil {
+		return m.TouchLastUsedFn(ctx, id, at)
+	}
+	return nil
+}
+
+func (m *MockAPITokenRepo) Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+	if m.DeleteFn != nil {
+		return m.DeleteFn(ctx, id, userID)
+	}
+	return nil
+}
+
+// ---- PreferencesRepository mock ----
+
+type MockPreferencesRepo struct {
+	FindByUserIDFn func(ctx context.Context, userID primitive.ObjectID) (*models.Preferences, error)
+	UpsertFn       func(ctx context.Context, prefs *models.Preferences) (*models.Preferences, error)
+}
+
+func (m *MockPreferencesRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) (*models.Preferences, error) {
+	if m.FindByUserIDFn != nil {
+		return m.FindByUserIDFn(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockPreferencesRepo) Upsert(ctx context.Context, prefs *models.Preferences) (*models.Preferences, error) {
+	if m.UpsertFn != nil {
+		return m.UpsertFn(ctx, prefs)
+	}
+	return prefs, nil
+}
+
+// ---- LedgerRepository mock ----
+
+type MockLedgerRepo struct {
+	CreateFn         func(ctx context.Context, ledger *models.Ledger) (*models.Ledger, error)
+	UpsertPersonalFn func(ctx context.Context, userID primitive.ObjectID) (*models.Ledger, error)
+	FindByIDFn       func(ctx context.Context, id primitive.ObjectID) (*models.Ledger, error)
+	FindByMemberFn   func(ctx context.Context, userID primitive.ObjectID) ([]*models.Ledger, error)
+	RenameFn         func(ctx context.Context, id primitive.ObjectID, name string) (*models.Ledger, error)
+	AddMemberFn      func(ctx context.Context, id primitive.ObjectID, member models.LedgerMember) (*models.Ledger, error)
+	SetMemberRoleFn  func(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, role string) (*models.Ledger, error)
+	RemoveMemberFn   func(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) (*models.Ledger, error)
+}
+
+func (m *MockLedgerRepo) Create(ctx context.Context, ledger *models.Ledger) (*models.Ledger, error) {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, ledger)
+	}
+	return nil, nil
+}
+
+func (m *MockLedgerRepo) UpsertPersonal(ctx context.Context, userID primitive.ObjectID) (*models.Ledger, error) {
+	if m.UpsertPersonalFn != nil {
+		return m.UpsertPersonalFn(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockLedgerRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Ledger, error) {
+	if m.FindByIDFn != nil {
+		return m.FindByIDFn(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockLedgerRepo) FindByMember(ctx context.Context, userID primitive.ObjectID) ([]*models.Ledger, error) {
+	if m.FindByMemberFn != nil {
+		return m.FindByMemberFn(ctx, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockLedgerRepo) Rename(ctx context.Context, id primitive.ObjectID, name string) (*models.Ledger, error) {
+	if m.RenameFn != nil {
+		return m.RenameFn(ctx, id, name)
+	}
+	return nil, nil
+}
+
+func (m *MockLedgerRepo) AddMember(ctx context.Context, id primitive.ObjectID, member models.LedgerMember) (*models.Ledger, error) {
+	if m.AddMemberFn != nil {
+		return m.AddMemberFn(ctx, id, member)
+	}
+	return nil, nil
+}
+
+func (m *MockLedgerRepo) SetMemberRole(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, role string) (*models.Ledger, error) {
+	if m.SetMemberRoleFn != nil {
+		return m.SetMemberRoleFn(ctx, id, userID, role)
+	}
+	return nil, nil
+}
+
+func (m *MockLedgerRepo) RemoveMember(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) (*models.Ledger, error) {
+	if m.RemoveMemberFn != nil {
+		return m.RemoveMemberFn(ctx, id, userID)
+	}
+	return nil, nil
+}
+
+// OwnLedgerRepo returns a ledger repository in which every user owns exactly one ledger,
+// ledgerID, so services resolve a user's default ledger to it.
+func OwnLedgerRepo(ledgerID primitive.ObjectID) *MockLedgerRepo {
+	return &MockLedgerRepo{
+		FindByMemberFn: func(_ context.Context, userID primitive.ObjectID) ([]*models.Ledger, error) {
+			return []*models.Ledger{{
+				ID:      ledgerID,
+				Name:    models.PersonalLedgerName,
+				Members: []models.LedgerMember{{UserID: userID, Role: models.RoleOwner}},
+			}}, nil
+		},
+	}
+}
+
+// ---- InvitationRepository mock ----
+
+type MockInvitationRepo struct {
+	CreateFn              func(ctx context.Context, inv *models.Invitation) (*models.Invitation, error)
+	FindByIDFn            func(ctx context.Context, id primitive.ObjectID) (*models.Invitation, error)
+	FindPendingByLedgerFn func(ctx context.Context, ledgerID primitive.ObjectID, now time.Time) ([]*models.Invitation, error)
+	MarkAcceptedFn        func(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) (*models.Invitation, error)
+	UnmarkAcceptedFn      func(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) error
+	RevokeFn              func(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, at time.Time) error
+}
+
+func (m *MockInvitationRepo) Create(ctx context.Context, inv *models.Invitation) (*models.Invitation, error) {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, inv)
+	}
+	return nil, nil
+}
+
+func (m *MockInvitationRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Invitation, error) {
+	if m.FindByIDFn != nil {
+		return m.FindByIDFn(ctx, id)
+	}
+	return nil, nil
+}
+
+func (m *MockInvitationRepo) FindPendingByLedger(ctx context.Context, ledgerID primitive.ObjectID, now time.Time) ([]*models.Invitation, error) {
+	if m.FindPendingByLedgerFn != nil {
+		return m.FindPendingByLedgerFn(ctx, ledgerID, now)
+	}
+	return nil, nil
+}
+
+func (m *MockInvitationRepo) MarkAccepted(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) (*models.Invitation, error) {
+	if m.MarkAcceptedFn != nil {
+		return m.MarkAcceptedFn(ctx, id, userID, at)
+	}
+	return nil, nil
+}
+
+func (m *MockInvitationRepo) UnmarkAccepted(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID, at time.Time) error {
+	if m.UnmarkAcceptedFn != nil {
+		return m.UnmarkAcceptedFn(ctx, id, userID, at)
+	}
+	return nil
+}
+
+func (m *MockInvitationRepo) Revoke(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, at time.Time) error {
+	if m.RevokeFn != nil {
+		return m.RevokeFn(ctx, id, ledgerID, at)
+	}
+	return nil
+}
+
+// ---- GroupExpenseRepository mock ----
+
+type MockGroupExpenseRepo struct {
+	CreateFn         func(ctx context.Context, expense *models.GroupExpense) (*models.GroupExpense, error)
+	FindByLedgerIDFn func(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.GroupExpense, error)
+	DeleteFn         func(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error
+}
+
+func (m *MockGroupExpenseRepo) Create(ctx context.Context, expense *models.GroupExpense) (*models.GroupExpense, error) {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, expense)
+	}
+	return nil, nil
+}
+
+func (m *MockGroupExpenseRepo) FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.GroupExpense, error) {
+	if m.FindByLedgerIDFn != nil {
+		return m.FindByLedgerIDFn(ctx, ledgerID)
+	}
+	return nil, nil
+}
+
+func (m *MockGroupExpenseRepo) Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error {
+	if m.DeleteFn != nil {
+		return m.DeleteFn(ctx, id, ledgerID)
+	}
+	return nil
+}
+
+// ---- SettlementRepository mock ----
+
+type MockSettlementRepo struct {
+	CreateFn         func(ctx context.Context, settlement *models.Settlement) (*models.Settlement, error)
+	FindByLedgerIDFn func(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Settlement, error)
+	DeleteFn         func(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error
+}
+
+func (m *MockSettlementRepo) Create(ctx context.Context, settlement *models.Settlement) (*models.Settlement, error) {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, settlement)
+	}
+	return nil, nil
+}
+
+func (m *MockSettlementRepo) FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Settlement, error) {
+	if m.FindByLedgerIDFn != nil {
+		return m.FindByLedgerIDFn(ctx, ledgerID)
+	}
+	return nil, nil
+}
+
+func (m *MockSettlementRepo) Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error {
+	if m.DeleteFn != nil {
+		return m.DeleteFn(ctx, id, ledgerID)
+	}
+	return nil
+}
+
+// ---- ExpenseReportRepository mock ----
+
+type MockExpenseReportRepo struct {
+	CreateFn         func(ctx context.Context, report *models.ExpenseReport) (*models.ExpenseReport, error)
+	FindByIDFn       func(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.ExpenseReport, error)
+	FindByLedgerIDFn func(ctx context.Context, ledgerID primitive.ObjectID, status string) ([]*models.ExpenseReport, error)
+	FindClaimingFn   func(ctx context.Context, ledgerID primitive.ObjectID, txIDs []primitive.ObjectID, exclude primitive.ObjectID) ([]*models.ExpenseReport, error)
+	UpdateDraftFn    func(ctx context.Context, report *models.ExpenseReport) (*models.ExpenseReport, error)
+	TransitionFn     func(ctx context.Context, report *models.ExpenseReport, from string, entry models.ReportTransition) (*models.ExpenseReport, error)
+	DeleteDraftFn    func(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error
+}
+
+func (m *MockExpenseReportRepo) Create(ctx context.Context, report *models.ExpenseReport) (*models.ExpenseReport, error) {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, report)
+	}
+	return nil, nil
+}
+
+func (m *MockExpenseReportRepo) FindByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.ExpenseReport, error) {
+	if m.FindByIDFn != nil {
+		return m.FindByIDFn(ctx, ledgerID, id)
+	}
+	return nil, nil
+}
+
+func (m *MockExpenseReportRepo) FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID, status string) ([]*models.ExpenseReport, error) {
+	if m.FindByLedgerIDFn != nil {
+		return m.FindByLedgerIDFn(ctx, ledgerID, status)
+	}
+	return nil, nil
+}
+
+func (m *MockExpenseReportRepo) FindClaiming(ctx context.Context, ledgerID primitive.ObjectID, txIDs []primitive.ObjectID, exclude primitive.ObjectID) ([]*models.ExpenseReport, error) {
+	if m.FindClaimingFn != nil {
+		return m.FindClaimingFn(ctx, ledgerID, txIDs, exclude)
+	}
+	return nil, nil
+}
+
+func (m *MockExpenseReportRepo) UpdateDraft(ctx context.Context, report *models.ExpenseReport) (*models.ExpenseReport, error) {
+	if m.UpdateDraftFn != nil {
+		return m.UpdateDraftFn(ctx, report)
+	}
+	return nil, nil
+}
+
+func (m *MockExpenseReportRepo) Transition(ctx context.Context, report *models.ExpenseReport, from string, entry models.ReportTransition) (*models.ExpenseReport, error) {
+	if m.TransitionFn != nil {
+		return m.TransitionFn(ctx, report, from, entry)
+	}
+	return nil, nil
+}
+
+func (m *MockExpenseReportRepo) DeleteDraft(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) error {
+	if m.DeleteDraftFn != nil {
+		return m.DeleteDraftFn(ctx, id, ledgerID)
+	}
+	return nil
+}
+
+// ---- AuditRepository mock ----
+
+type MockAuditRepo struct {
+	CreateFn func(ctx context.Context, entry *models.AuditEntry) (*models.AuditEntry, error)
+	FindFn   func(ctx context.Context, filter db.AuditFilter) ([]*models.AuditEntry, error)
+}
+
+func (m *MockAuditRepo) Create(ctx context.Context, entry *models.AuditEntry) (*models.AuditEntry, error) {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, entry)
+	}
+	return nil, nil
+}
+
+func (m *MockAuditRepo) Find(ctx context.Context, filter db.AuditFilter) ([]*models.AuditEntry, error) {
+	if m.FindFn != nil {
+		return m.FindFn(ctx, filter)
+	}
+	return nil, nil
+}
+
+// ---- IdempotencyRepository mock ----
+
+type MockIdempotencyRepo struct {
+	CreateFn      func(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error)
+	FindByKeyFn   func(ctx context.Context, userID primitive.ObjectID, key string) (*models.IdempotencyRecord, error)
+	SetResponseFn func(ctx context.Context, id primitive.ObjectID, resp models.StoredResponse) error
+	DeleteFn      func(ctx context.Context, id primitive.ObjectID) error
+}
+
+func (m *MockIdempotencyRepo) Create(ctx context.Context, rec *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	if m.CreateFn != nil {
+		return m.CreateFn(ctx, rec)
+	}
+	return nil, nil
+}
+
+func (m *MockIdempotencyRepo) FindByKey(ctx context.Context, userID primitive.ObjectID, key string) (*models.IdempotencyRecord, error) {
+	if m.FindByKeyFn != nil {
+		return m.FindByKeyFn(ctx, userID, key)
+	}
+	return nil, nil
+}
+
+func (m *MockIdempotencyRepo) SetResponse(ctx context.Context, id primitive.ObjectID, resp models.StoredResponse) error {
+	if m.SetResponseFn != nil {
+		return m.SetResponseFn(ctx, id, resp)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyRepo) Delete(ctx context.Context, id primitive.ObjectID) error {
+	if m.DeleteFn != nil {
+		return m.DeleteFn(ctx, id)
+	}
+	return nil
+}
+
 // ---- CategoryRepository mock ----
 
 type MockCategoryRepo struct {
 	FindDefaultCategoriesFn func(ctx context.Context) ([]*models.Category, error)
-	FindByUserIDFn          func(ctx context.Context, userID primitive.ObjectID) ([]*models.Category, error)
-	FindByIDFn              func(ctx context.Context, id primitive.ObjectID) (*models.Category, error)
-	FindByIDsFn             func(ctx context.Context, ids []primitive.ObjectID) ([]*models.Category, error)
+	FindByLedgerIDFn        func(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Category, error)
+	FindByIDFn              func(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Category, error)
+	FindByIDsFn             func(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Category, error)
 	CreateFn                func(ctx context.Context, category *models.Category) (*models.Category, error)
-	DeleteFn                func(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
+	DeleteFn                func(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error
+	FindDeletedFn           func(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Category, error)
+	RestoreFn               func(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) (*models.Category, error)
+	PurgeDeletedFn          func(ctx context.Context, before time.Time) (int64, error)
 }
 
 func (m *MockCategoryRepo) FindDefaultCategories(ctx context.Context) ([]*models.Category, error) {
@@ -96,23 +566,23 @@ func (m *MockCategoryRepo) FindDefaultCategories(ctx context.Context) ([]*models
 	return nil, nil
 }
 
-func (m *MockCategoryRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID) ([]*models.Category, error) {
-	if m.FindByUserIDFn != nil {
-		return m.FindByUserIDFn(ctx, userID)
+func (m *MockCategoryRepo) FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Category, error) {
+	if m.FindByLedgerIDFn != nil {
+		return m.FindByLedgerIDFn(ctx, ledgerID)
 	}
 	return nil, nil
 }
 
-func (m *MockCategoryRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Category, error) {
+func (m *MockCategoryRepo) FindByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Category, error) {
 	if m.FindByIDFn != nil {
-		return m.FindByIDFn(ctx, id)
+		return m.FindByIDFn(ctx, ledgerID, id)
 	}
 	return nil, nil
 }
 
-func (m *MockCategoryRepo) FindByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Category, error) {
+func (m *MockCategoryRepo) FindByIDs(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Category, error) {
 	if m.FindByIDsFn != nil {
-		return m.FindByIDsFn(ctx, ids)
+		return m.FindByIDsFn(ctx, ledgerID, ids)
 	}
 	return nil, nil
 }
@@ -124,24 +594,56 @@ func (m *MockCategoryRepo) Create(ctx context.Context, category *models.Category
 	return nil, nil
 }
 
-func (m *MockCategoryRepo) Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+func (m *MockCategoryRepo) Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error {
 	if m.DeleteFn != nil {
-		return m.DeleteFn(ctx, id, userID)
+		return m.DeleteFn(ctx, id, ledgerID, version)
 	}
 	return nil
 }
 
+func (m *MockCategoryRepo) FindDeleted(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Category, error) {
+	if m.FindDeletedFn != nil {
+		return m.FindDeletedFn(ctx, ledgerID)
+	}
+	return nil, nil
+}
+
+func (m *MockCategoryRepo) Restore(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) (*models.Category, error) {
+	if m.RestoreFn != nil {
+		return m.RestoreFn(ctx, id, ledgerID)
+	}
+	return nil, nil
+}
+
+func (m *MockCategoryRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	if m.PurgeDeletedFn != nil {
+		return m.PurgeDeletedFn(ctx, before)
+	}
+	return 0, nil
+}
+
 // ---- TransactionRepository mock ----
 
 type MockTransactionRepo struct {
-	CreateFn                func(ctx context.Context, tx *models.Transaction) (*models.Transaction, error)
-	FindByIDFn              func(ctx context.Context, id primitive.ObjectID) (*models.Transaction, error)
-	FindByUserIDFn          func(ctx context.Context, userID primitive.ObjectID, page, pageSize int) ([]*models.Transaction, int64, error)
-	UpdateFn                func(ctx context.Context, tx *models.Transaction) (*models.Transaction, error)
-	DeleteFn                func(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error
-	ExistsByCategoryIDFn    func(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID) (bool, error)
-	GetMonthlySummaryFn     func(ctx context.Context, userID primitive.ObjectID, since, until time.Time) ([]*db.MonthlyAgg, error)
-	GetCategoryTotalsFn     func(ctx context.Context, userID primitive.ObjectID, txType string, since, until time.Time) ([]*db.CategoryAgg, error)
+	CreateFn                   func(ctx context.Context, tx *models.Transaction) (*models.Transaction, error)
+	FindByIDFn                 func(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Transaction, error)
+	FindByIDsFn                func(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Transaction, error)
+	FindByLedgerIDFn           func(ctx context.Context, ledgerID primitive.ObjectID, page, pageSize int) ([]*models.Transaction, int64, error)
+	UpdateFn                   func(ctx context.Context, tx *models.Transaction) (*models.Transaction, error)
+	PatchFn                    func(ctx context.Context, id, ledgerID primitive.ObjectID, version int64, patch db.TransactionPatch) (*models.Transaction, error)
+	DeleteFn                   func(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error
+	PurgeFn                    func(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error
+	FindByFilterFn             func(ctx context.Context, ledgerID primitive.ObjectID, filter db.TransactionFilter, limit int64) ([]*models.Transaction, error)
+	FindDeletedFn              func(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Transaction, error)
+	FindDeletedByIDFn          func(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Transaction, error)
+	RestoreFn                  func(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) (*models.Transaction, error)
+	PurgeDeletedFn             func(ctx context.Context, before time.Time) (int64, error)
+	ExistsByCategoryIDFn       func(ctx context.Context, ledgerID primitive.ObjectID, categoryID primitive.ObjectID) (bool, error)
+	GetMonthlySummaryFn        func(ctx context.Context, ledgerID primitive.ObjectID, since, until time.Time, timeZone string) ([]*db.MonthlyAgg, error)
+	GetCategoryTotalsFn        func(ctx context.Context, ledgerID primitive.ObjectID, txType string, since, until time.Time) ([]*db.CategoryAgg, error)
+	GetCategoryMonthlyTotalsFn func(ctx context.Context, ledgerID primitive.ObjectID, since, until time.Time, timeZone string) ([]*db.CategoryMonthlyAgg, error)
+	GetCategoryAmountStatsFn   func(ctx context.Context, ledgerID primitive.ObjectID, txType string, since, until time.Time) ([]*db.CategoryAmountStats, error)
+	FindAmountsAboveFn         func(ctx context.Context, ledgerID primitive.ObjectID, txType string, thresholds map[primitive.ObjectID]float64, since, until time.Time) ([]*models.Transaction, error)
 }
 
 func (m *MockTransactionRepo) Create(ctx context.Context, tx *models.Transaction) (*models.Transaction, error) {
@@ -151,16 +653,23 @@ func (m *MockTransactionRepo) Create(ctx context.Context, tx *models.Transaction
 	return nil, nil
 }
 
-func (m *MockTransactionRepo) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Transaction, error) {
+func (m *MockTransactionRepo) FindByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Transaction, error) {
 	if m.FindByIDFn != nil {
-		return m.FindByIDFn(ctx, id)
+		return m.FindByIDFn(ctx, ledgerID, id)
 	}
 	return nil, nil
 }
 
-func (m *MockTransactionRepo) FindByUserID(ctx context.Context, userID primitive.ObjectID, page, pageSize int) ([]*models.Transaction, int64, error) {
-	if m.FindByUserIDFn != nil {
-		return m.FindByUserIDFn(ctx, userID, page, pageSize)
+func (m *MockTransactionRepo) FindByIDs(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) ([]*models.Transaction, error) {
+	if m.FindByIDsFn != nil {
+		return m.FindByIDsFn(ctx, ledgerID, ids)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) FindByLedgerID(ctx context.Context, ledgerID primitive.ObjectID, page, pageSize int) ([]*models.Transaction, int64, error) {
+	if m.FindByLedgerIDFn != nil {
+		return m.FindByLedgerIDFn(ctx, ledgerID, page, pageSize)
 	}
 	return nil, 0, nil
 }
@@ -172,30 +681,100 @@ func (m *MockTransactionRepo) Update(ctx context.Context, tx *models.Transaction
 	return nil, nil
 }
 
-func (m *MockTransactionRepo) Delete(ctx context.Context, id primitive.ObjectID, userID primitive.ObjectID) error {
+func (m *MockTransactionRepo) Patch(ctx context.Context, id, ledgerID primitive.ObjectID, version int64, patch db.TransactionPatch) (*models.Transaction, error) {
+	if m.PatchFn != nil {
+		return m.PatchFn(ctx, id, ledgerID, version, patch)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) Delete(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error {
 	if m.DeleteFn != nil {
-		return m.DeleteFn(ctx, id, userID)
+		return m.DeleteFn(ctx, id, ledgerID, version)
+	}
+	return nil
+}
+
+func (m *MockTransactionRepo) Purge(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID, version int64) error {
+	if m.PurgeFn != nil {
+		return m.PurgeFn(ctx, id, ledgerID, version)
 	}
 	return nil
 }
 
-func (m *MockTransactionRepo) ExistsByCategoryID(ctx context.Context, userID primitive.ObjectID, categoryID primitive.ObjectID) (bool, error) {
+func (m *MockTransactionRepo) FindByFilter(ctx context.Context, ledgerID primitive.ObjectID, filter db.TransactionFilter, limit int64) ([]*models.Transaction, error) {
+	if m.FindByFilterFn != nil {
+		return m.FindByFilterFn(ctx, ledgerID, filter, limit)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) FindDeleted(ctx context.Context, ledgerID primitive.ObjectID) ([]*models.Transaction, error) {
+	if m.FindDeletedFn != nil {
+		return m.FindDeletedFn(ctx, ledgerID)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) FindDeletedByID(ctx context.Context, ledgerID primitive.ObjectID, id primitive.ObjectID) (*models.Transaction, error) {
+	if m.FindDeletedByIDFn != nil {
+		return m.FindDeletedByIDFn(ctx, ledgerID, id)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) Restore(ctx context.Context, id primitive.ObjectID, ledgerID primitive.ObjectID) (*models.Transaction, error) {
+	if m.RestoreFn != nil {
+		return m.RestoreFn(ctx, id, ledgerID)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	if m.PurgeDeletedFn != nil {
+		return m.PurgeDeletedFn(ctx, before)
+	}
+	return 0, nil
+}
+
+func (m *MockTransactionRepo) ExistsByCategoryID(ctx context.Context, ledgerID primitive.ObjectID, categoryID primitive.ObjectID) (bool, error) {
 	if m.ExistsByCategoryIDFn != nil {
-		return m.ExistsByCategoryIDFn(ctx, userID, categoryID)
+		return m.ExistsByCategoryIDFn(ctx, ledgerID, categoryID)
 	}
 	return false, nil
 }
 
-func (m *MockTransactionRepo) GetMonthlySummary(ctx context.Context, userID primitive.ObjectID, since, until time.Time) ([]*db.MonthlyAgg, error) {
+func (m *MockTransactionRepo) GetMonthlySummary(ctx context.Context, ledgerID primitive.ObjectID, since, until time.Time, timeZone string) ([]*db.MonthlyAgg, error) {
 	if m.GetMonthlySummaryFn != nil {
-		return m.GetMonthlySummaryFn(ctx, userID, since, until)
+		return m.GetMonthlySummaryFn(ctx, ledgerID, since, until, timeZone)
 	}
 	return nil, nil
 }
 
-func (m *MockTransactionRepo) GetCategoryTotals(ctx context.Context, userID primitive.ObjectID, txType string, since, until time.Time) ([]*db.CategoryAgg, error) {
+func (m *MockTransactionRepo) GetCategoryTotals(ctx context.Context, ledgerID primitive.ObjectID, txType string, since, until time.Time) ([]*db.CategoryAgg, error) {
 	if m.GetCategoryTotalsFn != nil {
-		return m.GetCategoryTotalsFn(ctx, userID, txType, since, until)
+		return m.GetCategoryTotalsFn(ctx, ledgerID, txType, since, until)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) GetCategoryMonthlyTotals(ctx context.Context, ledgerID primitive.ObjectID, since, until time.Time, timeZone string) ([]*db.CategoryMonthlyAgg, error) {
+	if m.GetCategoryMonthlyTotalsFn != nil {
+		return m.GetCategoryMonthlyTotalsFn(ctx, ledgerID, since, until, timeZone)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) GetCategoryAmountStats(ctx context.Context, ledgerID primitive.ObjectID, txType string, since, until time.Time) ([]*db.CategoryAmountStats, error) {
+	if m.GetCategoryAmountStatsFn != nil {
+		return m.GetCategoryAmountStatsFn(ctx, ledgerID, txType, since, until)
+	}
+	return nil, nil
+}
+
+func (m *MockTransactionRepo) FindAmountsAbove(ctx context.Context, ledgerID primitive.ObjectID, txType string, thresholds map[primitive.ObjectID]float64, since, until time.Time) ([]*models.Transaction, error) {
+	if m.FindAmountsAboveFn != nil {
+		return m.FindAmountsAboveFn(ctx, ledgerID, txType, thresholds, since, until)
 	}
 	return nil, nil
 }
@@ -0,0 +1,168 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// FakeOAuthUser is the profile the fake server's userinfo endpoint returns,
+// shaped like Google's v2 userinfo response.
+type FakeOAuthUser struct {
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	VerifiedEmail bool   `json:"verified_email"`
+	Name          string `json:"name"`
+	Picture       string `json:"picture"`
+}
+
+// FakeOAuthServer is an in-process OAuth2 authorization server with a userinfo endpoint.
+// Its /authorize endpoint approves every request immediately and redirects back with a
+// one-time code, so a login flow can run end to end without network access.
+type FakeOAuthServer struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+	User         FakeOAuthUser
+
+	mu     sync.Mutex
+	codes  map[string]string // code -> redirect_uri it was issued for
+	tokens map[string]bool
+	// UserInfoCalls counts userinfo requests that presented a valid bearer token.
+	UserInfoCalls int
+}
+
+// NewFakeOAuthServer starts a fake server that is closed when the test ends.
+func NewFakeOAuthServer(t testing.TB, user FakeOAuthUser) *FakeOAuthServer {
+	t.Helper()
+	f := &FakeOAuthServer{
+		ClientID:     "fake-client",
+		ClientSecret: "fake-secret",
+		User:         user,
+		codes:        map[string]string{},
+		tokens:       map[string]bool{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", f.authorize)
+	mux.HandleFunc("/token", f.token)
+	mux.HandleFunc("/userinfo", f.userInfo)
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// Endpoint returns the server's authorization and token URLs.
+func (f *FakeOAuthServer) Endpoint() oauth2.Endpoint {
+	return oauth2.Endpoint{AuthURL: f.URL + "/authorize", TokenURL: f.URL + "/token"}
+}
+
+// UserInfoURL returns the server's userinfo endpoint.
+func (f *FakeOAuthServer) UserInfoURL() string {
+	return f.URL + "/userinfo"
+}
+
+func (f *FakeOAuthServer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	if q.Get("client_id") != f.ClientID || q.Get("response_type") != "code" || redirectURI == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+	code := randomHex()
+	f.mu.Lock()
+	f.codes[code] = redirectURI
+	f.mu.Unlock()
+
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	back := target.Query()
+	back.Set("code", code)
+	back.Set("state", q.Get("state"))
+	target.RawQuery = back.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (f *FakeOAuthServer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.Method != http.MethodPost {
+		http.Error(w, "invalid token request", http.StatusBadRequest)
+		return
+	}
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if id != f.ClientID || secret != f.ClientSecret {
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	f.mu.Lock()
+	redirectURI, ok := f.codes[code]
+	delete(f.codes, code) // codes are single use
+	f.mu.Unlock()
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != redirectURI {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	accessToken := randomHex()
+	f.mu.Lock()
+	f.tokens[accessToken] = true
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+	})
+}
+
+// userInfo only accepts the access token as a bearer header; a token in the query
+// string is rejected so tests catch it leaking into URLs.
+func (f *FakeOAuthServer) userInfo(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("access_token") {
+		http.Error(w, "access token must not be sent in the URL", http.StatusBadRequest)
+		return
+	}
+	auth := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	f.mu.Lock()
+	valid := ok && f.tokens[token]
+	if valid {
+		f.UserInfoCalls++
+	}
+	f.mu.Unlock()
+	if !valid {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(f.User)
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": code})
+}
+
+func randomHex() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,142 @@
+package testutil
+
+import (
+	"bufio"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ReceivedMail is a message accepted by FakeSMTPServer.
+type ReceivedMail struct {
+	From string
+	To   []string
+	// Data is the raw message, headers and body, with dot-stuffing removed.
+	Data string
+}
+
+// FakeSMTPServer is an in-process catch-all SMTP server. It accepts every message for
+// every recipient without authentication or TLS and keeps them for inspection, so mail
+// can be sent end to end without network access.
+type FakeSMTPServer struct {
+	// Addr is the host:port the server listens on.
+	Addr string
+
+	ln   net.Listener
+	mu   sync.Mutex
+	mail []ReceivedMail
+	wg   sync.WaitGroup
+}
+
+// NewFakeSMTPServer starts a fake server that is closed when the test ends.
+func NewFakeSMTPServer(t testing.TB) *FakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("fake smtp: listen: %v", err)
+	}
+	f := &FakeSMTPServer{Addr: ln.Addr().String(), ln: ln}
+	f.wg.Add(1)
+	go f.serve()
+	t.Cleanup(f.Close)
+	return f
+}
+
+// Messages returns the messages received so far.
+func (f *FakeSMTPServer) Messages() []ReceivedMail {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ReceivedMail(nil), f.mail...)
+}
+
+// Close stops accepting connections and waits for open sessions to finish.
+func (f *FakeSMTPServer) Close() {
+	f.ln.Close()
+	f.wg.Wait()
+}
+
+func (f *FakeSMTPServer) serve() {
+	defer f.wg.Done()
+	for {
+		conn, err := f.ln.Accept()
+		if err != nil {
+			return
+		}
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.session(conn)
+		}()
+	}
+}
+
+func (f *FakeSMTPServer) session(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(code int, msg string) { tp.PrintfLine("%d %s", code, msg) }
+
+	reply(220, "fake-smtp ready")
+	var cur ReceivedMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply(250, "fake-smtp")
+		case "MAIL":
+			cur = ReceivedMail{From: addrArg(arg)}
+			reply(250, "OK")
+		case "RCPT":
+			cur.To = append(cur.To, addrArg(arg))
+			reply(250, "OK")
+		case "DATA":
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+			data, err := readData(tp.R)
+			if err != nil {
+				return
+			}
+			cur.Data = data
+			f.mu.Lock()
+			f.mail = append(f.mail, cur)
+			f.mu.Unlock()
+			cur = ReceivedMail{}
+			reply(250, "queued")
+		case "RSET":
+			cur = ReceivedMail{}
+			reply(250, "OK")
+		case "NOOP":
+			reply(250, "OK")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+// addrArg extracts the address from "FROM:<a@b>" or "TO:<a@b>".
+func addrArg(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+	return strings.Trim(addr, "<>")
+}
+
+func readData(r *bufio.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		if line == ".\r\n" {
+			return b.String(), nil
+		}
+		b.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
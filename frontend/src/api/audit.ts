@@ -0,0 +1,7 @@
+import client from './client';
+import type { ApiEnvelope, AuditEntry, AuditQuery } from '../types';
+
+export async function fetchAuditLog(query: AuditQuery = {}): Promise<AuditEntry[]> {
+  const res = await client.get<ApiEnvelope<AuditEntry[]>>('/api/audit', { params: query });
+  return res.data.data ?? [];
+}
@@ -11,11 +11,32 @@ export async function logout(): Promise<void> {
   await client.post('/auth/logout');
 }
 
-/** Navigates to the Google OAuth flow (server-side redirect).
+/** Finishes a login held back because its email belongs to an existing account, as a separate account.
+ *  To use the existing account instead, sign in to it and link the new login from there. */
+export async function createSeparateAccount(): Promise<void> {
+  await client.post('/auth/pending', { merge: false });
+}
+
+export async function fetchLoginProviders(): Promise<string[]> {
+  const res = await client.get<ApiEnvelope<string[]>>('/auth/providers');
+  return res.data.data ?? [];
+}
+
+/** Navigates to a provider's login flow (server-side redirect).
  *  Must go directly to the backend origin so the oauth_state cookie is set
  *  on the same domain as the callback, bypassing the Vite proxy.
  */
+export function loginWith(provider: string): void {
+  const base = import.meta.env.VITE_API_BASE_URL || '';
+  window.location.href = `${base}/auth/${encodeURIComponent(provider)}`;
+}
+
 export function loginWithGoogle(): void {
+  loginWith('google');
+}
+
+/** Signs in as a named test user; only available when the backend runs with DEV_LOGIN. */
+export function loginAsDevUser(name: string): void {
   const base = import.meta.env.VITE_API_BASE_URL || '';
-  window.location.href = `${base}/auth/google`;
+  window.location.href = `${base}/auth/dev?name=${encodeURIComponent(name)}`;
 }
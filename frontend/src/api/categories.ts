@@ -1,4 +1,4 @@
-import client from './client';
+import client, { ifMatch } from './client';
 import type { Category, ApiEnvelope, CreateCategoryPayload } from '../types';
 
 export async function fetchCategories(): Promise<Category[]> {
@@ -12,6 +12,6 @@ export async function createCategory(payload: CreateCategoryPayload): Promise<Ca
   return res.data.data;
 }
 
-export async function deleteCategory(id: string): Promise<void> {
-  await client.delete(`/api/categories/${id}`);
+export async function deleteCategory(id: string, version: number): Promise<void> {
+  await client.delete(`/api/categories/${id}`, ifMatch(version));
 }
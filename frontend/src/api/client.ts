@@ -9,6 +9,51 @@ const client = axios.create({
   headers: { 'Content-Type': 'application/json' },
 });
 
+// Cookie-authenticated mutations must echo the session's CSRF token, which the
+// server sends in a response header from GET /auth/me.
+const CSRF_HEADER = 'X-CSRF-Token';
+let csrfToken: string | null = null;
+
+client.interceptors.request.use((config) => {
+  const method = (config.method ?? 'get').toLowerCase();
+  if (csrfToken && !['get', 'head', 'options'].includes(method)) {
+    config.headers.set(CSRF_HEADER, csrfToken);
+  }
+  return config;
+});
+
+client.interceptors.response.use((res) => {
+  const token = res.headers[CSRF_HEADER.toLowerCase()];
+  if (typeof token === 'string' && token) csrfToken = token;
+  return res;
+});
+
+// Ledger data (transactions, categories, reports) comes from the selected ledger;
+// without a selection the server uses the user's personal ledger.
+const LEDGER_HEADER = 'X-Ledger-ID';
+let ledgerId: string | null = null;
+
+export function setActiveLedger(id: string | null) {
+  ledgerId = id;
+}
+
+client.interceptors.request.use((config) => {
+  if (ledgerId) config.headers.set(LEDGER_HEADER, ledgerId);
+  return config;
+});
+
+// Updates and deletes of transactions and categories must name the version they were
+// read at; the server answers 412 if it has changed since.
+export function ifMatch(version: number) {
+  return { headers: { 'If-Match': `"${version}"` } };
+}
+
+// Creates sent with the same Idempotency-Key run only once; a retry gets the original
+// response, so a resubmitted form cannot create a duplicate.
+export function idempotencyKey(key: string) {
+  return { headers: { 'Idempotency-Key': key } };
+}
+
 // If the server returns 401, the caller (React Query) will surface it as an error.
 // We don't do a global redirect here; that's handled in the AuthContext.
 client.interceptors.response.use(
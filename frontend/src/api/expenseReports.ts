@@ -0,0 +1,45 @@
+import client from './client';
+import type { ApiEnvelope, ExpenseReport, ExpenseReportPayload, ExpenseReportStatus } from '../types';
+
+export async function fetchExpenseReports(status?: ExpenseReportStatus): Promise<ExpenseReport[]> {
+  const res = await client.get<ApiEnvelope<ExpenseReport[]>>('/api/expense-reports', {
+    params: status ? { status } : undefined,
+  });
+  return res.data.data ?? [];
+}
+
+export async function fetchExpenseReport(id: string): Promise<ExpenseReport> {
+  const res = await client.get<ApiEnvelope<ExpenseReport>>(`/api/expense-reports/${id}`);
+  if (!res.data.data) throw new Error('No expense report data returned');
+  return res.data.data;
+}
+
+export async function createExpenseReport(payload: ExpenseReportPayload): Promise<ExpenseReport> {
+  const res = await client.post<ApiEnvelope<ExpenseReport>>('/api/expense-reports', payload);
+  if (!res.data.data) throw new Error('No expense report data returned');
+  return res.data.data;
+}
+
+export async function updateExpenseReport(id: string, payload: ExpenseReportPayload): Promise<ExpenseReport> {
+  const res = await client.put<ApiEnvelope<ExpenseReport>>(`/api/expense-reports/${id}`, payload);
+  if (!res.data.data) throw new Error('No expense report data returned');
+  return res.data.data;
+}
+
+export async function deleteExpenseReport(id: string): Promise<void> {
+  await client.delete(`/api/expense-reports/${id}`);
+}
+
+async function transition(id: string, action: string, body: object): Promise<ExpenseReport> {
+  const res = await client.post<ApiEnvelope<ExpenseReport>>(`/api/expense-reports/${id}/${action}`, body);
+  if (!res.data.data) throw new Error('No expense report data returned');
+  return res.data.data;
+}
+
+export const submitExpenseReport = (id: string, note = '') => transition(id, 'submit', { note });
+export const approveExpenseReport = (id: string, note = '') => transition(id, 'approve', { note });
+export const rejectExpenseReport = (id: string, note = '') => transition(id, 'reject', { note });
+
+export function reimburseExpenseReport(id: string, categoryId = '', note = ''): Promise<ExpenseReport> {
+  return transition(id, 'reimburse', { category_id: categoryId, note });
+}
@@ -0,0 +1,57 @@
+import client from './client';
+import type { ApiEnvelope, Invitation, Ledger, LedgerRole, LedgerSummary } from '../types';
+
+export async function fetchLedgers(): Promise<LedgerSummary[]> {
+  const res = await client.get<ApiEnvelope<LedgerSummary[]>>('/api/ledgers');
+  return res.data.data ?? [];
+}
+
+export async function createLedger(name: string): Promise<Ledger> {
+  const res = await client.post<ApiEnvelope<Ledger>>('/api/ledgers', { name });
+  if (!res.data.data) throw new Error('No ledger data returned');
+  return res.data.data;
+}
+
+export async function renameLedger(id: string, name: string): Promise<Ledger> {
+  const res = await client.put<ApiEnvelope<Ledger>>(`/api/ledgers/${id}`, { name });
+  if (!res.data.data) throw new Error('No ledger data returned');
+  return res.data.data;
+}
+
+export async function addLedgerMember(id: string, email: string, role: LedgerRole): Promise<Ledger> {
+  const res = await client.post<ApiEnvelope<Ledger>>(`/api/ledgers/${id}/members`, { email, role });
+  if (!res.data.data) throw new Error('No ledger data returned');
+  return res.data.data;
+}
+
+export async function setLedgerMemberRole(id: string, userId: string, role: LedgerRole): Promise<Ledger> {
+  const res = await client.put<ApiEnvelope<Ledger>>(`/api/ledgers/${id}/members/${userId}`, { role });
+  if (!res.data.data) throw new Error('No ledger data returned');
+  return res.data.data;
+}
+
+export async function removeLedgerMember(id: string, userId: string): Promise<void> {
+  await client.delete(`/api/ledgers/${id}/members/${userId}`);
+}
+
+export async function fetchInvitations(ledgerId: string): Promise<Invitation[]> {
+  const res = await client.get<ApiEnvelope<Invitation[]>>(`/api/ledgers/${ledgerId}/invitations`);
+  return res.data.data ?? [];
+}
+
+export async function inviteToLedger(ledgerId: string, email: string, role: LedgerRole): Promise<Invitation> {
+  const res = await client.post<ApiEnvelope<Invitation>>(`/api/ledgers/${ledgerId}/invitations`, { email, role });
+  if (!res.data.data) throw new Error('No invitation data returned');
+  return res.data.data;
+}
+
+export async function revokeInvitation(ledgerId: string, invitationId: string): Promise<void> {
+  await client.delete(`/api/ledgers/${ledgerId}/invitations/${invitationId}`);
+}
+
+/** Accept an emailed invitation as the signed-in user; returns the ledger joined. */
+export async function acceptInvitation(token: string): Promise<Ledger> {
+  const res = await client.post<ApiEnvelope<Ledger>>('/api/invitations/accept', { token });
+  if (!res.data.data) throw new Error('No ledger data returned');
+  return res.data.data;
+}
@@ -0,0 +1,14 @@
+import client from './client';
+import type { ApiEnvelope, Preferences, UpdatePreferencesPayload } from '../types';
+
+export async function fetchPreferences(): Promise<Preferences> {
+  const res = await client.get<ApiEnvelope<Preferences>>('/api/me/preferences');
+  if (!res.data.data) throw new Error('No preferences data returned');
+  return res.data.data;
+}
+
+export async function updatePreferences(payload: UpdatePreferencesPayload): Promise<Preferences> {
+  const res = await client.put<ApiEnvelope<Preferences>>('/api/me/preferences', payload);
+  if (!res.data.data) throw new Error('No preferences data returned');
+  return res.data.data;
+}
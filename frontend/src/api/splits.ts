@@ -0,0 +1,45 @@
+import client from './client';
+import type {
+  ApiEnvelope,
+  CreateGroupExpensePayload,
+  CreateSettlementPayload,
+  GroupBalances,
+  GroupExpense,
+  Settlement,
+} from '../types';
+
+export async function fetchGroupExpenses(): Promise<GroupExpense[]> {
+  const res = await client.get<ApiEnvelope<GroupExpense[]>>('/api/group-expenses');
+  return res.data.data ?? [];
+}
+
+export async function createGroupExpense(payload: CreateGroupExpensePayload): Promise<GroupExpense> {
+  const res = await client.post<ApiEnvelope<GroupExpense>>('/api/group-expenses', payload);
+  if (!res.data.data) throw new Error('No group expense data returned');
+  return res.data.data;
+}
+
+export async function deleteGroupExpense(id: string): Promise<void> {
+  await client.delete(`/api/group-expenses/${id}`);
+}
+
+export async function fetchSettlements(): Promise<Settlement[]> {
+  const res = await client.get<ApiEnvelope<Settlement[]>>('/api/settlements');
+  return res.data.data ?? [];
+}
+
+export async function createSettlement(payload: CreateSettlementPayload): Promise<Settlement> {
+  const res = await client.post<ApiEnvelope<Settlement>>('/api/settlements', payload);
+  if (!res.data.data) throw new Error('No settlement data returned');
+  return res.data.data;
+}
+
+export async function deleteSettlement(id: string): Promise<void> {
+  await client.delete(`/api/settlements/${id}`);
+}
+
+export async function fetchBalances(): Promise<GroupBalances> {
+  const res = await client.get<ApiEnvelope<GroupBalances>>('/api/balances');
+  if (!res.data.data) throw new Error('No balance data returned');
+  return res.data.data;
+}
@@ -1,9 +1,12 @@
-import client from './client';
+import client, { idempotencyKey, ifMatch } from './client';
 import type {
   PaginatedTransactions,
   ApiEnvelope,
   CreateTransactionPayload,
   UpdateTransactionPayload,
+  PatchTransactionPayload,
+  BulkTransactionPayload,
+  BulkTransactionResult,
   Transaction,
   CashflowSummary,
 } from '../types';
@@ -15,20 +18,43 @@ export async function fetchTransactions(page: number, pageSize: number): Promise
   return res.data.data ?? { items: [], total: 0, page, page_size: pageSize, total_pages: 0 };
 }
 
-export async function createTransaction(payload: CreateTransactionPayload): Promise<Transaction> {
-  const res = await client.post<ApiEnvelope<Transaction>>('/api/transactions', payload);
+export async function createTransaction(payload: CreateTransactionPayload, key: string): Promise<Transaction> {
+  const res = await client.post<ApiEnvelope<Transaction>>('/api/transactions', payload, idempotencyKey(key));
   if (!res.data.data) throw new Error('No transaction data returned');
   return res.data.data;
 }
 
-export async function updateTransaction(id: string, payload: UpdateTransactionPayload): Promise<Transaction> {
-  const res = await client.put<ApiEnvelope<Transaction>>(`/api/transactions/${id}`, payload);
+export async function updateTransaction(
+  id: string,
+  version: number,
+  payload: UpdateTransactionPayload,
+): Promise<Transaction> {
+  const res = await client.put<ApiEnvelope<Transaction>>(`/api/transactions/${id}`, payload, ifMatch(version));
   if (!res.data.data) throw new Error('No transaction data returned');
   return res.data.data;
 }
 
-export async function deleteTransaction(id: string): Promise<void> {
-  await client.delete(`/api/transactions/${id}`);
+// Changes only the fields given, leaving the rest of the transaction as it is.
+export async function patchTransaction(
+  id: string,
+  version: number,
+  payload: PatchTransactionPayload,
+): Promise<Transaction> {
+  const res = await client.patch<ApiEnvelope<Transaction>>(`/api/transactions/${id}`, payload, {
+    headers: { ...ifMatch(version).headers, 'Content-Type': 'application/merge-patch+json' },
+  });
+  if (!res.data.data) throw new Error('No transaction data returned');
+  return res.data.data;
+}
+
+export async function bulkTransactions(payload: BulkTransactionPayload): Promise<BulkTransactionResult> {
+  const res = await client.post<ApiEnvelope<BulkTransactionResult>>('/api/transactions/bulk', payload);
+  if (!res.data.data) throw new Error('No bulk result returned');
+  return res.data.data;
+}
+
+export async function deleteTransaction(id: string, version: number): Promise<void> {
+  await client.delete(`/api/transactions/${id}`, ifMatch(version));
 }
 
 export async function fetchCashflowSummary(params: { year?: number; months?: number }): Promise<CashflowSummary> {
@@ -0,0 +1,19 @@
+import client from './client';
+import type { ApiEnvelope, Category, Transaction, Trash } from '../types';
+
+export async function fetchTrash(): Promise<Trash> {
+  const res = await client.get<ApiEnvelope<Trash>>('/api/trash');
+  return res.data.data ?? { transactions: [], categories: [] };
+}
+
+export async function restoreTransaction(id: string): Promise<Transaction> {
+  const res = await client.post<ApiEnvelope<Transaction>>(`/api/trash/transactions/${id}/restore`);
+  if (!res.data.data) throw new Error('No transaction data returned');
+  return res.data.data;
+}
+
+export async function restoreCategory(id: string): Promise<Category> {
+  const res = await client.post<ApiEnvelope<Category>>(`/api/trash/categories/${id}/restore`);
+  if (!res.data.data) throw new Error('No category data returned');
+  return res.data.data;
+}
@@ -73,6 +73,8 @@ export function TransactionForm({ categories, mode, onSubmit, onClose }: Transac
       amount: parsedAmount,
       description: description.trim(),
       date: new Date(date).toISOString(),
+      // The form does not edit tags yet; an edit must still send them, as PUT replaces all fields.
+      tags: tx?.tags ?? [],
     };
 
     setSubmitting(true);
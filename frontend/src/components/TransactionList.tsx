@@ -26,21 +26,21 @@ function formatAmount(amount: number): string {
 export function TransactionList({ categories, onAddClick }: TransactionListProps) {
   const [page, setPage] = useState(1);
   const [editingTx, setEditingTx] = useState<Transaction | null>(null);
-  const [confirmDeleteId, setConfirmDeleteId] = useState<string | null>(null);
+  const [confirmDelete, setConfirmDelete] = useState<Transaction | null>(null);
 
   const { data, isLoading, isError } = useTransactions(page);
   const deleteMutation = useDeleteTransaction();
   const updateMutation = useUpdateTransaction();
 
   async function handleConfirmDelete() {
-    if (!confirmDeleteId) return;
-    await deleteMutation.mutateAsync(confirmDeleteId);
-    setConfirmDeleteId(null);
+    if (!confirmDelete) return;
+    await deleteMutation.mutateAsync({ id: confirmDelete.id, version: confirmDelete.version });
+    setConfirmDelete(null);
   }
 
   async function handleUpdate(payload: UpdateTransactionPayload) {
     if (!editingTx) return;
-    await updateMutation.mutateAsync({ id: editingTx.id, payload });
+    await updateMutation.mutateAsync({ id: editingTx.id, version: editingTx.version, payload });
   }
 
   if (isLoading) return <div className="spinner" />;
@@ -123,7 +123,7 @@ export function TransactionList({ categories, onAddClick }: TransactionListProps
                         </button>
                         <button
                           className="btn btn-danger btn-sm"
-                          onClick={() => setConfirmDeleteId(tx.id)}
+                          onClick={() => setConfirmDelete(tx)}
                           disabled={deleteMutation.isPending}
                           title="Delete"
                         >
@@ -150,11 +150,11 @@ export function TransactionList({ categories, onAddClick }: TransactionListProps
         />
       )}
 
-      {confirmDeleteId && (
+      {confirmDelete && (
         <ConfirmDialog
-          message="Delete this transaction? This action cannot be undone."
+          message="Delete this transaction? It can be restored from the trash."
           onConfirm={handleConfirmDelete}
-          onClose={() => setConfirmDeleteId(null)}
+          onClose={() => setConfirmDelete(null)}
         />
       )}
     </>
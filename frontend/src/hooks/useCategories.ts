@@ -21,7 +21,7 @@ export function useCreateCategory() {
 export function useDeleteCategory() {
   const qc = useQueryClient();
   return useMutation({
-    mutationFn: (id: string) => deleteCategory(id),
+    mutationFn: ({ id, version }: { id: string; version: number }) => deleteCategory(id, version),
     onSuccess: () => qc.invalidateQueries({ queryKey: ['categories'] }),
   });
 }
@@ -21,7 +21,8 @@ export function useTransactions(page: number) {
 export function useCreateTransaction() {
   const qc = useQueryClient();
   return useMutation({
-    mutationFn: (payload: CreateTransactionPayload) => createTransaction(payload),
+    mutationFn: ({ payload, key }: { payload: CreateTransactionPayload; key: string }) =>
+      createTransaction(payload, key),
     onSuccess: () => qc.invalidateQueries({ queryKey: ['transactions'] }),
   });
 }
@@ -29,8 +30,8 @@ export function useCreateTransaction() {
 export function useUpdateTransaction() {
   const qc = useQueryClient();
   return useMutation({
-    mutationFn: ({ id, payload }: { id: string; payload: UpdateTransactionPayload }) =>
-      updateTransaction(id, payload),
+    mutationFn: ({ id, version, payload }: { id: string; version: number; payload: UpdateTransactionPayload }) =>
+      updateTransaction(id, version, payload),
     onSuccess: () => qc.invalidateQueries({ queryKey: ['transactions'] }),
   });
 }
@@ -38,7 +39,7 @@ export function useUpdateTransaction() {
 export function useDeleteTransaction() {
   const qc = useQueryClient();
   return useMutation({
-    mutationFn: (id: string) => deleteTransaction(id),
+    mutationFn: ({ id, version }: { id: string; version: number }) => deleteTransaction(id, version),
     onSuccess: () => qc.invalidateQueries({ queryKey: ['transactions'] }),
   });
 }
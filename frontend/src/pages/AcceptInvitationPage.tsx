@@ -0,0 +1,52 @@
+import { useEffect, useRef, useState } from 'react';
+import { Link, Navigate, useSearchParams } from 'react-router-dom';
+import { acceptInvitation } from '../api/ledgers';
+import { setActiveLedger } from '../api/client';
+import { useAuth } from '../hooks/useAuth';
+
+// Opened from the emailed invitation link. Accepting needs a signed-in session, which
+// also supplies the CSRF token the POST must carry.
+export function AcceptInvitationPage() {
+  const [params] = useSearchParams();
+  const token = params.get('token') ?? '';
+  const { isLoading, isAuthenticated, isError } = useAuth();
+  const [joined, setJoined] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+  const started = useRef(false);
+
+  useEffect(() => {
+    if (!isAuthenticated || !token || started.current) return;
+    started.current = true;
+    acceptInvitation(token)
+      .then((ledger) => {
+        setActiveLedger(ledger.id);
+        setJoined(true);
+      })
+      .catch((err: unknown) => {
+        const msg = (err as { response?: { data?: { error?: string } } })?.response?.data?.error;
+        setError(msg ?? 'Could not accept the invitation.');
+      });
+  }, [isAuthenticated, token]);
+
+  if (isLoading) return null;
+  if (isError) {
+    return (
+      <p style={{ padding: 24 }}>
+        Please <Link to="/login">sign in</Link> with the invited email address, then open the invitation link again.
+      </p>
+    );
+  }
+  if (joined) return <Navigate to="/dashboard" replace />;
+
+  return (
+    <p style={{ padding: 24 }}>
+      {!token ? 'This invitation link is incomplete.' : error ?? 'Joining ledger…'}
+      {(error || !token) && (
+        <>
+          {' '}
+          <Link to="/dashboard">Go to dashboard</Link>
+        </>
+      )}
+    </p>
+  );
+}
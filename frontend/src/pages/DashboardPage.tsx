@@ -19,6 +19,8 @@ export function DashboardPage() {
   const deleteCatMutation = useDeleteCategory();
 
   const [showAddTx, setShowAddTx] = useState(false);
+  // One key per opened form, so resubmitting after a network error cannot add the entry twice.
+  const [createTxKey, setCreateTxKey] = useState(() => crypto.randomUUID());
   const [showAddCat, setShowAddCat] = useState(false);
   const [activeTab, setActiveTab] = useState<'charts' | 'cashflow' | 'categories'>('charts');
   const [confirmDeleteCat, setConfirmDeleteCat] = useState<Category | null>(null);
@@ -33,12 +35,17 @@ export function DashboardPage() {
   }
 
   async function handleCreateTransaction(payload: CreateTransactionPayload) {
-    await createTxMutation.mutateAsync(payload);
+    await createTxMutation.mutateAsync({ payload, key: createTxKey });
+  }
+
+  function openAddTx() {
+    setCreateTxKey(crypto.randomUUID());
+    setShowAddTx(true);
   }
 
   function handleAddEntry() {
     setActiveTab('cashflow');
-    setShowAddTx(true);
+    openAddTx();
   }
 
   async function handleCreateCategory(payload: CreateCategoryPayload) {
@@ -49,13 +56,15 @@ export function DashboardPage() {
     if (!confirmDeleteCat) return;
     setDeleteCatError('');
     try {
-      await deleteCatMutation.mutateAsync(confirmDeleteCat.id);
+      await deleteCatMutation.mutateAsync({ id: confirmDeleteCat.id, version: confirmDeleteCat.version });
       setConfirmDeleteCat(null);
     } catch (err: unknown) {
       setConfirmDeleteCat(null);
       const status = (err as { response?: { status?: number } })?.response?.status;
       if (status === 409) {
         setDeleteCatError(`"${confirmDeleteCat.name}" has existing transactions and cannot be deleted.`);
+      } else if (status === 412) {
+        setDeleteCatError(`"${confirmDeleteCat.name}" was changed elsewhere. Reload and try again.`);
       } else {
         setDeleteCatError('Failed to delete category. Please try again.');
       }
@@ -122,12 +131,12 @@ export function DashboardPage() {
           {activeTab === 'cashflow' && (
             <TransactionList
               categories={categories}
-              onAddClick={() => setShowAddTx(true)}
+              onAddClick={openAddTx}
             />
           )}
 
           {activeTab === 'charts' && (
-            <ChartsView onAddClick={() => { setActiveTab('cashflow'); setShowAddTx(true); }} />
+            <ChartsView onAddClick={handleAddEntry} />
           )}
 
           {activeTab === 'categories' && (
@@ -1,6 +1,30 @@
-import { loginWithGoogle } from '../api/auth';
+import { useEffect, useState } from 'react';
+import { useNavigate, useSearchParams } from 'react-router-dom';
+import { createSeparateAccount, fetchLoginProviders, loginAsDevUser, loginWithGoogle } from '../api/auth';
 
 export function LoginPage() {
+  const [params] = useSearchParams();
+  const navigate = useNavigate();
+  const existingEmail = params.get('existing');
+  const [pendingError, setPendingError] = useState<string | null>(null);
+  const [devLogin, setDevLogin] = useState(false);
+  const [devName, setDevName] = useState('Dev User');
+
+  useEffect(() => {
+    fetchLoginProviders()
+      .then((providers) => setDevLogin(providers.includes('dev')))
+      .catch(() => setDevLogin(false));
+  }, []);
+
+  const createSeparate = async () => {
+    try {
+      await createSeparateAccount();
+      navigate('/dashboard', { replace: true });
+    } catch {
+      setPendingError('That sign-in has expired. Please sign in again.');
+    }
+  };
+
   return (
     <div
       style={{
@@ -42,6 +66,29 @@ export function LoginPage() {
           Track your spending. Know where your money goes.
         </p>
 
+        {existingEmail && !pendingError && (
+          <div className="card" style={{ marginBottom: 20 }}>
+            <h2 style={{ fontSize: 18, marginBottom: 8 }}>You already have an account</h2>
+            <p style={{ color: 'var(--gray-500)', fontSize: 14, marginBottom: 20 }}>
+              An account for {existingEmail} exists. To use it, sign in the way you usually do and link
+              this sign-in from there. Or keep a separate account.
+            </p>
+            <button
+              className="btn btn-primary"
+              onClick={() => navigate('/login', { replace: true })}
+              style={{ width: '100%', marginBottom: 8 }}
+            >
+              Sign in to my existing account
+            </button>
+            <button className="btn" onClick={createSeparate} style={{ width: '100%' }}>
+              Create a separate account
+            </button>
+          </div>
+        )}
+        {pendingError && (
+          <p style={{ color: 'var(--red)', fontSize: 14, marginBottom: 20 }}>{pendingError}</p>
+        )}
+
         {/* Sign in card */}
         <div className="card" style={{ marginBottom: 0 }}>
           <h2 style={{ fontSize: 18, marginBottom: 8 }}>Sign in to get started</h2>
@@ -57,6 +104,27 @@ export function LoginPage() {
             <GoogleIcon />
             Continue with Google
           </button>
+
+          {devLogin && (
+            <form
+              onSubmit={(e) => {
+                e.preventDefault();
+                loginAsDevUser(devName);
+              }}
+              style={{ display: 'flex', gap: 8, marginTop: 16 }}
+            >
+              <input
+                className="input"
+                value={devName}
+                onChange={(e) => setDevName(e.target.value)}
+                aria-label="Test user name"
+                style={{ flex: 1 }}
+              />
+              <button className="btn" type="submit">
+                Dev login
+              </button>
+            </form>
+          )}
         </div>
 
         <p style={{ marginTop: 20, fontSize: 12, color: 'var(--gray-500)' }}>
@@ -1,6 +1,13 @@
+export interface Identity {
+  provider: string;
+  subject: string;
+  email: string;
+  linked_at: string;
+}
+
 export interface User {
   id: string;
-  google_id: string;
+  identities: Identity[];
   email: string;
   name: string;
   picture: string;
@@ -8,18 +15,215 @@ export interface User {
   updated_at: string;
 }
 
+export interface Preferences {
+  currency: string;
+  time_zone: string;
+  locale: string;
+  /** 0 = Sunday … 6 = Saturday */
+  first_day_of_week: number;
+  default_transaction_type: 'inflow' | 'outflow';
+  default_page_size: number;
+  updated_at?: string;
+}
+
+export type UpdatePreferencesPayload = Omit<Preferences, 'updated_at'>;
+
+export type LedgerRole = 'owner' | 'approver' | 'editor' | 'viewer';
+
+export interface LedgerMember {
+  user_id: string;
+  role: LedgerRole;
+  added_at: string;
+}
+
+export interface Ledger {
+  id: string;
+  name: string;
+  members: LedgerMember[];
+  created_at: string;
+  updated_at: string;
+}
+
+/** A ledger as listed for the current user, with their role in it. */
+export interface LedgerSummary extends Ledger {
+  role: LedgerRole;
+}
+
+export interface Invitation {
+  id: string;
+  ledger_id: string;
+  email: string;
+  role: LedgerRole;
+  invited_by: string;
+  created_at: string;
+  expires_at: string;
+  accepted_at?: string;
+  accepted_by?: string;
+  revoked_at?: string;
+}
+
+export type SplitMethod = 'equal' | 'shares' | 'exact';
+
+export interface ExpenseShare {
+  user_id: string;
+  amount: number;
+  shares?: number;
+}
+
+export interface GroupExpense {
+  id: string;
+  ledger_id: string;
+  paid_by: string;
+  amount: number;
+  description: string;
+  date: string;
+  split_method: SplitMethod;
+  shares: ExpenseShare[];
+  created_by: string;
+  created_at: string;
+}
+
+export interface SplitParticipant {
+  user_id: string;
+  /** Read by the shares method. */
+  shares?: number;
+  /** Read by the exact method. */
+  amount?: number;
+}
+
+export interface CreateGroupExpensePayload {
+  /** Defaults to the signed-in user. */
+  paid_by?: string;
+  amount: number;
+  description: string;
+  date: string;
+  split_method: SplitMethod;
+  participants: SplitParticipant[];
+}
+
+export interface Settlement {
+  id: string;
+  ledger_id: string;
+  from: string;
+  to: string;
+  amount: number;
+  date: string;
+  note: string;
+  created_by: string;
+  created_at: string;
+}
+
+export interface CreateSettlementPayload {
+  /** Defaults to the signed-in user. */
+  from?: string;
+  to: string;
+  amount: number;
+  date: string;
+  note: string;
+}
+
+export interface MemberBalance {
+  user_id: string;
+  /** Positive when the member is owed money. */
+  net: number;
+}
+
+export interface SettlePayment {
+  from: string;
+  to: string;
+  amount: number;
+}
+
+export interface GroupBalances {
+  balances: MemberBalance[];
+  settle_up: SettlePayment[];
+}
+
+export type ExpenseReportStatus = 'draft' | 'submitted' | 'approved' | 'rejected' | 'reimbursed';
+
+export interface ReportTransition {
+  /** Empty for the report's creation. */
+  from: ExpenseReportStatus | '';
+  to: ExpenseReportStatus;
+  by: string;
+  at: string;
+  note?: string;
+}
+
+export interface ExpenseReport {
+  id: string;
+  ledger_id: string;
+  user_id: string;
+  title: string;
+  transaction_ids: string[];
+  total: number;
+  status: ExpenseReportStatus;
+  /** The inflow transaction recorded on reimbursement. */
+  reimbursement_id?: string;
+  history: ReportTransition[];
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ExpenseReportPayload {
+  title: string;
+  transaction_ids: string[];
+}
+
+export type AuditAction = 'create' | 'update' | 'delete' | 'restore';
+export type AuditEntityType = 'transaction' | 'category';
+
+export interface FieldChange {
+  field: string;
+  /** Absent for a create. */
+  before?: unknown;
+  /** Absent for a delete. */
+  after?: unknown;
+}
+
+export interface AuditEntry {
+  id: string;
+  ledger_id: string;
+  actor_id: string;
+  action: AuditAction;
+  entity_type: AuditEntityType;
+  entity_id: string;
+  changes: FieldChange[];
+  request_id?: string;
+  at: string;
+}
+
+export interface AuditQuery {
+  entity?: AuditEntityType;
+  entity_id?: string;
+  /** YYYY-MM-DD */
+  from?: string;
+  /** YYYY-MM-DD, exclusive */
+  to?: string;
+  limit?: number;
+}
+
 export interface Category {
   id: string;
+  /** Absent for default categories. */
+  ledger_id?: string;
   user_id?: string;
   name: string;
   icon: string;
   color: string;
   is_default: boolean;
   created_at: string;
+  /** Sent back in If-Match when deleting. */
+  version: number;
+  /** Set while the category is in the trash. */
+  deleted_at?: string;
 }
 
 export interface Transaction {
   id: string;
+  ledger_id: string;
+  /** The member who recorded the transaction. */
+  user_id: string;
   category_id: string;
   category_name: string;
   category_color: string;
@@ -28,8 +232,18 @@ export interface Transaction {
   amount: number;
   description: string;
   date: string;
+  tags: string[];
   created_at: string;
   updated_at: string;
+  /** Sent back in If-Match when updating or deleting. */
+  version: number;
+  /** Set while the transaction is in the trash. */
+  deleted_at?: string;
+}
+
+export interface Trash {
+  transactions: Transaction[];
+  categories: Category[];
 }
 
 export interface PaginatedTransactions {
@@ -46,6 +260,7 @@ export interface CreateTransactionPayload {
   amount: number;
   description: string;
   date: string;
+  tags?: string[];
 }
 
 export interface UpdateTransactionPayload {
@@ -54,6 +269,47 @@ export interface UpdateTransactionPayload {
   amount: number;
   description: string;
   date: string;
+  /** An empty list removes all tags. */
+  tags: string[];
+}
+
+// A null description or tags clears it; omitted fields are left unchanged.
+export type PatchTransactionPayload = Partial<Omit<UpdateTransactionPayload, 'description' | 'tags'>> & {
+  description?: string | null;
+  tags?: string[] | null;
+};
+
+export type BulkOperation = 'recategorize' | 'retag' | 'delete' | 'change-date';
+
+export interface BulkTransactionFilter {
+  category_id?: string;
+  type?: 'inflow' | 'outflow';
+  tag?: string;
+  since?: string;
+  /** End-exclusive. */
+  until?: string;
+}
+
+/** Give exactly one of ids and filter, plus the operand the operation needs. */
+export interface BulkTransactionPayload {
+  operation: BulkOperation;
+  ids?: string[];
+  filter?: BulkTransactionFilter;
+  category_id?: string;
+  tags?: string[];
+  date?: string;
+}
+
+export interface BulkItemResult {
+  id: string;
+  status: 'modified' | 'deleted' | 'unchanged' | 'conflict' | 'not_found' | 'invalid_id';
+}
+
+export interface BulkTransactionResult {
+  operation: BulkOperation;
+  matched: number;
+  modified: number;
+  items: BulkItemResult[];
 }
 
 export interface MonthlyPoint {
@@ -72,6 +328,7 @@ export interface CategoryPoint {
 }
 
 export interface CashflowSummary {
+  currency: string;
   monthly: MonthlyPoint[];
   by_category: CategoryPoint[];
 }